@@ -14,7 +14,10 @@ func GetPaymentProcessorABI() (abi.ABI, error) {
 		{"type":"function","name":"settleOrder","inputs":[{"name":"_orderId","type":"bytes32"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"},
 		{"type":"function","name":"refundOrder","inputs":[{"name":"_orderId","type":"bytes32"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"},
 		{"type":"function","name":"cancelOrder","inputs":[{"name":"_orderId","type":"bytes32"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"},
-		{"type":"event","name":"OrderCreated","inputs":[{"name":"orderId","type":"bytes32","indexed":true},{"name":"payer","type":"address","indexed":true},{"name":"merchantId","type":"bytes32","indexed":true},{"name":"merchantPayout","type":"address","indexed":false},{"name":"token","type":"address","indexed":false},{"name":"amount","type":"uint256","indexed":false},{"name":"status","type":"uint8","indexed":false},{"name":"metadataUri","type":"string","indexed":false}]}
+		{"type":"event","name":"OrderCreated","inputs":[{"name":"orderId","type":"bytes32","indexed":true},{"name":"payer","type":"address","indexed":true},{"name":"merchantId","type":"bytes32","indexed":true},{"name":"merchantPayout","type":"address","indexed":false},{"name":"token","type":"address","indexed":false},{"name":"amount","type":"uint256","indexed":false},{"name":"status","type":"uint8","indexed":false},{"name":"metadataUri","type":"string","indexed":false}]},
+		{"type":"event","name":"OrderPaid","inputs":[{"name":"orderId","type":"bytes32","indexed":true},{"name":"merchantId","type":"bytes32","indexed":true},{"name":"payer","type":"address","indexed":true},{"name":"token","type":"address","indexed":false},{"name":"amount","type":"uint256","indexed":false},{"name":"status","type":"uint8","indexed":false}]},
+		{"type":"event","name":"OrderSettled","inputs":[{"name":"orderId","type":"bytes32","indexed":true},{"name":"merchantId","type":"bytes32","indexed":true},{"name":"merchantPayout","type":"address","indexed":true},{"name":"token","type":"address","indexed":false},{"name":"merchantAmount","type":"uint256","indexed":false},{"name":"platformFee","type":"uint256","indexed":false},{"name":"status","type":"uint8","indexed":false}]},
+		{"type":"event","name":"OrderRefunded","inputs":[{"name":"orderId","type":"bytes32","indexed":true},{"name":"merchantId","type":"bytes32","indexed":true},{"name":"payer","type":"address","indexed":true},{"name":"token","type":"address","indexed":false},{"name":"amount","type":"uint256","indexed":false},{"name":"status","type":"uint8","indexed":false}]}
 	]`
 	return abi.JSON(strings.NewReader(abiJSON))
 }
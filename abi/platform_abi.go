@@ -13,7 +13,10 @@ func GetERC20ABI() (abi.ABI, error) {
 		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"},
 		{"type":"function","name":"transferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"},
 		{"type":"function","name":"balanceOf","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},
-		{"type":"function","name":"allowance","inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"}
+		{"type":"function","name":"decimals","inputs":[],"outputs":[{"name":"","type":"uint8"}],"stateMutability":"view"},
+		{"type":"function","name":"symbol","inputs":[],"outputs":[{"name":"","type":"string"}],"stateMutability":"view"},
+		{"type":"function","name":"allowance","inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},
+		{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]}
 	]`
 	return abi.JSON(strings.NewReader(abiJSON))
 }
@@ -28,6 +31,9 @@ func GetPlatformABI() (abi.ABI, error) {
 		{"type":"function","name":"registerMerchant","inputs":[{"name":"_payoutWalletAddress","type":"address"},{"name":"_metadataUri","type":"string"}],"outputs":[{"name":"_merchantId","type":"bytes32"}],"stateMutability":"nonpayable"},
 		{"type":"function","name":"updateMerchant","inputs":[{"name":"_merchantId","type":"bytes32"},{"name":"_payoutWalletAddress","type":"address"},{"name":"_metadataUri","type":"string"}],"outputs":[],"stateMutability":"nonpayable"},
 		{"type":"event","name":"OrderCreated","inputs":[{"name":"orderId","type":"bytes32","indexed":true},{"name":"payer","type":"address","indexed":true},{"name":"merchantId","type":"bytes32","indexed":true},{"name":"merchantPayout","type":"address","indexed":false},{"name":"token","type":"address","indexed":false},{"name":"amount","type":"uint256","indexed":false},{"name":"status","type":"uint8","indexed":false},{"name":"metadataUri","type":"string","indexed":false}]},
+		{"type":"event","name":"OrderPaid","inputs":[{"name":"orderId","type":"bytes32","indexed":true},{"name":"merchantId","type":"bytes32","indexed":true},{"name":"payer","type":"address","indexed":true},{"name":"token","type":"address","indexed":false},{"name":"amount","type":"uint256","indexed":false},{"name":"status","type":"uint8","indexed":false}]},
+		{"type":"event","name":"OrderSettled","inputs":[{"name":"orderId","type":"bytes32","indexed":true},{"name":"merchantId","type":"bytes32","indexed":true},{"name":"merchantPayout","type":"address","indexed":true},{"name":"token","type":"address","indexed":false},{"name":"merchantAmount","type":"uint256","indexed":false},{"name":"platformFee","type":"uint256","indexed":false},{"name":"status","type":"uint8","indexed":false}]},
+		{"type":"event","name":"OrderRefunded","inputs":[{"name":"orderId","type":"bytes32","indexed":true},{"name":"merchantId","type":"bytes32","indexed":true},{"name":"payer","type":"address","indexed":true},{"name":"token","type":"address","indexed":false},{"name":"amount","type":"uint256","indexed":false},{"name":"status","type":"uint8","indexed":false}]},
 		{"type":"event","name":"MerchantRegistered","inputs":[{"name":"merchantId","type":"bytes32","indexed":true},{"name":"owner","type":"address","indexed":true},{"name":"payoutWallet","type":"address","indexed":false},{"name":"metadataUri","type":"string","indexed":false}]}
 	]`
 	return abi.JSON(strings.NewReader(abiJSON))
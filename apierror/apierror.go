@@ -0,0 +1,167 @@
+// Package apierror defines the error envelope returned by every API endpoint.
+// Errors carry a stable, machine readable code alongside the HTTP status so
+// clients never have to match on message text.
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDKey is the gin context key holding the request id
+const RequestIDKey = "requestId"
+
+// RequestIDHeader carries the request id on requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// FieldError describes a problem with one request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an API error. Err is the underlying cause; it is logged but never
+// sent to the client.
+type Error struct {
+	Status  int                    `json:"-"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details []FieldError           `json:"details,omitempty"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+	Err     error                  `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Code + ": " + e.Message + ": " + e.Err.Error()
+	}
+	return e.Code + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors by code, so copies made with WithMeta or WithDetails still
+// match the sentinel they were made from
+func (e *Error) Is(target error) bool {
+	other, ok := target.(*Error)
+	return ok && other.Code == e.Code
+}
+
+// WithMeta returns a copy of the error with key set in its meta object
+func (e *Error) WithMeta(key string, value interface{}) *Error {
+	copied := *e
+	copied.Meta = make(map[string]interface{}, len(e.Meta)+1)
+	for k, v := range e.Meta {
+		copied.Meta[k] = v
+	}
+	copied.Meta[key] = value
+	return &copied
+}
+
+// WithDetails returns a copy of the error with field level details appended
+func (e *Error) WithDetails(details ...FieldError) *Error {
+	copied := *e
+	copied.Details = append(append([]FieldError{}, e.Details...), details...)
+	return &copied
+}
+
+// New creates an error with a status, code and client facing message
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Wrap creates an error whose cause is logged but hidden from the client
+func Wrap(status int, code, message string, err error) *Error {
+	return &Error{Status: status, Code: code, Message: message, Err: err}
+}
+
+// BadRequest creates a 400 error
+func BadRequest(code, message string) *Error {
+	return New(http.StatusBadRequest, code, message)
+}
+
+// InvalidField creates a 400 error about a single request field
+func InvalidField(code, field, message string) *Error {
+	return BadRequest(code, message).WithDetails(FieldError{Field: field, Message: message})
+}
+
+// NotFound creates a 404 error
+func NotFound(code, message string) *Error {
+	return New(http.StatusNotFound, code, message)
+}
+
+// Conflict creates a 409 error
+func Conflict(code, message string) *Error {
+	return New(http.StatusConflict, code, message)
+}
+
+// Internal wraps an unexpected failure as a 500 with a generic message
+func Internal(err error) *Error {
+	return Wrap(http.StatusInternalServerError, CodeInternal, "Internal server error", err)
+}
+
+// From converts any error into an *Error. Errors that are not already API
+// errors become internal errors.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return Internal(err)
+}
+
+type envelope struct {
+	Error body `json:"error"`
+}
+
+type body struct {
+	*Error
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// Respond writes err as the error envelope. Server errors are logged with their
+// cause and the request id so they can be matched to the client's report.
+func Respond(ctx *gin.Context, err error) {
+	apiErr := From(err)
+	requestId := ctx.GetString(RequestIDKey)
+
+	if apiErr.Status >= http.StatusInternalServerError {
+		log.Printf("request %s: %s %s: %v", requestId, ctx.Request.Method, ctx.Request.URL.Path, apiErr)
+	}
+
+	ctx.JSON(apiErr.Status, envelope{Error: body{Error: apiErr, RequestID: requestId}})
+}
+
+// Abort writes err as the error envelope and stops the handler chain
+func Abort(ctx *gin.Context, err error) {
+	Respond(ctx, err)
+	ctx.Abort()
+}
+
+// NoRoute answers requests for unknown paths
+func NoRoute(ctx *gin.Context) {
+	Respond(ctx, NotFound(CodeRouteNotFound, "Route not found"))
+}
+
+// NoMethod answers requests with a method the path does not support
+func NoMethod(ctx *gin.Context) {
+	Respond(ctx, New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed"))
+}
+
+// Recovery turns a panicking handler into an internal error response. A handler
+// that already started its response panics with http.ErrAbortHandler instead,
+// which is passed on so the server drops the connection.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(ctx *gin.Context, recovered interface{}) {
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+		Abort(ctx, Internal(fmt.Errorf("panic: %v", recovered)))
+	})
+}
@@ -0,0 +1,92 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Init makes binding errors report fields by their json (or form) name, which is
+// what clients send, rather than the Go struct field name
+func Init() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
+
+// Binding converts an error from ctx.ShouldBind* into a 400 listing the fields
+// that failed validation, or explaining why the body could not be decoded
+func Binding(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &validationErrs):
+		details := make([]FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			field := fieldPath(fieldErr)
+			details = append(details, FieldError{Field: field, Message: field + " " + validationMessage(fieldErr)})
+		}
+		return BadRequest(CodeValidationFailed, "Request validation failed").WithDetails(details...)
+	case errors.As(err, &maxBytesErr):
+		return New(http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+			"Request body exceeds "+strconv.FormatInt(maxBytesErr.Limit, 10)+" bytes")
+	case errors.As(err, &typeErr):
+		return BadRequest(CodeInvalidJSON, "Request body has a field of the wrong type").WithDetails(FieldError{
+			Field:   typeErr.Field,
+			Message: typeErr.Field + " must be a " + typeErr.Type.String(),
+		})
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return BadRequest(CodeInvalidJSON, "Request body must be valid JSON")
+	default:
+		return BadRequest(CodeInvalidRequest, err.Error())
+	}
+}
+
+// fieldPath drops the struct name from the namespace, so nested fields read as
+// items[0].productId
+func fieldPath(fieldErr validator.FieldError) string {
+	_, path, found := strings.Cut(fieldErr.Namespace(), ".")
+	if !found {
+		return fieldErr.Field()
+	}
+	return path
+}
+
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "min", "gte":
+		return "must be at least " + fieldErr.Param()
+	case "max", "lte":
+		return "must be at most " + fieldErr.Param()
+	default:
+		return "failed the " + fieldErr.Tag() + " check"
+	}
+}
@@ -0,0 +1,110 @@
+package apierror
+
+// Codes are part of the API contract: clients branch on them, so existing codes
+// must never be renamed or reused for a different condition.
+const (
+	// Request problems
+	CodeInvalidRequest   = "INVALID_REQUEST"
+	CodeInvalidJSON      = "INVALID_JSON"
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodePayloadTooLarge  = "PAYLOAD_TOO_LARGE"
+	CodeRouteNotFound    = "ROUTE_NOT_FOUND"
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	CodeRateLimited      = "RATE_LIMITED"
+
+	// Identifiers and values
+	CodeInvalidOrderId        = "INVALID_ORDER_ID"
+	CodeInvalidMerchantId     = "INVALID_MERCHANT_ID"
+	CodeInvalidAddress        = "INVALID_ADDRESS"
+	CodeInvalidTokenAddress   = "INVALID_TOKEN_ADDRESS"
+	CodeInvalidTxHash         = "INVALID_TX_HASH"
+	CodeInvalidAmount         = "INVALID_AMOUNT"
+	CodeInvalidStatus         = "INVALID_STATUS"
+	CodeUnsupportedNetwork    = "UNSUPPORTED_NETWORK"
+	CodeTokenNotPayable       = "TOKEN_NOT_PAYABLE"
+	CodeInvalidFile           = "INVALID_FILE"
+	CodeFileTooLarge          = "FILE_TOO_LARGE"
+	CodeUnsupportedFileType   = "UNSUPPORTED_FILE_TYPE"
+	CodeUnsupportedMetadata   = "UNSUPPORTED_METADATA_URI"
+	CodeInvalidRole           = "INVALID_ROLE"
+	CodeInvalidAPIKeyScope    = "INVALID_API_KEY_SCOPE"
+	CodeInvalidAllowedIP      = "INVALID_ALLOWED_IP"
+	CodeInvalidExpiry         = "INVALID_EXPIRY"
+	CodeInvalidWebhookURL     = "INVALID_WEBHOOK_URL"
+	CodeInvalidRedirectURL    = "INVALID_REDIRECT_URL"
+	CodeInvalidWebhookEvent   = "INVALID_WEBHOOK_EVENT"
+	CodeInvalidPagination     = "INVALID_PAGINATION"
+	CodeInvalidDateRange      = "INVALID_DATE_RANGE"
+	CodeInvalidBucket         = "INVALID_BUCKET"
+	CodeInvalidGroupBy        = "INVALID_GROUP_BY"
+	CodeInvalidExportKind     = "INVALID_EXPORT_KIND"
+	CodeInvalidExportFormat   = "INVALID_EXPORT_FORMAT"
+	CodeInvalidDocumentType   = "INVALID_DOCUMENT_TYPE"
+	CodeInvalidDocumentFormat = "INVALID_DOCUMENT_FORMAT"
+	CodeInvalidPayoutPolicy   = "INVALID_PAYOUT_POLICY"
+	CodeInvalidDisputeReason  = "INVALID_DISPUTE_REASON"
+	CodeInvalidDisputeOutcome = "INVALID_DISPUTE_OUTCOME"
+
+	// Missing resources
+	CodeOrderNotFound           = "ORDER_NOT_FOUND"
+	CodeMerchantNotFound        = "MERCHANT_NOT_FOUND"
+	CodeProductNotFound         = "PRODUCT_NOT_FOUND"
+	CodeCartNotFound            = "CART_NOT_FOUND"
+	CodeCheckoutNotFound        = "CHECKOUT_SESSION_NOT_FOUND"
+	CodeWebhookNotFound         = "WEBHOOK_NOT_FOUND"
+	CodeWebhookDeliveryNotFound = "WEBHOOK_DELIVERY_NOT_FOUND"
+	CodeAPIKeyNotFound          = "API_KEY_NOT_FOUND"
+	CodeMetadataNotFound        = "METADATA_NOT_FOUND"
+	CodeRoleNotGranted          = "ROLE_NOT_GRANTED"
+	CodeExportNotFound          = "EXPORT_NOT_FOUND"
+	CodeInvoiceNotFound         = "INVOICE_NOT_FOUND"
+	CodeSettlementHoldNotFound  = "SETTLEMENT_HOLD_NOT_FOUND"
+	CodeDisputeNotFound         = "DISPUTE_NOT_FOUND"
+
+	// State conflicts
+	CodeIllegalStateTransition  = "ILLEGAL_STATE_TRANSITION"
+	CodeInsufficientStock       = "INSUFFICIENT_STOCK"
+	CodeCartNotOpen             = "CART_NOT_OPEN"
+	CodeCartEmpty               = "CART_EMPTY"
+	CodeCartCheckoutActive      = "CART_CHECKOUT_ACTIVE"
+	CodeCartMerchantMismatch    = "CART_MERCHANT_MISMATCH"
+	CodeProductMerchantMismatch = "PRODUCT_MERCHANT_MISMATCH"
+	CodeCheckoutExpired         = "CHECKOUT_SESSION_EXPIRED"
+	CodeOrderExpired            = "ORDER_EXPIRED"
+	CodeOrderExists             = "ORDER_EXISTS"
+	CodeCheckoutPaid            = "CHECKOUT_SESSION_PAID"
+	CodeCheckoutOutOfOrder      = "CHECKOUT_STEP_OUT_OF_ORDER"
+	CodeRoleAlreadyGranted      = "ROLE_ALREADY_GRANTED"
+	CodeBootstrapAdminRole      = "BOOTSTRAP_ADMIN_ROLE"
+	CodeExportTooLarge          = "EXPORT_TOO_LARGE"
+	CodeExportNotReady          = "EXPORT_NOT_READY"
+	CodeInvoiceNotIssued        = "INVOICE_NOT_ISSUED"
+	CodeDisputeExists           = "DISPUTE_EXISTS"
+	CodeDisputeResolved         = "DISPUTE_RESOLVED"
+	CodeDisputeEvidenceLimit    = "DISPUTE_EVIDENCE_LIMIT"
+	CodeOrderDisputed           = "ORDER_DISPUTED"
+	CodeSettlementInFlight      = "SETTLEMENT_IN_FLIGHT"
+
+	// Transactions
+	CodeTxNotMined       = "TX_NOT_MINED"
+	CodeTxReverted       = "TX_REVERTED"
+	CodeTxEventNotFound  = "TX_EVENT_NOT_FOUND"
+	CodeCallReverted     = "CALL_REVERTED"
+	CodeChainUnavailable = "CHAIN_UNAVAILABLE"
+
+	// Authentication and authorization
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeInvalidSession     = "INVALID_SESSION"
+	CodeInvalidSIWEMessage = "INVALID_SIWE_MESSAGE"
+	CodeSIWERejected       = "SIWE_REJECTED"
+	CodeInvalidAPIKey      = "INVALID_API_KEY"
+	CodeForbidden          = "FORBIDDEN"
+	CodeNotMerchantOwner   = "NOT_MERCHANT_OWNER"
+	CodeIPNotAllowed       = "IP_NOT_ALLOWED"
+	CodePermissionDenied   = "PERMISSION_DENIED"
+
+	// Server side
+	CodeInternal           = "INTERNAL_ERROR"
+	CodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+	CodeUpstreamFailed     = "UPSTREAM_FAILED"
+)
@@ -0,0 +1,197 @@
+// Package auth implements Sign-In With Ethereum (EIP-4361) and the signed
+// session tokens issued after a successful sign in.
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+
+var (
+	ErrInvalidMessage   = errors.New("invalid SIWE message")
+	ErrInvalidSignature = errors.New("signature does not match the message address")
+	ErrMessageExpired   = errors.New("SIWE message has expired")
+	ErrMessageNotYet    = errors.New("SIWE message is not valid yet")
+	ErrDomainMismatch   = errors.New("SIWE message domain does not match this service")
+)
+
+// clockSkew tolerates small differences between the wallet's clock and ours
+const clockSkew = 5 * time.Minute
+
+// SIWEMessage is a parsed EIP-4361 message
+type SIWEMessage struct {
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// ParseSIWEMessage parses the text a wallet signed
+func ParseSIWEMessage(text string) (*SIWEMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) < 3 {
+		return nil, ErrInvalidMessage
+	}
+
+	header := lines[0]
+	if !strings.HasSuffix(header, siweHeaderSuffix) {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidMessage)
+	}
+
+	message := &SIWEMessage{
+		Domain: strings.TrimSuffix(header, siweHeaderSuffix),
+	}
+	// The domain may carry a scheme; only the authority is compared
+	if _, authority, found := strings.Cut(message.Domain, "://"); found {
+		message.Domain = authority
+	}
+
+	if !common.IsHexAddress(lines[1]) || !strings.HasPrefix(lines[1], "0x") {
+		return nil, fmt.Errorf("%w: invalid address", ErrInvalidMessage)
+	}
+	message.Address = common.HexToAddress(lines[1])
+
+	i := 2
+	for i < len(lines) && lines[i] == "" {
+		i++
+	}
+
+	if i < len(lines) && !strings.HasPrefix(lines[i], "URI: ") {
+		message.Statement = lines[i]
+		i++
+		for i < len(lines) && lines[i] == "" {
+			i++
+		}
+	}
+
+	var issuedAt string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" {
+			continue
+		}
+
+		if line == "Resources:" {
+			for i++; i < len(lines); i++ {
+				if resource, ok := strings.CutPrefix(lines[i], "- "); ok {
+					message.Resources = append(message.Resources, resource)
+				}
+			}
+			break
+		}
+
+		key, value, found := strings.Cut(line, ": ")
+		if !found {
+			return nil, fmt.Errorf("%w: unexpected line %q", ErrInvalidMessage, line)
+		}
+
+		switch key {
+		case "URI":
+			message.URI = value
+		case "Version":
+			message.Version = value
+		case "Chain ID":
+			chainId, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid chain id", ErrInvalidMessage)
+			}
+			message.ChainID = chainId
+		case "Nonce":
+			message.Nonce = value
+		case "Issued At":
+			issuedAt = value
+		case "Expiration Time":
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid expiration time", ErrInvalidMessage)
+			}
+			message.ExpirationTime = &t
+		case "Not Before":
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid not before", ErrInvalidMessage)
+			}
+			message.NotBefore = &t
+		case "Request ID":
+			message.RequestID = value
+		default:
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidMessage, key)
+		}
+	}
+
+	if message.URI == "" || message.Version != "1" || message.ChainID == 0 || len(message.Nonce) < 8 || issuedAt == "" {
+		return nil, fmt.Errorf("%w: uri, version 1, chain id, nonce and issued at are required", ErrInvalidMessage)
+	}
+
+	t, err := time.Parse(time.RFC3339, issuedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid issued at", ErrInvalidMessage)
+	}
+	message.IssuedAt = t
+
+	return message, nil
+}
+
+// Validate checks the message's domain and validity window
+func (m *SIWEMessage) Validate(domain string, now time.Time) error {
+	if !strings.EqualFold(m.Domain, domain) {
+		return ErrDomainMismatch
+	}
+
+	if m.IssuedAt.After(now.Add(clockSkew)) {
+		return ErrMessageNotYet
+	}
+
+	if m.NotBefore != nil && m.NotBefore.After(now.Add(clockSkew)) {
+		return ErrMessageNotYet
+	}
+
+	if m.ExpirationTime != nil && now.After(*m.ExpirationTime) {
+		return ErrMessageExpired
+	}
+
+	return nil
+}
+
+// VerifySignature checks that the EIP-191 personal_sign signature over text was
+// made by the message address. Smart contract wallets (EIP-1271) are not supported.
+func (m *SIWEMessage) VerifySignature(text, signature string) error {
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return ErrInvalidSignature
+	}
+
+	// Wallets return v as 27/28; go-ethereum expects 0/1
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(accounts.TextHash([]byte(text)), sig)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if crypto.PubkeyToAddress(*pub) != m.Address {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
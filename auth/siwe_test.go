@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const siweAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+func siweMessage(lines ...string) string {
+	return strings.Join(lines, "\n")
+}
+
+func TestParseSIWEMessage(t *testing.T) {
+	full := siweMessage(
+		"https://market.example wants you to sign in with your Ethereum account:",
+		siweAddress,
+		"",
+		"Sign in to Stable Market",
+		"",
+		"URI: https://market.example/login",
+		"Version: 1",
+		"Chain ID: 84532",
+		"Nonce: abcdef1234",
+		"Issued At: 2026-01-02T03:04:05Z",
+		"Expiration Time: 2026-01-02T03:14:05Z",
+		"Request ID: req-1",
+		"Resources:",
+		"- https://market.example/terms",
+		"- ipfs://bafy",
+	)
+
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+		check   func(t *testing.T, m *SIWEMessage)
+	}{
+		{
+			name: "full message",
+			text: full,
+			check: func(t *testing.T, m *SIWEMessage) {
+				if m.Domain != "market.example" {
+					t.Errorf("Domain = %q, want the scheme stripped", m.Domain)
+				}
+				if m.Address.Hex() != siweAddress || m.Statement != "Sign in to Stable Market" {
+					t.Errorf("Address, Statement = %s, %q", m.Address.Hex(), m.Statement)
+				}
+				if m.ChainID != 84532 || m.Nonce != "abcdef1234" || m.RequestID != "req-1" {
+					t.Errorf("ChainID, Nonce, RequestID = %d, %q, %q", m.ChainID, m.Nonce, m.RequestID)
+				}
+				if m.ExpirationTime == nil || m.ExpirationTime.Sub(m.IssuedAt).Minutes() != 10 {
+					t.Errorf("IssuedAt, ExpirationTime = %v, %v", m.IssuedAt, m.ExpirationTime)
+				}
+				if want := []string{"https://market.example/terms", "ipfs://bafy"}; !reflect.DeepEqual(m.Resources, want) {
+					t.Errorf("Resources = %v, want %v", m.Resources, want)
+				}
+			},
+		},
+		{
+			name: "no statement and CRLF line endings",
+			text: strings.Join([]string{
+				"market.example wants you to sign in with your Ethereum account:",
+				siweAddress,
+				"",
+				"URI: https://market.example",
+				"Version: 1",
+				"Chain ID: 1",
+				"Nonce: abcdef1234",
+				"Issued At: 2026-01-02T03:04:05Z",
+			}, "\r\n"),
+			check: func(t *testing.T, m *SIWEMessage) {
+				if m.Statement != "" || m.URI != "https://market.example" || m.ExpirationTime != nil {
+					t.Errorf("message = %+v", m)
+				}
+			},
+		},
+		{
+			name:    "too short",
+			text:    "market.example wants you to sign in with your Ethereum account:",
+			wantErr: true,
+		},
+		{
+			name:    "missing header",
+			text:    strings.Replace(full, "wants you to sign in", "asks you to sign in", 1),
+			wantErr: true,
+		},
+		{
+			name:    "address without prefix",
+			text:    strings.Replace(full, siweAddress, strings.TrimPrefix(siweAddress, "0x"), 1),
+			wantErr: true,
+		},
+		{
+			name:    "unsupported version",
+			text:    strings.Replace(full, "Version: 1", "Version: 2", 1),
+			wantErr: true,
+		},
+		{
+			name:    "invalid chain id",
+			text:    strings.Replace(full, "Chain ID: 84532", "Chain ID: base", 1),
+			wantErr: true,
+		},
+		{
+			name:    "short nonce",
+			text:    strings.Replace(full, "Nonce: abcdef1234", "Nonce: abc", 1),
+			wantErr: true,
+		},
+		{
+			name:    "invalid issued at",
+			text:    strings.Replace(full, "2026-01-02T03:04:05Z", "yesterday", 1),
+			wantErr: true,
+		},
+		{
+			name:    "invalid expiration time",
+			text:    strings.Replace(full, "2026-01-02T03:14:05Z", "later", 1),
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			text:    strings.Replace(full, "Request ID: req-1", "Session: 1", 1),
+			wantErr: true,
+		},
+		{
+			name:    "missing uri",
+			text:    strings.Replace(full, "URI: https://market.example/login\n", "", 1),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseSIWEMessage(tt.text)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMessage) {
+					t.Fatalf("err = %v, want ErrInvalidMessage", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, m)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const tokenIssuer = "stable-market"
+
+// DefaultTokenTTL is how long a session token stays valid when AUTH_TOKEN_TTL is unset
+const DefaultTokenTTL = time.Hour
+
+var ErrInvalidToken = errors.New("invalid or expired session token")
+
+var signingKey []byte
+
+// siweDomain is the domain sign-in messages must be issued for
+var siweDomain string
+
+// SIWEDomain returns the domain SIWE messages must be issued for (SIWE_DOMAIN)
+func SIWEDomain() string {
+	return siweDomain
+}
+
+// Init loads the SIWE domain from SIWE_DOMAIN and the token signing key from
+// AUTH_JWT_SECRET. The domain is required: taking it from the request's Host
+// header would let a phishing site choose the domain it is checked against.
+// Without a signing key a random one is generated, which invalidates sessions on
+// restart and across instances.
+func Init() error {
+	siweDomain = os.Getenv("SIWE_DOMAIN")
+	if siweDomain == "" {
+		return errors.New("SIWE_DOMAIN must be set to the domain sign-in messages are issued for")
+	}
+
+	if secret := os.Getenv("AUTH_JWT_SECRET"); secret != "" {
+		if len(secret) < 32 {
+			return errors.New("AUTH_JWT_SECRET must be at least 32 characters")
+		}
+		signingKey = []byte(secret)
+		return nil
+	}
+
+	log.Println("AUTH_JWT_SECRET not set, using a random signing key; sessions will not survive a restart")
+	signingKey = make([]byte, 32)
+	_, err := rand.Read(signingKey)
+	return err
+}
+
+// TokenTTL returns the configured session lifetime (AUTH_TOKEN_TTL, e.g. "1h")
+func TokenTTL() time.Duration {
+	if value := os.Getenv("AUTH_TOKEN_TTL"); value != "" {
+		if ttl, err := time.ParseDuration(value); err == nil && ttl > 0 {
+			return ttl
+		}
+	}
+	return DefaultTokenTTL
+}
+
+// SessionClaims are the claims carried by a session token
+type SessionClaims struct {
+	ChainID int64 `json:"chainId"`
+	jwt.RegisteredClaims
+}
+
+// IssueToken signs a session token for a wallet that completed sign in
+func IssueToken(address common.Address, chainId int64, now time.Time) (string, time.Time, error) {
+	if signingKey == nil {
+		return "", time.Time{}, errors.New("auth is not initialized")
+	}
+
+	expiresAt := now.Add(TokenTTL())
+	claims := SessionClaims{
+		ChainID: chainId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   address.Hex(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(signingKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// ParseToken verifies a session token and returns its claims
+func ParseToken(tokenString string) (*SessionClaims, error) {
+	if signingKey == nil {
+		return nil, ErrInvalidToken
+	}
+
+	claims := &SessionClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, ErrInvalidToken
+		}
+		return signingKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Issuer != tokenIssuer || !common.IsHexAddress(claims.Subject) {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// Address returns the wallet the token was issued to
+func (c *SessionClaims) Address() common.Address {
+	return common.HexToAddress(c.Subject)
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// ListRoles lists the roles held by walletAddress, or the role catalog when it is empty
+func (c *Client) ListRoles(ctx context.Context, walletAddress string) (Result, error) {
+	q := url.Values{}
+	setIf(q, "walletAddress", walletAddress)
+
+	var out Result
+	if err := c.doJSON(ctx, http.MethodGet, "/api/admin/roles", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GrantRole grants a role to a wallet
+func (c *Client) GrantRole(ctx context.Context, req models.GrantRoleRequest) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/admin/roles", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RevokeRole revokes a role from a wallet. The reason is recorded in the audit log.
+func (c *Client) RevokeRole(ctx context.Context, walletAddress, role string, req models.RevokeRoleRequest) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodDelete, path("/api/admin/roles/", walletAddress, "/", role), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListRoleAudit lists role grants and revocations, of one wallet when walletAddress is set
+func (c *Client) ListRoleAudit(ctx context.Context, walletAddress string, page Page) (*models.RoleAuditListResponse, error) {
+	q := url.Values{}
+	setIf(q, "walletAddress", walletAddress)
+
+	var out models.RoleAuditListResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/api/admin/roles/audit", page.query(q), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// APIKeyResult is the body of CreateAPIKey and RevokeAPIKey
+type APIKeyResult struct {
+	APIKey  models.APIKey `json:"apiKey"`
+	Message string        `json:"message"`
+}
+
+// APIKeyList is the body of ListAPIKeys
+type APIKeyList struct {
+	APIKeys []models.APIKey `json:"apiKeys"`
+	Scopes  []string        `json:"scopes"`
+}
+
+// CreateAPIKey creates a merchant API key. The key is only returned here.
+func (c *Client) CreateAPIKey(ctx context.Context, merchantId string, req models.CreateAPIKeyRequest) (*APIKeyResult, error) {
+	var out APIKeyResult
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/merchants/", merchantId, "/api-keys"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAPIKeys lists a merchant's API keys without their secrets
+func (c *Client) ListAPIKeys(ctx context.Context, merchantId string) (*APIKeyList, error) {
+	var out APIKeyList
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/api-keys"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeAPIKey revokes a merchant API key
+func (c *Client) RevokeAPIKey(ctx context.Context, merchantId string, keyId int64) (*APIKeyResult, error) {
+	var out APIKeyResult
+	if err := c.doJSON(ctx, http.MethodDelete, path("/api/merchants/", merchantId, "/api-keys/", strconv.FormatInt(keyId, 10)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// GetAuthNonce issues a nonce for a SIWE message
+func (c *Client) GetAuthNonce(ctx context.Context) (*models.AuthNonceResponse, error) {
+	var out models.AuthNonceResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/api/auth/nonce", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// VerifySIWE exchanges a signed SIWE message for a session. Pass the returned
+// token to WithSessionToken to call wallet authenticated operations.
+func (c *Client) VerifySIWE(ctx context.Context, req models.VerifySIWERequest) (*models.AuthSessionResponse, error) {
+	var out models.AuthSessionResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/auth/verify", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAuthSession describes the current session
+func (c *Client) GetAuthSession(ctx context.Context) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodGet, "/api/auth/me", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Logout ends the current session
+func (c *Client) Logout(ctx context.Context) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/auth/logout", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// CreateCart opens a cart
+func (c *Client) CreateCart(ctx context.Context, req models.CreateCartRequest) (*models.CartResponse, error) {
+	var out models.CartResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/carts", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCart fetches a cart with its priced lines
+func (c *Client) GetCart(ctx context.Context, cartId string) (*models.CartResponse, error) {
+	var out models.CartResponse
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/carts/", cartId), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AddCartItem adds a product to a cart
+func (c *Client) AddCartItem(ctx context.Context, cartId string, req models.CartItemRequest) (*models.CartResponse, error) {
+	var out models.CartResponse
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/carts/", cartId, "/items"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateCartItem changes the quantity of a product in a cart
+func (c *Client) UpdateCartItem(ctx context.Context, cartId string, productId int64, req models.UpdateCartItemRequest) (*models.CartResponse, error) {
+	var out models.CartResponse
+	route := path("/api/carts/", cartId, "/items/", strconv.FormatInt(productId, 10))
+	if err := c.doJSON(ctx, http.MethodPatch, route, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveCartItem removes a product from a cart
+func (c *Client) RemoveCartItem(ctx context.Context, cartId string, productId int64) (*models.CartResponse, error) {
+	var out models.CartResponse
+	route := path("/api/carts/", cartId, "/items/", strconv.FormatInt(productId, 10))
+	if err := c.doJSON(ctx, http.MethodDelete, route, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CheckoutCart builds the createOrder transaction for the cart total
+func (c *Client) CheckoutCart(ctx context.Context, cartId string, req models.CheckoutCartRequest) (*models.CheckoutCartResponse, error) {
+	var out models.CheckoutCartResponse
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/carts/", cartId, "/checkout"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// CreateCheckoutSession opens a hosted checkout session
+func (c *Client) CreateCheckoutSession(ctx context.Context, req models.CreateCheckoutSessionRequest) (*models.CheckoutSessionResponse, error) {
+	var out models.CheckoutSessionResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/checkout/sessions", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCheckoutSession fetches a checkout session
+func (c *Client) GetCheckoutSession(ctx context.Context, sessionId string) (*models.CheckoutSessionResponse, error) {
+	var out models.CheckoutSessionResponse
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/checkout/sessions/", sessionId), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCheckoutSessionQRCode returns the session's QR code as a PNG. It encodes the
+// payment URI, or the hosted checkout URL when targetURL is set.
+func (c *Client) GetCheckoutSessionQRCode(ctx context.Context, sessionId string, targetURL bool) ([]byte, error) {
+	var q url.Values
+	if targetURL {
+		q = url.Values{"target": {"url"}}
+	}
+
+	var png []byte
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/checkout/sessions/", sessionId, "/qr.png"), q, nil, &png); err != nil {
+		return nil, err
+	}
+	return png, nil
+}
+
+// ApproveCheckoutSession prepares the token approval for the session amount
+func (c *Client) ApproveCheckoutSession(ctx context.Context, sessionId string) (Result, error) {
+	return c.checkoutStep(ctx, sessionId, "/approve", nil)
+}
+
+// ConfirmApproveCheckoutSession records the payer's approval transaction
+func (c *Client) ConfirmApproveCheckoutSession(ctx context.Context, sessionId string, req models.CheckoutStepRequest) (Result, error) {
+	return c.checkoutStep(ctx, sessionId, "/confirm-approve", req)
+}
+
+// CreateCheckoutOrder prepares the session's createOrder transaction
+func (c *Client) CreateCheckoutOrder(ctx context.Context, sessionId string, req models.CheckoutStepRequest) (Result, error) {
+	return c.checkoutStep(ctx, sessionId, "/create", req)
+}
+
+// ConfirmCheckoutOrder records the session's mined createOrder transaction
+func (c *Client) ConfirmCheckoutOrder(ctx context.Context, sessionId string, req models.CheckoutStepRequest) (Result, error) {
+	return c.checkoutStep(ctx, sessionId, "/confirm-create", req)
+}
+
+// PayCheckoutOrder prepares the payOrder transaction for the session's order
+func (c *Client) PayCheckoutOrder(ctx context.Context, sessionId string) (Result, error) {
+	return c.checkoutStep(ctx, sessionId, "/pay", nil)
+}
+
+// ConfirmCheckoutPayment records the mined payOrder transaction
+func (c *Client) ConfirmCheckoutPayment(ctx context.Context, sessionId string, req models.CheckoutStepRequest) (Result, error) {
+	return c.checkoutStep(ctx, sessionId, "/confirm-pay", req)
+}
+
+func (c *Client) checkoutStep(ctx context.Context, sessionId, step string, req interface{}) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/checkout/sessions/", sessionId, step), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
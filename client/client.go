@@ -0,0 +1,230 @@
+// Package client is a typed Go client for the Stable Market HTTP API. Methods
+// follow the operations in the OpenAPI document served at /openapi.json and take
+// and return the request and response types from the models package.
+//
+// Failed requests return an *Error carrying the API error code. It matches the
+// service sentinel errors with errors.Is, so callers can test for example
+// errors.Is(err, services.ErrOrderNotFound).
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+)
+
+// Result is the body of operations that answer with a free form object
+type Result map[string]interface{}
+
+// Client calls the API. It is safe for concurrent use once configured.
+type Client struct {
+	baseURL      string
+	httpClient   *http.Client
+	sessionToken string
+	apiKey       string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient replaces the default HTTP client, which times out after 30 seconds
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithSessionToken authenticates requests with a wallet session token, as
+// returned by VerifySIWE
+func WithSessionToken(token string) Option {
+	return func(c *Client) {
+		c.sessionToken = token
+	}
+}
+
+// WithAPIKey authenticates requests with a merchant API key
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// New creates a client for the API at baseURL, e.g. https://api.example.com
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error is an error response from the API
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    []apierror.FieldError
+	Meta       map[string]interface{}
+	RequestID  string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%d %s: %s", e.StatusCode, e.Code, e.Message)
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// Is matches API errors by code
+func (e *Error) Is(target error) bool {
+	switch t := target.(type) {
+	case *apierror.Error:
+		return t.Code == e.Code
+	case *Error:
+		return t.Code == e.Code
+	}
+	return false
+}
+
+// path joins a path template's segments, escaping the parameters
+func path(segments ...string) string {
+	var b strings.Builder
+	for i, segment := range segments {
+		if i%2 == 1 {
+			segment = url.PathEscape(segment)
+		}
+		b.WriteString(segment)
+	}
+	return b.String()
+}
+
+// doJSON sends body as JSON, when it is not nil, and decodes the response into out
+func (c *Client) doJSON(ctx context.Context, method, route string, query url.Values, body, out interface{}) error {
+	var reader io.Reader
+	contentType := ""
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+		contentType = "application/json"
+	}
+
+	return c.do(ctx, method, route, query, reader, contentType, out)
+}
+
+func (c *Client) do(ctx context.Context, method, route string, query url.Values, body io.Reader, contentType string, out interface{}) error {
+	endpoint := c.baseURL + route
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.sessionToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.sessionToken)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *[]byte:
+		*out, err = io.ReadAll(resp.Body)
+		return err
+	default:
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+}
+
+func decodeError(resp *http.Response) error {
+	var envelope struct {
+		Error struct {
+			Code      string                 `json:"code"`
+			Message   string                 `json:"message"`
+			Details   []apierror.FieldError  `json:"details"`
+			Meta      map[string]interface{} `json:"meta"`
+			RequestID string                 `json:"requestId"`
+		} `json:"error"`
+	}
+
+	apiErr := &Error{StatusCode: resp.StatusCode, RequestID: resp.Header.Get(apierror.RequestIDHeader)}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil || envelope.Error.Code == "" {
+		// Not the API's envelope, e.g. a proxy error page
+		apiErr.Code = http.StatusText(resp.StatusCode)
+		apiErr.Message = resp.Status
+		return apiErr
+	}
+
+	apiErr.Code = envelope.Error.Code
+	apiErr.Message = envelope.Error.Message
+	apiErr.Details = envelope.Error.Details
+	apiErr.Meta = envelope.Error.Meta
+	if envelope.Error.RequestID != "" {
+		apiErr.RequestID = envelope.Error.RequestID
+	}
+	return apiErr
+}
+
+// Page selects a page of a list operation. The zero value is the first page,
+// newest first, at the server's default size.
+type Page struct {
+	Limit     int
+	Cursor    string
+	Ascending bool
+}
+
+func (p Page) query(q url.Values) url.Values {
+	if q == nil {
+		q = url.Values{}
+	}
+	if p.Limit > 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Cursor != "" {
+		q.Set("cursor", p.Cursor)
+	}
+	if p.Ascending {
+		q.Set("sort", "createdAt")
+	}
+	return q
+}
+
+// setIf adds a query parameter when value is not empty
+func setIf(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// OpenDispute opens the buyer's dispute on a paid order. The session must
+// belong to the order's buyer.
+func (c *Client) OpenDispute(ctx context.Context, orderId string, req models.OpenDisputeRequest) (*models.DisputeDetails, error) {
+	var out models.DisputeDetails
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/orders/", orderId, "/dispute"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOrderDispute returns an order's dispute to its buyer or merchant
+func (c *Client) GetOrderDispute(ctx context.Context, orderId string) (*models.DisputeDetails, error) {
+	var out models.DisputeDetails
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/orders/", orderId, "/dispute"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AddDisputeEvidence uploads a JPEG or PNG as evidence in an order's dispute,
+// with an optional note
+func (c *Client) AddDisputeEvidence(ctx context.Context, orderId string, image io.Reader, imageName, note string) (*models.DisputeEventDB, error) {
+	fields := map[string]string{}
+	if note != "" {
+		fields["note"] = note
+	}
+
+	var out models.DisputeEventDB
+	if err := c.doMultipart(ctx, http.MethodPost, path("/api/orders/", orderId, "/dispute/evidence"), fields, image, imageName, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RespondToDispute records the merchant's response to an order's dispute
+func (c *Client) RespondToDispute(ctx context.Context, orderId string, req models.RespondDisputeRequest) (*models.DisputeDetails, error) {
+	var out models.DisputeDetails
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/orders/", orderId, "/dispute/response"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListMerchantDisputes lists the disputes on a merchant's orders, newest
+// first. status may be empty, open, responded or resolved.
+func (c *Client) ListMerchantDisputes(ctx context.Context, merchantId, status string, page Page) (*models.DisputeListResponse, error) {
+	q := url.Values{}
+	setIf(q, "status", status)
+
+	var out models.DisputeListResponse
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/disputes"), page.query(q), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListDisputes lists every merchant's disputes for reviewers, newest first
+func (c *Client) ListDisputes(ctx context.Context, status string, page Page) (*models.DisputeListResponse, error) {
+	q := url.Values{}
+	setIf(q, "status", status)
+
+	var out models.DisputeListResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/api/platform/disputes", page.query(q), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDispute returns an order's dispute to a reviewer
+func (c *Client) GetDispute(ctx context.Context, orderId string) (*models.DisputeDetails, error) {
+	var out models.DisputeDetails
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/platform/orders/", orderId, "/dispute"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResolveDispute decides a dispute and queues the order's refund or
+// settlement. Resolving again with the same outcome queues a payout whose
+// attempt failed again.
+func (c *Client) ResolveDispute(ctx context.Context, orderId string, req models.ResolveDisputeRequest) (*models.ResolveDisputeResponse, error) {
+	var out models.ResolveDisputeResponse
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/platform/orders/", orderId, "/dispute/resolve"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// ExportQuery selects the format and range of an export. Empty fields use the
+// server's defaults: CSV over the last 30 days.
+type ExportQuery struct {
+	Format string
+	From   time.Time
+	To     time.Time
+}
+
+func (e ExportQuery) values() url.Values {
+	q := url.Values{}
+	setIf(q, "format", e.Format)
+	if !e.From.IsZero() {
+		q.Set("from", e.From.UTC().Format(time.RFC3339))
+	}
+	if !e.To.IsZero() {
+		q.Set("to", e.To.UTC().Format(time.RFC3339))
+	}
+	return q
+}
+
+// ExportMerchantRecords downloads a merchant's orders, settlements or refunds.
+// Exports too large to serve directly fail with EXPORT_TOO_LARGE; use
+// CreateMerchantExportJob for those.
+func (c *Client) ExportMerchantRecords(ctx context.Context, merchantId, kind string, query ExportQuery) ([]byte, error) {
+	var out []byte
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/exports/", kind), query.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateMerchantExportJob queues a merchant export to be written in the background
+func (c *Client) CreateMerchantExportJob(ctx context.Context, merchantId, kind string, query ExportQuery) (*models.ExportJob, error) {
+	var out models.ExportJob
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/merchants/", merchantId, "/exports/", kind), query.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListMerchantExportJobs lists a merchant's most recent export jobs
+func (c *Client) ListMerchantExportJobs(ctx context.Context, merchantId string) (*models.ExportJobListResponse, error) {
+	var out models.ExportJobListResponse
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/export-jobs"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMerchantExportJob fetches a merchant export job's status
+func (c *Client) GetMerchantExportJob(ctx context.Context, merchantId string, jobId int64) (*models.ExportJob, error) {
+	var out models.ExportJob
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/export-jobs/", strconv.FormatInt(jobId, 10)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DownloadMerchantExport downloads the file of a ready merchant export job
+func (c *Client) DownloadMerchantExport(ctx context.Context, merchantId string, jobId int64) ([]byte, error) {
+	var out []byte
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/export-jobs/", strconv.FormatInt(jobId, 10), "/download"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExportPlatformRecords downloads every merchant's orders, settlements or
+// refunds, or the platform's emergency withdrawals
+func (c *Client) ExportPlatformRecords(ctx context.Context, kind string, query ExportQuery) ([]byte, error) {
+	var out []byte
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/platform/exports/", kind), query.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreatePlatformExportJob queues a platform export to be written in the background
+func (c *Client) CreatePlatformExportJob(ctx context.Context, kind string, query ExportQuery) (*models.ExportJob, error) {
+	var out models.ExportJob
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/platform/exports/", kind), query.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListPlatformExportJobs lists the most recent platform export jobs
+func (c *Client) ListPlatformExportJobs(ctx context.Context) (*models.ExportJobListResponse, error) {
+	var out models.ExportJobListResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/api/platform/export-jobs", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPlatformExportJob fetches a platform export job's status
+func (c *Client) GetPlatformExportJob(ctx context.Context, jobId int64) (*models.ExportJob, error) {
+	var out models.ExportJob
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/platform/export-jobs/", strconv.FormatInt(jobId, 10)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DownloadPlatformExport downloads the file of a ready platform export job
+func (c *Client) DownloadPlatformExport(ctx context.Context, jobId int64) ([]byte, error) {
+	var out []byte
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/platform/export-jobs/", strconv.FormatInt(jobId, 10), "/download"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
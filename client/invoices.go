@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// GetOrderInvoice returns a paid order's invoice. The session must belong to
+// the order's buyer or its merchant's owner.
+func (c *Client) GetOrderInvoice(ctx context.Context, orderId string) (*models.Invoice, error) {
+	return c.getOrderDocument(ctx, orderId, "/invoice")
+}
+
+// GetOrderCreditNote returns a refunded order's credit note
+func (c *Client) GetOrderCreditNote(ctx context.Context, orderId string) (*models.Invoice, error) {
+	return c.getOrderDocument(ctx, orderId, "/credit-note")
+}
+
+// DownloadOrderInvoice returns a paid order's invoice rendered as html or pdf
+func (c *Client) DownloadOrderInvoice(ctx context.Context, orderId, format string) ([]byte, error) {
+	return c.downloadOrderDocument(ctx, orderId, "/invoice", format)
+}
+
+// DownloadOrderCreditNote returns a refunded order's credit note rendered as html or pdf
+func (c *Client) DownloadOrderCreditNote(ctx context.Context, orderId, format string) ([]byte, error) {
+	return c.downloadOrderDocument(ctx, orderId, "/credit-note", format)
+}
+
+// ListMerchantInvoices lists a merchant's invoices and credit notes, newest
+// first. documentType may be empty, invoice or credit_note.
+func (c *Client) ListMerchantInvoices(ctx context.Context, merchantId, documentType string, page Page) (*models.InvoiceListResponse, error) {
+	q := url.Values{}
+	setIf(q, "type", documentType)
+
+	var out models.InvoiceListResponse
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/invoices"), page.query(q), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) getOrderDocument(ctx context.Context, orderId, document string) (*models.Invoice, error) {
+	q := url.Values{"format": {models.DocumentFormatJSON}}
+
+	var out models.Invoice
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/orders/", orderId, document), q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) downloadOrderDocument(ctx context.Context, orderId, document, format string) ([]byte, error) {
+	q := url.Values{}
+	setIf(q, "format", format)
+
+	var out []byte
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/orders/", orderId, document), q, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
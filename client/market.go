@@ -0,0 +1,163 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// NewProduct is the multipart body of CreateProduct
+type NewProduct struct {
+	Name        string
+	Price       float64
+	Description string
+	MerchantId  string
+	// Stock is nil for products without inventory tracking
+	Stock *int64
+	// Image is uploaded as the product image under ImageName
+	Image     io.Reader
+	ImageName string
+}
+
+// ProductUpdate is the multipart body of UpdateProduct. Only the fields that are
+// set are sent.
+type ProductUpdate struct {
+	Name        *string
+	Price       *float64
+	Description *string
+	Stock       *int64
+	// ClearStock stops inventory tracking for the product
+	ClearStock bool
+	Image      io.Reader
+	ImageName  string
+}
+
+// CreateProduct adds a product to a merchant's catalog
+func (c *Client) CreateProduct(ctx context.Context, product NewProduct) (Result, error) {
+	fields := map[string]string{
+		"name":        product.Name,
+		"price":       strconv.FormatFloat(product.Price, 'f', -1, 64),
+		"description": product.Description,
+		"merchantId":  product.MerchantId,
+	}
+	if product.Stock != nil {
+		fields["stock"] = strconv.FormatInt(*product.Stock, 10)
+	}
+
+	var out Result
+	if err := c.doMultipart(ctx, http.MethodPost, "/api/market/add-product", fields, product.Image, product.ImageName, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateProduct changes the fields set in update
+func (c *Client) UpdateProduct(ctx context.Context, productId int64, update ProductUpdate) (Result, error) {
+	fields := map[string]string{}
+	if update.Name != nil {
+		fields["name"] = *update.Name
+	}
+	if update.Price != nil {
+		fields["price"] = strconv.FormatFloat(*update.Price, 'f', -1, 64)
+	}
+	if update.Description != nil {
+		fields["description"] = *update.Description
+	}
+	if update.Stock != nil {
+		fields["stock"] = strconv.FormatInt(*update.Stock, 10)
+	} else if update.ClearStock {
+		fields["stock"] = ""
+	}
+
+	var out Result
+	route := path("/api/market/products/", strconv.FormatInt(productId, 10))
+	if err := c.doMultipart(ctx, http.MethodPatch, route, fields, update.Image, update.ImageName, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteProduct removes a product from the catalog
+func (c *Client) DeleteProduct(ctx context.Context, productId int64) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodDelete, path("/api/market/products/", strconv.FormatInt(productId, 10)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListProducts lists catalog products, of one merchant when merchantId is set
+func (c *Client) ListProducts(ctx context.Context, merchantId string, page Page) (*models.ProductListResponse, error) {
+	q := url.Values{}
+	setIf(q, "merchantId", merchantId)
+
+	var out models.ProductListResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/api/market/products", page.query(q), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetProduct fetches a product
+func (c *Client) GetProduct(ctx context.Context, productId int64) (*models.Products, error) {
+	var out models.Products
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/market/products/", strconv.FormatInt(productId, 10)), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListMerchantProducts lists a merchant's catalog
+func (c *Client) ListMerchantProducts(ctx context.Context, merchantId string, page Page) (*models.ProductListResponse, error) {
+	var out models.ProductListResponse
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/market/merchants/", merchantId, "/products"), page.query(nil), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetLowStockProducts lists a merchant's tracked products at or below threshold.
+// A threshold of zero or less uses the server default.
+func (c *Client) GetLowStockProducts(ctx context.Context, merchantId string, threshold int64) (Result, error) {
+	q := url.Values{}
+	if threshold > 0 {
+		q.Set("threshold", strconv.FormatInt(threshold, 10))
+	}
+
+	var out Result
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/market/merchants/", merchantId, "/low-stock"), q, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) doMultipart(ctx context.Context, method, route string, fields map[string]string, file io.Reader, fileName string, out interface{}) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+	if file != nil {
+		part, err := writer.CreateFormFile("file", fileName)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, file); err != nil {
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return c.do(ctx, method, route, nil, &body, writer.FormDataContentType(), out)
+}
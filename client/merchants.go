@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// BalanceHistoryQuery selects a balance history. Empty fields use the server's
+// defaults: daily buckets over the last 30 days, every network and token.
+type BalanceHistoryQuery struct {
+	Bucket       string
+	From         time.Time
+	To           time.Time
+	Network      string
+	TokenAddress string
+}
+
+func (h BalanceHistoryQuery) values() url.Values {
+	q := url.Values{}
+	setIf(q, "bucket", h.Bucket)
+	setIf(q, "network", h.Network)
+	setIf(q, "tokenAddress", h.TokenAddress)
+	if !h.From.IsZero() {
+		q.Set("from", h.From.UTC().Format(time.RFC3339))
+	}
+	if !h.To.IsZero() {
+		q.Set("to", h.To.UTC().Format(time.RFC3339))
+	}
+	return q
+}
+
+// ReportQuery selects the range and grouping of merchant analytics and fee
+// reports. Empty fields use the server's defaults: daily groups over the last
+// 30 days, every network and token.
+type ReportQuery struct {
+	GroupBy      string
+	From         time.Time
+	To           time.Time
+	Network      string
+	TokenAddress string
+}
+
+func (r ReportQuery) values() url.Values {
+	q := url.Values{}
+	setIf(q, "groupBy", r.GroupBy)
+	setIf(q, "network", r.Network)
+	setIf(q, "tokenAddress", r.TokenAddress)
+	if !r.From.IsZero() {
+		q.Set("from", r.From.UTC().Format(time.RFC3339))
+	}
+	if !r.To.IsZero() {
+		q.Set("to", r.To.UTC().Format(time.RFC3339))
+	}
+	return q
+}
+
+// RegisterMerchant registers a merchant owned by the signed in wallet
+func (c *Client) RegisterMerchant(ctx context.Context, req models.MerchantInfo) (*models.MerchantResponse, error) {
+	var out models.MerchantResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/merchants/register", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMerchantInfo fetches a merchant
+func (c *Client) GetMerchantInfo(ctx context.Context, merchantId string) (*models.MerchantResponse, error) {
+	var out models.MerchantResponse
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/merchant-info/", merchantId), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteMerchant deletes a merchant
+func (c *Client) DeleteMerchant(ctx context.Context, merchantId string) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodDelete, path("/api/merchants/delete/", merchantId), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetMerchantBalance reads a merchant's token balance held by the contract
+//
+// Deprecated: use GetMerchantBalances.
+func (c *Client) GetMerchantBalance(ctx context.Context, merchantId string, req models.TokenBalance) (*models.TokenBalanceDB, error) {
+	q := url.Values{"walletAddress": {req.WalletAddress}, "tokenAddress": {req.TokenAddress}}
+	var out models.TokenBalanceDB
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/balance/", merchantId), q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMerchantBalances reads the merchant's payout wallet balances on every
+// network and its paid orders awaiting settlement
+func (c *Client) GetMerchantBalances(ctx context.Context, merchantId string) (*models.MerchantBalancesResponse, error) {
+	var out models.MerchantBalancesResponse
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/balances"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMerchantBalanceHistory reads the bucketed balance history of the
+// merchant's payout wallet
+func (c *Client) GetMerchantBalanceHistory(ctx context.Context, merchantId string, query BalanceHistoryQuery) (*models.BalanceHistoryResponse, error) {
+	var out models.BalanceHistoryResponse
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/balances/history"), query.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMerchantAnalytics reads the merchant's sales analytics
+func (c *Client) GetMerchantAnalytics(ctx context.Context, merchantId string, query ReportQuery) (*models.MerchantAnalyticsResponse, error) {
+	var out models.MerchantAnalyticsResponse
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/analytics"), query.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPayoutPolicy reads when the merchant's paid orders are settled automatically
+func (c *Client) GetPayoutPolicy(ctx context.Context, merchantId string) (*models.PayoutPolicyDB, error) {
+	var out models.PayoutPolicyDB
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/payout-policy"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdatePayoutPolicy sets the merchant's payout policy
+func (c *Client) UpdatePayoutPolicy(ctx context.Context, merchantId string, req models.UpdatePayoutPolicyRequest) (*models.PayoutPolicyDB, error) {
+	var out models.PayoutPolicyDB
+	if err := c.doJSON(ctx, http.MethodPut, path("/api/merchants/", merchantId, "/payout-policy"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOrderExpiryPolicy reads how long the merchant's orders may stay unpaid
+func (c *Client) GetOrderExpiryPolicy(ctx context.Context, merchantId string) (*models.OrderExpiryPolicyDB, error) {
+	var out models.OrderExpiryPolicyDB
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/order-expiry"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateOrderExpiryPolicy sets how long the merchant's new orders may stay unpaid
+func (c *Client) UpdateOrderExpiryPolicy(ctx context.Context, merchantId string, req models.UpdateOrderExpiryPolicyRequest) (*models.OrderExpiryPolicyDB, error) {
+	var out models.OrderExpiryPolicyDB
+	if err := c.doJSON(ctx, http.MethodPut, path("/api/merchants/", merchantId, "/order-expiry"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// IsMerchantVerified reads a merchant's verification status
+func (c *Client) IsMerchantVerified(ctx context.Context, merchantId string) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/merchant-status/", merchantId), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PrepareUpdateMerchant builds the merchant update transaction for the owner to sign
+func (c *Client) PrepareUpdateMerchant(ctx context.Context, merchantId string, req models.MerchantUpdateRequest) (*models.PrepareUpdateResponse, error) {
+	var out models.PrepareUpdateResponse
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/merchants/prepare-update/", merchantId), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmMerchantUpdate records a mined merchant update
+func (c *Client) ConfirmMerchantUpdate(ctx context.Context, merchantId string, req models.ConfirmTransactionRequest) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/merchants/confirm-update/", merchantId), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PrepareRefundOrderMerchant builds a refund transaction for the merchant to sign
+func (c *Client) PrepareRefundOrderMerchant(ctx context.Context, orderId string) (*models.PrepareRefundResponse, error) {
+	var out models.PrepareRefundResponse
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/merchants/prepare-refund/", orderId), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmRefundOrderMerchant records a mined merchant refund
+func (c *Client) ConfirmRefundOrderMerchant(ctx context.Context, orderId string, req models.ConfirmRefundRequest) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/merchants/confirm-refund/", orderId), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
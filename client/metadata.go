@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// MetadataQuery selects the document ResolveMetadata fetches. OrderId and
+// MerchantId resolve the URI and transaction recorded for that order or merchant.
+type MetadataQuery struct {
+	URI             string
+	TransactionHash string
+	OrderId         string
+	MerchantId      string
+}
+
+// ResolveMetadata fetches a metadata document and checks it against its URI and
+// the URI recorded on-chain
+func (c *Client) ResolveMetadata(ctx context.Context, query MetadataQuery) (*models.MetadataResolution, error) {
+	q := url.Values{}
+	setIf(q, "uri", query.URI)
+	setIf(q, "transactionHash", query.TransactionHash)
+	setIf(q, "orderId", query.OrderId)
+	setIf(q, "merchantId", query.MerchantId)
+
+	var out models.MetadataResolution
+	if err := c.doJSON(ctx, http.MethodGet, "/api/metadata/resolve", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMetadataDocument fetches a stored metadata document by its sha256 hash
+func (c *Client) GetMetadataDocument(ctx context.Context, hash string) (json.RawMessage, error) {
+	var document []byte
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/metadata/", hash), nil, nil, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// OrderFilter narrows ListOrders. Empty fields are not applied.
+type OrderFilter struct {
+	MerchantId   string
+	PayerAddress string
+	Token        string
+	Status       string
+	Network      string
+	From         time.Time
+	To           time.Time
+}
+
+// PrepareCreateOrder builds a createOrder transaction for the payer to sign
+func (c *Client) PrepareCreateOrder(ctx context.Context, req models.CreateOrderRequest) (*models.PrepareCreateOrderResponse, error) {
+	var out models.PrepareCreateOrderResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/orders/prepare-create", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmCreateOrder records a mined createOrder transaction
+func (c *Client) ConfirmCreateOrder(ctx context.Context, req models.ConfirmCreateOrderRequest) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/orders/confirm-create", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PreparePayOrder builds a payOrder transaction for the payer to sign
+func (c *Client) PreparePayOrder(ctx context.Context, req models.PrepareOrder) (*models.PreparePayOrderResponse, error) {
+	var out models.PreparePayOrderResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/orders/prepare-pay-order", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmPayOrder records a mined payOrder transaction
+func (c *Client) ConfirmPayOrder(ctx context.Context, req models.ConfirmPayOrderRequest) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/orders/confirm-pay-order", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CancelOrder cancels an unpaid order
+func (c *Client) CancelOrder(ctx context.Context, req models.PrepareOrder) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/orders/cancel", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListOrders lists orders matching filter
+func (c *Client) ListOrders(ctx context.Context, filter OrderFilter, page Page) (*models.OrderListResponse, error) {
+	q := url.Values{}
+	setIf(q, "merchantId", filter.MerchantId)
+	setIf(q, "payerAddress", filter.PayerAddress)
+	setIf(q, "token", filter.Token)
+	setIf(q, "status", filter.Status)
+	setIf(q, "network", filter.Network)
+	if !filter.From.IsZero() {
+		q.Set("from", filter.From.UTC().Format(time.RFC3339))
+	}
+	if !filter.To.IsZero() {
+		q.Set("to", filter.To.UTC().Format(time.RFC3339))
+	}
+
+	var out models.OrderListResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/api/orders", page.query(q), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOrder fetches an order with its status history
+func (c *Client) GetOrder(ctx context.Context, orderId string) (*models.OrderDetails, error) {
+	var out models.OrderDetails
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/orders/", orderId), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
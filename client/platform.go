@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// EmergencyWithdraw withdraws tokens from the platform contract
+func (c *Client) EmergencyWithdraw(ctx context.Context, req models.EmergencyWithdraw) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/emergency-withdrawal", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetEmergencyWithdrawalEnabled enables or disables emergency withdrawals
+func (c *Client) SetEmergencyWithdrawalEnabled(ctx context.Context, req models.WithdrawalStatus) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/enable-emergency-withdrawal", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateMerchantRegistry points the platform at a merchant registry
+func (c *Client) UpdateMerchantRegistry(ctx context.Context, req models.MerchantRegistryUpdate) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/update-merchant-registry", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetTokenSupport adds or removes a supported token
+func (c *Client) SetTokenSupport(ctx context.Context, req models.TokenSupport) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/set-token-support", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateMerchantVerificationStatus sets a merchant's verification status
+func (c *Client) UpdateMerchantVerificationStatus(ctx context.Context, req models.UpdateMerchantVerificationStatus) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/merchant-verification-status", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetPlatformTokenBalance reads the token balance of a platform wallet
+func (c *Client) GetPlatformTokenBalance(ctx context.Context, req models.PlatformBalanceCheck) (Result, error) {
+	q := url.Values{"platformWallet": {req.PlatformWallet}, "tokenAddress": {req.TokenAddress}}
+	var out Result
+	if err := c.doJSON(ctx, http.MethodGet, "/api/platform/token-balance", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetContractTokenBalance reads the token balance held by the platform contract
+func (c *Client) GetContractTokenBalance(ctx context.Context, req models.ContractBalanceCheck) (Result, error) {
+	q := url.Values{"tokenAddress": {req.TokenAddress}}
+	var out Result
+	if err := c.doJSON(ctx, http.MethodGet, "/api/platform/contract-token-balance", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetContractBalanceHistory reads the bucketed balance history of the platform
+// contract on every network
+func (c *Client) GetContractBalanceHistory(ctx context.Context, query BalanceHistoryQuery) (*models.BalanceHistoryResponse, error) {
+	var out models.BalanceHistoryResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/api/platform/contract-balance-history", query.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetFeeReport reads platform fee revenue per period, merchant and token. An
+// empty merchantId covers every merchant.
+func (c *Client) GetFeeReport(ctx context.Context, merchantId string, query ReportQuery) (*models.FeeReportResponse, error) {
+	q := query.values()
+	setIf(q, "merchantId", merchantId)
+	var out models.FeeReportResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/api/platform/fee-report", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PrepareApproveToken builds a token approval transaction for the payer to sign
+func (c *Client) PrepareApproveToken(ctx context.Context, req models.ApproveTokenRequest) (*models.PrepareApproveResponse, error) {
+	var out models.PrepareApproveResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/approve-token", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmApproveToken checks a mined token approval
+func (c *Client) ConfirmApproveToken(ctx context.Context, req models.ConfirmApproveRequest) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/confirm-approve", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PrepareSettleOrder builds a settlement transaction
+func (c *Client) PrepareSettleOrder(ctx context.Context, req models.PrepareSettleOrderRequest) (*models.PrepareSettleOrderResponse, error) {
+	var out models.PrepareSettleOrderResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/prepare-settle", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmSettleOrder records a mined settlement
+func (c *Client) ConfirmSettleOrder(ctx context.Context, req models.ConfirmSettleOrderRequest) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/confirm-settle", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PrepareBatchSettle builds settlement transactions for up to 50 orders
+func (c *Client) PrepareBatchSettle(ctx context.Context, req models.PrepareBatchOrdersRequest) (*models.BatchOrdersResponse, error) {
+	var out models.BatchOrdersResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/prepare-batch-settle", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmBatchSettle records a batch of mined settlements
+func (c *Client) ConfirmBatchSettle(ctx context.Context, req models.ConfirmBatchOrdersRequest) (*models.BatchOrdersResponse, error) {
+	var out models.BatchOrdersResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/confirm-batch-settle", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOrderSettlement reads an order's payout policy, settlement holds and
+// automatic settlement attempts
+func (c *Client) GetOrderSettlement(ctx context.Context, orderId string) (*models.OrderSettlementStatus, error) {
+	var out models.OrderSettlementStatus
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/platform/orders/", orderId, "/settlement"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// HoldOrderSettlement holds an order back from automatic settlement
+func (c *Client) HoldOrderSettlement(ctx context.Context, orderId string, req models.HoldSettlementRequest) (*models.SettlementHoldDB, error) {
+	var out models.SettlementHoldDB
+	if err := c.doJSON(ctx, http.MethodPut, path("/api/platform/orders/", orderId, "/settlement-hold"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReleaseOrderSettlement releases an operator's settlement hold on an order
+func (c *Client) ReleaseOrderSettlement(ctx context.Context, orderId string) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodDelete, path("/api/platform/orders/", orderId, "/settlement-hold"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PrepareRefundOrder builds a platform refund transaction
+func (c *Client) PrepareRefundOrder(ctx context.Context, req models.PrepareRefundOrderRequest) (*models.PrepareRefundResponse, error) {
+	var out models.PrepareRefundResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/prepare-refund", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmRefundOrder records a mined platform refund
+func (c *Client) ConfirmRefundOrder(ctx context.Context, req models.ConfirmRefundOrderRequest) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/confirm-refund", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PrepareBatchRefund builds refund transactions for up to 50 orders
+func (c *Client) PrepareBatchRefund(ctx context.Context, req models.PrepareBatchOrdersRequest) (*models.BatchOrdersResponse, error) {
+	var out models.BatchOrdersResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/prepare-batch-refund", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmBatchRefund records a batch of mined refunds
+func (c *Client) ConfirmBatchRefund(ctx context.Context, req models.ConfirmBatchOrdersRequest) (*models.BatchOrdersResponse, error) {
+	var out models.BatchOrdersResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/platform/confirm-batch-refund", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
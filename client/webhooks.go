@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+func webhookPath(merchantId string, webhookId int64, suffix string) string {
+	return path("/api/merchants/", merchantId, "/webhooks/", strconv.FormatInt(webhookId, 10), suffix)
+}
+
+// CreateWebhook registers a webhook endpoint. The signing secret is only
+// returned here and by RotateWebhookSecret.
+func (c *Client) CreateWebhook(ctx context.Context, merchantId string, req models.CreateWebhookRequest) (*models.WebhookEndpoint, error) {
+	var out models.WebhookEndpoint
+	if err := c.doJSON(ctx, http.MethodPost, path("/api/merchants/", merchantId, "/webhooks"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWebhooks lists a merchant's webhook endpoints
+func (c *Client) ListWebhooks(ctx context.Context, merchantId string) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodGet, path("/api/merchants/", merchantId, "/webhooks"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateWebhook changes a webhook endpoint
+func (c *Client) UpdateWebhook(ctx context.Context, merchantId string, webhookId int64, req models.UpdateWebhookRequest) (*models.WebhookEndpoint, error) {
+	var out models.WebhookEndpoint
+	if err := c.doJSON(ctx, http.MethodPatch, webhookPath(merchantId, webhookId, ""), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteWebhook removes a webhook endpoint
+func (c *Client) DeleteWebhook(ctx context.Context, merchantId string, webhookId int64) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodDelete, webhookPath(merchantId, webhookId, ""), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RotateWebhookSecret issues a new signing secret
+func (c *Client) RotateWebhookSecret(ctx context.Context, merchantId string, webhookId int64) (Result, error) {
+	var out Result
+	if err := c.doJSON(ctx, http.MethodPost, webhookPath(merchantId, webhookId, "/rotate-secret"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListWebhookDeliveries lists deliveries to a webhook endpoint
+func (c *Client) ListWebhookDeliveries(ctx context.Context, merchantId string, webhookId int64, page Page) (*models.WebhookDeliveryListResponse, error) {
+	var out models.WebhookDeliveryListResponse
+	if err := c.doJSON(ctx, http.MethodGet, webhookPath(merchantId, webhookId, "/deliveries"), page.query(nil), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWebhookDelivery fetches a delivery with its attempts
+func (c *Client) GetWebhookDelivery(ctx context.Context, merchantId string, webhookId, deliveryId int64) (Result, error) {
+	var out Result
+	route := webhookPath(merchantId, webhookId, "/deliveries/") + strconv.FormatInt(deliveryId, 10)
+	if err := c.doJSON(ctx, http.MethodGet, route, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RedeliverWebhook sends a delivery again
+func (c *Client) RedeliverWebhook(ctx context.Context, merchantId string, webhookId, deliveryId int64) (Result, error) {
+	var out Result
+	route := webhookPath(merchantId, webhookId, "/deliveries/") + strconv.FormatInt(deliveryId, 10) + "/redeliver"
+	if err := c.doJSON(ctx, http.MethodPost, route, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// reportRanges holds the default and the longest range of each report grouping
+var reportRanges = map[string]struct{ def, max time.Duration }{
+	models.AnalyticsGroupDay:   {def: 30 * 24 * time.Hour, max: 366 * 24 * time.Hour},
+	models.AnalyticsGroupWeek:  {def: 12 * 7 * 24 * time.Hour, max: 3 * 366 * 24 * time.Hour},
+	models.AnalyticsGroupMonth: {def: 365 * 24 * time.Hour, max: 5 * 366 * 24 * time.Hour},
+}
+
+// GetMerchantAnalytics returns the merchant's order volume, funnel and refund
+// figures grouped by day, week or month, with its best selling products
+func GetMerchantAnalytics(ctx *gin.Context) {
+	query := services.AnalyticsQuery{MerchantId: ctx.Param("merchantId")}
+
+	var err error
+	if query.GroupBy, query.From, query.To, err = parseReportRange(ctx); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	if query.Network, query.TokenAddress, err = parseNetworkTokenFilter(ctx); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if _, err := services.GetMerchant(query.MerchantId); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	analytics, err := services.MerchantAnalytics(query)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, analytics)
+}
+
+// parseReportRange reads groupBy, from and to for reports built from daily data.
+// The range is widened to whole UTC days; it ends today and spans the grouping's
+// default when from or to are left out.
+func parseReportRange(ctx *gin.Context) (groupBy string, from, to time.Time, err error) {
+	groupBy = ctx.DefaultQuery("groupBy", models.AnalyticsGroupDay)
+	ranges, ok := reportRanges[groupBy]
+	if !ok {
+		return "", from, to, apierror.InvalidField(apierror.CodeInvalidGroupBy, "groupBy", "groupBy must be day, week or month")
+	}
+
+	fromParam, toParam, err := utils.ParseDateRange(ctx)
+	if err != nil {
+		return "", from, to, err
+	}
+
+	to = time.Now().UTC()
+	if toParam != nil {
+		to = *toParam
+	}
+	from = to.Add(-ranges.def)
+	if fromParam != nil {
+		from = *fromParam
+	}
+	from = from.Truncate(24 * time.Hour)
+	to = to.Truncate(24 * time.Hour)
+
+	if to.Before(from) {
+		return "", from, to, apierror.InvalidField(apierror.CodeInvalidDateRange, "from", "from must not be after to")
+	}
+	if to.Sub(from) > ranges.max {
+		return "", from, to, apierror.InvalidField(apierror.CodeInvalidDateRange, "from", "range is too long for "+groupBy+" grouping")
+	}
+
+	return groupBy, from, to, nil
+}
+
+// parseNetworkTokenFilter reads the optional network and tokenAddress filters,
+// checksumming the token address
+func parseNetworkTokenFilter(ctx *gin.Context) (network, tokenAddress string, err error) {
+	if network = ctx.Query("network"); network != "" {
+		if _, exists := networks.GetNetworkConfig(network); !exists {
+			return "", "", apierror.InvalidField(apierror.CodeUnsupportedNetwork, "network", "Unknown network: "+network)
+		}
+	}
+
+	if tokenAddress = ctx.Query("tokenAddress"); tokenAddress != "" {
+		if !common.IsHexAddress(tokenAddress) {
+			return "", "", errInvalidTokenAddress
+		}
+		tokenAddress = common.HexToAddress(tokenAddress).Hex()
+	}
+
+	return network, tokenAddress, nil
+}
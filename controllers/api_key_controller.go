@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKey issues an API key for a merchant's backend. The key is only
+// returned in this response; the service keeps its hash.
+func CreateAPIKey(ctx *gin.Context) {
+	merchantId := ctx.Param("merchantId")
+
+	var req models.CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	scopes, err := services.ValidateAPIKeyScopes(req.Scopes)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	allowedIPs, err := services.ValidateAllowedIPs(req.AllowedIPs)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil || !t.After(time.Now()) {
+			respondError(ctx, services.ErrInvalidAPIKeyExpiry)
+			return
+		}
+		expiresAt = &t
+	}
+
+	owner, _ := middleware.Wallet(ctx)
+
+	apiKey, key, err := services.CreateAPIKey(merchantId, req.Name, scopes, allowedIPs, expiresAt, owner.Hex())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response := toAPIKey(*apiKey)
+	response.Key = key
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"apiKey":  response,
+		"message": "Store this key now; it will not be shown again",
+	})
+}
+
+// ListAPIKeys returns a merchant's keys without the key material
+func ListAPIKeys(ctx *gin.Context) {
+	keys, err := services.ListAPIKeys(ctx.Param("merchantId"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response := make([]models.APIKey, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, toAPIKey(key))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"apiKeys": response,
+		"scopes":  models.APIKeyScopes,
+	})
+}
+
+// RevokeAPIKey disables a key immediately
+func RevokeAPIKey(ctx *gin.Context) {
+	keyId, err := strconv.ParseInt(ctx.Param("keyId"), 10, 64)
+	if err != nil || keyId <= 0 {
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "keyId", "keyId must be a positive integer"))
+		return
+	}
+
+	apiKey, err := services.RevokeAPIKey(ctx.Param("merchantId"), keyId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"apiKey":  toAPIKey(*apiKey),
+		"message": "API key revoked",
+	})
+}
+
+func toAPIKey(key models.APIKeyDB) models.APIKey {
+	response := models.APIKey{
+		Id:         key.Id,
+		MerchantId: key.MerchantId,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		AllowedIPs: key.AllowedIPs,
+		ExpiresAt:  key.ExpiresAt,
+		RevokedAt:  key.RevokedAt,
+		LastUsedAt: key.LastUsedAt,
+		LastUsedIP: key.LastUsedIP,
+		CreatedAt:  key.CreatedAt,
+	}
+
+	if response.AllowedIPs == nil {
+		response.AllowedIPs = []string{}
+	}
+
+	return response
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/auth"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuthNonce issues a single use nonce for the client to put in its SIWE message
+func GetAuthNonce(ctx *gin.Context) {
+	nonce, err := services.CreateAuthNonce()
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, models.AuthNonceResponse{
+		Nonce:     nonce.Nonce,
+		ExpiresAt: nonce.ExpiresAt,
+		Domain:    auth.SIWEDomain(),
+		ChainIds:  supportedChainIds(),
+	})
+}
+
+// VerifySIWE checks a signed EIP-4361 message and starts a session for its wallet.
+// The token is returned in the body and also set as an HttpOnly cookie.
+func VerifySIWE(ctx *gin.Context) {
+	var req models.VerifySIWERequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	message, err := auth.ParseSIWEMessage(req.Message)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	now := time.Now()
+	if err := message.Validate(auth.SIWEDomain(), now); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if !isSupportedChain(message.ChainID) {
+		respondError(ctx, apierror.BadRequest(apierror.CodeUnsupportedNetwork, "Unsupported chain id"))
+		return
+	}
+
+	if err := message.VerifySignature(req.Message, req.Signature); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	// Consuming the nonce last means a bad signature cannot burn someone else's nonce
+	if err := services.ConsumeAuthNonce(message.Nonce, message.Address); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	token, expiresAt, err := auth.IssueToken(message.Address, message.ChainID, now)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	setSessionCookie(ctx, token, int(time.Until(expiresAt).Seconds()))
+
+	ctx.JSON(http.StatusOK, models.AuthSessionResponse{
+		Token:     token,
+		Address:   message.Address.Hex(),
+		ChainId:   message.ChainID,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// GetAuthSession returns the wallet behind the current session with its roles
+// and the permissions they grant
+func GetAuthSession(ctx *gin.Context) {
+	wallet, _ := middleware.Wallet(ctx)
+
+	roles, err := services.WalletRoles(wallet)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	permissions := []string{}
+	seen := map[string]bool{}
+	for _, role := range roles {
+		for _, permission := range models.RolePermissions(role) {
+			if !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	if roles == nil {
+		roles = []string{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"address":     wallet.Hex(),
+		"roles":       roles,
+		"permissions": permissions,
+	})
+}
+
+// Logout clears the session cookie. Bearer tokens stay valid until they expire.
+func Logout(ctx *gin.Context) {
+	setSessionCookie(ctx, "", -1)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Signed out",
+	})
+}
+
+func supportedChainIds() []int64 {
+	configs := networks.GetAllNetworkConfigs()
+	chainIds := make([]int64, 0, len(configs))
+	for _, config := range configs {
+		chainIds = append(chainIds, config.ChainID.Int64())
+	}
+	return chainIds
+}
+
+func isSupportedChain(chainId int64) bool {
+	for _, supported := range supportedChainIds() {
+		if supported == chainId {
+			return true
+		}
+	}
+	return false
+}
+
+func setSessionCookie(ctx *gin.Context, token string, maxAge int) {
+	secure := ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https"
+
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(middleware.SessionCookie, token, maxAge, "/", "", secure, true)
+}
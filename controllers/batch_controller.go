@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/gin-gonic/gin"
+)
+
+// PrepareBatchSettle builds a settleOrder transaction for each order of the
+// batch that can be settled, reporting the others individually
+func PrepareBatchSettle(ctx *gin.Context) {
+	prepareBatch(ctx, models.BatchActionSettle)
+}
+
+// ConfirmBatchSettle settles the orders covered by the batch's mined
+// transactions and records their fee splits
+func ConfirmBatchSettle(ctx *gin.Context) {
+	confirmBatch(ctx, models.BatchActionSettle)
+}
+
+// PrepareBatchRefund builds a refundOrder transaction for each order of the
+// batch that can be refunded, reporting the others individually
+func PrepareBatchRefund(ctx *gin.Context) {
+	prepareBatch(ctx, models.BatchActionRefund)
+}
+
+// ConfirmBatchRefund refunds the orders covered by the batch's mined transactions
+func ConfirmBatchRefund(ctx *gin.Context) {
+	confirmBatch(ctx, models.BatchActionRefund)
+}
+
+func prepareBatch(ctx *gin.Context, action string) {
+	var req models.PrepareBatchOrdersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	results, err := services.PrepareBatch(action, req.OrderIds)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response := batchResponse(action, results)
+	response.Message = fmt.Sprintf("%d of %d transactions prepared. Sign and send each, then confirm the batch with their hashes", response.Succeeded, len(results))
+	ctx.JSON(http.StatusOK, response)
+}
+
+func confirmBatch(ctx *gin.Context, action string) {
+	var req models.ConfirmBatchOrdersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	results, err := services.ConfirmBatch(ctx.Request.Context(), action, req.OrderIds, req.TransactionHashes)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response := batchResponse(action, results)
+	response.Message = fmt.Sprintf("%d of %d orders %s", response.Succeeded, len(results), batchPastTense[action])
+	ctx.JSON(http.StatusOK, response)
+}
+
+var batchPastTense = map[string]string{
+	models.BatchActionSettle: "settled",
+	models.BatchActionRefund: "refunded",
+}
+
+func batchResponse(action string, results []models.BatchOrderResult) models.BatchOrdersResponse {
+	response := models.BatchOrdersResponse{Action: action, Orders: results}
+	for _, result := range results {
+		if result.Success {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	return response
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCart opens an empty single-merchant cart
+func CreateCart(ctx *gin.Context) {
+	var req models.CreateCartRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	if req.BuyerAddress != "" && !common.IsHexAddress(req.BuyerAddress) {
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidAddress, "buyerAddress", "Invalid buyer address"))
+		return
+	}
+
+	if req.MerchantId != "" {
+		if _, err := services.GetMerchant(req.MerchantId); err != nil {
+			respondError(ctx, err)
+			return
+		}
+	}
+
+	cart, err := services.CreateCart(req.MerchantId, req.BuyerAddress)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.CartResponse{
+		CartDB: *cart,
+		Items:  []models.CartLine{},
+	})
+}
+
+// GetCart returns a cart with its lines priced from the products table
+func GetCart(ctx *gin.Context) {
+	cart, err := services.GetCart(ctx.Param("cartId"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	respondCart(ctx, cart)
+}
+
+// AddCartItem adds a product to a cart
+func AddCartItem(ctx *gin.Context) {
+	var req models.CartItemRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	if req.ProductId <= 0 || req.Quantity <= 0 {
+		respondError(ctx, apierror.BadRequest(apierror.CodeValidationFailed, "productId and quantity must be positive integers"))
+		return
+	}
+
+	cartId := ctx.Param("cartId")
+	if err := services.AddCartItem(cartId, req.ProductId, req.Quantity); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	cart, err := services.GetCart(cartId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	respondCart(ctx, cart)
+}
+
+// UpdateCartItem sets the quantity of a cart line; zero removes it
+func UpdateCartItem(ctx *gin.Context) {
+	var req models.UpdateCartItemRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	productId, ok := parseProductId(ctx)
+	if !ok {
+		return
+	}
+
+	if *req.Quantity < 0 {
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "quantity", "quantity must not be negative"))
+		return
+	}
+
+	cartId := ctx.Param("cartId")
+	if err := services.SetCartItemQuantity(cartId, productId, *req.Quantity); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	cart, err := services.GetCart(cartId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	respondCart(ctx, cart)
+}
+
+// RemoveCartItem removes a product line from a cart
+func RemoveCartItem(ctx *gin.Context) {
+	productId, ok := parseProductId(ctx)
+	if !ok {
+		return
+	}
+
+	cartId := ctx.Param("cartId")
+	if err := services.RemoveCartItem(cartId, productId); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	cart, err := services.GetCart(cartId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	respondCart(ctx, cart)
+}
+
+// CheckoutCart prices the cart server side, reserves stock, generates the order
+// metadata document and returns unsigned createOrder calldata for the total
+func CheckoutCart(ctx *gin.Context) {
+	var req models.CheckoutCartRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	response, err := services.CheckoutCart(ctx.Request.Context(), ctx.Param("cartId"), "", req)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response)
+}
+
+func respondCart(ctx *gin.Context, cart *models.CartDB) {
+	lines, subtotal, err := services.PriceCart(cart.Id)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	var itemCount int64
+	for _, line := range lines {
+		itemCount += line.Quantity
+	}
+
+	ctx.JSON(http.StatusOK, models.CartResponse{
+		CartDB:    *cart,
+		Items:     lines,
+		ItemCount: itemCount,
+		Subtotal:  subtotal,
+	})
+}
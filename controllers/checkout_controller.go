@@ -0,0 +1,580 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// CreateCheckoutSession creates a hosted checkout for a fixed amount or a cart and
+// returns a shareable link, an EIP-681 payment URI and a QR code
+func CreateCheckoutSession(ctx *gin.Context) {
+	var req models.CreateCheckoutSessionRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	if !common.IsHexAddress(req.TokenAddress) {
+		respondError(ctx, errInvalidTokenAddress)
+		return
+	}
+	tokenAddress := common.HexToAddress(req.TokenAddress)
+
+	if req.Network == "" {
+		req.Network = networks.DefaultNetworkName
+	}
+	if _, exists := networks.GetNetworkConfig(req.Network); !exists {
+		respondError(ctx, apierror.InvalidField(apierror.CodeUnsupportedNetwork, "network", "Unknown network: "+req.Network))
+		return
+	}
+	if req.Network != networks.BaseSepoliaConfig.NetworkName {
+		respondError(ctx, apierror.New(http.StatusUnprocessableEntity, apierror.CodeUnsupportedNetwork, "Checkout is only available on "+networks.BaseSepoliaConfig.NetworkName))
+		return
+	}
+
+	if req.SuccessUrl != "" {
+		if err := services.ValidateCheckoutRedirectURL("successUrl", req.SuccessUrl); err != nil {
+			respondError(ctx, err)
+			return
+		}
+	}
+	if req.CancelUrl != "" {
+		if err := services.ValidateCheckoutRedirectURL("cancelUrl", req.CancelUrl); err != nil {
+			respondError(ctx, err)
+			return
+		}
+	}
+
+	if (req.CartId == "") == (req.Amount == "") {
+		respondError(ctx, apierror.BadRequest(apierror.CodeValidationFailed, "Provide either amount or cartId"))
+		return
+	}
+
+	ttl := services.CheckoutSessionTTL()
+	if req.ExpiresIn < 0 {
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidExpiry, "expiresIn", "expiresIn must be a positive number of seconds"))
+		return
+	}
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+	}
+	if ttl > services.MaxCheckoutSessionTTL {
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidExpiry, "expiresIn", "expiresIn cannot be longer than 7 days"))
+		return
+	}
+	if req.OrderExpiresIn != 0 {
+		if err := services.CheckOrderExpiry(time.Duration(req.OrderExpiresIn)*time.Second, "orderExpiresIn"); err != nil {
+			respondError(ctx, err)
+			return
+		}
+	}
+
+	session := models.CheckoutSessionDB{
+		MerchantId:   req.MerchantId,
+		TokenAddress: tokenAddress.Hex(),
+		Network:      req.Network,
+		SuccessUrl:   req.SuccessUrl,
+		CancelUrl:    req.CancelUrl,
+		ExpiresAt:    time.Now().Add(ttl).UTC().Format(time.RFC3339),
+	}
+	if req.OrderExpiresIn != 0 {
+		session.OrderExpiresIn = &req.OrderExpiresIn
+	}
+
+	if req.CartId != "" {
+		cart, err := services.GetOpenCart(req.CartId)
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
+
+		lines, _, err := services.PriceCart(cart.Id)
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
+		if len(lines) == 0 {
+			respondError(ctx, services.ErrCartEmpty)
+			return
+		}
+
+		sdkClient := networks.GetBaseClient()
+		if sdkClient == nil {
+			respondError(ctx, errBlockchainUnavailable)
+			return
+		}
+
+		decimals, err := services.TokenDecimals(context.Background(), sdkClient.EthClient, tokenAddress)
+		if err != nil {
+			respondError(ctx, apierror.Wrap(http.StatusBadRequest, apierror.CodeInvalidTokenAddress, "Could not read token decimals", err))
+			return
+		}
+
+		total, err := cartTokenTotal(lines, decimals)
+		if err != nil {
+			respondError(ctx, apierror.New(http.StatusUnprocessableEntity, apierror.CodeTokenNotPayable, "Cart cannot be paid in this token: "+apierror.From(err).Message))
+			return
+		}
+
+		session.MerchantId = cart.MerchantId
+		session.CartId = &cart.Id
+		session.Amount = total.String()
+	} else {
+		amount, ok := new(big.Int).SetString(req.Amount, 10)
+		if !ok || amount.Sign() <= 0 {
+			respondError(ctx, apierror.InvalidField(apierror.CodeInvalidAmount, "amount", "amount must be a positive integer in token base units"))
+			return
+		}
+		session.Amount = amount.String()
+	}
+
+	if session.MerchantId == "" {
+		respondError(ctx, errMerchantIdRequired)
+		return
+	}
+
+	merchant, err := services.GetMerchant(common.HexToHash(session.MerchantId).Hex())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	session.MerchantId = merchant.MerchantId
+
+	if !authorizeMerchantRequest(ctx, merchant.MerchantId, "Only the owning merchant can create checkout sessions") {
+		return
+	}
+
+	created, err := services.CreateCheckoutSession(session)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response, err := checkoutSessionResponse(created)
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// GetCheckoutSession returns a session with its payment URI, QR code and next step
+func GetCheckoutSession(ctx *gin.Context) {
+	session, err := services.GetActiveCheckoutSession(ctx.Param("sessionId"))
+	if err != nil && !errors.Is(err, services.ErrCheckoutSessionExpired) && !errors.Is(err, services.ErrCheckoutSessionPaid) {
+		respondError(ctx, err)
+		return
+	}
+
+	response, err := checkoutSessionResponse(session)
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetCheckoutSessionQRCode serves the session QR code as a PNG. By default it encodes
+// the EIP-681 payment URI; target=url encodes the shareable checkout link instead.
+func GetCheckoutSessionQRCode(ctx *gin.Context) {
+	session, err := services.GetCheckoutSession(ctx.Param("sessionId"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	content := checkoutPaymentURI(session)
+	if ctx.Query("target") == "url" {
+		content = checkoutSessionURL(session)
+	}
+
+	png, err := qrcode.Encode(content, qrcode.Medium, 256)
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+
+	ctx.Data(http.StatusOK, "image/png", png)
+}
+
+// ApproveCheckoutSession prepares the token approval for the session amount
+func ApproveCheckoutSession(ctx *gin.Context) {
+	session, ok := loadCheckoutSession(ctx, false)
+	if !ok {
+		return
+	}
+
+	approval, err := services.PrepareApproveToken(models.ApproveTokenRequest{
+		TokenAddress: session.TokenAddress,
+		Amount:       session.Amount,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.CheckoutApproveResponse{
+		PrepareApproveResponse: *approval,
+		CheckoutStep:           checkoutStep(session),
+	})
+}
+
+// ConfirmApproveCheckoutSession records that the payer submitted the approval
+func ConfirmApproveCheckoutSession(ctx *gin.Context) {
+	session, ok := loadCheckoutSession(ctx, false)
+	if !ok {
+		return
+	}
+
+	req, ok := bindCheckoutStep(ctx)
+	if !ok {
+		return
+	}
+
+	if req.TransactionHash == "" {
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "transactionHash", "transactionHash is required"))
+		return
+	}
+
+	confirmed := services.ConfirmApproveToken(models.ConfirmApproveRequest{
+		TransactionHash: req.TransactionHash,
+	})
+
+	if session.Status == models.CheckoutStatusOpen {
+		updated, err := services.UpdateCheckoutSession(session.Id, map[string]interface{}{
+			"status": models.CheckoutStatusApproved,
+		})
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
+		session = updated
+	}
+
+	ctx.JSON(http.StatusOK, models.CheckoutConfirmApproveResponse{
+		ConfirmApproveResponse: *confirmed,
+		CheckoutStep:           checkoutStep(session),
+	})
+}
+
+// CreateCheckoutOrder prepares the createOrder transaction, checking out the cart for
+// cart sessions
+func CreateCheckoutOrder(ctx *gin.Context) {
+	session, ok := loadCheckoutSession(ctx, false)
+	if !ok {
+		return
+	}
+
+	if session.OrderId != nil {
+		respondError(ctx, apierror.Conflict(apierror.CodeCheckoutOutOfOrder, "Order has already been created for this session").
+			WithMeta("orderId", *session.OrderId))
+		return
+	}
+
+	req, ok := bindCheckoutStep(ctx)
+	if !ok {
+		return
+	}
+
+	var response models.CheckoutOrderResponse
+	if session.CartId != nil {
+		checkout, err := services.CheckoutCart(ctx.Request.Context(), *session.CartId, session.Id, models.CheckoutCartRequest{
+			TokenAddress: session.TokenAddress,
+			BuyerAddress: req.BuyerAddress,
+		})
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
+		response.PrepareCreateOrderResponse = checkout.PrepareCreateOrderResponse
+		response.CartId = checkout.CartId
+		response.Metadata = &checkout.Metadata
+	} else {
+		prepared, err := services.PrepareCreateOrder(ctx.Request.Context(), models.CreateOrderRequest{
+			MerchantId:   session.MerchantId,
+			TokenAddress: session.TokenAddress,
+			Amount:       session.Amount,
+			BuyerAddress: req.BuyerAddress,
+		})
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
+		response.PrepareCreateOrderResponse = *prepared
+	}
+
+	updates := map[string]interface{}{
+		"status":      models.CheckoutStatusOrderPending,
+		"metadataURI": response.MetadataURI,
+		// The cart is repriced at checkout, so the amount to approve may have changed
+		"amount": response.Amount,
+	}
+	if response.ReservationId != "" {
+		updates["reservationId"] = response.ReservationId
+	}
+	if req.BuyerAddress != "" {
+		updates["payerAddress"] = req.BuyerAddress
+	}
+
+	updated, err := services.UpdateCheckoutSession(session.Id, updates)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response.CheckoutStep = checkoutStep(updated)
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// ConfirmCheckoutOrder records the mined createOrder transaction against the session
+func ConfirmCheckoutOrder(ctx *gin.Context) {
+	// The order exists on-chain once created, so it is recorded even after the session expires
+	session, ok := loadCheckoutSession(ctx, true)
+	if !ok {
+		return
+	}
+
+	if session.MetadataURI == nil {
+		respondError(ctx, apierror.Conflict(apierror.CodeCheckoutOutOfOrder, "Create the order for this session before confirming it"))
+		return
+	}
+
+	req, ok := bindCheckoutStep(ctx)
+	if !ok {
+		return
+	}
+
+	payerAddress := req.PayerAddress
+	if payerAddress == "" && session.PayerAddress != nil {
+		payerAddress = *session.PayerAddress
+	}
+
+	confirm := models.ConfirmCreateOrderRequest{
+		TransactionHash: req.TransactionHash,
+		MerchantId:      session.MerchantId,
+		TokenAddress:    session.TokenAddress,
+		Amount:          session.Amount,
+		MetadataURI:     *session.MetadataURI,
+		PayerAddress:    payerAddress,
+	}
+	if session.ReservationId != nil {
+		confirm.ReservationId = *session.ReservationId
+	}
+	if session.CartId != nil {
+		confirm.CartId = *session.CartId
+	}
+	if session.OrderExpiresIn != nil {
+		confirm.ExpiresIn = *session.OrderExpiresIn
+	}
+
+	confirmed, err := services.ConfirmCreateOrder(ctx.Request.Context(), confirm)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	updated, err := services.UpdateCheckoutSession(session.Id, map[string]interface{}{
+		"status":       models.CheckoutStatusOrderCreated,
+		"orderId":      confirmed.Order.OrderId,
+		"payerAddress": confirmed.Order.PayerAddress,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.CheckoutConfirmOrderResponse{
+		ConfirmCreateOrderResponse: *confirmed,
+		CheckoutStep:               checkoutStep(updated),
+	})
+}
+
+// PayCheckoutOrder prepares the payOrder transaction for the session's order
+func PayCheckoutOrder(ctx *gin.Context) {
+	session, ok := loadCheckoutSession(ctx, false)
+	if !ok {
+		return
+	}
+
+	if session.OrderId == nil {
+		respondError(ctx, apierror.Conflict(apierror.CodeCheckoutOutOfOrder, "Create and confirm the order for this session before paying"))
+		return
+	}
+
+	prepared, err := services.PreparePayOrder(*session.OrderId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.CheckoutPayResponse{
+		PreparePayOrderResponse: *prepared,
+		CheckoutStep:            checkoutStep(session),
+	})
+}
+
+// ConfirmCheckoutPayment records the mined payOrder transaction and completes the session
+func ConfirmCheckoutPayment(ctx *gin.Context) {
+	session, ok := loadCheckoutSession(ctx, true)
+	if !ok {
+		return
+	}
+
+	if session.OrderId == nil {
+		respondError(ctx, apierror.Conflict(apierror.CodeCheckoutOutOfOrder, "Create and confirm the order for this session before paying"))
+		return
+	}
+
+	req, ok := bindCheckoutStep(ctx)
+	if !ok {
+		return
+	}
+
+	payerAddress := req.PayerAddress
+	if payerAddress == "" && session.PayerAddress != nil {
+		payerAddress = *session.PayerAddress
+	}
+
+	paid, err := services.ConfirmPayOrder(ctx.Request.Context(), models.ConfirmPayOrderRequest{
+		TransactionHash: req.TransactionHash,
+		OrderId:         *session.OrderId,
+		MerchantId:      session.MerchantId,
+		PayerAddress:    payerAddress,
+		TokenAddress:    session.TokenAddress,
+		Status:          models.OrderStatusPaid,
+		Amount:          session.Amount,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	updated, err := services.UpdateCheckoutSession(session.Id, map[string]interface{}{
+		"status": models.CheckoutStatusPaid,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	step := checkoutStep(updated)
+	step.RedirectUrl = updated.SuccessUrl
+
+	ctx.JSON(http.StatusOK, models.CheckoutConfirmPaymentResponse{
+		ConfirmPayOrderResponse: *paid,
+		CheckoutStep:            step,
+	})
+}
+
+// loadCheckoutSession fetches the session named in the path, writing the error
+// response itself. Confirm steps pass allowExpired because the transaction they
+// record has already happened on-chain.
+func loadCheckoutSession(ctx *gin.Context, allowExpired bool) (*models.CheckoutSessionDB, bool) {
+	session, err := services.GetActiveCheckoutSession(ctx.Param("sessionId"))
+	if errors.Is(err, services.ErrCheckoutSessionExpired) && allowExpired && session.MetadataURI != nil {
+		return session, true
+	}
+	if err != nil {
+		respondError(ctx, err)
+		return nil, false
+	}
+
+	return session, true
+}
+
+func bindCheckoutStep(ctx *gin.Context) (models.CheckoutStepRequest, bool) {
+	var req models.CheckoutStepRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(ctx, apierror.Binding(err))
+		return req, false
+	}
+
+	return req, true
+}
+
+// checkoutStep describes where the session stands after a step
+func checkoutStep(session *models.CheckoutSessionDB) models.CheckoutStep {
+	return models.CheckoutStep{
+		SessionId:     session.Id,
+		SessionStatus: session.Status,
+		NextStep:      services.CheckoutNextStep(session.Status),
+	}
+}
+
+func checkoutSessionResponse(session *models.CheckoutSessionDB) (models.CheckoutSessionResponse, error) {
+	response := models.CheckoutSessionResponse{
+		CheckoutSessionDB: *session,
+		URL:               checkoutSessionURL(session),
+		PaymentURI:        checkoutPaymentURI(session),
+		NextStep:          services.CheckoutNextStep(session.Status),
+	}
+
+	png, err := qrcode.Encode(response.PaymentURI, qrcode.Medium, 256)
+	if err != nil {
+		return response, err
+	}
+	response.QRCode = "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+
+	return response, nil
+}
+
+// checkoutSessionURL is the hosted checkout page for a session (CHECKOUT_BASE_URL)
+func checkoutSessionURL(session *models.CheckoutSessionDB) string {
+	baseURL := os.Getenv("CHECKOUT_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+
+	return strings.TrimRight(baseURL, "/") + "/checkout/" + session.Id
+}
+
+// checkoutPaymentURI is an EIP-681 approval URI: scanning it lets a wallet approve
+// the PaymentProcessor to spend the session amount. It does not pay the order;
+// the order is created and paid through the session steps once approved.
+func checkoutPaymentURI(session *models.CheckoutSessionDB) string {
+	amount, ok := new(big.Int).SetString(session.Amount, 10)
+	if !ok {
+		amount = new(big.Int)
+	}
+
+	return utils.ERC20ApproveURI(
+		session.TokenAddress,
+		networks.BaseSepoliaConfig.PaymentProcessorAddress.Hex(),
+		networks.BaseSepoliaConfig.ChainID.Int64(),
+		amount,
+	)
+}
+
+// cartTokenTotal prices cart lines in token base units
+func cartTokenTotal(lines []models.CartLine, decimals uint8) (*big.Int, error) {
+	total := new(big.Int)
+	for _, line := range lines {
+		unitAmount, err := utils.ParseTokenAmount(line.UnitPrice, decimals)
+		if err != nil {
+			return nil, err
+		}
+		total.Add(total, new(big.Int).Mul(unitAmount, big.NewInt(line.Quantity)))
+	}
+
+	return total, nil
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// maxDisputeNote caps the note sent with an evidence image
+const maxDisputeNote = 2000
+
+// OpenOrderDispute opens the buyer's dispute on a paid order, holding the
+// order back from settlement until a reviewer resolves it
+func OpenOrderDispute(ctx *gin.Context) {
+	orderIdHex, ok := orderIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.OpenDisputeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	wallet, _ := middleware.Wallet(ctx)
+	dispute, err := services.OpenDispute(orderIdHex, wallet, req.Reason, req.Description)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dispute)
+}
+
+// GetOrderDispute returns an order's dispute and its recorded steps to the
+// order's buyer or merchant
+func GetOrderDispute(ctx *gin.Context) {
+	orderIdHex, ok := orderIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	if _, ok := disputeParty(ctx, orderIdHex); !ok {
+		return
+	}
+
+	dispute, err := services.GetDisputeDetails(orderIdHex)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dispute)
+}
+
+// AddOrderDisputeEvidence uploads an evidence image for the buyer or the
+// merchant of a disputed order, with an optional note
+func AddOrderDisputeEvidence(ctx *gin.Context) {
+	orderIdHex, ok := orderIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	party, ok := disputeParty(ctx, orderIdHex)
+	if !ok {
+		return
+	}
+
+	if err := ctx.Request.ParseMultipartForm(utils.MaxFileSize + 1<<20); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	note := ctx.PostForm("note")
+	if len(note) > maxDisputeNote {
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "note", "note must be at most 2000 characters"))
+		return
+	}
+
+	dispute, err := services.CheckDisputeEvidence(orderIdHex)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	uploadResult, err := utils.UploadImageToFolder(ctx, utils.DisputeEvidenceFolder)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	wallet, _ := middleware.Wallet(ctx)
+	event, err := services.AddDisputeEvidence(*dispute, party, wallet.Hex(), uploadResult.ImageURL, note)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, event)
+}
+
+// RespondOrderDispute records the merchant's response to a dispute
+func RespondOrderDispute(ctx *gin.Context) {
+	orderIdHex, ok := orderIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.RespondDisputeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	wallet, _ := middleware.Wallet(ctx)
+	dispute, err := services.RespondToDispute(orderIdHex, wallet.Hex(), req.Message)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dispute)
+}
+
+// ListMerchantDisputes returns the disputes opened on the merchant's orders,
+// newest first
+func ListMerchantDisputes(ctx *gin.Context) {
+	listDisputes(ctx, ctx.Param("merchantId"))
+}
+
+// ListDisputes returns every merchant's disputes for reviewers, newest first
+func ListDisputes(ctx *gin.Context) {
+	listDisputes(ctx, "")
+}
+
+// GetDispute returns an order's dispute and its recorded steps to a reviewer
+func GetDispute(ctx *gin.Context) {
+	orderIdHex, ok := orderIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	dispute, err := services.GetDisputeDetails(orderIdHex)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dispute)
+}
+
+// ResolveOrderDispute records a reviewer's decision and queues the order's
+// refund or settlement for the settlement worker. Settling also needs the
+// order:settle permission.
+func ResolveOrderDispute(ctx *gin.Context) {
+	orderIdHex, ok := orderIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.ResolveDisputeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	wallet, _ := middleware.Wallet(ctx)
+	if req.Outcome == models.DisputeOutcomeSettle {
+		if err := services.CheckPermission(wallet, models.PermissionOrderSettle); err != nil {
+			respondError(ctx, err)
+			return
+		}
+	}
+
+	resolved, err := services.ResolveDispute(orderIdHex, req.Outcome, req.Note, wallet.Hex())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", "/api/platform/orders/"+orderIdHex+"/settlement")
+	ctx.JSON(http.StatusAccepted, resolved)
+}
+
+func listDisputes(ctx *gin.Context, merchantId string) {
+	page, err := utils.ParsePagination(ctx)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	status := ctx.Query("status")
+	if status != "" && !services.IsDisputeStatus(status) {
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidStatus, "status", "status must be open, responded or resolved"))
+		return
+	}
+
+	disputes, err := services.ListDisputes(merchantId, status, page.Limit+1, page.Cursor)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response := models.DisputeListResponse{Disputes: disputes}
+	if response.Disputes == nil {
+		response.Disputes = []models.DisputeDB{}
+	}
+
+	if len(disputes) > page.Limit {
+		response.Disputes = disputes[:page.Limit]
+		response.HasMore = true
+		response.NextCursor = utils.EncodeCursor(disputes[page.Limit-1].Id)
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// disputeParty reports whether the signed in wallet is the order's buyer or
+// merchant, responding with the error when it is neither
+func disputeParty(ctx *gin.Context, orderIdHex string) (string, bool) {
+	order, err := services.GetOrder(orderIdHex)
+	if err != nil {
+		respondError(ctx, err)
+		return "", false
+	}
+
+	wallet, _ := middleware.Wallet(ctx)
+	party, err := services.DisputeParty(*order, wallet)
+	if err != nil {
+		respondError(ctx, err)
+		return "", false
+	}
+
+	return party, true
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+// GetOpenAPISpec serves the OpenAPI 3 document describing the API
+func GetOpenAPISpec(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, openapi.Build())
+}
+
+// apiDocsPage renders /openapi.json with Swagger UI
+const apiDocsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Stable Market API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// GetAPIDocs serves the interactive API documentation
+func GetAPIDocs(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(apiDocsPage))
+}
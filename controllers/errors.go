@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/auth"
+	"github.com/Dbriane208/stable-market/metadata"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Errors shared by several handlers
+var (
+	errBlockchainUnavailable = services.ErrBlockchainUnavailable
+	errPlatformUnavailable   = apierror.New(http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Platform client not initialized")
+	errInvalidOrderId        = services.ErrInvalidOrderId
+	errInvalidMerchantId     = services.ErrInvalidMerchantId
+	errMerchantIdRequired    = apierror.InvalidField(apierror.CodeValidationFailed, "merchantId", "merchantId is required")
+	errInvalidTokenAddress   = services.ErrInvalidTokenAddress
+	errInvalidTxHash         = services.ErrInvalidTxHash
+	errInvalidWalletAddress  = apierror.InvalidField(apierror.CodeInvalidAddress, "walletAddress", "walletAddress must be a hex address")
+	errTxReverted            = services.ErrTxReverted
+)
+
+// respondError writes err in the API error envelope. Service errors already carry
+// their codes; errors from the auth and metadata packages and order state errors
+// are mapped here, and anything else is reported as an internal error.
+func respondError(ctx *gin.Context, err error) {
+	apierror.Respond(ctx, apiError(err))
+}
+
+func apiError(err error) error {
+	var illegal *services.IllegalTransitionError
+
+	switch {
+	case errors.As(err, &illegal):
+		return apierror.Conflict(apierror.CodeIllegalStateTransition, "Illegal order state transition: "+illegal.Error()).
+			WithMeta("currentStatus", illegal.From)
+	case errors.Is(err, auth.ErrInvalidMessage):
+		return apierror.Wrap(http.StatusBadRequest, apierror.CodeInvalidSIWEMessage, "Invalid SIWE message", err)
+	case errors.Is(err, auth.ErrDomainMismatch), errors.Is(err, auth.ErrMessageExpired),
+		errors.Is(err, auth.ErrMessageNotYet), errors.Is(err, auth.ErrInvalidSignature):
+		return apierror.Wrap(http.StatusUnauthorized, apierror.CodeSIWERejected, err.Error(), err)
+	case errors.Is(err, metadata.ErrNotFound):
+		return apierror.NotFound(apierror.CodeMetadataNotFound, "Metadata document not found")
+	case errors.Is(err, metadata.ErrUnsupportedURI):
+		return apierror.New(http.StatusUnprocessableEntity, apierror.CodeUnsupportedMetadata, "URI is not served by the configured metadata store")
+	}
+
+	return err
+}
@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/export"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// exportDefaultRange is exported when from is left out, and exportMaxRange
+	// is the longest range one export may cover
+	exportDefaultRange = 30 * 24 * time.Hour
+	exportMaxRange     = 5 * 366 * 24 * time.Hour
+
+	exportJobListLimit = 50
+)
+
+// ExportMerchantRecords downloads the merchant's orders, settlements or refunds
+// in the range as CSV, JSON Lines or a PDF statement
+func ExportMerchantRecords(ctx *gin.Context) {
+	exportRecords(ctx, ctx.Param("merchantId"))
+}
+
+// ExportPlatformRecords downloads the orders, settlements, refunds or emergency
+// withdrawals of every merchant in the range
+func ExportPlatformRecords(ctx *gin.Context) {
+	exportRecords(ctx, "")
+}
+
+// CreateMerchantExportJob queues a merchant export too large to download directly
+func CreateMerchantExportJob(ctx *gin.Context) {
+	createExportJob(ctx, ctx.Param("merchantId"), "/api/merchants/"+ctx.Param("merchantId")+"/export-jobs/")
+}
+
+// CreatePlatformExportJob queues a platform export too large to download directly
+func CreatePlatformExportJob(ctx *gin.Context) {
+	createExportJob(ctx, "", "/api/platform/export-jobs/")
+}
+
+// ListMerchantExportJobs returns the merchant's most recent export jobs
+func ListMerchantExportJobs(ctx *gin.Context) {
+	listExportJobs(ctx, ctx.Param("merchantId"), "/api/merchants/"+ctx.Param("merchantId")+"/export-jobs/")
+}
+
+// ListPlatformExportJobs returns the most recent platform export jobs
+func ListPlatformExportJobs(ctx *gin.Context) {
+	listExportJobs(ctx, "", "/api/platform/export-jobs/")
+}
+
+// GetMerchantExportJob returns a merchant export job's status, with its
+// download link once the file is ready
+func GetMerchantExportJob(ctx *gin.Context) {
+	getExportJob(ctx, ctx.Param("merchantId"), "/api/merchants/"+ctx.Param("merchantId")+"/export-jobs/")
+}
+
+// GetPlatformExportJob returns a platform export job's status, with its
+// download link once the file is ready
+func GetPlatformExportJob(ctx *gin.Context) {
+	getExportJob(ctx, "", "/api/platform/export-jobs/")
+}
+
+// DownloadMerchantExport serves the file written by a merchant export job
+func DownloadMerchantExport(ctx *gin.Context) {
+	downloadExport(ctx, ctx.Param("merchantId"))
+}
+
+// DownloadPlatformExport serves the file written by a platform export job
+func DownloadPlatformExport(ctx *gin.Context) {
+	downloadExport(ctx, "")
+}
+
+func exportRecords(ctx *gin.Context, merchantId string) {
+	query, err := parseExportQuery(ctx, merchantId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if merchantId != "" {
+		if _, err := services.GetMerchant(merchantId); err != nil {
+			respondError(ctx, err)
+			return
+		}
+	}
+
+	// The size is checked before anything is written, so an oversized export
+	// still gets an error response
+	if err := services.CheckExportSize(query, services.ExportDirectLimit); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	// A PDF statement totals its records, so it is built from all of them
+	if query.Format == models.ExportFormatPDF {
+		records, err := services.CollectExportRecords(ctx.Request.Context(), query, services.ExportDirectLimit)
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
+
+		startExport(ctx, query)
+		if err := services.WriteExport(ctx.Writer, query, records); err != nil {
+			log.Printf("exports: writing %s export: %v", query.Kind, err)
+		}
+		return
+	}
+
+	writer, err := export.NewWriter(ctx.Writer, query.Format)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	// CSV and JSON Lines are streamed as the records are read. The response
+	// starts with the first record, so a failure before it is still reported;
+	// a failure after it drops the connection rather than ending the file early.
+	started := false
+	start := func() {
+		if !started {
+			startExport(ctx, query)
+			started = true
+		}
+	}
+
+	_, err = services.ExportRecords(ctx.Request.Context(), query, services.ExportDirectLimit, func(record models.ExportRecord) error {
+		start()
+		return writer.Write(record)
+	})
+	if err == nil {
+		start()
+		err = writer.Close()
+	}
+	if err != nil {
+		if !started {
+			respondError(ctx, err)
+			return
+		}
+		log.Printf("exports: writing %s export: %v", query.Kind, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func startExport(ctx *gin.Context, query services.ExportQuery) {
+	ctx.Header("Content-Type", export.ContentType(query.Format))
+	ctx.Header("Content-Disposition", `attachment; filename="`+query.FileName()+`"`)
+	ctx.Status(http.StatusOK)
+}
+
+func createExportJob(ctx *gin.Context, merchantId, jobsPath string) {
+	query, err := parseExportQuery(ctx, merchantId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if merchantId != "" {
+		if _, err := services.GetMerchant(merchantId); err != nil {
+			respondError(ctx, err)
+			return
+		}
+	}
+
+	wallet, _ := middleware.Wallet(ctx)
+	job, err := services.CreateExportJob(query, wallet.Hex())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	location := jobsPath + strconv.FormatInt(job.Id, 10)
+	ctx.Header("Location", location)
+	ctx.JSON(http.StatusAccepted, services.ToExportJob(*job, location+"/download"))
+}
+
+func listExportJobs(ctx *gin.Context, merchantId, jobsPath string) {
+	jobs, err := services.ListExportJobs(merchantId, exportJobListLimit)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response := models.ExportJobListResponse{Jobs: make([]models.ExportJob, 0, len(jobs))}
+	for _, job := range jobs {
+		response.Jobs = append(response.Jobs, services.ToExportJob(job, jobsPath+strconv.FormatInt(job.Id, 10)+"/download"))
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func getExportJob(ctx *gin.Context, merchantId, jobsPath string) {
+	jobId, ok := parseExportJobId(ctx)
+	if !ok {
+		return
+	}
+
+	job, err := services.GetExportJob(merchantId, jobId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, services.ToExportJob(*job, jobsPath+strconv.FormatInt(job.Id, 10)+"/download"))
+}
+
+func downloadExport(ctx *gin.Context, merchantId string) {
+	jobId, ok := parseExportJobId(ctx)
+	if !ok {
+		return
+	}
+
+	job, err := services.GetExportJob(merchantId, jobId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	location, err := services.ExportJobFile(ctx.Request.Context(), job)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	// Files in shared storage are downloaded from a short-lived signed URL
+	if location.URL != "" {
+		ctx.Redirect(http.StatusFound, location.URL)
+		return
+	}
+
+	query, err := services.ExportJobQuery(*job)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Type", export.ContentType(job.Format))
+	ctx.FileAttachment(location.Path, query.FileName())
+}
+
+// parseExportQuery reads the kind path parameter and the format, from and to
+// query parameters. The range ends now and spans 30 days when from or to are
+// left out; emergency withdrawals are only exported for the platform.
+func parseExportQuery(ctx *gin.Context, merchantId string) (services.ExportQuery, error) {
+	query := services.ExportQuery{MerchantId: merchantId, Kind: ctx.Param("kind")}
+
+	switch query.Kind {
+	case models.ExportKindOrders, models.ExportKindSettlements, models.ExportKindRefunds:
+	case models.ExportKindWithdrawals:
+		if merchantId != "" {
+			return query, apierror.InvalidField(apierror.CodeInvalidExportKind, "kind", "withdrawals are only exported for the platform")
+		}
+	default:
+		return query, apierror.InvalidField(apierror.CodeInvalidExportKind, "kind", "kind must be orders, settlements, refunds or withdrawals")
+	}
+
+	query.Format = ctx.DefaultQuery("format", models.ExportFormatCSV)
+	switch query.Format {
+	case models.ExportFormatCSV, models.ExportFormatJSONL, models.ExportFormatPDF:
+	default:
+		return query, apierror.InvalidField(apierror.CodeInvalidExportFormat, "format", "format must be csv, jsonl or pdf")
+	}
+
+	from, to, err := utils.ParseDateRange(ctx)
+	if err != nil {
+		return query, err
+	}
+
+	query.To = time.Now().UTC().Truncate(time.Second)
+	if to != nil {
+		query.To = *to
+	}
+	query.From = query.To.Add(-exportDefaultRange)
+	if from != nil {
+		query.From = *from
+	}
+
+	if query.To.Before(query.From) {
+		return query, apierror.InvalidField(apierror.CodeInvalidDateRange, "from", "from must not be after to")
+	}
+	if query.To.Sub(query.From) > exportMaxRange {
+		return query, apierror.InvalidField(apierror.CodeInvalidDateRange, "from", "range must not exceed 5 years")
+	}
+
+	return query, nil
+}
+
+func parseExportJobId(ctx *gin.Context) (int64, bool) {
+	jobId, err := strconv.ParseInt(ctx.Param("jobId"), 10, 64)
+	if err != nil || jobId <= 0 {
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "jobId", "jobId must be a positive integer"))
+		return 0, false
+	}
+	return jobId, true
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/export"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetOrderInvoice returns a paid order's invoice as HTML, PDF or JSON to its
+// buyer or merchant, issuing it first if the issuer has not got to it yet
+func GetOrderInvoice(ctx *gin.Context) {
+	getOrderDocument(ctx, models.DocumentTypeInvoice)
+}
+
+// GetOrderCreditNote returns a refunded order's credit note as HTML, PDF or
+// JSON to its buyer or merchant
+func GetOrderCreditNote(ctx *gin.Context) {
+	getOrderDocument(ctx, models.DocumentTypeCreditNote)
+}
+
+// ListMerchantInvoices returns the merchant's invoices and credit notes, newest
+// first, optionally filtered by type
+func ListMerchantInvoices(ctx *gin.Context) {
+	page, err := utils.ParsePagination(ctx)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	documentType := ctx.Query("type")
+	switch documentType {
+	case "", models.DocumentTypeInvoice, models.DocumentTypeCreditNote:
+	default:
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidDocumentType, "type", "type must be invoice or credit_note"))
+		return
+	}
+
+	documents, err := services.ListInvoices(ctx.Param("merchantId"), documentType, page.Limit+1, page.Cursor)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response := models.InvoiceListResponse{
+		Invoices: make([]models.Invoice, 0, len(documents)),
+	}
+
+	if len(documents) > page.Limit {
+		documents = documents[:page.Limit]
+		response.HasMore = true
+		response.NextCursor = utils.EncodeCursor(documents[page.Limit-1].Id)
+	}
+
+	for _, document := range documents {
+		response.Invoices = append(response.Invoices, services.ToInvoice(document))
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func getOrderDocument(ctx *gin.Context, documentType string) {
+	orderIdHex, ok := orderIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	format := ctx.DefaultQuery("format", models.DocumentFormatHTML)
+	switch format {
+	case models.DocumentFormatHTML, models.DocumentFormatPDF, models.DocumentFormatJSON:
+	default:
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidDocumentFormat, "format", "format must be html, pdf or json"))
+		return
+	}
+
+	order, err := services.GetOrder(orderIdHex)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	wallet, _ := middleware.Wallet(ctx)
+	if err := services.AuthorizeOrderDocument(*order, wallet); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	document, err := services.OrderDocument(ctx.Request.Context(), *order, documentType)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	invoice := services.ToInvoice(*document)
+	switch format {
+	case models.DocumentFormatJSON:
+		ctx.JSON(http.StatusOK, invoice)
+
+	case models.DocumentFormatPDF:
+		ctx.Header("Content-Type", "application/pdf")
+		ctx.Header("Content-Disposition", `attachment; filename="`+invoice.InvoiceNumber+`.pdf"`)
+		ctx.Status(http.StatusOK)
+		if err := export.WriteInvoicePDF(ctx.Writer, invoice); err != nil {
+			log.Printf("invoices: writing %s: %v", invoice.InvoiceNumber, err)
+		}
+
+	default:
+		ctx.Header("Content-Type", "text/html; charset=utf-8")
+		ctx.Header("Content-Disposition", `inline; filename="`+invoice.InvoiceNumber+`.html"`)
+		ctx.Status(http.StatusOK)
+		if err := export.WriteInvoiceHTML(ctx.Writer, invoice); err != nil {
+			log.Printf("invoices: writing %s: %v", invoice.InvoiceNumber, err)
+		}
+	}
+}
@@ -3,18 +3,19 @@ package controllers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/Dbriane208/stable-market/apierror"
 	"github.com/Dbriane208/stable-market/db"
 	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/services"
 	"github.com/Dbriane208/stable-market/utils"
 	"github.com/gin-gonic/gin"
 )
 
 func CreateProduct(ctx *gin.Context) {
 	if err := ctx.Request.ParseMultipartForm(100 << 20); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Failed to parse form: " + err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
@@ -24,35 +25,33 @@ func CreateProduct(ctx *gin.Context) {
 	merchantId := ctx.PostForm("merchantId")
 
 	if name == "" || priceStr == "" || merchantId == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Name, price and description are required",
-		})
+		respondError(ctx, apierror.BadRequest(apierror.CodeValidationFailed, "Name, price and description are required"))
 		return
 	}
 
 	price, err := strconv.ParseFloat(priceStr, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Price must be a valid number",
-		})
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidAmount, "price", "Price must be a valid number"))
+		return
+	}
+
+	stock, ok := parseStockForm(ctx)
+	if !ok {
+		return
+	}
+
+	if !authorizeProductMerchant(ctx, merchantId, "add products for") {
 		return
 	}
 
 	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+		respondError(ctx, services.ErrDatabaseNotInitialized)
 		return
 	}
 
 	uploadResult, err := utils.UploadImageToCloudinary(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Failed to upload image: " + err.Error(),
-		})
-		return
-	}
-	if uploadResult == nil {
+		respondError(ctx, err)
 		return
 	}
 
@@ -62,13 +61,12 @@ func CreateProduct(ctx *gin.Context) {
 		ImageUrl:    uploadResult.ImageURL,
 		Description: description,
 		MerchantId:  merchantId,
+		Stock:       stock,
 	}
 
 	var result []models.Products
 	if err := db.Supabase.DB.From("products").Insert(dbProduct).Execute(&result); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not add product: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
@@ -78,21 +76,271 @@ func CreateProduct(ctx *gin.Context) {
 	})
 }
 
-func GetAllProducts(ctx *gin.Context){
+// GetAllProducts lists catalog products, optionally filtered by merchantId
+func GetAllProducts(ctx *gin.Context) {
+	listProducts(ctx, ctx.Query("merchantId"))
+}
+
+// GetMerchantProducts lists the catalog of a single merchant
+func GetMerchantProducts(ctx *gin.Context) {
+	merchantId := ctx.Param("merchantId")
+	if merchantId == "" {
+		respondError(ctx, errMerchantIdRequired)
+		return
+	}
+
+	listProducts(ctx, merchantId)
+}
+
+func listProducts(ctx *gin.Context, merchantId string) {
+	page, err := utils.ParsePagination(ctx)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if merchantId != "" && !utils.IsHexId(merchantId) {
+		respondError(ctx, errInvalidMerchantId)
+		return
+	}
+
 	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+		respondError(ctx, services.ErrDatabaseNotInitialized)
 		return
 	}
 
+	query := db.Supabase.DB.From("products").Select("*")
+	query.OrderBy("id", page.Direction()).Limit(page.Limit + 1)
+
+	filter := query.IsNull("deletedAt")
+	if merchantId != "" {
+		filter.Eq("merchantId", merchantId)
+	}
+	if page.Cursor > 0 {
+		if page.Ascending {
+			filter.Gt("id", strconv.FormatInt(page.Cursor, 10))
+		} else {
+			filter.Lt("id", strconv.FormatInt(page.Cursor, 10))
+		}
+	}
+
 	var products []models.Products
-	if err := db.Supabase.DB.From("products").Select("*").Execute(&products); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not get merchant details: " + err.Error(),
-		})
+	if err := filter.Execute(&products); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response := models.ProductListResponse{
+		Products: products,
+	}
+
+	if len(products) > page.Limit {
+		response.Products = products[:page.Limit]
+		response.HasMore = true
+		response.NextCursor = utils.EncodeCursor(response.Products[page.Limit-1].Id)
+	}
+
+	if response.Products == nil {
+		response.Products = []models.Products{}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetProductById returns a single catalog product
+func GetProductById(ctx *gin.Context) {
+	productId, ok := parseProductId(ctx)
+	if !ok {
+		return
+	}
+
+	product, err := services.GetProduct(productId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, product)
+}
+
+// UpdateProduct applies a partial update to a product, optionally replacing its image.
+// Only the owning merchant may change a product.
+func UpdateProduct(ctx *gin.Context) {
+	productId, ok := parseProductId(ctx)
+	if !ok {
+		return
+	}
+
+	product, err := services.GetProduct(productId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if !authorizeProductMerchant(ctx, product.MerchantId, "update") {
+		return
+	}
+
+	updates := map[string]interface{}{}
+
+	if name, exists := ctx.GetPostForm("name"); exists {
+		if name == "" {
+			respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "name", "Name cannot be empty"))
+			return
+		}
+		updates["name"] = name
+	}
+
+	if priceStr, exists := ctx.GetPostForm("price"); exists {
+		price, err := strconv.ParseFloat(priceStr, 64)
+		if err != nil || price <= 0 {
+			respondError(ctx, apierror.InvalidField(apierror.CodeInvalidAmount, "price", "Price must be a valid positive number"))
+			return
+		}
+		updates["price"] = price
+	}
+
+	if description, exists := ctx.GetPostForm("description"); exists {
+		updates["description"] = description
+	}
+
+	if _, exists := ctx.GetPostForm("stock"); exists {
+		stock, ok := parseStockForm(ctx)
+		if !ok {
+			return
+		}
+		updates["stock"] = stock
+	}
+
+	if _, err := ctx.FormFile("file"); err == nil {
+		uploadResult, err := utils.UploadImageToCloudinary(ctx)
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
+		updates["imageUrl"] = uploadResult.ImageURL
+	}
+
+	if len(updates) == 0 {
+		respondError(ctx, apierror.BadRequest(apierror.CodeInvalidRequest, "Nothing to update: provide name, price, description, stock or file"))
+		return
+	}
+
+	updates["updatedAt"] = time.Now().UTC().Format(time.RFC3339)
+
+	var result []models.Products
+	err = db.Supabase.DB.From("products").Update(updates).Eq("id", strconv.FormatInt(productId, 10)).IsNull("deletedAt").Execute(&result)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if len(result) == 0 {
+		respondError(ctx, services.ErrProductNotFound)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Product updated successfully",
+		"data":    result[0],
+	})
+}
+
+// DeleteProduct soft deletes a product so existing orders keep their references.
+// Only the owning merchant may delete a product.
+func DeleteProduct(ctx *gin.Context) {
+	productId, ok := parseProductId(ctx)
+	if !ok {
+		return
+	}
+
+	product, err := services.GetProduct(productId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if !authorizeProductMerchant(ctx, product.MerchantId, "delete") {
+		return
+	}
+
+	updates := map[string]interface{}{
+		"deletedAt": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var result []models.Products
+	err = db.Supabase.DB.From("products").Update(updates).Eq("id", strconv.FormatInt(productId, 10)).IsNull("deletedAt").Execute(&result)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"productId": productId,
+		"message":   "Product deleted successfully",
+	})
+}
+
+// GetLowStockProducts lists a merchant's inventory tracked products at or below
+// the threshold query parameter (default 5)
+func GetLowStockProducts(ctx *gin.Context) {
+	merchantId := ctx.Param("merchantId")
+	if merchantId == "" {
+		respondError(ctx, errMerchantIdRequired)
+		return
+	}
+
+	threshold, err := strconv.ParseInt(ctx.DefaultQuery("threshold", "5"), 10, 64)
+	if err != nil || threshold < 0 {
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "threshold", "threshold must be a non-negative integer"))
+		return
+	}
+
+	products, err := services.GetLowStockProducts(merchantId, threshold)
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, products)
-}
\ No newline at end of file
+	if products == nil {
+		products = []models.Products{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"merchantId": merchantId,
+		"threshold":  threshold,
+		"products":   products,
+	})
+}
+
+// parseStockForm reads the optional stock form field. An empty value means the
+// product is not inventory tracked.
+func parseStockForm(ctx *gin.Context) (*int64, bool) {
+	stockStr := ctx.PostForm("stock")
+	if stockStr == "" {
+		return nil, true
+	}
+
+	stock, err := strconv.ParseInt(stockStr, 10, 64)
+	if err != nil || stock < 0 {
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "stock", "Stock must be a non-negative integer"))
+		return nil, false
+	}
+
+	return &stock, true
+}
+
+func parseProductId(ctx *gin.Context) (int64, bool) {
+	productId, err := strconv.ParseInt(ctx.Param("productId"), 10, 64)
+	if err != nil || productId <= 0 {
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "productId", "productId must be a positive integer"))
+		return 0, false
+	}
+	return productId, true
+}
+
+// authorizeProductMerchant checks that the request's API key belongs to merchantId
+// or that the signed in wallet owns it, responding with the error when not
+func authorizeProductMerchant(ctx *gin.Context, merchantId, action string) bool {
+	return authorizeMerchantRequest(ctx, merchantId, "Only the owning merchant can "+action+" this product")
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/Dbriane208/stable-market/apierror"
 	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/middleware"
 	"github.com/Dbriane208/stable-market/models"
 	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/Dbriane208/stable-market/utils"
 	"github.com/Dbriane208/stablebase-go-sdk/merchant"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
@@ -28,56 +33,75 @@ func RegisterMerchant(ctx *gin.Context) {
 	var info models.MerchantInfo
 
 	if err := ctx.ShouldBindJSON(&info); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
 	m := getMerchantClient()
 	if m == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Network client not initialized",
-		})
+		respondError(ctx, errBlockchainUnavailable)
 		return
 	}
 
 	bgCtx := context.Background()
+
+	// Without a client supplied URI, publish a merchant document from the registration details
+	var metadataHash string
+	if info.MetadataURI == "" {
+		document, err := services.PublishMetadata(bgCtx, models.MerchantMetadata{
+			Type:          "merchant",
+			Version:       1,
+			Name:          info.MerchantName,
+			PayoutAddress: info.PayoutWalletAddress.Hex(),
+			Description:   info.Description,
+			Website:       info.Website,
+			LogoUrl:       info.LogoUrl,
+			ContactEmail:  info.ContactEmail,
+			UpdatedAt:     time.Now().UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			respondError(ctx, metadataError(err))
+			return
+		}
+		info.MetadataURI = document.URI
+		metadataHash = document.Hash
+	}
+
 	_, merchantId, receipt, err := m.RegisterMerchant(bgCtx, info.PayoutWalletAddress, info.MetadataURI)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, services.ChainError(err))
 		return
 	}
 
 	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+		respondError(ctx, services.ErrDatabaseNotInitialized)
 		return
 	}
 
+	owner, _ := middleware.Wallet(ctx)
+
 	dbMerchant := models.MerchantDB{
 		MerchantName:        info.MerchantName,
 		MerchantId:          common.BytesToHash(merchantId[:]).Hex(),
 		PayoutWalletAddress: info.PayoutWalletAddress.Hex(),
+		OwnerAddress:        owner.Hex(),
 		MetadataURI:         info.MetadataURI,
 		TransactionHash:     receipt.TxHash.Hex(),
 	}
 
 	var result []map[string]interface{}
 	if err := db.Supabase.DB.From("merchants").Insert(dbMerchant).Execute(&result); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not create merchant: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
+	services.EnsureRole(owner, models.RoleMerchant, "registered merchant "+dbMerchant.MerchantId)
+
 	response := models.MerchantResponse{
 		MerchantId:      merchantId,
 		Message:         "Merchant registered successfully",
 		MetadataURI:     info.MetadataURI,
+		MetadataHash:    metadataHash,
 		WalletAddress:   info.PayoutWalletAddress,
 		MerchantName:    info.MerchantName,
 		TransactionHash: receipt.TxHash,
@@ -92,24 +116,18 @@ func GetMerchantInfoById(ctx *gin.Context) {
 	merchantId := ctx.Param("merchantId")
 
 	if merchantId == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "merchantId is required",
-		})
+		respondError(ctx, errMerchantIdRequired)
 		return
 	}
 
 	var merchants []models.MerchantDB
 	if err := db.Supabase.DB.From("merchants").Select("*").Eq("merchantId", merchantId).Execute(&merchants); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not get merchant details: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
 	if len(merchants) == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"error": "Merchant not found",
-		})
+		respondError(ctx, services.ErrMerchantNotFound)
 		return
 	}
 
@@ -129,24 +147,18 @@ func DeleteMerchant(ctx *gin.Context) {
 	merchantId := ctx.Param("merchantId")
 
 	if merchantId == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "merchantId is required",
-		})
+		respondError(ctx, errMerchantIdRequired)
 		return
 	}
 
 	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+		respondError(ctx, services.ErrDatabaseNotInitialized)
 		return
 	}
 
 	var result []map[string]interface{}
 	if err := db.Supabase.DB.From("merchants").Delete().Eq("merchantId", merchantId).Execute(&result); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not update merchant: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
@@ -156,72 +168,140 @@ func DeleteMerchant(ctx *gin.Context) {
 	})
 }
 
+// GetMerchantBalance reads one token balance of a wallet on Base Sepolia.
+// GetMerchantBalances covers every token and network.
 func GetMerchantBalance(ctx *gin.Context) {
 	merchantId := ctx.Param("merchantId")
 
-	var data *models.TokenBalance
+	var data models.TokenBalance
 
-	if err := ctx.ShouldBindJSON(&data); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	if err := ctx.ShouldBindQuery(&data); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	if !common.IsHexAddress(data.WalletAddress) {
+		respondError(ctx, errInvalidWalletAddress)
 		return
 	}
+	if !common.IsHexAddress(data.TokenAddress) {
+		respondError(ctx, errInvalidTokenAddress)
+		return
+	}
+	walletAddress := common.HexToAddress(data.WalletAddress)
+	tokenAddress := common.HexToAddress(data.TokenAddress)
 
 	m := getMerchantClient()
 	if m == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Network client not initialized",
-		})
+		respondError(ctx, errBlockchainUnavailable)
 		return
 	}
 
 	bgCtx := context.Background()
-	balance, err := m.GetMerchantTokenBalance(bgCtx, data.WalletAddress, data.TokenAddress)
+	balance, err := m.GetMerchantTokenBalance(bgCtx, walletAddress, tokenAddress)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, services.ChainError(err))
 		return
 	}
 
-	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+	ctx.JSON(http.StatusOK, &models.TokenBalanceDB{
+		MerchantId:    merchantId,
+		WalletAddress: walletAddress.Hex(),
+		TokenAddress:  tokenAddress.Hex(),
+		TokenBalance:  balance.String(),
+	})
+}
+
+// GetMerchantBalances returns the merchant's payout wallet balances for every
+// registered token on every network, and its paid orders awaiting settlement
+func GetMerchantBalances(ctx *gin.Context) {
+	merchant, err := services.GetMerchant(ctx.Param("merchantId"))
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
-	dbTokenBalance := &models.TokenBalanceDB{
-		MerchantId:    merchantId,
-		WalletAddress: data.WalletAddress.Hex(),
-		TokenAddress:  data.TokenAddress.Hex(),
-		TokenBalance:  balance.String(),
+	balances, err := services.MerchantBalances(ctx.Request.Context(), merchant)
+	if err != nil {
+		respondError(ctx, err)
+		return
 	}
 
-	var result []map[string]interface{}
-	if err := db.Supabase.DB.From("tokenBalance").Insert(dbTokenBalance).Execute(&result); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not create token balance: " + err.Error(),
-		})
+	ctx.JSON(http.StatusOK, balances)
+}
+
+// GetMerchantBalanceHistory returns snapshots of the merchant's current payout
+// wallet, bucketed by hour or day
+func GetMerchantBalanceHistory(ctx *gin.Context) {
+	query, err := parseBalanceHistoryQuery(ctx)
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, &models.TokenBalanceDB{
-		MerchantId:    merchantId,
-		WalletAddress: data.WalletAddress.Hex(),
-		TokenAddress:  data.TokenAddress.Hex(),
-		TokenBalance:  balance.String(),
-	})
+	merchant, err := services.GetMerchant(ctx.Param("merchantId"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	query.Holders = []string{common.HexToAddress(merchant.PayoutWalletAddress).Hex()}
+
+	history, err := services.BalanceHistory(query)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+// balanceHistoryRanges holds the default and the longest range of each bucket size
+var balanceHistoryRanges = map[string]struct{ def, max time.Duration }{
+	models.BalanceBucketHour: {def: 7 * 24 * time.Hour, max: 31 * 24 * time.Hour},
+	models.BalanceBucketDay:  {def: 30 * 24 * time.Hour, max: 366 * 24 * time.Hour},
+}
+
+// parseBalanceHistoryQuery reads bucket, from, to, network and tokenAddress. The
+// range ends now and spans the bucket's default when from or to are left out.
+func parseBalanceHistoryQuery(ctx *gin.Context) (services.BalanceHistoryQuery, error) {
+	query := services.BalanceHistoryQuery{Bucket: ctx.DefaultQuery("bucket", models.BalanceBucketDay)}
+
+	ranges, ok := balanceHistoryRanges[query.Bucket]
+	if !ok {
+		return query, apierror.InvalidField(apierror.CodeInvalidBucket, "bucket", "bucket must be hour or day")
+	}
+
+	from, to, err := utils.ParseDateRange(ctx)
+	if err != nil {
+		return query, err
+	}
+	query.To = time.Now().UTC()
+	if to != nil {
+		query.To = *to
+	}
+	query.From = query.To.Add(-ranges.def)
+	if from != nil {
+		query.From = *from
+	}
+	if query.To.Before(query.From) {
+		return query, apierror.InvalidField(apierror.CodeInvalidDateRange, "from", "from must not be after to")
+	}
+	if query.To.Sub(query.From) > ranges.max {
+		return query, apierror.InvalidField(apierror.CodeInvalidDateRange, "from", "range is too long for "+query.Bucket+" buckets")
+	}
+
+	if query.Network, query.TokenAddress, err = parseNetworkTokenFilter(ctx); err != nil {
+		return query, err
+	}
+
+	return query, nil
 }
 
 func IsMerchantVerified(ctx *gin.Context) {
 	merchantId := ctx.Param("merchantId")
 
 	if merchantId == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "merchantId is required",
-		})
+		respondError(ctx, errMerchantIdRequired)
 		return
 	}
 
@@ -229,9 +309,7 @@ func IsMerchantVerified(ctx *gin.Context) {
 	bgCtx := context.Background()
 	isVerified, err := m.IsMerchantVerified(bgCtx, common.HexToHash(merchantId))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, services.ChainError(err))
 		return
 	}
 
@@ -265,46 +343,37 @@ func getPaymentProcessorRegistryABI() (abi.ABI, error) {
 func PrepareUpdateMerchant(ctx *gin.Context) {
 	merchantIdParam := ctx.Param("merchantId")
 	if merchantIdParam == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "merchantId is required",
-		})
+		respondError(ctx, errMerchantIdRequired)
 		return
 	}
 
 	var input models.MerchantUpdateRequest
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
-	if input.PayoutWalletAddress == nil && input.MetadataURI == nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "payoutWalletAdPayoutWalletAddress or metadataURI is required for blockchain update",
-		})
+	profileChanged := input.MerchantName != nil || input.Description != nil || input.Website != nil ||
+		input.LogoUrl != nil || input.ContactEmail != nil
+
+	if input.PayoutWalletAddress == nil && input.MetadataURI == nil && !profileChanged {
+		respondError(ctx, apierror.BadRequest(apierror.CodeValidationFailed, "payoutWalletAdPayoutWalletAddress, metadataURI or a profile field is required for blockchain update"))
 		return
 	}
 
 	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+		respondError(ctx, services.ErrDatabaseNotInitialized)
 		return
 	}
 
 	var merchants []models.MerchantDB
 	if err := db.Supabase.DB.From("merchants").Select("*").Eq("merchantId", merchantIdParam).Execute(&merchants); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not fetch merchant data: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
 	if len(merchants) == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"error": "Merchant not found",
-		})
+		respondError(ctx, services.ErrMerchantNotFound)
 		return
 	}
 
@@ -318,29 +387,31 @@ func PrepareUpdateMerchant(ctx *gin.Context) {
 	}
 
 	if payoutWalletAdPayoutWalletAddressStr == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "payoutWalletAdPayoutWalletAddress is required",
-		})
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "payoutWalletAddress", "payoutWalletAdPayoutWalletAddress is required"))
 		return
 	}
 
 	metadataURI := deref(input.MetadataURI)
+	var metadataHash string
 	if metadataURI == "" {
-		metadataURI = currentMerchant.MetadataURI
+		// Publish a new merchant document carrying the changes over the current one
+		document, err := publishMerchantUpdateMetadata(currentMerchant, input, payoutWalletAdPayoutWalletAddressStr)
+		if err != nil {
+			respondError(ctx, metadataError(err))
+			return
+		}
+		metadataURI = document.URI
+		metadataHash = document.Hash
 	}
 
 	if metadataURI == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "metadataURI is required",
-		})
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "metadataURI", "metadataURI is required"))
 		return
 	}
 
 	contractABI, err := getMerchantRegistryABI()
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to parse contract ABI: " + err.Error(),
-		})
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
@@ -349,9 +420,7 @@ func PrepareUpdateMerchant(ctx *gin.Context) {
 
 	callData, err := contractABI.Pack("updateMerchant", merchantIdBytes, payoutAddress, metadataURI)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to encode transaction data: " + err.Error(),
-		})
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
@@ -368,6 +437,7 @@ func PrepareUpdateMerchant(ctx *gin.Context) {
 		MerchantId:          merchantIdParam,
 		PayoutWalletAddress: payoutWalletAdPayoutWalletAddressStr,
 		MetadataURI:         metadataURI,
+		MetadataHash:        metadataHash,
 		Message:             "Sign this transaction with your wallet to update merchant",
 	})
 }
@@ -375,24 +445,18 @@ func PrepareUpdateMerchant(ctx *gin.Context) {
 func ConfirmMerchantUpdate(ctx *gin.Context) {
 	merchantIdParam := ctx.Param("merchantId")
 	if merchantIdParam == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "merchantId is required",
-		})
+		respondError(ctx, errMerchantIdRequired)
 		return
 	}
 
 	var input models.ConfirmTransactionRequest
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
 	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+		respondError(ctx, services.ErrDatabaseNotInitialized)
 		return
 	}
 
@@ -417,9 +481,7 @@ func ConfirmMerchantUpdate(ctx *gin.Context) {
 		Update(updates).
 		Eq("merchantId", merchantIdParam).
 		Execute(&result); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not update merchant: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
@@ -435,17 +497,22 @@ func PrepareRefundOrderMerchant(ctx *gin.Context) {
 	orderId := ctx.Param("orderId")
 
 	if orderId == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "orderId is required",
-		})
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "orderId", "orderId is required"))
+		return
+	}
+
+	if !strings.HasPrefix(orderId, "0x") {
+		orderId = "0x" + orderId
+	}
+
+	if _, err := services.CheckOrderTransition(orderId, models.OrderStatusRefunded); err != nil {
+		respondError(ctx, err)
 		return
 	}
 
 	contractABI, err := getPaymentProcessorRegistryABI()
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to parse contract ABI: " + err.Error(),
-		})
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
@@ -453,9 +520,7 @@ func PrepareRefundOrderMerchant(ctx *gin.Context) {
 
 	callData, err := contractABI.Pack("refundOrder", orderIdBytes)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to encode transaction data: " + err.Error(),
-		})
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
@@ -480,47 +545,69 @@ func ConfirmRefundOrderMerchant(ctx *gin.Context) {
 	var input models.ConfirmRefundRequest
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
-	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+	if !strings.HasPrefix(orderId, "0x") {
+		orderId = "0x" + orderId
+	}
+
+	if _, err := services.CheckOrderTransition(orderId, models.OrderStatusRefunded); err != nil {
+		respondError(ctx, err)
 		return
 	}
 
-	updates := map[string]interface{}{
-		"status":          "refunded",
-		"transactionHash": input.TransactionHash,
+	sdkClient := networks.GetBaseClient()
+	if sdkClient == nil {
+		respondError(ctx, errBlockchainUnavailable)
+		return
 	}
 
-	var result []map[string]interface{}
-	if err := db.Supabase.DB.
-		From("orders").
-		Update(updates).
-		Eq("orderId", orderId).
-		Execute(&result); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not update order status: " + err.Error(),
-		})
+	txHash := common.HexToHash(input.TransactionHash)
+	receipt, err := sdkClient.EthClient.TransactionReceipt(context.Background(), txHash)
+	if err != nil {
+		respondError(ctx, services.ReceiptError(err))
+		return
+	}
+
+	if receipt.Status == 0 {
+		respondError(ctx, errTxReverted)
+		return
+	}
+
+	tx, _, err := sdkClient.EthClient.TransactionByHash(context.Background(), txHash)
+	if err != nil {
+		respondError(ctx, services.ReceiptError(err))
+		return
+	}
+	if err := services.CheckOrderTransaction(tx, receipt, sdkClient.PaymentProcessorAddress, "refundOrder", services.EventOrderRefunded, common.HexToHash(orderId)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	actor, _ := services.SenderOf(tx)
+	order, event, err := services.TransitionOrder(services.OrderTransition{
+		OrderId:         orderId,
+		To:              models.OrderStatusRefunded,
+		TransactionHash: input.TransactionHash,
+		BlockNumber:     receipt.BlockNumber.Uint64(),
+		Actor:           actor,
+	})
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
 	response := gin.H{
 		"orderId":         orderId,
 		"message":         "Order refunded successfully",
-		"status":          "refunded",
+		"status":          models.OrderStatusRefunded,
+		"order":           order,
+		"event":           event,
 		"transactionHash": input.TransactionHash,
 		"explorerUrl":     networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + input.TransactionHash,
 	}
 
-	if len(result) > 0 {
-		response["order"] = result[0]
-	}
-
 	ctx.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/metadata"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// GetMetadataDocument serves a document from the local metadata store by its sha256 hash
+func GetMetadataDocument(ctx *gin.Context) {
+	local, ok := metadata.Default().(*metadata.LocalStore)
+	if !ok {
+		respondError(ctx, apierror.NotFound(apierror.CodeMetadataNotFound, "Metadata documents are not served by this API"))
+		return
+	}
+
+	document, err := local.GetByHash(ctx.Param("hash"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	// Content addressed documents never change
+	ctx.Header("Cache-Control", "public, max-age=31536000, immutable")
+	ctx.Data(http.StatusOK, "application/json", document)
+}
+
+// ResolveMetadata fetches a metadata document, checks it against its URI and, when an
+// orderId, merchantId or transactionHash is given, against the URI recorded on-chain
+func ResolveMetadata(ctx *gin.Context) {
+	uri := ctx.Query("uri")
+	txHash := ctx.Query("transactionHash")
+
+	switch {
+	case ctx.Query("orderId") != "":
+		order, err := services.GetOrder(ctx.Query("orderId"))
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
+		if uri == "" {
+			uri = order.MetadataURI
+		}
+		if txHash == "" {
+			txHash = order.TransactionHash
+		}
+	case ctx.Query("merchantId") != "":
+		merchant, err := services.GetMerchant(ctx.Query("merchantId"))
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
+		if uri == "" {
+			uri = merchant.MetadataURI
+		}
+		if txHash == "" {
+			txHash = merchant.TransactionHash
+		}
+	}
+
+	if uri == "" {
+		respondError(ctx, apierror.BadRequest(apierror.CodeValidationFailed, "uri, orderId or merchantId is required"))
+		return
+	}
+
+	bgCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resolution, err := services.ResolveMetadata(bgCtx, uri)
+	if err != nil {
+		respondError(ctx, metadataError(err))
+		return
+	}
+
+	if txHash != "" {
+		sdkClient := networks.GetBaseClient()
+		if sdkClient == nil {
+			respondError(ctx, errBlockchainUnavailable)
+			return
+		}
+
+		onChainURI, err := services.OnChainMetadataURI(bgCtx, sdkClient.EthClient, common.HexToHash(txHash))
+		if err != nil && !errors.Is(err, services.ErrNoOnChainMetadataURI) {
+			respondError(ctx, services.ChainError(err))
+			return
+		}
+
+		matches := onChainURI != "" && onChainURI == uri
+		resolution.OnChainURI = onChainURI
+		resolution.MatchesOnChain = &matches
+		resolution.TransactionHash = txHash
+	}
+
+	ctx.JSON(http.StatusOK, resolution)
+}
+
+// metadataError reports a failed metadata fetch or publish. Failures the store does
+// not classify come from IPFS or the URI's host.
+func metadataError(err error) error {
+	var apiErr *apierror.Error
+	if err = apiError(err); errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return apierror.Wrap(http.StatusBadGateway, apierror.CodeUpstreamFailed, "Could not resolve metadata", err)
+}
+
+// publishMerchantUpdateMetadata overlays the requested changes on the merchant's
+// current document, or on its database record if the document is not in our store
+func publishMerchantUpdateMetadata(current models.MerchantDB, input models.MerchantUpdateRequest, payoutAddress string) (metadata.Document, error) {
+	bgCtx := context.Background()
+
+	base, err := services.LoadMerchantMetadata(bgCtx, current.MetadataURI)
+	if err != nil {
+		return metadata.Document{}, err
+	}
+
+	document := models.MerchantMetadata{
+		Type:    "merchant",
+		Version: 1,
+		Name:    current.MerchantName,
+	}
+	if base != nil {
+		document = *base
+	}
+
+	document.MerchantId = current.MerchantId
+	document.PayoutAddress = common.HexToAddress(payoutAddress).Hex()
+	document.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	if input.MerchantName != nil {
+		document.Name = *input.MerchantName
+	}
+	if input.Description != nil {
+		document.Description = *input.Description
+	}
+	if input.Website != nil {
+		document.Website = *input.Website
+	}
+	if input.LogoUrl != nil {
+		document.LogoUrl = *input.LogoUrl
+	}
+	if input.ContactEmail != nil {
+		document.ContactEmail = *input.ContactEmail
+	}
+
+	return services.PublishMetadata(bgCtx, document)
+}
@@ -3,14 +3,19 @@ package controllers
 import (
 	"context"
 	"encoding/hex"
-	"math/big"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/Dbriane208/stable-market/abi"
+	"github.com/Dbriane208/stable-market/apierror"
 	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/middleware"
 	"github.com/Dbriane208/stable-market/models"
 	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/Dbriane208/stable-market/utils"
 	"github.com/Dbriane208/stablebase-go-sdk/order"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
@@ -28,60 +33,17 @@ func PrepareApproveToken(ctx *gin.Context) {
 	var input models.ApproveTokenRequest
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
-		return
-	}
-
-	tokenAddress := common.HexToAddress(input.TokenAddress)
-	if tokenAddress == (common.Address{}) {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid token address",
-		})
-		return
-	}
-
-	amount := new(big.Int)
-	amount, ok := amount.SetString(input.Amount, 10)
-	if !ok {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid amount",
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
-	contractABI, err := abi.GetERC20ABI()
+	response, err := services.PrepareApproveToken(input)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to parse ERC20 ABI: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
-	spenderAddress := networks.BaseSepoliaConfig.PaymentProcessorAddress
-
-	callData, err := contractABI.Pack("approve", spenderAddress, amount)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to encode transaction data: " + err.Error(),
-		})
-		return
-	}
-
-	ctx.JSON(http.StatusOK, models.PrepareApproveResponse{
-		TransactionData: models.TransactionData{
-			To:       tokenAddress.Hex(),
-			Data:     "0x" + common.Bytes2Hex(callData),
-			ChainId:  networks.BaseSepoliaConfig.ChainID.Int64(),
-			Value:    "0",
-			GasLimit: 100000,
-		},
-		TokenAddress: input.TokenAddress,
-		Spender:      spenderAddress.Hex(),
-		Amount:       input.Amount,
-		Message:      "Sign this transaction to approve PaymentProcessor to spend your tokens",
-	})
+	ctx.JSON(http.StatusOK, response)
 }
 
 func ConfirmApproveToken(ctx *gin.Context) {
@@ -89,17 +51,11 @@ func ConfirmApproveToken(ctx *gin.Context) {
 	var input models.ConfirmApproveRequest
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"message":         "Token approval confirmed",
-		"transactionHash": input.TransactionHash,
-		"explorerUrl":     networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + input.TransactionHash,
-	})
+	ctx.JSON(http.StatusOK, services.ConfirmApproveToken(input))
 }
 
 // PrepareCreateOrder prepares an unsigned transaction for creating an order
@@ -107,442 +63,417 @@ func PrepareCreateOrder(ctx *gin.Context) {
 	var req models.CreateOrderRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
-	var merchantId [32]byte
-	merchantIdBytes, err := hex.DecodeString(strings.TrimPrefix(req.MerchantId, "0x"))
-	if err != nil || len(merchantIdBytes) != 32 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid merchantId format (must be 32 bytes hex string)",
-		})
+	if !utils.IsHexId(req.MerchantId) {
+		respondError(ctx, errInvalidMerchantId)
 		return
 	}
-	copy(merchantId[:], merchantIdBytes)
 
-	if !common.IsHexAddress(req.TokenAddress) {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid token address",
-		})
+	if !apiKeyAllowsMerchant(ctx, req.MerchantId) {
 		return
 	}
-	tokenAddress := common.HexToAddress(req.TokenAddress)
 
-	amount := new(big.Int)
-	amount, ok := amount.SetString(req.Amount, 10)
-	if !ok {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid amount format",
-		})
+	response, err := services.PrepareCreateOrder(ctx.Request.Context(), req)
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
-	if amount.Cmp(big.NewInt(0)) <= 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Amount must be greater than zero",
-		})
+	ctx.JSON(http.StatusCreated, response)
+}
+
+func ConfirmCreateOrder(ctx *gin.Context) {
+	var req models.ConfirmCreateOrderRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
-	if req.MetadataURI == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Metadata URI is required",
-		})
-		return
+	merchantIdHex := req.MerchantId
+	if !strings.HasPrefix(merchantIdHex, "0x") {
+		merchantIdHex = "0x" + merchantIdHex
 	}
 
-	contractABI, err := abi.GetPaymentProcessorABI()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get PaymentProcessor ABI: " + err.Error(),
-		})
+	if !apiKeyAllowsMerchant(ctx, merchantIdHex) {
 		return
 	}
 
-	data, err := contractABI.Pack("createOrder", merchantId, tokenAddress, amount, req.MetadataURI)
+	// Only the merchant chooses how long its orders may stay unpaid; anyone
+	// else gets the merchant's order expiry policy
+	if req.ExpiresIn != 0 && !merchantAuthenticated(ctx, merchantIdHex) {
+		req.ExpiresIn = 0
+	}
+
+	response, err := services.ConfirmCreateOrder(ctx.Request.Context(), req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to encode transaction data: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
-	paymentProcessorAddress := networks.BaseSepoliaConfig.PaymentProcessorAddress
+	ctx.JSON(http.StatusOK, response)
+}
 
-	// Return unsigned transaction data
-	response := models.PrepareCreateOrderResponse{
-		TransactionData: models.TransactionData{
-			To:       paymentProcessorAddress.Hex(),
-			Data:     "0x" + hex.EncodeToString(data),
-			ChainId:  networks.BaseSepoliaConfig.ChainID.Int64(),
-			Value:    "0",
-			GasLimit: 300000,
-		},
-		MerchantId:   req.MerchantId,
-		TokenAddress: req.TokenAddress,
-		Amount:       req.Amount,
-		MetadataURI:  req.MetadataURI,
-		Message:      "Please sign with your wallet and submit the transaction hash to confirm.",
+func PreparePayOrder(ctx *gin.Context) {
+	var req models.PrepareOrder
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
 	}
 
-	ctx.JSON(http.StatusCreated, response)
+	response, err := services.PreparePayOrder(req.OrderId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
 }
 
-func ConfirmCreateOrder(ctx *gin.Context) {
-	var req models.ConfirmCreateOrderRequest
+func ConfirmPayOrder(ctx *gin.Context) {
+	var req models.ConfirmPayOrderRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
-	if !common.IsHexAddress(req.TransactionHash) && len(req.TransactionHash) != 66 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid transaction hash format",
-		})
+	response, err := services.ConfirmPayOrder(ctx.Request.Context(), req)
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
-	if !common.IsHexAddress(req.PayerAddress) {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid payer address",
-		})
+	ctx.JSON(http.StatusOK, response)
+}
+
+func CancelOrder(ctx *gin.Context) {
+	var req models.PrepareOrder
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
-	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+	var orderId [32]byte
+	orderIdBytes, err := hex.DecodeString(strings.TrimPrefix(req.OrderId, "0x"))
+	if err != nil || len(orderIdBytes) != 32 {
+		respondError(ctx, errInvalidOrderId)
 		return
 	}
+	copy(orderId[:], orderIdBytes)
 
-	merchantIdHex := req.MerchantId
-	if !strings.HasPrefix(merchantIdHex, "0x") {
-		merchantIdHex = "0x" + merchantIdHex
+	orderIdHex := req.OrderId
+	if !strings.HasPrefix(orderIdHex, "0x") {
+		orderIdHex = "0x" + orderIdHex
 	}
 
-	var merchants []map[string]interface{}
-	err := db.Supabase.DB.From("merchants").Select("*").Eq("merchantId", merchantIdHex).Execute(&merchants)
+	existing, err := services.CheckOrderTransition(orderIdHex, models.OrderStatusCancelled)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch merchant: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
-	if len(merchants) == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"error": "Merchant not found",
-		})
+	// The cancellation is signed with the platform key, so only the order's
+	// merchant may ask for it
+	if !authorizeMerchantRequest(ctx, existing.MerchantId, "Only the owning merchant can cancel this order") {
 		return
 	}
 
-	sdkClient := networks.GetBaseClient()
-	if sdkClient == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to initialize blockchain client",
-		})
+	o := getOrderClient()
+	if o == nil {
+		respondError(ctx, errBlockchainUnavailable)
 		return
 	}
 
-	txHash := common.HexToHash(req.TransactionHash)
 	bgCtx := context.Background()
-	receipt, err := sdkClient.EthClient.TransactionReceipt(bgCtx, txHash)
+	tx, receipt, err := o.CancelOrder(bgCtx, orderId)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Transaction not found or not yet mined: " + err.Error(),
-			"message": "Please wait for the transaction to be confirmed and try again",
-		})
+		respondError(ctx, services.ChainError(err))
 		return
 	}
 
-	if receipt.Status == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Transaction failed on blockchain",
-		})
-		return
-	}
+	actor, _ := services.SenderOf(tx)
 
-	contractABI, err := abi.GetPaymentProcessorABI()
+	_, event, err := services.TransitionOrder(services.OrderTransition{
+		OrderId:         orderIdHex,
+		To:              models.OrderStatusCancelled,
+		TransactionHash: receipt.TxHash.Hex(),
+		BlockNumber:     receipt.BlockNumber.Uint64(),
+		Actor:           actor,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get PaymentProcessor ABI: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
-	var orderId string
-	for _, log := range receipt.Logs {
-		event := struct {
-			OrderId [32]byte
-		}{}
+	ctx.JSON(http.StatusOK, gin.H{
+		"orderId":         orderIdHex,
+		"status":          models.OrderStatusCancelled,
+		"event":           event,
+		"transactionHash": receipt.TxHash.Hex(),
+		"explorerUrl":     networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + receipt.TxHash.Hex(),
+	})
+}
 
-		err := contractABI.UnpackIntoInterface(&event, "OrderCreated", log.Data)
-		if err == nil {
-			orderId = "0x" + hex.EncodeToString(event.OrderId[:])
-			break
-		}
+// toOrderDetails attaches explorer links and transition history to an order
+func toOrderDetails(o models.OrderDB, events []models.OrderEvent) models.OrderDetails {
+	network := o.Network
+	if network == "" {
+		network = networks.DefaultNetworkName
 	}
 
-	if orderId == "" {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Order created but orderId not found in transaction logs",
+	history := make([]models.OrderHistoryEntry, 0, len(events))
+	for _, event := range events {
+		history = append(history, models.OrderHistoryEntry{
+			OrderEvent:  event,
+			ExplorerURL: networks.ExplorerTxURL(network, event.TransactionHash),
 		})
-		return
 	}
 
-	dbOrder := models.OrderDB{
-		OrderId:         orderId,
-		MerchantId:      merchantIdHex,
-		PayerAddress:    req.PayerAddress,
-		TokenAddress:    req.TokenAddress,
-		Amount:          req.Amount,
-		Status:          "created",
-		MetadataURI:     req.MetadataURI,
-		TransactionHash: req.TransactionHash,
+	return models.OrderDetails{
+		OrderDB:     o,
+		ExplorerURL: networks.ExplorerTxURL(network, o.TransactionHash),
+		History:     history,
 	}
+}
 
-	var result []models.OrderDB
-	if err := db.Supabase.DB.From("orders").Insert(dbOrder).Execute(&result); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Order not saved to database: " + err.Error(),
-		})
-		return
+// GetOrderById returns a single order with its transition history
+func GetOrderById(ctx *gin.Context) {
+	orderIdHex := ctx.Param("orderId")
+	if !strings.HasPrefix(orderIdHex, "0x") {
+		orderIdHex = "0x" + orderIdHex
 	}
 
-	if len(result) > 0 {
-		ctx.JSON(http.StatusOK, gin.H{
-			"success":     true,
-			"order":       result[0],
-			"explorerUrl": networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + req.TransactionHash,
-		})
-	} else {
-		ctx.JSON(http.StatusOK, gin.H{
-			"success":     true,
-			"order":       dbOrder,
-			"explorerUrl": networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + req.TransactionHash,
-		})
+	if orderIdBytes, err := hex.DecodeString(strings.TrimPrefix(orderIdHex, "0x")); err != nil || len(orderIdBytes) != 32 {
+		respondError(ctx, errInvalidOrderId)
+		return
 	}
-}
-
-func PreparePayOrder(ctx *gin.Context) {
-	var req models.PrepareOrder
 
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	o, err := services.GetOrder(orderIdHex)
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
-	var orderId [32]byte
-	orderIdBytes, err := hex.DecodeString(strings.TrimPrefix(req.OrderId, "0x"))
-	if err != nil || len(orderIdBytes) != 32 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid orderId format (must be 32 bytes hex string)",
-		})
+	if !apiKeyAllowsMerchant(ctx, o.MerchantId) {
 		return
 	}
-	copy(orderId[:], orderIdBytes)
 
-	contractABI, err := abi.GetPaymentProcessorABI()
+	events, err := services.ListOrderEvents(orderIdHex)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get PaymentProcessor ABI: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
-	data, err := contractABI.Pack("payOrder", orderId)
+	items, err := services.ListOrderItems(orderIdHex)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to encode transaction data: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
-	paymentProcessorAddress := networks.BaseSepoliaConfig.PaymentProcessorAddress
-
-	// Return unsigned transaction data
-	response := models.PreparePayOrderResponse{
-		TransactionData: models.TransactionData{
-			To:       paymentProcessorAddress.Hex(),
-			Data:     "0x" + hex.EncodeToString(data),
-			ChainId:  networks.BaseSepoliaConfig.ChainID.Int64(),
-			Value:    "0",
-			GasLimit: 300000,
-		},
-		OrderId: req.OrderId,
-		Message: "Please sign with your wallet to Pay the order.",
-	}
+	details := toOrderDetails(*o, events)
+	details.Items = items
 
-	ctx.JSON(http.StatusOK, response)
+	ctx.JSON(http.StatusOK, details)
 }
 
-func ConfirmPayOrder(ctx *gin.Context) {
-	var req models.ConfirmPayOrderRequest
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+// ListOrders returns orders filtered by merchantId, payerAddress, status, token,
+// network and a from/to date range, using cursor pagination
+func ListOrders(ctx *gin.Context) {
+	page, err := utils.ParsePagination(ctx)
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
-	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+	from, to, err := utils.ParseDateRange(ctx)
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
-	orderIdHex := req.OrderId
-	if !strings.HasPrefix(orderIdHex, "0x") {
-		orderIdHex = "0x" + orderIdHex
+	status := ctx.Query("status")
+	if status != "" && !models.IsValidOrderStatus(status) {
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidStatus, "status", "Invalid status. Must be: created, paid, settled, refunded or cancelled"))
+		return
 	}
 
-	var existingOrders []map[string]interface{}
-	err := db.Supabase.DB.From("orders").Select("*").Eq("orderId", orderIdHex).Execute(&existingOrders)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch order: " + err.Error(),
-		})
-		return
+	network := ctx.Query("network")
+	if network != "" {
+		if _, exists := networks.GetNetworkConfig(network); !exists {
+			respondError(ctx, apierror.BadRequest(apierror.CodeUnsupportedNetwork, "Unsupported network: "+network))
+			return
+		}
 	}
 
-	if len(existingOrders) == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"error": "Order not found",
-		})
+	// Addresses are matched case insensitively, so only hex is let through to
+	// the pattern
+	payerAddress := ctx.Query("payerAddress")
+	if payerAddress != "" && !common.IsHexAddress(payerAddress) {
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidAddress, "payerAddress", "payerAddress must be a hex address"))
 		return
 	}
-
-	sdkClient := networks.GetBaseClient()
-	if sdkClient == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to initialize blockchain client",
-		})
+	token := ctx.Query("token")
+	if token != "" && !common.IsHexAddress(token) {
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidTokenAddress, "token", "token must be a hex address"))
 		return
 	}
 
-	txHash := common.HexToHash(req.TransactionHash)
-	bgCtx := context.Background()
-	receipt, err := sdkClient.EthClient.TransactionReceipt(bgCtx, txHash)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Transaction not found or not yet mined: " + err.Error(),
-			"message": "Please wait for the transaction to be confirmed and try again",
-		})
+	if db.Supabase == nil {
+		respondError(ctx, services.ErrDatabaseNotInitialized)
 		return
 	}
 
-	if receipt.Status == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Transaction failed on blockchain",
-		})
+	query := db.Supabase.DB.From("orders").Select("*")
+	query.OrderBy("id", page.Direction()).Limit(page.Limit + 1)
+
+	merchantId := ctx.Query("merchantId")
+	if merchantId != "" && !utils.IsHexId(merchantId) {
+		respondError(ctx, errInvalidMerchantId)
 		return
 	}
+	if keyMerchantId, ok := middleware.APIKeyMerchant(ctx); ok {
+		// An API key only ever sees its own merchant's orders
+		if merchantId != "" && !apiKeyAllowsMerchant(ctx, merchantId) {
+			return
+		}
+		merchantId = keyMerchantId
+	}
 
-	updates := map[string]interface{}{
-		"status":          "paid",
-		"transactionHash": req.TransactionHash,
+	filter := &query.FilterRequestBuilder
+	if merchantId != "" {
+		filter.Eq("merchantId", merchantId)
+	}
+	if payerAddress != "" {
+		filter.Ilike("payerAddress", payerAddress)
+	}
+	if token != "" {
+		filter.Ilike("tokenAddress", token)
+	}
+	if status != "" {
+		filter.Eq("status", status)
+	}
+	if network != "" {
+		filter.Eq("network", network)
+	}
+	if from != nil {
+		filter.Filter("createdAt", "gte", from.Format(time.RFC3339Nano))
+	}
+	if to != nil {
+		filter.Filter("createdAt", "lte", to.Format(time.RFC3339Nano))
+	}
+	if page.Cursor > 0 {
+		if page.Ascending {
+			filter.Gt("id", strconv.FormatInt(page.Cursor, 10))
+		} else {
+			filter.Lt("id", strconv.FormatInt(page.Cursor, 10))
+		}
 	}
 
-	var result []map[string]interface{}
-	if err := db.Supabase.DB.From("orders").Update(updates).Eq("orderId", orderIdHex).Execute(&result); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not update order status: " + err.Error(),
-		})
+	var orders []models.OrderDB
+	if err := filter.Execute(&orders); err != nil {
+		respondError(ctx, err)
 		return
 	}
 
-	response := gin.H{
-		"success":         true,
-		"orderId":         orderIdHex,
-		"message":         "Order paid successfully",
-		"status":          "paid",
-		"transactionHash": req.TransactionHash,
-		"explorerUrl":     networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + req.TransactionHash,
+	response := models.OrderListResponse{
+		Orders: make([]models.OrderDetails, 0, len(orders)),
 	}
 
-	ctx.JSON(http.StatusOK, response)
-}
+	if len(orders) > page.Limit {
+		orders = orders[:page.Limit]
+		response.HasMore = true
+		response.NextCursor = utils.EncodeCursor(orders[len(orders)-1].Id)
+	}
 
-func CancelOrder(ctx *gin.Context) {
-	var req models.PrepareOrder
+	orderIds := make([]string, 0, len(orders))
+	for _, o := range orders {
+		orderIds = append(orderIds, o.OrderId)
+	}
 
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	history, err := services.ListOrderEventsByOrder(orderIds)
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
-	var orderId [32]byte
-	orderIdBytes, err := hex.DecodeString(strings.TrimPrefix(req.OrderId, "0x"))
-	if err != nil || len(orderIdBytes) != 32 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid orderId format (must be 32 bytes hex string)",
-		})
-		return
+	for _, o := range orders {
+		response.Orders = append(response.Orders, toOrderDetails(o, history[o.OrderId]))
 	}
-	copy(orderId[:], orderIdBytes)
 
-	orderIdHex := req.OrderId
+	ctx.JSON(http.StatusOK, response)
+}
+
+// orderIdParam reads the orderId path parameter as a 0x prefixed 32 byte hex
+// string, responding with INVALID_ORDER_ID when it is not one
+func orderIdParam(ctx *gin.Context) (string, bool) {
+	orderIdHex := ctx.Param("orderId")
 	if !strings.HasPrefix(orderIdHex, "0x") {
 		orderIdHex = "0x" + orderIdHex
 	}
 
-	var existingOrders []map[string]interface{}
-	err = db.Supabase.DB.From("orders").Select("*").Eq("orderId", orderIdHex).Execute(&existingOrders)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch order: " + err.Error(),
-		})
-		return
+	if orderIdBytes, err := hex.DecodeString(strings.TrimPrefix(orderIdHex, "0x")); err != nil || len(orderIdBytes) != 32 {
+		respondError(ctx, errInvalidOrderId)
+		return "", false
 	}
+	return orderIdHex, true
+}
 
-	if len(existingOrders) == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"error": "Order not found",
-		})
-		return
+// apiKeyAllowsMerchant rejects requests made with another merchant's API key.
+// Requests without a key are left to the handler.
+func apiKeyAllowsMerchant(ctx *gin.Context, merchantId string) bool {
+	keyMerchantId, ok := middleware.APIKeyMerchant(ctx)
+	if !ok || common.HexToHash(keyMerchantId) == common.HexToHash(merchantId) {
+		return true
 	}
 
-	o := getOrderClient()
-	bgCtx := context.Background()
-	_, receipt, err := o.CancelOrder(bgCtx, orderId)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
-		return
+	respondError(ctx, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "API key does not belong to this merchant"))
+	return false
+}
+
+// merchantAuthenticated reports whether the request was made with merchantId's
+// API key or by its owner's signed in wallet
+func merchantAuthenticated(ctx *gin.Context, merchantId string) bool {
+	if keyMerchantId, ok := middleware.APIKeyMerchant(ctx); ok {
+		return common.HexToHash(keyMerchantId) == common.HexToHash(merchantId)
 	}
 
-	updates := map[string]interface{}{
-		"status":          "cancelled",
-		"transactionHash": receipt.TxHash.Hex(),
+	wallet, ok := middleware.Wallet(ctx)
+	if !ok {
+		return false
 	}
+	_, err := services.AuthorizeMerchant(merchantId, wallet)
+	return err == nil
+}
 
-	var result []map[string]interface{}
-	err = db.Supabase.DB.From("orders").Update(updates).Eq("orderId", orderIdHex).Execute(&result)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not update order status: " + err.Error(),
-		})
-		return
+// authorizeMerchantRequest checks that the request's API key belongs to merchantId
+// or that the signed in wallet owns it, responding with forbidden and message
+// when not. Routes using it run RequireMerchantAuth first.
+func authorizeMerchantRequest(ctx *gin.Context, merchantId, message string) bool {
+	if keyMerchantId, ok := middleware.APIKeyMerchant(ctx); ok {
+		if common.HexToHash(keyMerchantId) == common.HexToHash(merchantId) {
+			return true
+		}
+		respondError(ctx, apierror.New(http.StatusForbidden, apierror.CodeNotMerchantOwner, message))
+		return false
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"orderId":         orderIdHex,
-		"status":          "cancelled",
-		"transactionHash": receipt.TxHash.Hex(),
-		"explorerUrl":     networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + receipt.TxHash.Hex(),
-	})
+	wallet, _ := middleware.Wallet(ctx)
+
+	_, err := services.AuthorizeMerchant(merchantId, wallet)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, services.ErrNotMerchantOwner):
+		respondError(ctx, apierror.New(http.StatusForbidden, apierror.CodeNotMerchantOwner, message))
+	default:
+		respondError(ctx, err)
+	}
+
+	return false
 }
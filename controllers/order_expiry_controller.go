@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetOrderExpiryPolicy returns how long the merchant's orders may stay unpaid
+func GetOrderExpiryPolicy(ctx *gin.Context) {
+	policy, err := services.GetOrderExpiryPolicy(ctx.Param("merchantId"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
+
+// UpdateOrderExpiryPolicy sets how long the merchant's new orders may stay
+// unpaid before they are cancelled
+func UpdateOrderExpiryPolicy(ctx *gin.Context) {
+	var req models.UpdateOrderExpiryPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	wallet, _ := middleware.Wallet(ctx)
+	policy, err := services.SetOrderExpiryPolicy(ctx.Param("merchantId"), req.ExpiryMinutes, wallet.Hex())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
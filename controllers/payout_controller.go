@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetPayoutPolicy returns when the merchant's paid orders are settled automatically
+func GetPayoutPolicy(ctx *gin.Context) {
+	policy, err := services.GetPayoutPolicy(ctx.Param("merchantId"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
+
+// UpdatePayoutPolicy sets the merchant's payout policy: immediate, delayed by
+// holdHours, a daily batch or manual settlement only
+func UpdatePayoutPolicy(ctx *gin.Context) {
+	var req models.UpdatePayoutPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	wallet, _ := middleware.Wallet(ctx)
+	policy, err := services.SetPayoutPolicy(ctx.Param("merchantId"), req.Policy, req.HoldHours, wallet.Hex())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
+
+// GetOrderSettlement returns an order's payout policy, settlement holds and the
+// settlement worker's attempts
+func GetOrderSettlement(ctx *gin.Context) {
+	orderIdHex, ok := orderIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := services.GetOrderSettlementStatus(orderIdHex)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+// HoldOrderSettlement stops the settlement worker from settling an order
+func HoldOrderSettlement(ctx *gin.Context) {
+	orderIdHex, ok := orderIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	// The note is optional, so an empty body is accepted
+	var req models.HoldSettlementRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			respondError(ctx, apierror.Binding(err))
+			return
+		}
+	}
+
+	wallet, _ := middleware.Wallet(ctx)
+	hold, err := services.HoldSettlement(orderIdHex, models.SettlementHoldManual, req.Note, wallet.Hex())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+// ReleaseOrderSettlement removes an operator's hold from an order. Holds placed
+// for other reasons, such as disputes, stay in place.
+func ReleaseOrderSettlement(ctx *gin.Context) {
+	orderIdHex, ok := orderIdParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := services.ReleaseSettlement(orderIdHex, models.SettlementHoldManual); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"orderId": orderIdHex,
+		"message": "Settlement hold released",
+	})
+}
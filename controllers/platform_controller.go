@@ -3,14 +3,18 @@ package controllers
 import (
 	"context"
 	"encoding/hex"
+	"log"
 	"math/big"
 	"net/http"
 	"strings"
 
 	"github.com/Dbriane208/stable-market/abi"
+	"github.com/Dbriane208/stable-market/apierror"
 	"github.com/Dbriane208/stable-market/db"
 	"github.com/Dbriane208/stable-market/models"
 	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/Dbriane208/stable-market/utils"
 	"github.com/Dbriane208/stablebase-go-sdk/platform"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
@@ -28,18 +32,14 @@ func PrepareSettleOrder(ctx *gin.Context) {
 	var req models.PrepareSettleOrderRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
 	var orderId [32]byte
 	orderIdBytes, err := hex.DecodeString(strings.TrimPrefix(req.OrderId, "0x"))
 	if err != nil || len(orderIdBytes) != 32 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid orderId format (must be 32 bytes hex string)",
-		})
+		respondError(ctx, errInvalidOrderId)
 		return
 	}
 	copy(orderId[:], orderIdBytes)
@@ -49,46 +49,24 @@ func PrepareSettleOrder(ctx *gin.Context) {
 		orderIdHex = "0x" + orderIdHex
 	}
 
-	if db.Supabase != nil {
-
-		var existingOrders []map[string]interface{}
-		err = db.Supabase.DB.From("orders").Select("*").Eq("orderId", orderIdHex).Execute(&existingOrders)
-
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to fetch order: " + err.Error(),
-			})
-			return
-		}
-
-		if len(existingOrders) == 0 {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error": "Order not found",
-			})
-			return
-		}
-
-		if status, ok := existingOrders[0]["status"].(string); ok && status != "paid" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Order must be in 'paid' status to be settled. Current status: " + status,
-			})
-			return
-		}
+	if _, err := services.CheckOrderTransition(orderIdHex, models.OrderStatusSettled); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	if err := services.CheckOrderNotDisputed(orderIdHex); err != nil {
+		respondError(ctx, err)
+		return
 	}
 
 	contractABI, err := abi.GetPaymentProcessorABI()
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get PaymentProcessor ABI: " + err.Error(),
-		})
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
 	data, err := contractABI.Pack("settleOrder", orderId)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to encode transaction data: " + err.Error(),
-		})
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
@@ -113,23 +91,17 @@ func ConfirmSettleOrder(ctx *gin.Context) {
 	var req models.ConfirmSettleOrderRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
 	if len(req.TransactionHash) != 66 || !strings.HasPrefix(req.TransactionHash, "0x") {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid transaction hash format",
-		})
+		respondError(ctx, errInvalidTxHash)
 		return
 	}
 
 	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+		respondError(ctx, services.ErrDatabaseNotInitialized)
 		return
 	}
 
@@ -138,27 +110,20 @@ func ConfirmSettleOrder(ctx *gin.Context) {
 		orderIdHex = "0x" + orderIdHex
 	}
 
-	var existingOrders []map[string]interface{}
-	err := db.Supabase.DB.From("orders").Select("*").Eq("orderId", orderIdHex).Execute(&existingOrders)
+	order, err := services.CheckOrderTransition(orderIdHex, models.OrderStatusSettled)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch order: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
-	if len(existingOrders) == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"error": "Order not found",
-		})
+	if err := services.CheckOrderNotDisputed(orderIdHex); err != nil {
+		respondError(ctx, err)
 		return
 	}
 
 	sdkClient := networks.GetBaseClient()
 	if sdkClient == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to initialize blockchain client",
-		})
+		respondError(ctx, errBlockchainUnavailable)
 		return
 	}
 
@@ -166,30 +131,47 @@ func ConfirmSettleOrder(ctx *gin.Context) {
 	bgCtx := context.Background()
 	receipt, err := sdkClient.EthClient.TransactionReceipt(bgCtx, txHash)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Transaction not found or not yet mined: " + err.Error(),
-			"message": "Please wait for the transaction to be confirmed and try again",
-		})
+		respondError(ctx, services.ReceiptError(err))
 		return
 	}
 
 	if receipt.Status == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Transaction failed on blockchain",
-		})
+		respondError(ctx, errTxReverted)
 		return
 	}
 
-	updates := map[string]interface{}{
-		"status":          "settled",
-		"transactionHash": req.TransactionHash,
+	tx, _, err := sdkClient.EthClient.TransactionByHash(bgCtx, txHash)
+	if err != nil {
+		respondError(ctx, services.ReceiptError(err))
+		return
+	}
+	if err := services.CheckOrderTransaction(tx, receipt, sdkClient.PaymentProcessorAddress, "settleOrder", services.EventOrderSettled, common.HexToHash(orderIdHex)); err != nil {
+		respondError(ctx, err)
+		return
 	}
 
-	var result []map[string]interface{}
-	if err := db.Supabase.DB.From("orders").Update(updates).Eq("orderId", orderIdHex).Execute(&result); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not update order status: " + err.Error(),
-		})
+	// Record the fee split before the order moves, so a failed write can be
+	// retried with the same transaction
+	settlement, err := services.ParseSettlement(order, sdkClient.PaymentProcessorAddress, receipt)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	if settlement, err = services.RecordSettlement(settlement); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	actor, _ := services.SenderOf(tx)
+	_, event, err := services.TransitionOrder(services.OrderTransition{
+		OrderId:         orderIdHex,
+		To:              models.OrderStatusSettled,
+		TransactionHash: req.TransactionHash,
+		BlockNumber:     receipt.BlockNumber.Uint64(),
+		Actor:           actor,
+	})
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
@@ -197,7 +179,9 @@ func ConfirmSettleOrder(ctx *gin.Context) {
 		"success":         true,
 		"orderId":         orderIdHex,
 		"message":         "Order settled successfully. Funds transferred to merchant.",
-		"status":          "settled",
+		"status":          models.OrderStatusSettled,
+		"event":           event,
+		"settlement":      settlement,
 		"transactionHash": req.TransactionHash,
 		"explorerUrl":     networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + req.TransactionHash,
 	})
@@ -207,18 +191,14 @@ func PrepareRefundOrder(ctx *gin.Context) {
 	var req models.PrepareRefundOrderRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
 	var orderId [32]byte
 	orderIdBytes, err := hex.DecodeString(strings.TrimPrefix(req.OrderId, "0x"))
 	if err != nil || len(orderIdBytes) != 32 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid orderId format (must be 32 bytes hex string)",
-		})
+		respondError(ctx, errInvalidOrderId)
 		return
 	}
 	copy(orderId[:], orderIdBytes)
@@ -228,47 +208,20 @@ func PrepareRefundOrder(ctx *gin.Context) {
 		orderIdHex = "0x" + orderIdHex
 	}
 
-	if db.Supabase != nil {
-		var existingOrders []map[string]interface{}
-
-		err = db.Supabase.DB.From("orders").Select("*").Eq("orderId", orderIdHex).Execute(&existingOrders)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to fetch order: " + err.Error(),
-			})
-			return
-		}
-
-		if len(existingOrders) == 0 {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error": "Order not found",
-			})
-			return
-		}
-
-		if status, ok := existingOrders[0]["status"].(string); ok {
-			if status != "paid" && status != "settled" {
-				ctx.JSON(http.StatusBadRequest, gin.H{
-					"error": "Order must be in 'paid' or 'settled' status to be refunded. Current status: " + status,
-				})
-				return
-			}
-		}
+	if _, err := services.CheckOrderTransition(orderIdHex, models.OrderStatusRefunded); err != nil {
+		respondError(ctx, err)
+		return
 	}
 
 	contractABI, err := abi.GetPaymentProcessorABI()
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get PaymentProcessor ABI: " + err.Error(),
-		})
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
 	data, err := contractABI.Pack("refundOrder", orderId)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to encode transaction data: " + err.Error(),
-		})
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
@@ -293,23 +246,17 @@ func ConfirmRefundOrder(ctx *gin.Context) {
 	var req models.ConfirmRefundOrderRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
 	if len(req.TransactionHash) != 66 || !strings.HasPrefix(req.TransactionHash, "0x") {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid transaction hash format",
-		})
+		respondError(ctx, errInvalidTxHash)
 		return
 	}
 
 	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+		respondError(ctx, services.ErrDatabaseNotInitialized)
 		return
 	}
 
@@ -318,27 +265,14 @@ func ConfirmRefundOrder(ctx *gin.Context) {
 		orderIdHex = "0x" + orderIdHex
 	}
 
-	var existingOrders []map[string]interface{}
-	err := db.Supabase.DB.From("orders").Select("*").Eq("orderId", orderIdHex).Execute(&existingOrders)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch order: " + err.Error(),
-		})
-		return
-	}
-
-	if len(existingOrders) == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"error": "Order not found",
-		})
+	if _, err := services.CheckOrderTransition(orderIdHex, models.OrderStatusRefunded); err != nil {
+		respondError(ctx, err)
 		return
 	}
 
 	sdkClient := networks.GetBaseClient()
 	if sdkClient == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to initialize blockchain client",
-		})
+		respondError(ctx, errBlockchainUnavailable)
 		return
 	}
 
@@ -346,30 +280,35 @@ func ConfirmRefundOrder(ctx *gin.Context) {
 	bgCtx := context.Background()
 	receipt, err := sdkClient.EthClient.TransactionReceipt(bgCtx, txHash)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Transaction not found or not yet mined: " + err.Error(),
-			"message": "Please wait for the transaction to be confirmed and try again",
-		})
+		respondError(ctx, services.ReceiptError(err))
 		return
 	}
 
 	if receipt.Status == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Transaction failed on blockchain",
-		})
+		respondError(ctx, errTxReverted)
 		return
 	}
 
-	updates := map[string]interface{}{
-		"status":          "refunded",
-		"transactionHash": req.TransactionHash,
+	tx, _, err := sdkClient.EthClient.TransactionByHash(bgCtx, txHash)
+	if err != nil {
+		respondError(ctx, services.ReceiptError(err))
+		return
+	}
+	if err := services.CheckOrderTransaction(tx, receipt, sdkClient.PaymentProcessorAddress, "refundOrder", services.EventOrderRefunded, common.HexToHash(orderIdHex)); err != nil {
+		respondError(ctx, err)
+		return
 	}
 
-	var result []map[string]interface{}
-	if err := db.Supabase.DB.From("orders").Update(updates).Eq("orderId", orderIdHex).Execute(&result); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not update order status: " + err.Error(),
-		})
+	actor, _ := services.SenderOf(tx)
+	_, event, err := services.TransitionOrder(services.OrderTransition{
+		OrderId:         orderIdHex,
+		To:              models.OrderStatusRefunded,
+		TransactionHash: req.TransactionHash,
+		BlockNumber:     receipt.BlockNumber.Uint64(),
+		Actor:           actor,
+	})
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
 
@@ -377,7 +316,8 @@ func ConfirmRefundOrder(ctx *gin.Context) {
 		"success":         true,
 		"orderId":         orderIdHex,
 		"message":         "Order refunded successfully. Funds returned to payer.",
-		"status":          "refunded",
+		"status":          models.OrderStatusRefunded,
+		"event":           event,
 		"transactionHash": req.TransactionHash,
 		"explorerUrl":     networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + req.TransactionHash,
 	})
@@ -387,82 +327,67 @@ func EmergencyWithdraw(ctx *gin.Context) {
 	var req models.EmergencyWithdraw
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
 	tokenAddress := common.HexToAddress(req.TokenAddress)
 	if tokenAddress == (common.Address{}) {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid token address",
-		})
+		respondError(ctx, errInvalidTokenAddress)
 		return
 	}
 
 	receiverAddress := common.HexToAddress(req.RecieverAddress)
-	if tokenAddress == (common.Address{}) {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid receiver address",
-		})
+	if !common.IsHexAddress(req.RecieverAddress) || receiverAddress == (common.Address{}) {
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidAddress, "recieverAddress", "Invalid receiver address"))
 		return
 	}
 
 	amount := new(big.Int)
 	amount, ok := amount.SetString(req.Amount, 10)
 	if !ok {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid amount",
-		})
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidAmount, "amount", "Invalid amount"))
 		return
 	}
 
 	p := getPlatformClient()
 	if p == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to initialize platform client",
-		})
+		respondError(ctx, errPlatformUnavailable)
 		return
 	}
 	bgCtx := context.Background()
 	_, receipt, err := p.EmergencyWithdraw(bgCtx, tokenAddress, receiverAddress, amount)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to withraw on emergency: " + err.Error(),
-		})
+		respondError(ctx, services.ChainError(err))
 		return
 	}
 
 	req.TransactionHash = receipt.TxHash.Hex()
 
 	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+		respondError(ctx, services.ErrDatabaseNotInitialized)
 		return
 	}
 
 	sdkClient := networks.GetBaseClient()
 	if sdkClient == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to initialize blockchain client",
-		})
+		respondError(ctx, errBlockchainUnavailable)
 		return
 	}
 
-	dbEmergencyWithrawal := models.EmergencyWithdraw{
+	dbEmergencyWithrawal := models.EmergencyWithdrawalDB{
 		TokenAddress:    req.TokenAddress,
-		RecieverAddress: req.RecieverAddress,
+		ReceiverAddress: req.RecieverAddress,
 		Amount:          req.Amount,
 		SenderAddress:   sdkClient.PaymentProcessorAddress.Hex(),
+		TransactionHash: req.TransactionHash,
+		BlockNumber:     receipt.BlockNumber.Uint64(),
+		Network:         networks.BaseSepoliaConfig.NetworkName,
 	}
 
 	var result []map[string]interface{}
 	if err := db.Supabase.DB.From("emergencyWithdrawal").Insert(dbEmergencyWithrawal).Execute(&result); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Emergency Withdrawal not saved in database: " + err.Error(),
-		})
+		respondError(ctx, apierror.Wrap(http.StatusInternalServerError, apierror.CodeInternal, "Emergency withdrawal not saved in database", err))
 		return
 	}
 
@@ -477,26 +402,20 @@ func SetEmergencyWithdrawalEnabled(ctx *gin.Context) {
 	var req models.WithdrawalStatus
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
 	p := getPlatformClient()
 	if p == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to initialize platform client",
-		})
+		respondError(ctx, errPlatformUnavailable)
 		return
 	}
 	bgCtx := context.Background()
 
 	_, receipt, err := p.SetEmergencyWithdrawalEnabled(bgCtx, *req.IsWithdrawalEnabled)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to enable emergency withdrawal: " + err.Error(),
-		})
+		respondError(ctx, services.ChainError(err))
 		return
 	}
 
@@ -515,17 +434,13 @@ func UpdateMerchantRegistry(ctx *gin.Context) {
 	var req models.MerchantRegistryUpdate
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
 	p := getPlatformClient()
 	if p == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to initialize platform client",
-		})
+		respondError(ctx, errPlatformUnavailable)
 		return
 	}
 	bgCtx := context.Background()
@@ -534,9 +449,7 @@ func UpdateMerchantRegistry(ctx *gin.Context) {
 
 	_, receipt, err := p.UpdateMerchantRegistry(bgCtx, newRegistryAddress)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update the merchantRegistry",
-		})
+		respondError(ctx, services.ChainError(err))
 		return
 	}
 
@@ -550,17 +463,13 @@ func SetTokenSupport(ctx *gin.Context) {
 	var req models.TokenSupport
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
 		return
 	}
 
 	p := getPlatformClient()
 	if p == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to initialize platform client",
-		})
+		respondError(ctx, errPlatformUnavailable)
 		return
 	}
 	bgCtx := context.Background()
@@ -572,9 +481,7 @@ func SetTokenSupport(ctx *gin.Context) {
 	case "enabled":
 		statusValue = big.NewInt(1)
 	default:
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid status value. Must be: enabled or disabled",
-		})
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidStatus, "status", "Invalid status value. Must be: enabled or disabled"))
 		return
 	}
 
@@ -582,12 +489,18 @@ func SetTokenSupport(ctx *gin.Context) {
 
 	_, receipt, err := p.SetTokenSupport(bgCtx, tokenAddress, statusValue)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to enable token support",
-		})
+		respondError(ctx, services.ChainError(err))
 		return
 	}
 
+	// Keep the token registry used by balance lookups in step with the contract
+	if sdkClient := networks.GetBaseClient(); sdkClient != nil {
+		err := services.SetTokenEnabled(bgCtx, sdkClient.EthClient, networks.BaseSepoliaConfig.NetworkName, tokenAddress, req.StatusValue == "enabled")
+		if err != nil {
+			log.Printf("tokens: could not record support for %s: %v", tokenAddress.Hex(), err)
+		}
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"message":         "Token Enabled Successfully",
 		"transactionHash": networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + receipt.TxHash.Hex(),
@@ -597,18 +510,23 @@ func SetTokenSupport(ctx *gin.Context) {
 func GetPlatformTokenBalance(ctx *gin.Context) {
 	var req models.PlatformBalanceCheck
 
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	if !common.IsHexAddress(req.PlatformWallet) {
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidAddress, "platformWallet", "platformWallet must be a hex address"))
+		return
+	}
+	if !common.IsHexAddress(req.TokenAddress) {
+		respondError(ctx, errInvalidTokenAddress)
 		return
 	}
 
 	p := getPlatformClient()
 	if p == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to initialize platform client",
-		})
+		respondError(ctx, errPlatformUnavailable)
 		return
 	}
 	bgCtx := context.Background()
@@ -618,9 +536,7 @@ func GetPlatformTokenBalance(ctx *gin.Context) {
 
 	balance, err := p.GetPlatformTokenBalance(bgCtx, platformWallet, tokenAddress)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get platform balance: " + err.Error(),
-		})
+		respondError(ctx, services.ChainError(err))
 		return
 	}
 
@@ -633,18 +549,19 @@ func GetPlatformTokenBalance(ctx *gin.Context) {
 func GetContractTokenBalance(ctx *gin.Context) {
 	var req models.ContractBalanceCheck
 
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	if !common.IsHexAddress(req.TokenAddress) {
+		respondError(ctx, errInvalidTokenAddress)
 		return
 	}
 
 	p := getPlatformClient()
 	if p == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to initialize platform client",
-		})
+		respondError(ctx, errPlatformUnavailable)
 		return
 	}
 	bgCtx := context.Background()
@@ -653,9 +570,7 @@ func GetContractTokenBalance(ctx *gin.Context) {
 
 	balance, err := p.GetContractTokenBalance(bgCtx, tokenAddress)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get contract balance: " + err.Error(),
-		})
+		respondError(ctx, services.ChainError(err))
 		return
 	}
 
@@ -665,44 +580,90 @@ func GetContractTokenBalance(ctx *gin.Context) {
 	})
 }
 
+// GetContractBalanceHistory returns snapshots of the payment processor
+// contract's balances on every network, bucketed by hour or day
+func GetContractBalanceHistory(ctx *gin.Context) {
+	query, err := parseBalanceHistoryQuery(ctx)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	for _, config := range networks.GetAllNetworkConfigs() {
+		query.Holders = append(query.Holders, config.PaymentProcessorAddress.Hex())
+	}
+
+	history, err := services.BalanceHistory(query)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+// GetFeeReport returns platform fee revenue from recorded settlements per
+// period, per merchant and per token
+func GetFeeReport(ctx *gin.Context) {
+	var query services.FeeReportQuery
+
+	var err error
+	if query.GroupBy, query.From, query.To, err = parseReportRange(ctx); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	if query.Network, query.TokenAddress, err = parseNetworkTokenFilter(ctx); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	query.MerchantId = ctx.Query("merchantId")
+	if query.MerchantId != "" && !utils.IsHexId(query.MerchantId) {
+		respondError(ctx, errInvalidMerchantId)
+		return
+	}
+
+	report, err := services.FeeReport(query)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
 func UpdateMerchantVerificationStatus(ctx *gin.Context) {
 	var req models.UpdateMerchantVerificationStatus
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	if !utils.IsHexId(req.MerchantId) {
+		respondError(ctx, errInvalidMerchantId)
 		return
 	}
 
 	if db.Supabase == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database client not initialized",
-		})
+		respondError(ctx, services.ErrDatabaseNotInitialized)
 		return
 	}
 
 	var existingMerchant []map[string]interface{}
 	err := db.Supabase.DB.From("merchants").Select("*").Eq("merchantId", req.MerchantId).Execute(&existingMerchant)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch merchant: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
 	if len(existingMerchant) == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"error": "Merchant not found",
-		})
+		respondError(ctx, services.ErrMerchantNotFound)
 		return
 	}
 
 	p := getPlatformClient()
 	if p == nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to initialize platform client",
-		})
+		respondError(ctx, errPlatformUnavailable)
 		return
 	}
 	bgCtx := context.Background()
@@ -722,17 +683,13 @@ func UpdateMerchantVerificationStatus(ctx *gin.Context) {
 	case "suspended":
 		verificationStatus = 3
 	default:
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid verification status. Must be: pending, verified, rejected, or suspended",
-		})
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidStatus, "status", "Invalid verification status. Must be: pending, verified, rejected, or suspended"))
 		return
 	}
 
 	_, receipt, err := p.UpdateMerchantVerificationStatus(bgCtx, merchantId, verificationStatus)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update merchant verification: " + err.Error(),
-		})
+		respondError(ctx, services.ChainError(err))
 		return
 	}
 
@@ -743,9 +700,7 @@ func UpdateMerchantVerificationStatus(ctx *gin.Context) {
 	var result []map[string]interface{}
 	err = db.Supabase.DB.From("merchants").Update(updates).Eq("merchantId", req.MerchantId).Execute(&result)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not update merchant status: " + err.Error(),
-		})
+		respondError(ctx, err)
 		return
 	}
 
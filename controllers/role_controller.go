@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ListRoles returns the roles held by the wallet in the walletAddress query
+// parameter, or the role catalog when none is given
+func ListRoles(ctx *gin.Context) {
+	walletAddress := ctx.Query("walletAddress")
+	if walletAddress == "" {
+		catalog := make(gin.H, len(models.Roles))
+		for _, role := range models.Roles {
+			catalog[role] = models.RolePermissions(role)
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"roles": catalog,
+		})
+		return
+	}
+
+	if !common.IsHexAddress(walletAddress) {
+		respondError(ctx, errInvalidWalletAddress)
+		return
+	}
+
+	wallet := common.HexToAddress(walletAddress)
+	roles, err := services.WalletRoles(wallet)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if roles == nil {
+		roles = []string{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"walletAddress": wallet.Hex(),
+		"roles":         roles,
+	})
+}
+
+// GrantRole gives a wallet a role. The signed in admin is recorded as the actor.
+func GrantRole(ctx *gin.Context) {
+	var req models.GrantRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	if !common.IsHexAddress(req.WalletAddress) {
+		respondError(ctx, errInvalidWalletAddress)
+		return
+	}
+
+	actor, _ := middleware.Wallet(ctx)
+
+	row, err := services.GrantRole(common.HexToAddress(req.WalletAddress), req.Role, actor.Hex(), req.Reason)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"role":    row,
+		"message": "Role granted",
+	})
+}
+
+// RevokeRole removes a role from a wallet
+func RevokeRole(ctx *gin.Context) {
+	walletAddress := ctx.Param("walletAddress")
+	if !common.IsHexAddress(walletAddress) {
+		respondError(ctx, errInvalidWalletAddress)
+		return
+	}
+
+	// The reason is optional, so an empty body is accepted
+	var req models.RevokeRoleRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			respondError(ctx, apierror.Binding(err))
+			return
+		}
+	}
+
+	actor, _ := middleware.Wallet(ctx)
+	wallet := common.HexToAddress(walletAddress)
+	role := ctx.Param("role")
+
+	if err := services.RevokeRole(wallet, role, actor.Hex(), req.Reason); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"walletAddress": wallet.Hex(),
+		"role":          role,
+		"message":       "Role revoked",
+	})
+}
+
+// ListRoleAudit returns role grants and revokes newest first, optionally for one walletAddress
+func ListRoleAudit(ctx *gin.Context) {
+	page, err := utils.ParsePagination(ctx)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	walletAddress := ctx.Query("walletAddress")
+	if walletAddress != "" {
+		if !common.IsHexAddress(walletAddress) {
+			respondError(ctx, errInvalidWalletAddress)
+			return
+		}
+		walletAddress = common.HexToAddress(walletAddress).Hex()
+	}
+
+	entries, err := services.ListRoleAudit(walletAddress, page.Limit+1, page.Cursor)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response := models.RoleAuditListResponse{
+		Entries: entries,
+	}
+
+	if len(entries) > page.Limit {
+		response.Entries = entries[:page.Limit]
+		response.HasMore = true
+		response.NextCursor = utils.EncodeCursor(response.Entries[page.Limit-1].Id)
+	}
+
+	if response.Entries == nil {
+		response.Entries = []models.RoleAuditEntry{}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/events"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	streamHeartbeatInterval = 15 * time.Second
+	wsWriteTimeout          = 10 * time.Second
+	wsPongTimeout           = 60 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Clients authenticating with a token subprotocol also offer this one,
+	// which is the one selected
+	Subprotocols: []string{middleware.StreamProtocol},
+	// CORS is open for the REST API, so the stream follows the same policy
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// StreamOrderEvents streams an order's status transitions as Server-Sent Events.
+// A new connection first receives the order's history; a reconnecting client sends
+// Last-Event-ID and only receives what it missed.
+func StreamOrderEvents(ctx *gin.Context) {
+	orderIdHex := ctx.Param("orderId")
+	if !strings.HasPrefix(orderIdHex, "0x") {
+		orderIdHex = "0x" + orderIdHex
+	}
+
+	if orderIdBytes, err := hex.DecodeString(strings.TrimPrefix(orderIdHex, "0x")); err != nil || len(orderIdBytes) != 32 {
+		respondError(ctx, errInvalidOrderId)
+		return
+	}
+
+	lastId, ok := parseLastEventId(ctx)
+	if !ok {
+		return
+	}
+
+	if _, err := services.GetOrder(orderIdHex); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	reqCtx := ctx.Request.Context()
+
+	// Subscribe before reading history so nothing written in between is lost
+	live, cancel, err := events.Default().Subscribe(reqCtx, events.OrderTopic(orderIdHex))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	defer cancel()
+
+	history, err := services.ListOrderEventsSince(orderIdHex, lastId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	fmt.Fprint(ctx.Writer, "retry: 3000\n\n")
+	for _, event := range history {
+		writeSSE(ctx, services.OrderStreamEvent(event))
+		lastId = event.Id
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-reqCtx.Done():
+			return
+		case event, ok := <-live:
+			if !ok {
+				return
+			}
+			if event.ID <= lastId {
+				continue
+			}
+			writeSSE(ctx, event)
+			lastId = event.ID
+			ctx.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(ctx.Writer, ": ping\n\n")
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+// StreamMerchantOrders upgrades to a WebSocket carrying status transitions for every
+// order of a merchant. Pass lastEventId to receive events missed while disconnected.
+func StreamMerchantOrders(ctx *gin.Context) {
+	merchantId := ctx.Param("merchantId")
+
+	lastId, ok := parseLastEventId(ctx)
+	if !ok {
+		return
+	}
+
+	if _, err := services.GetMerchant(merchantId); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// The upgrader has already written the error response
+		return
+	}
+	defer conn.Close()
+
+	streamCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	live, cancel, err := events.Default().Subscribe(streamCtx, events.MerchantTopic(merchantId))
+	if err != nil {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "could not subscribe"),
+			time.Now().Add(wsWriteTimeout))
+		return
+	}
+	defer cancel()
+
+	// The feed is one-way; reading only handles pongs and notices the client leaving
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+	go func() {
+		defer stop()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if lastId > 0 {
+		history, err := services.ListMerchantOrderEventsSince(merchantId, lastId)
+		if err != nil {
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "could not load missed events"),
+				time.Now().Add(wsWriteTimeout))
+			return
+		}
+
+		for _, event := range history {
+			if err := writeWebSocketEvent(conn, services.OrderStreamEvent(event)); err != nil {
+				return
+			}
+			lastId = event.Id
+		}
+	}
+
+	ping := time.NewTicker(streamHeartbeatInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-streamCtx.Done():
+			return
+		case event, ok := <-live:
+			if !ok {
+				return
+			}
+			if event.ID <= lastId {
+				continue
+			}
+			if err := writeWebSocketEvent(conn, event); err != nil {
+				return
+			}
+			lastId = event.ID
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseLastEventId reads the resume point from the Last-Event-ID header, falling
+// back to the lastEventId query parameter for clients that cannot set headers
+func parseLastEventId(ctx *gin.Context) (int64, bool) {
+	value := ctx.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = ctx.Query("lastEventId")
+	}
+	if value == "" {
+		return 0, true
+	}
+
+	lastId, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || lastId < 0 {
+		respondError(ctx, apierror.InvalidField(apierror.CodeInvalidRequest, "Last-Event-ID", "Last-Event-ID must be a non-negative integer"))
+		return 0, false
+	}
+
+	return lastId, true
+}
+
+func writeSSE(ctx *gin.Context, event events.Event) {
+	fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
+
+func writeWebSocketEvent(conn *websocket.Conn, event events.Event) error {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteJSON(event)
+}
@@ -0,0 +1,287 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWebhook registers a webhook endpoint for a merchant. The signing secret is
+// only returned here and when it is rotated.
+func CreateWebhook(ctx *gin.Context) {
+	merchantId := ctx.Param("merchantId")
+
+	var req models.CreateWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	if err := services.ValidateWebhookURL(req.URL); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	events, err := services.ValidateWebhookEvents(req.Events)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if _, err := services.GetMerchant(merchantId); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	endpoint, err := services.CreateWebhookEndpoint(merchantId, req.URL, events)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, toWebhookEndpoint(*endpoint, true))
+}
+
+// ListWebhooks returns a merchant's webhook endpoints without their secrets
+func ListWebhooks(ctx *gin.Context) {
+	endpoints, err := services.ListWebhookEndpoints(ctx.Param("merchantId"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response := make([]models.WebhookEndpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		response = append(response, toWebhookEndpoint(endpoint, false))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"webhooks":   response,
+		"eventTypes": models.WebhookEventTypes,
+	})
+}
+
+// UpdateWebhook changes an endpoint's URL, subscribed events or active flag
+func UpdateWebhook(ctx *gin.Context) {
+	webhookId, ok := parseWebhookId(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, apierror.Binding(err))
+		return
+	}
+
+	updates := map[string]interface{}{}
+
+	if req.URL != nil {
+		if err := services.ValidateWebhookURL(*req.URL); err != nil {
+			respondError(ctx, err)
+			return
+		}
+		updates["url"] = *req.URL
+	}
+
+	if req.Events != nil {
+		events, err := services.ValidateWebhookEvents(req.Events)
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
+		updates["events"] = events
+	}
+
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+
+	if len(updates) == 0 {
+		respondError(ctx, apierror.BadRequest(apierror.CodeInvalidRequest, "Nothing to update: provide url, events or active"))
+		return
+	}
+
+	endpoint, err := services.UpdateWebhookEndpoint(ctx.Param("merchantId"), webhookId, updates)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, toWebhookEndpoint(*endpoint, false))
+}
+
+// DeleteWebhook removes an endpoint and its delivery log
+func DeleteWebhook(ctx *gin.Context) {
+	webhookId, ok := parseWebhookId(ctx)
+	if !ok {
+		return
+	}
+
+	if err := services.DeleteWebhookEndpoint(ctx.Param("merchantId"), webhookId); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"webhookId": webhookId,
+		"message":   "Webhook deleted successfully",
+	})
+}
+
+// RotateWebhookSecret issues a new signing secret. Deliveries carry a signature for
+// both secrets until the previous one expires.
+func RotateWebhookSecret(ctx *gin.Context) {
+	webhookId, ok := parseWebhookId(ctx)
+	if !ok {
+		return
+	}
+
+	endpoint, err := services.RotateWebhookSecret(ctx.Param("merchantId"), webhookId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"webhook":                 toWebhookEndpoint(*endpoint, true),
+		"previousSecretExpiresAt": endpoint.PreviousSecretExpiresAt,
+		"message":                 "Secret rotated; the previous secret stays valid until previousSecretExpiresAt",
+	})
+}
+
+// ListWebhookDeliveries returns an endpoint's delivery log, newest first
+func ListWebhookDeliveries(ctx *gin.Context) {
+	webhookId, ok := parseWebhookId(ctx)
+	if !ok {
+		return
+	}
+
+	page, err := utils.ParsePagination(ctx)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if _, err := services.GetWebhookEndpoint(ctx.Param("merchantId"), webhookId); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	deliveries, err := services.ListWebhookDeliveries(webhookId, page.Limit+1, page.Cursor)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	response := models.WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+	}
+
+	if len(deliveries) > page.Limit {
+		response.Deliveries = deliveries[:page.Limit]
+		response.HasMore = true
+		response.NextCursor = utils.EncodeCursor(response.Deliveries[page.Limit-1].Id)
+	}
+
+	if response.Deliveries == nil {
+		response.Deliveries = []models.WebhookDeliveryDB{}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetWebhookDelivery returns a delivery with every attempt made for it
+func GetWebhookDelivery(ctx *gin.Context) {
+	webhookId, deliveryId, ok := parseWebhookDeliveryIds(ctx)
+	if !ok {
+		return
+	}
+
+	delivery, err := services.GetWebhookDelivery(ctx.Param("merchantId"), webhookId, deliveryId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	attempts, err := services.ListWebhookDeliveryAttempts(deliveryId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if attempts == nil {
+		attempts = []models.WebhookDeliveryAttempt{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"delivery": delivery,
+		"attempts": attempts,
+	})
+}
+
+// RedeliverWebhook sends a delivery again immediately, with the same event id and body
+func RedeliverWebhook(ctx *gin.Context) {
+	webhookId, deliveryId, ok := parseWebhookDeliveryIds(ctx)
+	if !ok {
+		return
+	}
+
+	delivery, err := services.RedeliverWebhook(ctx.Param("merchantId"), webhookId, deliveryId)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"delivery": delivery,
+		"message":  "Redelivery attempted",
+	})
+}
+
+func toWebhookEndpoint(endpoint models.WebhookEndpointDB, withSecret bool) models.WebhookEndpoint {
+	response := models.WebhookEndpoint{
+		Id:         endpoint.Id,
+		MerchantId: endpoint.MerchantId,
+		URL:        endpoint.URL,
+		Events:     endpoint.Events,
+		Active:     endpoint.Active,
+		CreatedAt:  endpoint.CreatedAt,
+		UpdatedAt:  endpoint.UpdatedAt,
+	}
+
+	if withSecret {
+		response.Secret = endpoint.Secret
+	}
+
+	return response
+}
+
+func parseWebhookId(ctx *gin.Context) (int64, bool) {
+	webhookId, err := strconv.ParseInt(ctx.Param("webhookId"), 10, 64)
+	if err != nil || webhookId <= 0 {
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "webhookId", "webhookId must be a positive integer"))
+		return 0, false
+	}
+	return webhookId, true
+}
+
+func parseWebhookDeliveryIds(ctx *gin.Context) (int64, int64, bool) {
+	webhookId, ok := parseWebhookId(ctx)
+	if !ok {
+		return 0, 0, false
+	}
+
+	deliveryId, err := strconv.ParseInt(ctx.Param("deliveryId"), 10, 64)
+	if err != nil || deliveryId <= 0 {
+		respondError(ctx, apierror.InvalidField(apierror.CodeValidationFailed, "deliveryId", "deliveryId must be a positive integer"))
+		return 0, 0, false
+	}
+
+	return webhookId, deliveryId, true
+}
@@ -0,0 +1,18 @@
+-- Order transition history. Every status change made through the order state
+-- machine is appended here instead of overwriting orders."transactionHash".
+
+alter table orders add column if not exists id bigint generated by default as identity;
+alter table orders add column if not exists "createdAt" timestamptz not null default now();
+
+create table if not exists order_events (
+    id                bigint generated by default as identity primary key,
+    "orderId"         text        not null,
+    "fromStatus"      text        not null default '',
+    "toStatus"        text        not null,
+    "transactionHash" text        not null default '',
+    "blockNumber"     bigint,
+    actor             text        not null default '',
+    "createdAt"       timestamptz not null default now()
+);
+
+create index if not exists order_events_order_id_idx on order_events ("orderId", id);
@@ -0,0 +1,8 @@
+-- Columns and indexes backing GET /api/orders filters and cursor pagination.
+
+alter table orders add column if not exists network text not null default 'base-sepolia';
+
+create index if not exists orders_merchant_id_idx on orders ("merchantId", id);
+create index if not exists orders_payer_address_idx on orders (lower("payerAddress"), id);
+create index if not exists orders_status_idx on orders (status, id);
+create index if not exists orders_created_at_idx on orders ("createdAt");
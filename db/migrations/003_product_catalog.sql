@@ -0,0 +1,9 @@
+-- Product ids, timestamps and soft delete for the catalog API.
+
+alter table products add column if not exists id bigint generated by default as identity;
+alter table products add column if not exists "createdAt" timestamptz not null default now();
+alter table products add column if not exists "updatedAt" timestamptz;
+alter table products add column if not exists "deletedAt" timestamptz;
+
+create unique index if not exists products_id_idx on products (id);
+create index if not exists products_merchant_id_idx on products ("merchantId", id) where "deletedAt" is null;
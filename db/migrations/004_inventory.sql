@@ -0,0 +1,106 @@
+-- Stock tracking and checkout reservations. A null stock means the product is
+-- not inventory tracked. All stock changes go through the functions below so
+-- concurrent checkouts cannot oversell: each one is a single conditional UPDATE.
+
+alter table products add column if not exists stock bigint check (stock is null or stock >= 0);
+
+create table if not exists inventory_reservations (
+    id            bigint generated by default as identity primary key,
+    reference     text        not null,
+    "productId"   bigint      not null,
+    quantity      bigint      not null check (quantity > 0),
+    status        text        not null default 'reserved',
+    "orderId"     text,
+    "expiresAt"   timestamptz not null,
+    "createdAt"   timestamptz not null default now(),
+    "updatedAt"   timestamptz
+);
+
+create index if not exists inventory_reservations_reference_idx on inventory_reservations (reference);
+create index if not exists inventory_reservations_order_id_idx on inventory_reservations ("orderId");
+create index if not exists inventory_reservations_expiry_idx on inventory_reservations ("expiresAt") where status = 'reserved';
+
+-- Takes quantity units out of stock and records a reservation that expires after p_ttl_seconds
+create or replace function reserve_stock(p_reference text, p_product_id bigint, p_quantity bigint, p_ttl_seconds integer)
+returns setof inventory_reservations
+language plpgsql as $$
+begin
+    update products
+       set stock = stock - p_quantity
+     where id = p_product_id
+       and "deletedAt" is null
+       and (stock is null or stock >= p_quantity);
+
+    if not found then
+        raise exception 'insufficient_stock';
+    end if;
+
+    return query
+    insert into inventory_reservations (reference, "productId", quantity, "expiresAt")
+    values (p_reference, p_product_id, p_quantity, now() + make_interval(secs => p_ttl_seconds))
+    returning *;
+end;
+$$;
+
+-- Attaches the reservations of a checkout to the order created on-chain
+create or replace function bind_reservations(p_reference text, p_order_id text)
+returns setof inventory_reservations
+language sql as $$
+    update inventory_reservations
+       set "orderId" = p_order_id, "updatedAt" = now()
+     where reference = p_reference and status = 'reserved' and "orderId" is null
+    returning *;
+$$;
+
+-- Makes the reservations of a paid order permanent
+create or replace function commit_reservations(p_order_id text)
+returns setof inventory_reservations
+language sql as $$
+    update inventory_reservations
+       set status = 'committed', "updatedAt" = now()
+     where "orderId" = p_order_id and status = 'reserved'
+    returning *;
+$$;
+
+-- Returns the reserved units of an order to stock
+create or replace function release_reservations(p_order_id text)
+returns setof inventory_reservations
+language plpgsql as $$
+begin
+    return query
+    with released as (
+        update inventory_reservations
+           set status = 'released', "updatedAt" = now()
+         where "orderId" = p_order_id and status = 'reserved'
+        returning *
+    ), restocked as (
+        update products p
+           set stock = p.stock + r.total
+          from (select "productId", sum(quantity) as total from released group by "productId") r
+         where p.id = r."productId" and p.stock is not null
+    )
+    select * from released;
+end;
+$$;
+
+-- Releases checkout reservations that were never turned into an order before their TTL ran out.
+-- Reservations bound to an order are held until the order is paid or cancelled.
+create or replace function release_expired_reservations()
+returns setof inventory_reservations
+language plpgsql as $$
+begin
+    return query
+    with released as (
+        update inventory_reservations
+           set status = 'released', "updatedAt" = now()
+         where status = 'reserved' and "orderId" is null and "expiresAt" < now()
+        returning *
+    ), restocked as (
+        update products p
+           set stock = p.stock + r.total
+          from (select "productId", sum(quantity) as total from released group by "productId") r
+         where p.id = r."productId" and p.stock is not null
+    )
+    select * from released;
+end;
+$$;
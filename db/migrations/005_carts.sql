@@ -0,0 +1,39 @@
+-- Single-merchant carts and the priced line items produced at checkout.
+
+create table if not exists carts (
+    id              text primary key,
+    "merchantId"    text        not null default '',
+    "buyerAddress"  text        not null default '',
+    status          text        not null default 'open',
+    "reservationId" text,
+    "orderId"       text,
+    "createdAt"     timestamptz not null default now(),
+    "updatedAt"     timestamptz
+);
+
+create table if not exists cart_items (
+    id          bigint generated by default as identity primary key,
+    "cartId"    text   not null references carts (id) on delete cascade,
+    "productId" bigint not null,
+    quantity    bigint not null check (quantity > 0),
+    unique ("cartId", "productId")
+);
+
+-- Rows are written at checkout with a null orderId and attached once the
+-- order is confirmed on-chain.
+create table if not exists order_items (
+    id                bigint generated by default as identity primary key,
+    "orderId"         text,
+    "cartId"          text             not null,
+    "productId"       bigint           not null,
+    name              text             not null,
+    "unitPrice"       double precision not null,
+    quantity          bigint           not null,
+    "lineTotal"       text             not null,
+    "lineTotalAmount" text             not null,
+    "tokenAddress"    text             not null,
+    "createdAt"       timestamptz      not null default now()
+);
+
+create index if not exists order_items_order_id_idx on order_items ("orderId");
+create index if not exists order_items_cart_id_idx on order_items ("cartId");
@@ -0,0 +1,24 @@
+-- Hosted checkout sessions. A session carries a payer from token approval
+-- through createOrder and payOrder for one merchant.
+
+create table if not exists checkout_sessions (
+    id              text primary key,
+    "merchantId"    text        not null,
+    "cartId"        text,
+    "tokenAddress"  text        not null,
+    amount          text        not null,
+    network         text        not null default 'base-sepolia',
+    status          text        not null default 'open',
+    "metadataURI"   text,
+    "reservationId" text,
+    "orderId"       text,
+    "payerAddress"  text,
+    "successUrl"    text,
+    "cancelUrl"     text,
+    "expiresAt"     timestamptz not null,
+    "createdAt"     timestamptz not null default now(),
+    "updatedAt"     timestamptz
+);
+
+create index if not exists checkout_sessions_merchant_id_idx on checkout_sessions ("merchantId");
+create index if not exists checkout_sessions_order_id_idx on checkout_sessions ("orderId");
@@ -0,0 +1,51 @@
+-- Merchant webhook endpoints and the deliveries queued for them.
+
+create table if not exists webhook_endpoints (
+    id                          bigint generated by default as identity primary key,
+    "merchantId"                text        not null,
+    url                         text        not null,
+    events                      text[]      not null default '{}',
+    secret                      text        not null,
+    "previousSecret"            text,
+    "previousSecretExpiresAt"   timestamptz,
+    active                      boolean     not null default true,
+    "createdAt"                 timestamptz not null default now(),
+    "updatedAt"                 timestamptz
+);
+
+create index if not exists webhook_endpoints_merchant_id_idx on webhook_endpoints ("merchantId");
+
+-- One row per event per endpoint. The payload is stored so retries and
+-- redeliveries send the same event id and body.
+create table if not exists webhook_deliveries (
+    id               bigint generated by default as identity primary key,
+    "endpointId"     bigint      not null references webhook_endpoints (id) on delete cascade,
+    "merchantId"     text        not null,
+    "eventId"        text        not null,
+    "eventType"      text        not null,
+    "orderId"        text,
+    payload          jsonb       not null,
+    status           text        not null default 'pending',
+    attempts         integer     not null default 0,
+    "nextAttemptAt"  timestamptz not null default now(),
+    "lastStatusCode" integer,
+    "lastError"      text,
+    "deliveredAt"    timestamptz,
+    "createdAt"      timestamptz not null default now()
+);
+
+create index if not exists webhook_deliveries_due_idx on webhook_deliveries (status, "nextAttemptAt");
+create index if not exists webhook_deliveries_endpoint_id_idx on webhook_deliveries ("endpointId", id desc);
+
+-- Every HTTP attempt, for the delivery log
+create table if not exists webhook_delivery_attempts (
+    id             bigint generated by default as identity primary key,
+    "deliveryId"   bigint      not null references webhook_deliveries (id) on delete cascade,
+    attempt        integer     not null,
+    "statusCode"   integer,
+    error          text,
+    "durationMs"   bigint      not null,
+    "createdAt"    timestamptz not null default now()
+);
+
+create index if not exists webhook_delivery_attempts_delivery_id_idx on webhook_delivery_attempts ("deliveryId");
@@ -0,0 +1,11 @@
+-- Merchant order feeds resume from order_events ids, so events carry the
+-- merchant directly.
+
+alter table order_events add column if not exists "merchantId" text not null default '';
+
+update order_events e
+set "merchantId" = o."merchantId"
+from orders o
+where o."orderId" = e."orderId" and e."merchantId" = '';
+
+create index if not exists order_events_merchant_id_idx on order_events ("merchantId", id);
@@ -0,0 +1,21 @@
+-- Sign-In With Ethereum. Nonces are single use; a merchant's owner is the wallet
+-- allowed to manage it through the API.
+
+create table if not exists auth_nonces (
+    nonce         text primary key,
+    "expiresAt"   timestamptz not null,
+    "usedAt"      timestamptz,
+    "usedBy"      text,
+    "createdAt"   timestamptz not null default now()
+);
+
+create index if not exists auth_nonces_expires_at_idx on auth_nonces ("expiresAt");
+
+alter table merchants add column if not exists "ownerAddress" text;
+
+-- Existing merchants are owned by their payout wallet until reassigned
+update merchants
+set "ownerAddress" = "payoutWalletAddress"
+where "ownerAddress" is null;
+
+create index if not exists merchants_owner_address_idx on merchants (lower("ownerAddress"));
@@ -0,0 +1,23 @@
+-- Roles held by wallets and an append-only log of every grant and revoke.
+-- Wallets listed in PLATFORM_ADMIN_WALLETS are platform admins without a row here.
+
+create table if not exists user_roles (
+    id                bigint generated by default as identity primary key,
+    "walletAddress"   text        not null,
+    role              text        not null,
+    "grantedBy"       text        not null,
+    "createdAt"       timestamptz not null default now(),
+    unique ("walletAddress", role)
+);
+
+create table if not exists role_audit_log (
+    id                bigint generated by default as identity primary key,
+    action            text        not null,
+    "walletAddress"   text        not null,
+    role              text        not null,
+    actor             text        not null,
+    reason            text,
+    "createdAt"       timestamptz not null default now()
+);
+
+create index if not exists role_audit_log_wallet_address_idx on role_audit_log ("walletAddress", id);
@@ -0,0 +1,20 @@
+-- Merchant API keys for server-to-server calls. Only the sha256 of a key is
+-- stored; the key itself is shown once when it is created.
+
+create table if not exists api_keys (
+    id              bigint generated by default as identity primary key,
+    "merchantId"    text        not null,
+    name            text        not null,
+    prefix          text        not null,
+    "keyHash"       text        not null unique,
+    scopes          text[]      not null default '{}',
+    "allowedIps"    text[]      not null default '{}',
+    "expiresAt"     timestamptz,
+    "revokedAt"     timestamptz,
+    "lastUsedAt"    timestamptz,
+    "lastUsedIp"    text,
+    "createdBy"     text        not null,
+    "createdAt"     timestamptz not null default now()
+);
+
+create index if not exists api_keys_merchant_id_idx on api_keys ("merchantId");
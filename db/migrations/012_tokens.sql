@@ -0,0 +1,21 @@
+-- Tokens the platform accepts on each network. Rows are written when a token's
+-- support is changed on-chain; balance lookups read every enabled token.
+
+create table if not exists tokens (
+    network       text        not null,
+    address       text        not null,
+    symbol        text        not null,
+    decimals      smallint    not null,
+    enabled       boolean     not null default true,
+    "createdAt"   timestamptz not null default now(),
+    "updatedAt"   timestamptz,
+    primary key (network, address)
+);
+
+insert into tokens (network, address, symbol, decimals) values
+    ('base-sepolia', '0x036CbD53842c5426634e7929541eC2318f3dCF7e', 'USDC', 6),
+    ('polygon-amoy', '0x41E94Eb019C0762f9Bfcf9Fb1E58725BfB0e7582', 'USDC', 6)
+on conflict (network, address) do nothing;
+
+-- Pending settlement totals read paid orders per merchant
+create index if not exists orders_merchant_status_idx on orders ("merchantId", status);
@@ -0,0 +1,32 @@
+-- Point in time token balances of merchant payout wallets and the payment
+-- processor contract, written by the snapshot job. A holder's balance is stored
+-- once per block, so snapshots taken at the same block merge into one row.
+-- This replaces the unordered rows GetMerchantBalance used to insert into
+-- "tokenBalance".
+
+create table if not exists balance_snapshots (
+    network          text        not null,
+    "tokenAddress"   text        not null,
+    "holderAddress"  text        not null,
+    "blockNumber"    bigint      not null,
+    balance          text        not null,
+    "capturedAt"     timestamptz not null default now(),
+    primary key (network, "tokenAddress", "holderAddress", "blockNumber")
+);
+
+create index if not exists balance_snapshots_holder_captured_idx on balance_snapshots ("holderAddress", "capturedAt");
+
+-- Closing balance of each network/token series per hour or day bucket: the
+-- snapshot with the highest block in the bucket
+create or replace function balance_history(p_holders text[], p_from timestamptz, p_to timestamptz, p_bucket text, p_network text default null, p_token text default null)
+returns table (network text, "tokenAddress" text, "holderAddress" text, bucket timestamptz, balance text, "blockNumber" bigint)
+language sql stable as $$
+    select distinct on (s.network, s."tokenAddress", s."holderAddress", date_trunc(p_bucket, s."capturedAt"))
+           s.network, s."tokenAddress", s."holderAddress", date_trunc(p_bucket, s."capturedAt"), s.balance, s."blockNumber"
+      from balance_snapshots s
+     where s."holderAddress" = any(p_holders)
+       and s."capturedAt" between p_from and p_to
+       and (p_network is null or s.network = p_network)
+       and (p_token is null or lower(s."tokenAddress") = lower(p_token))
+     order by s.network, s."tokenAddress", s."holderAddress", date_trunc(p_bucket, s."capturedAt"), s."blockNumber" desc;
+$$;
@@ -0,0 +1,136 @@
+-- Daily per merchant order rollups behind the analytics endpoint. Each status
+-- change counts on the day it happened, taken from order_events; orders older
+-- than the event history fall back to their creation day. Volumes are token
+-- base units. refresh_merchant_rollups rebuilds the days from p_since onwards,
+-- and the rollup job calls it for the last two days.
+
+create table if not exists merchant_order_rollups (
+    "merchantId"       text           not null,
+    day                date           not null,
+    network            text           not null,
+    "tokenAddress"     text           not null,
+    "ordersCreated"    bigint         not null default 0,
+    "ordersPaid"       bigint         not null default 0,
+    "ordersSettled"    bigint         not null default 0,
+    "ordersRefunded"   bigint         not null default 0,
+    "ordersCancelled"  bigint         not null default 0,
+    "grossVolume"      numeric(78, 0) not null default 0,
+    "refundedVolume"   numeric(78, 0) not null default 0,
+    "updatedAt"        timestamptz    not null default now(),
+    primary key ("merchantId", day, network, "tokenAddress")
+);
+
+create table if not exists product_sales_rollups (
+    "merchantId"    text           not null,
+    day             date           not null,
+    "productId"     bigint         not null,
+    network         text           not null,
+    "tokenAddress"  text           not null,
+    name            text           not null,
+    quantity        bigint         not null default 0,
+    "salesVolume"   numeric(78, 0) not null default 0,
+    "orderCount"    bigint         not null default 0,
+    primary key ("merchantId", day, "productId", network, "tokenAddress")
+);
+
+create index if not exists order_events_created_at_idx on order_events ("createdAt");
+
+-- When each order created or changed since p_since reached each status
+create or replace function order_status_times(p_since timestamptz)
+returns table ("orderId" text, "merchantId" text, network text, "tokenAddress" text, amount numeric, status text, "reachedAt" timestamptz)
+language sql stable as $$
+    with changed as (
+        select o."orderId" from orders o where o."createdAt" >= p_since
+        union
+        select e."orderId" from order_events e where e."createdAt" >= p_since
+    )
+    select o."orderId", o."merchantId", o.network, lower(o."tokenAddress"),
+           case when o.amount ~ '^[0-9]+$' then o.amount::numeric else 0 end,
+           s.status,
+           coalesce(
+               (select min(e."createdAt") from order_events e where e."orderId" = o."orderId" and e."toStatus" = s.status),
+               case when s.status = 'created' or o.status = s.status
+                      or (s.status = 'paid' and o.status in ('settled', 'refunded'))
+                    then o."createdAt" end
+           )
+      from orders o
+      join changed c on c."orderId" = o."orderId"
+     cross join (values ('created'), ('paid'), ('settled'), ('refunded'), ('cancelled')) as s(status);
+$$;
+
+create or replace function refresh_merchant_rollups(p_since timestamptz)
+returns void
+language plpgsql as $$
+declare
+    v_from date := p_since::date;
+begin
+    delete from merchant_order_rollups where day >= v_from;
+    delete from product_sales_rollups where day >= v_from;
+
+    insert into merchant_order_rollups ("merchantId", day, network, "tokenAddress",
+           "ordersCreated", "ordersPaid", "ordersSettled", "ordersRefunded", "ordersCancelled",
+           "grossVolume", "refundedVolume")
+    select t."merchantId", t."reachedAt"::date, t.network, t."tokenAddress",
+           count(*) filter (where t.status = 'created'),
+           count(*) filter (where t.status = 'paid'),
+           count(*) filter (where t.status = 'settled'),
+           count(*) filter (where t.status = 'refunded'),
+           count(*) filter (where t.status = 'cancelled'),
+           coalesce(sum(t.amount) filter (where t.status = 'paid'), 0),
+           coalesce(sum(t.amount) filter (where t.status = 'refunded'), 0)
+      from order_status_times(v_from) t
+     where t."reachedAt" >= v_from
+     group by 1, 2, 3, 4;
+
+    insert into product_sales_rollups ("merchantId", day, "productId", network, "tokenAddress",
+           name, quantity, "salesVolume", "orderCount")
+    select t."merchantId", t."reachedAt"::date, i."productId", t.network, t."tokenAddress",
+           max(i.name), sum(i.quantity),
+           sum(case when i."lineTotalAmount" ~ '^[0-9]+$' then i."lineTotalAmount"::numeric else 0 end),
+           count(distinct t."orderId")
+      from order_status_times(v_from) t
+      join order_items i on i."orderId" = t."orderId"
+     where t.status = 'paid' and t."reachedAt" >= v_from
+     group by 1, 2, 3, 4, 5;
+end;
+$$;
+
+-- Sums the rollups of a merchant per day, week or month and token
+create or replace function merchant_analytics(p_merchant_id text, p_from date, p_to date, p_group text, p_network text default null, p_token text default null)
+returns table (period date, network text, "tokenAddress" text,
+               "ordersCreated" bigint, "ordersPaid" bigint, "ordersSettled" bigint,
+               "ordersRefunded" bigint, "ordersCancelled" bigint,
+               "grossVolume" text, "refundedVolume" text)
+language sql stable as $$
+    select date_trunc(p_group, r.day)::date, r.network, r."tokenAddress",
+           sum(r."ordersCreated")::bigint, sum(r."ordersPaid")::bigint, sum(r."ordersSettled")::bigint,
+           sum(r."ordersRefunded")::bigint, sum(r."ordersCancelled")::bigint,
+           sum(r."grossVolume")::text, sum(r."refundedVolume")::text
+      from merchant_order_rollups r
+     where r."merchantId" = p_merchant_id
+       and r.day between p_from and p_to
+       and (p_network is null or r.network = p_network)
+       and (p_token is null or r."tokenAddress" = lower(p_token))
+     group by 1, 2, 3
+     order by 1, 2, 3;
+$$;
+
+-- Best selling products of a merchant by units sold
+create or replace function merchant_top_products(p_merchant_id text, p_from date, p_to date, p_limit integer, p_network text default null, p_token text default null)
+returns table ("productId" bigint, name text, network text, "tokenAddress" text,
+               quantity bigint, "salesVolume" text, "orderCount" bigint)
+language sql stable as $$
+    select r."productId", max(r.name), r.network, r."tokenAddress",
+           sum(r.quantity)::bigint, sum(r."salesVolume")::text, sum(r."orderCount")::bigint
+      from product_sales_rollups r
+     where r."merchantId" = p_merchant_id
+       and r.day between p_from and p_to
+       and (p_network is null or r.network = p_network)
+       and (p_token is null or r."tokenAddress" = lower(p_token))
+     group by r."productId", r.network, r."tokenAddress"
+     order by sum(r.quantity) desc, sum(r."salesVolume") desc
+     limit p_limit;
+$$;
+
+-- Backfill the full history once
+select refresh_merchant_rollups('epoch');
@@ -0,0 +1,37 @@
+-- One row per settled order, read from the settleOrder receipt: the order
+-- amount, the platform fee kept from it and the merchant's net payout. Amounts
+-- are token base units, like orders.amount.
+
+create table if not exists settlements (
+    "orderId"          text        primary key,
+    "merchantId"       text        not null,
+    network            text        not null,
+    "tokenAddress"     text        not null,
+    "grossAmount"      text        not null,
+    "platformFee"      text        not null,
+    "merchantNet"      text        not null,
+    "payoutAddress"    text        not null,
+    "feeRecipient"     text        not null default '',
+    "transactionHash"  text        not null,
+    "blockNumber"      bigint      not null,
+    "settledAt"        timestamptz not null default now()
+);
+
+create index if not exists settlements_settled_at_idx on settlements ("settledAt");
+create index if not exists settlements_merchant_id_idx on settlements ("merchantId", "settledAt");
+
+-- Sums settlements per day, week or month, merchant and token
+create or replace function fee_report(p_from date, p_to date, p_group text, p_merchant_id text default null, p_network text default null, p_token text default null)
+returns table (period date, "merchantId" text, network text, "tokenAddress" text,
+               settlements bigint, "grossAmount" text, "platformFee" text, "merchantNet" text)
+language sql stable as $$
+    select date_trunc(p_group, s."settledAt")::date, s."merchantId", s.network, lower(s."tokenAddress"),
+           count(*), sum(s."grossAmount"::numeric)::text, sum(s."platformFee"::numeric)::text, sum(s."merchantNet"::numeric)::text
+      from settlements s
+     where s."settledAt" >= p_from and s."settledAt" < p_to + 1
+       and (p_merchant_id is null or s."merchantId" = p_merchant_id)
+       and (p_network is null or s.network = p_network)
+       and (p_token is null or lower(s."tokenAddress") = lower(p_token))
+     group by 1, 2, 3, 4
+     order by 1, 2, 3, 4;
+$$;
@@ -0,0 +1,92 @@
+-- Order, settlement, refund and emergency withdrawal exports. export_records
+-- reads one kind of record in a uniform shape, keyset paginated on the source
+-- row id; export_jobs queues the exports too large to serve in one request.
+
+-- Settlements and withdrawals need a row id to page on. Withdrawals recorded
+-- before this migration keep an empty network and block and are dated to it.
+alter table settlements add column if not exists id bigint generated by default as identity;
+create unique index if not exists settlements_id_idx on settlements (id);
+
+alter table "emergencyWithdrawal" add column if not exists id bigint generated by default as identity;
+alter table "emergencyWithdrawal" add column if not exists network text not null default '';
+alter table "emergencyWithdrawal" add column if not exists "blockNumber" bigint not null default 0;
+alter table "emergencyWithdrawal" add column if not exists "createdAt" timestamptz not null default now();
+create unique index if not exists emergency_withdrawal_id_idx on "emergencyWithdrawal" (id);
+
+create index if not exists orders_created_at_idx on orders ("createdAt");
+create index if not exists order_events_to_status_idx on order_events ("toStatus", "createdAt");
+
+create table if not exists export_jobs (
+    id             bigint generated by default as identity primary key,
+    "merchantId"   text        not null default '',
+    kind           text        not null,
+    format         text        not null,
+    "from"         timestamptz not null,
+    "to"           timestamptz not null,
+    status         text        not null default 'queued',
+    "rowCount"     bigint      not null default 0,
+    "fileName"     text        not null default '',
+    "fileSize"     bigint      not null default 0,
+    error          text        not null default '',
+    "createdBy"    text        not null,
+    "createdAt"    timestamptz not null default now(),
+    "startedAt"    timestamptz,
+    "completedAt"  timestamptz,
+    "expiresAt"    timestamptz
+);
+
+create index if not exists export_jobs_status_idx on export_jobs (status, id);
+create index if not exists export_jobs_merchant_id_idx on export_jobs ("merchantId", id desc);
+
+-- Records of one kind in the range with ids above p_after, oldest id first.
+-- Platform exports pass no merchant; withdrawals belong to no merchant.
+create or replace function export_records(p_kind text, p_from timestamptz, p_to timestamptz, p_after bigint, p_limit integer, p_merchant_id text default null)
+returns table (id bigint, kind text, reference text, "merchantId" text, network text, "tokenAddress" text,
+               amount text, "platformFee" text, "merchantNet" text, status text, counterparty text,
+               "transactionHash" text, "blockNumber" bigint, "recordedAt" timestamptz)
+language sql stable as $$
+    select * from (
+        select o.id, 'orders', o."orderId", o."merchantId", o.network, o."tokenAddress",
+               o.amount, ''::text, ''::text, o.status, o."payerAddress", o."transactionHash",
+               coalesce((select e."blockNumber" from order_events e
+                          where e."orderId" = o."orderId" and e."transactionHash" = o."transactionHash"
+                          order by e.id limit 1), 0),
+               o."createdAt"
+          from orders o
+         where p_kind = 'orders'
+           and o."createdAt" >= p_from and o."createdAt" < p_to
+           and (p_merchant_id is null or o."merchantId" = p_merchant_id)
+           and o.id > p_after
+        union all
+        select s.id, 'settlements', s."orderId", s."merchantId", s.network, s."tokenAddress",
+               s."grossAmount", s."platformFee", s."merchantNet", 'settled', s."payoutAddress", s."transactionHash",
+               s."blockNumber", s."settledAt"
+          from settlements s
+         where p_kind = 'settlements'
+           and s."settledAt" >= p_from and s."settledAt" < p_to
+           and (p_merchant_id is null or s."merchantId" = p_merchant_id)
+           and s.id > p_after
+        union all
+        select e.id, 'refunds', o."orderId", o."merchantId", o.network, o."tokenAddress",
+               o.amount, ''::text, ''::text, 'refunded', o."payerAddress", e."transactionHash",
+               coalesce(e."blockNumber", 0), e."createdAt"
+          from order_events e
+          join orders o on o."orderId" = e."orderId"
+         where p_kind = 'refunds'
+           and e."toStatus" = 'refunded'
+           and e."createdAt" >= p_from and e."createdAt" < p_to
+           and (p_merchant_id is null or o."merchantId" = p_merchant_id)
+           and e.id > p_after
+        union all
+        select w.id, 'withdrawals', w."transactionHash", ''::text, coalesce(nullif(w.network, ''), 'base-sepolia'), w."tokenAddress",
+               w.amount, ''::text, ''::text, 'withdrawn', w."receiverAddress", w."transactionHash",
+               w."blockNumber", w."createdAt"
+          from "emergencyWithdrawal" w
+         where p_kind = 'withdrawals'
+           and p_merchant_id is null
+           and w."createdAt" >= p_from and w."createdAt" < p_to
+           and w.id > p_after
+    ) r
+    order by 1
+    limit p_limit;
+$$;
@@ -0,0 +1,98 @@
+-- Invoices for paid orders and credit notes for refunded ones. Each merchant
+-- numbers its invoices (INV-000001, ...) and credit notes (CN-000001, ...) in
+-- separate gap-free series. Documents are snapshots: the merchant, line items,
+-- amount and payment transaction are copied in when the document is issued.
+
+create table if not exists invoice_counters (
+    "merchantId"  text   not null,
+    series        text   not null,
+    "lastNumber"  bigint not null default 0,
+    primary key ("merchantId", series)
+);
+
+create table if not exists invoices (
+    id                      bigint generated by default as identity primary key,
+    "merchantId"            text        not null,
+    "documentType"          text        not null,
+    sequence                bigint      not null,
+    "invoiceNumber"         text        not null,
+    "creditedInvoiceNumber" text        not null default '',
+    "orderId"               text        not null,
+    "merchantName"          text        not null,
+    "payoutAddress"         text        not null,
+    "buyerAddress"          text        not null,
+    network                 text        not null,
+    "tokenAddress"          text        not null,
+    "tokenSymbol"           text        not null default '',
+    "tokenDecimals"         integer,
+    amount                  text        not null,
+    "lineItems"             jsonb       not null default '[]',
+    "transactionHash"       text        not null,
+    "blockNumber"           bigint      not null default 0,
+    "blockTimestamp"        timestamptz,
+    "issuedAt"              timestamptz not null default now(),
+    unique ("orderId", "documentType"),
+    unique ("merchantId", "documentType", sequence)
+);
+
+create index if not exists invoices_merchant_id_idx on invoices ("merchantId", id desc);
+
+-- Issues the order's document of the given type, or returns the one already
+-- issued. The counter row stays locked until the call commits and is rolled
+-- back with a failed insert, so two issuers never share or skip a number; a
+-- concurrent issuer for the same order fails on the unique key and can read
+-- the winner's document.
+create or replace function issue_order_document(
+    p_document_type text, p_order_id text, p_merchant_id text, p_merchant_name text, p_payout_address text,
+    p_buyer_address text, p_network text, p_token_address text, p_token_symbol text, p_token_decimals integer,
+    p_amount text, p_line_items jsonb, p_transaction_hash text, p_block_number bigint, p_block_timestamp timestamptz)
+returns setof invoices
+language plpgsql as $$
+declare
+    v_series   text := case p_document_type when 'credit_note' then 'CN' else 'INV' end;
+    v_sequence bigint;
+    v_credited text := '';
+begin
+    return query select * from invoices i where i."orderId" = p_order_id and i."documentType" = p_document_type;
+    if found then
+        return;
+    end if;
+
+    if p_document_type = 'credit_note' then
+        select i."invoiceNumber" into v_credited
+          from invoices i where i."orderId" = p_order_id and i."documentType" = 'invoice';
+        if v_credited is null then
+            raise exception 'order % has no invoice to credit', p_order_id;
+        end if;
+    end if;
+
+    insert into invoice_counters as c ("merchantId", series, "lastNumber")
+    values (p_merchant_id, v_series, 1)
+    on conflict ("merchantId", series) do update set "lastNumber" = c."lastNumber" + 1
+    returning c."lastNumber" into v_sequence;
+
+    return query
+    insert into invoices ("merchantId", "documentType", sequence, "invoiceNumber", "creditedInvoiceNumber", "orderId",
+                          "merchantName", "payoutAddress", "buyerAddress", network, "tokenAddress", "tokenSymbol",
+                          "tokenDecimals", amount, "lineItems", "transactionHash", "blockNumber", "blockTimestamp")
+    values (p_merchant_id, p_document_type, v_sequence, v_series || '-' || lpad(v_sequence::text, 6, '0'), v_credited, p_order_id,
+            p_merchant_name, p_payout_address, p_buyer_address, p_network, p_token_address, p_token_symbol,
+            p_token_decimals, p_amount, p_line_items, p_transaction_hash, p_block_number, p_block_timestamp)
+    returning *;
+end;
+$$;
+
+-- Paid, settled and refunded orders still missing their invoice, and refunded
+-- orders missing their credit note, oldest order first
+create or replace function orders_missing_documents(p_limit integer)
+returns table ("orderId" text, "documentType" text)
+language sql stable as $$
+    select o."orderId", d.type
+      from orders o
+     cross join (values ('invoice'), ('credit_note')) as d(type)
+     where (o.status in ('paid', 'settled', 'refunded') and d.type = 'invoice'
+            or o.status = 'refunded' and d.type = 'credit_note')
+       and not exists (select 1 from invoices i where i."orderId" = o."orderId" and i."documentType" = d.type)
+     order by o.id, d.type desc
+     limit p_limit;
+$$;
@@ -0,0 +1,77 @@
+-- Automatic settlement. Each merchant picks when its paid orders are settled:
+-- immediately, holdHours after payment, in a daily batch after the UTC day
+-- they were paid in, or only by hand (the default). The settlement worker
+-- signs settleOrder with the platform key and logs every attempt.
+
+create table if not exists payout_policies (
+    "merchantId"  text        primary key,
+    policy        text        not null default 'manual'
+                              check (policy in ('immediate', 'delayed', 'daily', 'manual')),
+    "holdHours"   integer     not null default 0 check ("holdHours" >= 0),
+    "updatedBy"   text        not null default '',
+    "updatedAt"   timestamptz not null default now()
+);
+
+-- Orders the worker must not settle. An order can be held for several
+-- reasons at once, such as an operator's hold and an open dispute, and stays
+-- held until each is released. Settling by hand is not affected.
+create table if not exists settlement_holds (
+    "orderId"    text        not null,
+    reason       text        not null,
+    note         text        not null default '',
+    "createdBy"  text        not null default '',
+    "createdAt"  timestamptz not null default now(),
+    primary key ("orderId", reason)
+);
+
+create table if not exists settlement_attempts (
+    id                 bigint generated by default as identity primary key,
+    "orderId"          text        not null,
+    "merchantId"       text        not null,
+    network            text        not null,
+    status             text        not null default 'submitting'
+                                   check (status in ('submitting', 'submitted', 'confirmed', 'failed')),
+    "transactionHash"  text        not null default '',
+    "blockNumber"      bigint      not null default 0,
+    error              text        not null default '',
+    "createdAt"        timestamptz not null default now(),
+    "updatedAt"        timestamptz not null default now()
+);
+
+create index if not exists settlement_attempts_order_id_idx on settlement_attempts ("orderId", id desc);
+
+-- At most one attempt per order is in flight, so two workers never both send
+-- settleOrder for it
+create unique index if not exists settlement_attempts_in_flight_idx on settlement_attempts ("orderId")
+    where status in ('submitting', 'submitted');
+
+-- Paid orders whose merchant's policy makes them due at p_now, oldest payment
+-- first. Held orders, orders with an attempt in flight and orders whose last
+-- attempt failed after p_retry_after are left out.
+create or replace function orders_due_for_settlement(p_now timestamptz, p_retry_after timestamptz, p_limit integer)
+returns table ("orderId" text, "merchantId" text, network text, "paidAt" timestamptz)
+language sql stable as $$
+    select o."orderId", o."merchantId", o.network, p."paidAt"
+      from orders o
+      join payout_policies pp on pp."merchantId" = o."merchantId"
+     cross join lateral (
+            select coalesce(max(e."createdAt"), o."createdAt") as "paidAt"
+              from order_events e
+             where e."orderId" = o."orderId" and e."toStatus" = 'paid'
+           ) p
+     where o.status = 'paid'
+       and case pp.policy
+             when 'immediate' then true
+             when 'delayed'   then p."paidAt" + make_interval(hours => pp."holdHours") <= p_now
+             when 'daily'     then p."paidAt" < date_trunc('day', p_now at time zone 'utc') at time zone 'utc'
+                               and p."paidAt" + make_interval(hours => pp."holdHours") <= p_now
+             else false
+           end
+       and not exists (select 1 from settlement_holds h where h."orderId" = o."orderId")
+       and not exists (select 1 from settlement_attempts a
+                        where a."orderId" = o."orderId"
+                          and (a.status in ('submitting', 'submitted')
+                               or a.status = 'failed' and a."updatedAt" > p_retry_after))
+     order by p."paidAt"
+     limit p_limit;
+$$;
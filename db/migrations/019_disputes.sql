@@ -0,0 +1,48 @@
+-- Buyer disputes. A buyer may dispute a paid order before it is settled; the
+-- merchant responds and a platform reviewer decides whether the order is
+-- refunded or settled. While a dispute is open the order carries a 'dispute'
+-- settlement hold. Every step, evidence image and the payout that follows the
+-- decision is recorded in dispute_events.
+
+create table if not exists disputes (
+    id                 bigint generated by default as identity primary key,
+    "orderId"          text        not null unique,
+    "merchantId"       text        not null,
+    "buyerAddress"     text        not null,
+    reason             text        not null
+                                   check (reason in ('not_received', 'not_as_described', 'unauthorized', 'other')),
+    description        text        not null default '',
+    status             text        not null default 'open'
+                                   check (status in ('open', 'responded', 'resolved')),
+    outcome            text        not null default ''
+                                   check (outcome in ('', 'refund', 'settle')),
+    "resolutionNote"   text        not null default '',
+    "resolvedBy"       text        not null default '',
+    "resolvedAt"       timestamptz,
+    "createdAt"        timestamptz not null default now(),
+    "updatedAt"        timestamptz not null default now()
+);
+
+create index if not exists disputes_status_idx on disputes (status, id desc);
+create index if not exists disputes_merchant_id_idx on disputes ("merchantId", id desc);
+
+create table if not exists dispute_events (
+    id              bigint generated by default as identity primary key,
+    "disputeId"     bigint      not null references disputes (id) on delete cascade,
+    "orderId"       text        not null,
+    action          text        not null
+                                check (action in ('opened', 'evidence', 'responded', 'resolved', 'refunded', 'settled')),
+    party           text        not null check (party in ('buyer', 'merchant', 'platform')),
+    actor           text        not null default '',
+    message         text        not null default '',
+    "evidenceUrl"   text        not null default '',
+    "createdAt"     timestamptz not null default now()
+);
+
+create index if not exists dispute_events_dispute_id_idx on dispute_events ("disputeId", id);
+
+-- A reviewer's refund decision is sent by the settlement worker's signer and
+-- tracked like its settlements, so an order never has a refund and a
+-- settlement in flight at once
+alter table settlement_attempts add column if not exists action text not null default 'settle'
+    check (action in ('settle', 'refund'));
@@ -0,0 +1,35 @@
+-- Order expiry. An order created on-chain but never paid is cancelled once
+-- "expiresAt" passes. The expiry comes from the checkout session or create
+-- request, else from the merchant's policy, else from the platform default.
+
+alter table orders add column if not exists "expiresAt" timestamptz;
+
+-- When the expiry job last tried to cancel the order, so orders whose
+-- cancellation keeps failing are retried later instead of on every run
+alter table orders add column if not exists "expiryAttemptedAt" timestamptz;
+
+create index if not exists orders_expires_at_idx on orders ("expiresAt") where status = 'created';
+
+create table if not exists order_expiry_policies (
+    "merchantId"      text        primary key,
+    "expiryMinutes"   integer     not null check ("expiryMinutes" > 0),
+    "updatedBy"       text        not null default '',
+    "updatedAt"       timestamptz not null default now()
+);
+
+-- Seconds the order created from a checkout session may stay unpaid
+alter table checkout_sessions add column if not exists "orderExpiresIn" bigint check ("orderExpiresIn" > 0);
+
+-- Unpaid orders past their expiry at p_now, soonest expired first. Orders
+-- whose cancellation was tried after p_retry_after are left out.
+create or replace function orders_due_for_expiry(p_now timestamptz, p_retry_after timestamptz, p_limit integer)
+returns table ("orderId" text, network text, "expiresAt" timestamptz)
+language sql stable as $$
+    select o."orderId", o.network, o."expiresAt"
+      from orders o
+     where o.status = 'created'
+       and o."expiresAt" <= p_now
+       and (o."expiryAttemptedAt" is null or o."expiryAttemptedAt" <= p_retry_after)
+     order by o."expiresAt"
+     limit p_limit;
+$$;
@@ -0,0 +1,4 @@
+-- Order item unit prices are kept as the exact decimal the product was priced
+-- at, like the line totals, instead of a float.
+
+alter table order_items alter column "unitPrice" type text using "unitPrice"::text;
@@ -0,0 +1,15 @@
+-- Export job files live in a private Supabase Storage bucket shared by every
+-- instance (EXPORT_BUCKET, default "exports"). count_export_records lets a
+-- direct export be refused before any of it is streamed.
+
+insert into storage.buckets (id, name, public)
+values ('exports', 'exports', false)
+on conflict (id) do nothing;
+
+-- The number of records export_records would return for the range, counting
+-- no further than p_limit
+create or replace function count_export_records(p_kind text, p_from timestamptz, p_to timestamptz, p_limit integer, p_merchant_id text default null)
+returns bigint
+language sql stable as $$
+    select count(*) from export_records(p_kind, p_from, p_to, 0, p_limit, p_merchant_id);
+$$;
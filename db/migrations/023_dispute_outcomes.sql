@@ -0,0 +1,17 @@
+-- Disputed orders that were refunded or settled without the refund or
+-- settlement being recorded on their dispute yet, oldest dispute first. The
+-- dispute sweeper records them.
+
+create or replace function disputes_missing_outcomes(p_limit integer)
+returns table ("orderId" text)
+language sql stable as $$
+    select d."orderId"
+      from disputes d
+      join orders o on o."orderId" = d."orderId"
+     where o.status in ('refunded', 'settled')
+       and not exists (select 1 from dispute_events e
+                        where e."disputeId" = d.id
+                          and e.action = case o.status when 'refunded' then 'refunded' else 'settled' end)
+     order by d.id
+     limit p_limit;
+$$;
@@ -0,0 +1,42 @@
+-- A reviewer's dispute decision queues its refund or settlement for the
+-- settlement worker instead of sending it during the request. Queued attempts
+-- count as in flight, so an order never has two payouts pending at once.
+
+alter table settlement_attempts drop constraint if exists settlement_attempts_status_check;
+alter table settlement_attempts add constraint settlement_attempts_status_check
+    check (status in ('queued', 'submitting', 'submitted', 'confirmed', 'failed'));
+
+drop index if exists settlement_attempts_in_flight_idx;
+create unique index if not exists settlement_attempts_in_flight_idx on settlement_attempts ("orderId")
+    where status in ('queued', 'submitting', 'submitted');
+
+create index if not exists settlement_attempts_queued_idx on settlement_attempts (id)
+    where status = 'queued';
+
+create or replace function orders_due_for_settlement(p_now timestamptz, p_retry_after timestamptz, p_limit integer)
+returns table ("orderId" text, "merchantId" text, network text, "paidAt" timestamptz)
+language sql stable as $$
+    select o."orderId", o."merchantId", o.network, p."paidAt"
+      from orders o
+      join payout_policies pp on pp."merchantId" = o."merchantId"
+     cross join lateral (
+            select coalesce(max(e."createdAt"), o."createdAt") as "paidAt"
+              from order_events e
+             where e."orderId" = o."orderId" and e."toStatus" = 'paid'
+           ) p
+     where o.status = 'paid'
+       and case pp.policy
+             when 'immediate' then true
+             when 'delayed'   then p."paidAt" + make_interval(hours => pp."holdHours") <= p_now
+             when 'daily'     then p."paidAt" < date_trunc('day', p_now at time zone 'utc') at time zone 'utc'
+                               and p."paidAt" + make_interval(hours => pp."holdHours") <= p_now
+             else false
+           end
+       and not exists (select 1 from settlement_holds h where h."orderId" = o."orderId")
+       and not exists (select 1 from settlement_attempts a
+                        where a."orderId" = o."orderId"
+                          and (a.status in ('queued', 'submitting', 'submitted')
+                               or a.status = 'failed' and a."updatedAt" > p_retry_after))
+     order by p."paidAt"
+     limit p_limit;
+$$;
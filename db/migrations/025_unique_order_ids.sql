@@ -0,0 +1,11 @@
+-- One row per on-chain order. Confirming the same createOrder transaction twice
+-- used to insert a second row for the order, which broke lookups and status
+-- transitions by "orderId". Duplicates already stored are dropped, keeping the
+-- first row of each order.
+
+delete from orders o
+ using orders first
+ where o."orderId" = first."orderId"
+   and o.id > first.id;
+
+create unique index if not exists orders_order_id_idx on orders ("orderId");
@@ -0,0 +1,9 @@
+-- A cart cannot change once a checkout references it. A checkout session holds
+-- its cart until the session is paid or expires, and a checked out cart keeps
+-- its checkout, and the stock it reserved, until the reservation expires. Only
+-- an order created with the current checkout's document is accepted for the
+-- cart, so an order from a replaced checkout cannot be confirmed against it.
+
+alter table carts add column if not exists "checkoutSessionId" text;
+alter table carts add column if not exists "checkoutExpiresAt" timestamptz;
+alter table carts add column if not exists "metadataURI"       text;
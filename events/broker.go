@@ -0,0 +1,71 @@
+// Package events fans order status changes out to live subscribers (SSE and
+// WebSocket clients) through a pluggable pub/sub broker.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+// Event is one message on a topic. ID is the order_events id, which clients
+// send back as Last-Event-ID to resume.
+type Event struct {
+	ID   int64           `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Broker publishes events to every subscriber of a topic, on any server instance
+type Broker interface {
+	Publish(ctx context.Context, topic string, event Event) error
+	// Subscribe returns a channel of events for the topic and a function that ends
+	// the subscription. The subscription also ends when ctx is cancelled.
+	Subscribe(ctx context.Context, topic string) (<-chan Event, func(), error)
+}
+
+// OrderTopic carries the status changes of a single order
+func OrderTopic(orderId string) string {
+	return "order:" + orderId
+}
+
+// MerchantTopic carries the status changes of every order of a merchant
+func MerchantTopic(merchantId string) string {
+	return "merchant:" + merchantId
+}
+
+var broker Broker = NewMemoryBroker()
+
+// Init configures the broker from EVENTS_BROKER ("memory" or "redis", default "memory")
+func Init() error {
+	switch os.Getenv("EVENTS_BROKER") {
+	case "", "memory":
+		broker = NewMemoryBroker()
+	case "redis":
+		redisURL := os.Getenv("REDIS_URL")
+		if redisURL == "" {
+			return errors.New("REDIS_URL must be set when EVENTS_BROKER=redis")
+		}
+
+		redisBroker, err := NewRedisBroker(redisURL)
+		if err != nil {
+			return err
+		}
+		broker = redisBroker
+	default:
+		return errors.New("EVENTS_BROKER must be memory or redis")
+	}
+
+	return nil
+}
+
+// Default returns the configured broker
+func Default() Broker {
+	return broker
+}
+
+// SetDefault replaces the configured broker
+func SetDefault(b Broker) {
+	broker = b
+}
@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// subscriberBuffer is how many events a slow subscriber can fall behind before
+// events are dropped for it. Dropped events can be recovered by resuming from
+// the last event id.
+const subscriberBuffer = 32
+
+// MemoryBroker delivers events to subscribers in this process only
+type MemoryBroker struct {
+	mu     sync.RWMutex
+	topics map[string]map[chan Event]struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{topics: make(map[string]map[chan Event]struct{})}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, topic string, event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.topics[topic] {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("events: dropped event %d on %s for a slow subscriber", event.ID, topic)
+		}
+	}
+
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, topic string) (<-chan Event, func(), error) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.topics[topic] == nil {
+		b.topics[topic] = make(map[chan Event]struct{})
+	}
+	b.topics[topic][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.topics[topic], ch)
+			if len(b.topics[topic]) == 0 {
+				delete(b.topics, topic)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
+
+	return ch, cancel, nil
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisChannel is the single Redis channel every instance publishes to and
+// listens on; topics are routed locally
+const redisChannel = "stablemarket:events"
+
+type redisEnvelope struct {
+	Topic string `json:"topic"`
+	Event Event  `json:"event"`
+}
+
+// RedisBroker shares events between server instances over Redis pub/sub. Each
+// instance keeps one subscription and fans events out to its local subscribers.
+type RedisBroker struct {
+	client *redis.Client
+	local  *MemoryBroker
+}
+
+// NewRedisBroker connects to redisURL and starts listening for events
+func NewRedisBroker(redisURL string) (*RedisBroker, error) {
+	options, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(options)
+
+	b := &RedisBroker{client: client, local: NewMemoryBroker()}
+
+	// Fail fast on a bad URL or credentials
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	go b.listen()
+
+	return b, nil
+}
+
+// Publish sends the event through Redis; local subscribers receive it when it
+// comes back on the shared subscription
+func (b *RedisBroker) Publish(ctx context.Context, topic string, event Event) error {
+	payload, err := json.Marshal(redisEnvelope{Topic: topic, Event: event})
+	if err != nil {
+		return err
+	}
+
+	return b.client.Publish(ctx, redisChannel, payload).Err()
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context, topic string) (<-chan Event, func(), error) {
+	return b.local.Subscribe(ctx, topic)
+}
+
+// listen keeps the shared subscription open; the client reconnects and
+// resubscribes on its own when the connection drops
+func (b *RedisBroker) listen() {
+	sub := b.client.Subscribe(context.Background(), redisChannel)
+	defer sub.Close()
+
+	for message := range sub.Channel() {
+		var envelope redisEnvelope
+		if err := json.Unmarshal([]byte(message.Payload), &envelope); err != nil {
+			log.Printf("events: ignoring malformed message: %v", err)
+			continue
+		}
+		b.local.Publish(context.Background(), envelope.Topic, envelope.Event)
+	}
+}
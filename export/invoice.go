@@ -0,0 +1,237 @@
+package export
+
+import (
+	"html/template"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+var invoiceLineColumns = []struct {
+	title string
+	x     float64
+}{
+	{"Item", 36}, {"Quantity", 430}, {"Unit price", 520}, {"Amount", 640},
+}
+
+// maxLineName keeps long product names inside the item column of a PDF
+const maxLineName = 90
+
+var invoiceTemplate = template.Must(template.New("invoice").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} {{.InvoiceNumber}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #1a1a1a; margin: 40px; font-size: 14px; }
+h1 { font-size: 24px; margin-bottom: 4px; }
+h2 { font-size: 16px; margin-top: 24px; }
+table { border-collapse: collapse; width: 100%; margin: 16px 0; }
+th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #ddd; }
+th { background: #f5f5f5; }
+td.number, th.number { text-align: right; }
+.parties { display: flex; gap: 64px; margin: 24px 0; }
+.muted { color: #666; }
+code { font-size: 12px; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>{{.Title}} {{.InvoiceNumber}}</h1>
+<div class="muted">Issued {{.IssuedAt}}</div>
+{{if .CreditedInvoiceNumber}}<div class="muted">Credits invoice {{.CreditedInvoiceNumber}}</div>{{end}}
+<div class="muted">Order <code>{{.OrderId}}</code></div>
+
+<div class="parties">
+<div>
+<strong>From</strong><br>
+{{.MerchantName}}<br>
+<span class="muted">Merchant</span> <code>{{.MerchantId}}</code><br>
+<span class="muted">Payout wallet</span> <code>{{.PayoutAddress}}</code>
+</div>
+<div>
+<strong>Billed to</strong><br>
+<code>{{.BuyerAddress}}</code>
+</div>
+</div>
+
+<table>
+<thead><tr><th>Item</th><th class="number">Quantity</th><th class="number">Unit price</th><th class="number">Amount</th></tr></thead>
+<tbody>
+{{range .Lines}}<tr><td>{{.Name}}</td><td class="number">{{.Quantity}}</td><td class="number">{{.UnitPrice}}</td><td class="number">{{.Amount}}</td></tr>
+{{end}}</tbody>
+<tfoot><tr><th colspan="3">Total</th><th class="number">{{.Total}}</th></tr></tfoot>
+</table>
+
+<h2>{{.Transfer}}</h2>
+<table>
+<tbody>
+<tr><th>Network</th><td>{{.Network}}</td></tr>
+<tr><th>Token</th><td>{{.Token}} <code>{{.TokenAddress}}</code></td></tr>
+<tr><th>Transaction</th><td>{{if .ExplorerURL}}<a href="{{.ExplorerURL}}"><code>{{.TransactionHash}}</code></a>{{else}}<code>{{.TransactionHash}}</code>{{end}}</td></tr>
+{{if .BlockNumber}}<tr><th>Block</th><td>{{.BlockNumber}}</td></tr>{{end}}
+{{if .BlockTimestamp}}<tr><th>Block time</th><td>{{.BlockTimestamp}}</td></tr>{{end}}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+type invoiceLineView struct {
+	Name      string
+	Quantity  int64
+	UnitPrice string
+	Amount    string
+}
+
+type invoiceView struct {
+	models.Invoice
+	Title          string
+	Transfer       string
+	Token          string
+	Total          string
+	Lines          []invoiceLineView
+	IssuedAt       string
+	BlockNumber    string
+	BlockTimestamp string
+}
+
+// newInvoiceView prepares the labels shared by the HTML and PDF documents
+func newInvoiceView(invoice models.Invoice) invoiceView {
+	view := invoiceView{
+		Invoice:        invoice,
+		Title:          DocumentTitle(invoice.DocumentType),
+		Transfer:       "Payment",
+		Token:          tokenLabel(invoice.Symbol, invoice.TokenAddress),
+		Total:          amountLabel(invoice.AmountFormatted, invoice.Amount),
+		IssuedAt:       documentTime(invoice.IssuedAt),
+		BlockNumber:    blockLabel(invoice.BlockNumber),
+		BlockTimestamp: documentTime(invoice.BlockTimestamp),
+	}
+	if invoice.DocumentType == models.DocumentTypeCreditNote {
+		view.Transfer = "Refund"
+	}
+	if invoice.Symbol != "" {
+		view.Total += " " + invoice.Symbol
+	}
+
+	for _, line := range invoice.LineItems {
+		view.Lines = append(view.Lines, invoiceLineView{
+			Name:      line.Name,
+			Quantity:  line.Quantity,
+			UnitPrice: line.UnitPrice,
+			Amount:    amountLabel(line.AmountFormatted, line.Amount),
+		})
+	}
+
+	return view
+}
+
+// DocumentTitle names a document type for people, such as "Credit note"
+func DocumentTitle(documentType string) string {
+	if documentType == models.DocumentTypeCreditNote {
+		return "Credit note"
+	}
+	return "Invoice"
+}
+
+// WriteInvoiceHTML renders an invoice or credit note as a standalone HTML page
+func WriteInvoiceHTML(w io.Writer, invoice models.Invoice) error {
+	return invoiceTemplate.Execute(w, newInvoiceView(invoice))
+}
+
+// WriteInvoicePDF renders an invoice or credit note as a PDF with its
+// transaction linked to the block explorer
+func WriteInvoicePDF(w io.Writer, invoice models.Invoice) error {
+	view := newInvoiceView(invoice)
+
+	layout := &statementLayout{}
+	layout.newPage()
+
+	page := layout.page()
+	page.text("F2", 16, pageMargin, layout.y-10, view.Title+" "+invoice.InvoiceNumber)
+	layout.y -= 30
+	details := []string{"Issued " + view.IssuedAt, "Order " + invoice.OrderId}
+	if invoice.CreditedInvoiceNumber != "" {
+		details = append(details, "Credits invoice "+invoice.CreditedInvoiceNumber)
+	}
+	for _, line := range details {
+		page.text("F1", 9, pageMargin, layout.y, line)
+		layout.y -= 12
+	}
+
+	layout.y -= 10
+	page.text("F2", 10, pageMargin, layout.y, "From")
+	page.text("F2", 10, 430, layout.y, "Billed to")
+	layout.y -= 13
+	page.text("F1", 9, pageMargin, layout.y, invoice.MerchantName)
+	page.text("F1", 9, 430, layout.y, invoice.BuyerAddress)
+	layout.y -= 12
+	page.text("F1", 9, pageMargin, layout.y, "Merchant "+invoice.MerchantId)
+	layout.y -= 12
+	page.text("F1", 9, pageMargin, layout.y, "Payout wallet "+invoice.PayoutAddress)
+	layout.y -= 24
+
+	lineHeader := func() {
+		for _, column := range invoiceLineColumns {
+			layout.page().text("F2", bodyFontSize+1, column.x, layout.y, column.title)
+		}
+		layout.page().rule(layout.y - 3)
+		layout.y -= rowHeight + 3
+	}
+	lineHeader()
+	for _, line := range view.Lines {
+		if layout.ensure(rowHeight + 1) {
+			lineHeader()
+		}
+		name := line.Name
+		if len(name) > maxLineName {
+			name = name[:maxLineName-3] + "..."
+		}
+		values := []string{name, strconv.FormatInt(line.Quantity, 10), line.UnitPrice, line.Amount}
+		for i, column := range invoiceLineColumns {
+			layout.page().text("F1", bodyFontSize+1, column.x, layout.y, values[i])
+		}
+		layout.y -= rowHeight + 1
+	}
+	layout.page().rule(layout.y + rowHeight - 3)
+	layout.page().text("F2", bodyFontSize+1, invoiceLineColumns[0].x, layout.y-2, "Total")
+	layout.page().text("F2", bodyFontSize+1, invoiceLineColumns[3].x, layout.y-2, view.Total)
+	layout.y -= 2*rowHeight + 8
+
+	payment := [][2]string{
+		{"Network", invoice.Network},
+		{"Token", view.Token + " " + invoice.TokenAddress},
+		{"Transaction", invoice.TransactionHash},
+		{"Block", view.BlockNumber},
+		{"Block time", view.BlockTimestamp},
+	}
+	layout.ensure(float64(len(payment)+1) * 12)
+	layout.page().text("F2", 10, pageMargin, layout.y, view.Transfer)
+	layout.y -= 14
+	for _, row := range payment {
+		if row[1] == "" {
+			continue
+		}
+		page := layout.page()
+		page.text("F2", 9, pageMargin, layout.y, row[0])
+		page.text("F1", 9, 130, layout.y, row[1])
+		if row[0] == "Transaction" && invoice.ExplorerURL != "" {
+			page.links = append(page.links, pdfLink{
+				x1: 130, y1: layout.y - 2, x2: 130 + 330, y2: layout.y + 9,
+				uri: invoice.ExplorerURL,
+			})
+		}
+		layout.y -= 12
+	}
+
+	return writePDF(w, layout.pages)
+}
+
+func documentTime(value string) string {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC().Format("2006-01-02 15:04:05") + " UTC"
+	}
+	return value
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalStore keeps export files in a directory on local disk. Only the instance
+// that wrote a file can serve it, so it suits development and single-instance
+// deployments.
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates the storage directory if needed
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalStore{dir: dir}, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, name, contentType string, write func(w io.Writer) error) (int64, error) {
+	tmp, err := os.CreateTemp(s.dir, name+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+func (s *LocalStore) Locate(ctx context.Context, name, downloadName string) (Location, error) {
+	path := filepath.Join(s.dir, name)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return Location{}, ErrNotFound
+	} else if err != nil {
+		return Location{}, err
+	}
+
+	return Location{Path: path}, nil
+}
+
+func (s *LocalStore) Remove(ctx context.Context, name string) error {
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
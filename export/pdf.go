@@ -0,0 +1,334 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/utils"
+)
+
+// A4 landscape in points, with the built-in Helvetica fonts so the document
+// needs no embedded font data
+const (
+	pageWidth    = 842.0
+	pageHeight   = 595.0
+	pageMargin   = 36.0
+	rowHeight    = 11.0
+	bodyFontSize = 7.0
+)
+
+type pdfColumn struct {
+	title string
+	x     float64
+	value func(record models.ExportRecord) string
+}
+
+var statementColumns = []pdfColumn{
+	{"Date (UTC)", 36, func(r models.ExportRecord) string { return statementTime(r) }},
+	{"Reference", 106, func(r models.ExportRecord) string { return shorten(r.Reference) }},
+	{"Network", 176, func(r models.ExportRecord) string { return r.Network }},
+	{"Token", 246, func(r models.ExportRecord) string { return tokenLabel(r.Symbol, r.TokenAddress) }},
+	{"Amount", 300, func(r models.ExportRecord) string { return amountLabel(r.AmountFormatted, r.Amount) }},
+	{"Fee", 390, func(r models.ExportRecord) string { return formatAmount(r.PlatformFee, r.Decimals) }},
+	{"Net", 460, func(r models.ExportRecord) string { return formatAmount(r.MerchantNet, r.Decimals) }},
+	{"Status", 540, func(r models.ExportRecord) string { return r.Status }},
+	{"Counterparty", 590, func(r models.ExportRecord) string { return shorten(r.Counterparty) }},
+	{"Transaction", 666, func(r models.ExportRecord) string { return shorten(r.TransactionHash) }},
+	{"Block", 742, func(r models.ExportRecord) string { return blockLabel(r.BlockNumber) }},
+}
+
+// transactionColumn is the column linked to the record's explorer page
+const transactionColumn = 9
+
+var totalColumns = []struct {
+	title string
+	x     float64
+}{
+	{"Network", 36}, {"Token", 126}, {"Records", 226}, {"Amount", 296}, {"Fee", 426}, {"Net", 556},
+}
+
+type pdfLink struct {
+	x1, y1, x2, y2 float64
+	uri            string
+}
+
+type pdfPage struct {
+	content bytes.Buffer
+	links   []pdfLink
+}
+
+func (p *pdfPage) text(font string, size, x, y float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(s))
+}
+
+func (p *pdfPage) rule(y float64) {
+	fmt.Fprintf(&p.content, "0.6 w %.2f %.2f m %.2f %.2f l S\n", pageMargin, y, pageWidth-pageMargin, y)
+}
+
+type statementLayout struct {
+	pages []*pdfPage
+	y     float64
+}
+
+func (l *statementLayout) page() *pdfPage {
+	return l.pages[len(l.pages)-1]
+}
+
+func (l *statementLayout) newPage() {
+	l.pages = append(l.pages, &pdfPage{})
+	l.y = pageHeight - pageMargin
+}
+
+// ensure starts a new page when fewer than height points are left above the
+// footer, reporting whether it did
+func (l *statementLayout) ensure(height float64) bool {
+	if l.y-height < pageMargin+rowHeight {
+		l.newPage()
+		return true
+	}
+	return false
+}
+
+func (l *statementLayout) recordHeader() {
+	page := l.page()
+	for _, column := range statementColumns {
+		page.text("F2", bodyFontSize, column.x, l.y, column.title)
+	}
+	page.rule(l.y - 3)
+	l.y -= rowHeight + 2
+}
+
+// WriteStatement renders a PDF statement: the title and description lines,
+// per token totals, then one row per record with its transaction linked to the
+// block explorer
+func WriteStatement(w io.Writer, statement models.ExportStatement) error {
+	layout := &statementLayout{}
+	layout.newPage()
+
+	page := layout.page()
+	page.text("F2", 14, pageMargin, layout.y-10, statement.Title)
+	layout.y -= 28
+	for _, line := range statement.Lines {
+		page.text("F1", 9, pageMargin, layout.y, line)
+		layout.y -= 12
+	}
+	if statement.GeneratedAt != "" {
+		page.text("F1", 9, pageMargin, layout.y, "Generated "+statement.GeneratedAt)
+		layout.y -= 12
+	}
+
+	layout.y -= 10
+	layout.page().text("F2", 10, pageMargin, layout.y, "Totals by token")
+	layout.y -= rowHeight + 4
+	for _, column := range totalColumns {
+		layout.page().text("F2", bodyFontSize, column.x, layout.y, column.title)
+	}
+	layout.page().rule(layout.y - 3)
+	layout.y -= rowHeight + 2
+	if len(statement.Totals) == 0 {
+		layout.page().text("F1", bodyFontSize, pageMargin, layout.y, "No records in this period")
+		layout.y -= rowHeight
+	}
+	for _, total := range statement.Totals {
+		layout.ensure(rowHeight)
+		values := []string{
+			total.Network, tokenLabel(total.Symbol, total.TokenAddress), strconv.FormatInt(total.Records, 10),
+			total.Amount, total.PlatformFee, total.MerchantNet,
+		}
+		for i, column := range totalColumns {
+			layout.page().text("F1", bodyFontSize, column.x, layout.y, values[i])
+		}
+		layout.y -= rowHeight
+	}
+
+	if len(statement.Records) > 0 {
+		layout.y -= 14
+		layout.ensure(3 * rowHeight)
+		layout.page().text("F2", 10, pageMargin, layout.y, "Records")
+		layout.y -= rowHeight + 4
+		layout.recordHeader()
+	}
+	for _, record := range statement.Records {
+		if layout.ensure(rowHeight) {
+			layout.recordHeader()
+		}
+
+		page := layout.page()
+		for i, column := range statementColumns {
+			value := column.value(record)
+			page.text("F1", bodyFontSize, column.x, layout.y, value)
+			if i == transactionColumn && record.ExplorerURL != "" && value != "" {
+				page.links = append(page.links, pdfLink{
+					x1: column.x, y1: layout.y - 2, x2: column.x + 72, y2: layout.y + bodyFontSize,
+					uri: record.ExplorerURL,
+				})
+			}
+		}
+		layout.y -= rowHeight
+	}
+
+	return writePDF(w, layout.pages)
+}
+
+// writePDF serializes the pages: catalog, page tree and the two fonts, then
+// each page followed by its content stream and link annotations
+func writePDF(w io.Writer, pages []*pdfPage) error {
+	var buf bytes.Buffer
+	var offsets []int
+
+	begin := func() int {
+		offsets = append(offsets, buf.Len())
+		id := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n", id)
+		return id
+	}
+	end := func() {
+		buf.WriteString("endobj\n")
+	}
+
+	// Object ids are assigned in write order, so the page ids are known up front
+	pageIds := make([]int, len(pages))
+	next := 5
+	for i, page := range pages {
+		pageIds[i] = next
+		next += 2 + len(page.links)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	begin()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\n")
+	end()
+
+	begin()
+	kids := make([]string, len(pageIds))
+	for i, id := range pageIds {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	fmt.Fprintf(&buf, "<< /Type /Pages /Kids [%s] /Count %d >>\n", strings.Join(kids, " "), len(pages))
+	end()
+
+	begin()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>\n")
+	end()
+
+	begin()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>\n")
+	end()
+
+	for i, page := range pages {
+		page.text("F1", bodyFontSize, pageWidth-pageMargin-50, pageMargin-10, fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+
+		id := begin()
+		annots := make([]string, len(page.links))
+		for j := range page.links {
+			annots[j] = fmt.Sprintf("%d 0 R", id+2+j)
+		}
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R",
+			pageWidth, pageHeight, id+1)
+		if len(annots) > 0 {
+			fmt.Fprintf(&buf, " /Annots [%s]", strings.Join(annots, " "))
+		}
+		buf.WriteString(" >>\n")
+		end()
+
+		begin()
+		fmt.Fprintf(&buf, "<< /Length %d >>\nstream\n", page.content.Len())
+		buf.Write(page.content.Bytes())
+		buf.WriteString("endstream\n")
+		end()
+
+		for _, link := range page.links {
+			begin()
+			fmt.Fprintf(&buf, "<< /Type /Annot /Subtype /Link /Rect [%.2f %.2f %.2f %.2f] /Border [0 0 0] /A << /S /URI /URI (%s) >> >>\n",
+				link.x1, link.y1, link.x2, link.y2, pdfEscape(link.uri))
+			end()
+		}
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfEscape makes s safe inside a PDF string literal. The standard fonts only
+// cover printable ASCII here, so anything else becomes '?'.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// shorten keeps the ends of long hashes and addresses so they fit a column
+func shorten(s string) string {
+	if len(s) <= 16 {
+		return s
+	}
+	return s[:8] + "..." + s[len(s)-6:]
+}
+
+func statementTime(record models.ExportRecord) string {
+	value := record.BlockTimestamp
+	if value == "" {
+		value = record.RecordedAt
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC().Format("2006-01-02 15:04")
+	}
+	return value
+}
+
+func tokenLabel(symbol, tokenAddress string) string {
+	if symbol != "" {
+		return symbol
+	}
+	return shorten(tokenAddress)
+}
+
+func amountLabel(formatted, amount string) string {
+	if formatted != "" {
+		return formatted
+	}
+	return amount
+}
+
+// formatAmount renders base units in token units when the decimals are known
+func formatAmount(amount string, decimals *uint8) string {
+	if decimals == nil {
+		return amount
+	}
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return amount
+	}
+	return utils.FormatTokenAmount(value, *decimals)
+}
+
+func blockLabel(blockNumber uint64) string {
+	if blockNumber == 0 {
+		return ""
+	}
+	return strconv.FormatUint(blockNumber, 10)
+}
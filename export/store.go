@@ -0,0 +1,125 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+)
+
+var (
+	ErrNotFound        = errors.New("export file not found")
+	ErrNotInitialized  = errors.New("export store not initialized")
+	errInvalidFileName = errors.New("invalid export file name")
+)
+
+var fileNamePattern = regexp.MustCompile(`^[a-z0-9-]+\.(csv|jsonl|pdf)$`)
+
+// Store keeps the files written by export jobs. Names are validated before they
+// reach a store.
+type Store interface {
+	// Put stores the output of write under name and returns its size. The file
+	// only appears once write has finished, so readers never see a partial export.
+	Put(ctx context.Context, name, contentType string, write func(w io.Writer) error) (int64, error)
+	// Locate returns where a stored file can be downloaded as downloadName
+	Locate(ctx context.Context, name, downloadName string) (Location, error)
+	// Remove deletes a stored file; a file that is already gone is not an error
+	Remove(ctx context.Context, name string) error
+}
+
+// Location is where a stored export file is downloaded from: a local Path this
+// API serves itself, or a short-lived signed URL to redirect the client to
+type Location struct {
+	Path string
+	URL  string
+}
+
+var store Store
+
+// Init configures the store from EXPORT_STORE ("supabase" or "local", default
+// "supabase"). Supabase Storage keeps files in the private EXPORT_BUCKET (default
+// "exports"), so any instance can serve a job another instance wrote; the local
+// EXPORT_DIR (default "data/exports") only suits a single instance.
+func Init() error {
+	switch os.Getenv("EXPORT_STORE") {
+	case "", "supabase":
+		url := os.Getenv("SUPABASE_URL")
+		key := os.Getenv("SUPABASE_KEY")
+		if url == "" || key == "" {
+			return errors.New("SUPABASE_URL and SUPABASE_KEY must be set when EXPORT_STORE=supabase")
+		}
+
+		bucket := os.Getenv("EXPORT_BUCKET")
+		if bucket == "" {
+			bucket = "exports"
+		}
+		store = NewSupabaseStore(url, key, bucket)
+	case "local":
+		dir := os.Getenv("EXPORT_DIR")
+		if dir == "" {
+			dir = "data/exports"
+		}
+
+		local, err := NewLocalStore(dir)
+		if err != nil {
+			return err
+		}
+		store = local
+	default:
+		return errors.New("EXPORT_STORE must be supabase or local")
+	}
+
+	return nil
+}
+
+// Default returns the configured store, or nil if Init has not run
+func Default() Store {
+	return store
+}
+
+// SetDefault replaces the configured store
+func SetDefault(s Store) {
+	store = s
+}
+
+// WriteFile stores the output of write under name in the default store and
+// returns its size
+func WriteFile(ctx context.Context, name string, write func(w io.Writer) error) (int64, error) {
+	if store == nil {
+		return 0, ErrNotInitialized
+	}
+	if !fileNamePattern.MatchString(name) {
+		return 0, errInvalidFileName
+	}
+
+	return store.Put(ctx, name, fileContentType(name), write)
+}
+
+// Locate returns where a file of the default store is downloaded as downloadName
+func Locate(ctx context.Context, name, downloadName string) (Location, error) {
+	if store == nil {
+		return Location{}, ErrNotInitialized
+	}
+	if !fileNamePattern.MatchString(name) {
+		return Location{}, ErrNotFound
+	}
+
+	return store.Locate(ctx, name, downloadName)
+}
+
+// Remove deletes a file of the default store
+func Remove(ctx context.Context, name string) error {
+	if store == nil {
+		return ErrNotInitialized
+	}
+	if !fileNamePattern.MatchString(name) {
+		return errInvalidFileName
+	}
+
+	return store.Remove(ctx, name)
+}
+
+func fileContentType(name string) string {
+	return ContentType(fileNamePattern.FindStringSubmatch(name)[1])
+}
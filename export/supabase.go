@@ -0,0 +1,178 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// signedURLExpiry is how long a download link handed to a client stays valid
+const signedURLExpiry = 5 * time.Minute
+
+var errUploadFinished = errors.New("storage upload finished")
+
+// SupabaseStore keeps export files in a private Supabase Storage bucket, shared
+// by every instance. Downloads are served from short-lived signed URLs.
+type SupabaseStore struct {
+	storageURL string
+	key        string
+	bucket     string
+	client     *http.Client
+}
+
+// NewSupabaseStore stores files in bucket of the project at projectURL,
+// authenticating with the service key
+func NewSupabaseStore(projectURL, key, bucket string) *SupabaseStore {
+	return &SupabaseStore{
+		storageURL: strings.TrimRight(projectURL, "/") + "/storage/v1",
+		key:        key,
+		bucket:     bucket,
+		// Uploads stream a whole export job, so only the job's context bounds them
+		client: &http.Client{},
+	}
+}
+
+// Put streams the file to the bucket as it is written. Storage only keeps the
+// object once the upload completes, so a failed write leaves nothing behind.
+func (s *SupabaseStore) Put(ctx context.Context, name, contentType string, write func(w io.Writer) error) (int64, error) {
+	reader, writer := io.Pipe()
+	counter := &countingWriter{w: writer}
+	written := make(chan error, 1)
+	go func() {
+		err := write(counter)
+		writer.CloseWithError(err)
+		written <- err
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.objectURL("object", name), reader)
+	if err != nil {
+		reader.CloseWithError(err)
+		<-written
+		return 0, err
+	}
+	s.authorize(req)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-upsert", "true")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		reader.CloseWithError(err)
+		if writeErr := <-written; writeErr != nil {
+			return 0, writeErr
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Storage may answer before reading the whole body; unblock the writer
+	reader.CloseWithError(errUploadFinished)
+	if writeErr := <-written; writeErr != nil && !errors.Is(writeErr, errUploadFinished) {
+		return 0, writeErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("storage upload returned %d", resp.StatusCode)
+	}
+
+	return counter.n, nil
+}
+
+func (s *SupabaseStore) Locate(ctx context.Context, name, downloadName string) (Location, error) {
+	body, err := json.Marshal(map[string]int{"expiresIn": int(signedURLExpiry / time.Second)})
+	if err != nil {
+		return Location{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.objectURL("object/sign", name), bytes.NewReader(body))
+	if err != nil {
+		return Location{}, err
+	}
+	s.authorize(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Location{}, err
+	}
+	defer resp.Body.Close()
+
+	// Storage reports a missing object as 400 or 404 depending on its version
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+		return Location{}, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Location{}, fmt.Errorf("storage sign returned %d", resp.StatusCode)
+	}
+
+	var result struct {
+		SignedURL string `json:"signedURL"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&result); err != nil {
+		return Location{}, err
+	}
+	if !strings.HasPrefix(result.SignedURL, "/") {
+		return Location{}, errors.New("storage sign returned no URL")
+	}
+
+	signed, err := url.Parse(s.storageURL + result.SignedURL)
+	if err != nil {
+		return Location{}, err
+	}
+	query := signed.Query()
+	query.Set("download", downloadName)
+	signed.RawQuery = query.Encode()
+
+	return Location{URL: signed.String()}, nil
+}
+
+func (s *SupabaseStore) Remove(ctx context.Context, name string) error {
+	body, err := json.Marshal(map[string][]string{"prefixes": {name}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.storageURL+"/object/"+url.PathEscape(s.bucket), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	s.authorize(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Deleting an object that is already gone succeeds with an empty list
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("storage delete returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *SupabaseStore) objectURL(route, name string) string {
+	return s.storageURL + "/" + route + "/" + url.PathEscape(s.bucket) + "/" + name
+}
+
+func (s *SupabaseStore) authorize(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+s.key)
+	req.Header.Set("apikey", s.key)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
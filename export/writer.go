@@ -0,0 +1,124 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// Columns is the CSV header, in the order of the json fields of ExportRecord
+var Columns = []string{
+	"kind", "reference", "merchantId", "network", "tokenAddress", "symbol", "decimals",
+	"amount", "amountFormatted", "platformFee", "merchantNet", "status", "counterparty",
+	"transactionHash", "blockNumber", "blockTimestamp", "explorerUrl", "recordedAt",
+}
+
+var errUnsupportedFormat = errors.New("unsupported export format")
+
+// Writer streams records in one format. Close must be called once all records
+// are written.
+type Writer interface {
+	Write(record models.ExportRecord) error
+	Close() error
+}
+
+// NewWriter returns a streaming writer for csv or jsonl. PDF statements carry
+// totals over all records, so they are written by WriteStatement instead.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case models.ExportFormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case models.ExportFormatJSONL:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return &jsonlWriter{encoder: encoder}, nil
+	default:
+		return nil, errUnsupportedFormat
+	}
+}
+
+// ContentType returns the media type of a format
+func ContentType(format string) string {
+	switch format {
+	case models.ExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case models.ExportFormatJSONL:
+		return "application/x-ndjson"
+	case models.ExportFormatPDF:
+		return "application/pdf"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (c *csvWriter) Write(record models.ExportRecord) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	blockNumber := ""
+	if record.BlockNumber > 0 {
+		blockNumber = strconv.FormatUint(record.BlockNumber, 10)
+	}
+
+	decimals := ""
+	if record.Decimals != nil {
+		decimals = strconv.Itoa(int(*record.Decimals))
+	}
+
+	row := []string{
+		record.Kind, record.Reference, record.MerchantId, record.Network, record.TokenAddress, record.Symbol, decimals,
+		record.Amount, record.AmountFormatted, record.PlatformFee, record.MerchantNet, record.Status, record.Counterparty,
+		record.TransactionHash, blockNumber, record.BlockTimestamp, record.ExplorerURL, record.RecordedAt,
+	}
+	for i, cell := range row {
+		row[i] = escapeFormula(cell)
+	}
+
+	return c.w.Write(row)
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	return c.w.Write(Columns)
+}
+
+// escapeFormula keeps spreadsheets from evaluating a cell as a formula
+func escapeFormula(cell string) string {
+	if cell != "" && (cell[0] == '=' || cell[0] == '+' || cell[0] == '-' || cell[0] == '@') {
+		return "'" + cell
+	}
+	return cell
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (j *jsonlWriter) Write(record models.ExportRecord) error {
+	return j.encoder.Encode(record)
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
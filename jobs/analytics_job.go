@@ -0,0 +1,19 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Dbriane208/stable-market/services"
+)
+
+// StartAnalyticsRollup periodically rebuilds the merchant analytics rollups of
+// yesterday and today, so late transitions near midnight are counted
+func StartAnalyticsRollup(ctx context.Context, interval time.Duration) {
+	go runEvery(ctx, interval, func() {
+		if err := services.RefreshAnalyticsRollups(time.Now().UTC().AddDate(0, 0, -1)); err != nil {
+			log.Println("analytics rollup: ", err)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Dbriane208/stable-market/services"
+)
+
+// StartBalanceSnapshotter periodically records merchant payout wallet and
+// payment processor contract balances for the balance history
+func StartBalanceSnapshotter(ctx context.Context, interval time.Duration) {
+	go runEvery(ctx, interval, func() {
+		written, err := services.SnapshotBalances(ctx)
+		if err != nil {
+			log.Println("balance snapshotter: ", err)
+			return
+		}
+
+		if written > 0 {
+			log.Printf("balance snapshotter: recorded %d balances", written)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Dbriane208/stable-market/services"
+)
+
+// disputeBatchSize caps how many dispute outcomes one tick records
+const disputeBatchSize = 100
+
+// StartDisputeRecorder periodically records the refunds and settlements of
+// disputed orders on their disputes
+func StartDisputeRecorder(ctx context.Context, interval time.Duration) {
+	go runEvery(ctx, interval, func() {
+		recorded, err := services.RecordDisputeOutcomes(disputeBatchSize)
+		if err != nil {
+			log.Println("dispute recorder: ", err)
+		} else if recorded > 0 {
+			log.Printf("dispute recorder: recorded %d outcomes", recorded)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Dbriane208/stable-market/services"
+)
+
+// exportBatchSize caps how many queued export jobs one tick writes
+const exportBatchSize = 5
+
+// StartExportWorker periodically writes queued export jobs and deletes the
+// files of expired ones
+func StartExportWorker(ctx context.Context, interval time.Duration) {
+	go runEvery(ctx, interval, func() {
+		finished, err := services.ProcessExportJobs(ctx, exportBatchSize)
+		if err != nil {
+			log.Println("export worker: ", err)
+		} else if finished > 0 {
+			log.Printf("export worker: finished %d jobs", finished)
+		}
+
+		if _, err := services.ExpireExportJobs(ctx); err != nil {
+			log.Println("export worker: ", err)
+		}
+	})
+}
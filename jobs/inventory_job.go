@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Dbriane208/stable-market/services"
+)
+
+// StartReservationSweeper periodically returns stock held by checkouts whose
+// reservation TTL expired before an order was created
+func StartReservationSweeper(ctx context.Context, interval time.Duration) {
+	go runEvery(ctx, interval, func() {
+		released, err := services.ReleaseExpiredReservations()
+		if err != nil {
+			log.Println("inventory sweeper: ", err)
+			return
+		}
+
+		if len(released) > 0 {
+			log.Printf("inventory sweeper: released %d expired reservations", len(released))
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Dbriane208/stable-market/services"
+)
+
+// invoiceBatchSize caps how many missing documents one tick issues
+const invoiceBatchSize = 100
+
+// StartInvoiceIssuer periodically issues the invoices of paid orders and the
+// credit notes of refunded ones
+func StartInvoiceIssuer(ctx context.Context, interval time.Duration) {
+	go runEvery(ctx, interval, func() {
+		issued, err := services.IssueMissingDocuments(ctx, invoiceBatchSize)
+		if err != nil {
+			log.Println("invoice issuer: ", err)
+		} else if issued > 0 {
+			log.Printf("invoice issuer: issued %d documents", issued)
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// runEvery calls fn on every tick of interval until ctx is cancelled
+func runEvery(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
+
+// IntervalFromEnv reads a duration such as "5m" from the environment, falling back to def
+func IntervalFromEnv(key string, def time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+			return interval
+		}
+	}
+	return def
+}
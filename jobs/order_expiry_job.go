@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Dbriane208/stable-market/services"
+)
+
+// orderExpiryBatchSize caps how many expired orders one tick cancels. Each
+// waits for its receipt, so the batch is kept small.
+const orderExpiryBatchSize = 20
+
+// StartOrderExpirer periodically cancels unpaid orders past their expiry,
+// releasing their stock reservations
+func StartOrderExpirer(ctx context.Context, interval time.Duration) {
+	go runEvery(ctx, interval, func() {
+		cancelled, err := services.CancelExpiredOrders(ctx, orderExpiryBatchSize)
+		if err != nil {
+			log.Println("order expirer: ", err)
+		} else if cancelled > 0 {
+			log.Printf("order expirer: cancelled %d orders", cancelled)
+		}
+	})
+}
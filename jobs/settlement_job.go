@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Dbriane208/stable-market/services"
+)
+
+// settlementBatchSize caps how many due orders one tick sends settlements for
+const settlementBatchSize = 25
+
+// StartSettlementWorker periodically settles paid orders that their merchant's
+// payout policy makes due
+func StartSettlementWorker(ctx context.Context, interval time.Duration) {
+	go runEvery(ctx, interval, func() {
+		settled, err := services.SettleDueOrders(ctx, settlementBatchSize)
+		if err != nil {
+			log.Println("settlement worker: ", err)
+		} else if settled > 0 {
+			log.Printf("settlement worker: settled %d orders", settled)
+		}
+	})
+}
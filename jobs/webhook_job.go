@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Dbriane208/stable-market/services"
+)
+
+// webhookBatchSize caps how many due deliveries one tick retries
+const webhookBatchSize = 50
+
+// StartWebhookWorker periodically retries webhook deliveries whose backoff has elapsed
+func StartWebhookWorker(ctx context.Context, interval time.Duration) {
+	go runEvery(ctx, interval, func() {
+		attempted, err := services.ProcessDueWebhookDeliveries(webhookBatchSize)
+		if err != nil {
+			log.Println("webhook worker: ", err)
+			return
+		}
+
+		if attempted > 0 {
+			log.Printf("webhook worker: retried %d deliveries", attempted)
+		}
+	})
+}
@@ -1,11 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/auth"
 	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/events"
+	"github.com/Dbriane208/stable-market/export"
+	"github.com/Dbriane208/stable-market/jobs"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/metadata"
 	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/ratelimit"
 	"github.com/Dbriane208/stable-market/routes"
 	"github.com/Dbriane208/stable-market/utils"
 	"github.com/gin-gonic/gin"
@@ -34,22 +44,64 @@ func main() {
 		log.Fatal("Failed to initialize cloudinary client: ", err)
 	}
 
-	// Setup Gin router
-	router := gin.Default()
+	if err = metadata.Init(); err != nil {
+		log.Fatal("Failed to initialize metadata store: ", err)
+	}
+
+	if err = export.Init(); err != nil {
+		log.Fatal("Failed to initialize export store: ", err)
+	}
+
+	if err = events.Init(); err != nil {
+		log.Fatal("Failed to initialize event broker: ", err)
+	}
+
+	if err = auth.Init(); err != nil {
+		log.Fatal("Failed to initialize auth: ", err)
+	}
+
+	if err = ratelimit.Init(); err != nil {
+		log.Fatal("Failed to initialize rate limiter: ", err)
+	}
+
+	// Start background jobs
+	bgCtx := context.Background()
+	jobs.StartReservationSweeper(bgCtx, jobs.IntervalFromEnv("RESERVATION_SWEEP_INTERVAL", time.Minute))
+	jobs.StartWebhookWorker(bgCtx, jobs.IntervalFromEnv("WEBHOOK_RETRY_INTERVAL", 15*time.Second))
+	jobs.StartBalanceSnapshotter(bgCtx, jobs.IntervalFromEnv("BALANCE_SNAPSHOT_INTERVAL", time.Hour))
+	jobs.StartAnalyticsRollup(bgCtx, jobs.IntervalFromEnv("ANALYTICS_ROLLUP_INTERVAL", 5*time.Minute))
+	jobs.StartExportWorker(bgCtx, jobs.IntervalFromEnv("EXPORT_WORKER_INTERVAL", 30*time.Second))
+	jobs.StartSettlementWorker(bgCtx, jobs.IntervalFromEnv("SETTLEMENT_WORKER_INTERVAL", time.Minute))
+	jobs.StartInvoiceIssuer(bgCtx, jobs.IntervalFromEnv("INVOICE_SWEEP_INTERVAL", time.Minute))
+	jobs.StartDisputeRecorder(bgCtx, jobs.IntervalFromEnv("DISPUTE_SWEEP_INTERVAL", time.Minute))
+	jobs.StartOrderExpirer(bgCtx, jobs.IntervalFromEnv("ORDER_EXPIRY_INTERVAL", time.Minute))
+
+	// Report binding errors by json field name
+	apierror.Init()
+
+	// Setup Gin router. Recovery and unknown routes answer with the error envelope,
+	// tagged with the request id.
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+
+	// ClientIP only honours forwarding headers from configured proxies
+	if err := router.SetTrustedProxies(middleware.TrustedProxies()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES: ", err)
+	}
+	router.Use(middleware.RequestID(), gin.Logger(), apierror.Recovery())
+	router.NoRoute(apierror.NoRoute)
+	router.NoMethod(apierror.NoMethod)
 
 	router.Use(cors.New(cors.Config{
 		AllowAllOrigins:  true,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "X-Request-ID"},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-Request-ID"},
 		AllowCredentials: false, 
 	}))
 
 	// Setup routes
-	routes.SetupMerchantRoutes(router)
-	routes.SetupPlatformRoutes(router)
-	routes.SetupOrderRoutes(router)
-	routes.SetupMarketRoutes(router)
+	routes.Setup(router)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -0,0 +1,144 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// IPFSStore stores documents through an IPFS-compatible HTTP API (Kubo's /api/v0/add)
+// and reads them back through a gateway. URIs have the form ipfs://{cid}.
+type IPFSStore struct {
+	apiURL     string
+	gatewayURL string
+	client     *http.Client
+}
+
+// NewIPFSStore uses apiURL for writes and gatewayURL (defaulting to apiURL) for reads
+func NewIPFSStore(apiURL, gatewayURL string) *IPFSStore {
+	if gatewayURL == "" {
+		gatewayURL = apiURL
+	}
+
+	return &IPFSStore{
+		apiURL:     strings.TrimRight(apiURL, "/"),
+		gatewayURL: strings.TrimRight(gatewayURL, "/"),
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *IPFSStore) Put(ctx context.Context, document []byte) (Document, error) {
+	cid, err := s.add(ctx, document, false)
+	if err != nil {
+		return Document{}, err
+	}
+
+	return Document{URI: "ipfs://" + cid, Hash: Hash(document)}, nil
+}
+
+func (s *IPFSStore) Get(ctx context.Context, uri string) ([]byte, error) {
+	cid, err := cidFromURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.gatewayURL+"/ipfs/"+cid, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ipfs gateway returned %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// Verify recomputes the CID of document without storing it and compares it with the URI
+func (s *IPFSStore) Verify(ctx context.Context, uri string, document []byte) (bool, error) {
+	cid, err := cidFromURI(uri)
+	if err != nil {
+		return false, err
+	}
+
+	computed, err := s.add(ctx, document, true)
+	if err != nil {
+		return false, err
+	}
+
+	return computed == cid, nil
+}
+
+func (s *IPFSStore) add(ctx context.Context, document []byte, onlyHash bool) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", "metadata.json")
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(document); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v0/add?cid-version=1&pin=%t&only-hash=%t", s.apiURL, !onlyHash, onlyHash)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ipfs add returned %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Hash string `json:"Hash"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.Hash == "" {
+		return "", errors.New("ipfs add returned no hash")
+	}
+
+	return result.Hash, nil
+}
+
+func cidFromURI(uri string) (string, error) {
+	if !strings.HasPrefix(uri, "ipfs://") {
+		return "", ErrUnsupportedURI
+	}
+
+	cid := strings.TrimPrefix(strings.TrimPrefix(uri, "ipfs://"), "ipfs/")
+	if cid == "" || strings.ContainsAny(cid, "/?#") {
+		return "", ErrUnsupportedURI
+	}
+
+	return cid, nil
+}
@@ -0,0 +1,105 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var hashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// LocalStore keeps documents on local disk, named by their sha256 hash.
+// Documents are served by this API at {publicURL}/api/metadata/{hash}.
+type LocalStore struct {
+	dir       string
+	publicURL string
+}
+
+// NewLocalStore creates the storage directory if needed
+func NewLocalStore(dir, publicURL string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	if publicURL == "" {
+		publicURL = "http://localhost:8080"
+	}
+
+	return &LocalStore{dir: dir, publicURL: strings.TrimRight(publicURL, "/")}, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, document []byte) (Document, error) {
+	hash := Hash(document)
+	path := filepath.Join(s.dir, hash+".json")
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		// Write to a temp file first so readers never see a partial document
+		tmp, err := os.CreateTemp(s.dir, hash+".*.tmp")
+		if err != nil {
+			return Document{}, err
+		}
+		if _, err := tmp.Write(document); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return Document{}, err
+		}
+		if err := tmp.Close(); err != nil {
+			os.Remove(tmp.Name())
+			return Document{}, err
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return Document{}, err
+		}
+	}
+
+	return Document{URI: s.publicURL + "/api/metadata/" + hash, Hash: hash}, nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, uri string) ([]byte, error) {
+	hash, err := s.hashFromURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetByHash(hash)
+}
+
+// GetByHash reads a document by its sha256 hash
+func (s *LocalStore) GetByHash(hash string) ([]byte, error) {
+	if !hashPattern.MatchString(hash) {
+		return nil, ErrNotFound
+	}
+
+	document, err := os.ReadFile(filepath.Join(s.dir, hash+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return document, err
+}
+
+func (s *LocalStore) Verify(ctx context.Context, uri string, document []byte) (bool, error) {
+	hash, err := s.hashFromURI(uri)
+	if err != nil {
+		return false, err
+	}
+
+	return Hash(document) == hash, nil
+}
+
+func (s *LocalStore) hashFromURI(uri string) (string, error) {
+	prefix := s.publicURL + "/api/metadata/"
+	if !strings.HasPrefix(uri, prefix) {
+		return "", ErrUnsupportedURI
+	}
+
+	hash := strings.TrimPrefix(uri, prefix)
+	if !hashPattern.MatchString(hash) {
+		return "", ErrUnsupportedURI
+	}
+
+	return hash, nil
+}
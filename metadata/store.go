@@ -0,0 +1,123 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+var (
+	ErrNotFound       = errors.New("metadata document not found")
+	ErrUnsupportedURI = errors.New("metadata URI is not served by this store")
+)
+
+// Document describes a stored metadata document
+type Document struct {
+	URI  string `json:"uri"`
+	Hash string `json:"hash"`
+}
+
+// Store is a content-addressed document store. The URI returned by Put is
+// derived from the document bytes, so the same document always gets the same URI.
+type Store interface {
+	// Put stores a canonical JSON document and returns its URI and sha256 hash
+	Put(ctx context.Context, document []byte) (Document, error)
+	// Get fetches the raw document behind a URI returned by Put
+	Get(ctx context.Context, uri string) ([]byte, error)
+	// Verify reports whether document is the content addressed by uri
+	Verify(ctx context.Context, uri string, document []byte) (bool, error)
+}
+
+var store Store
+
+// Init configures the store from METADATA_STORE ("local" or "ipfs", default "local")
+func Init() error {
+	switch os.Getenv("METADATA_STORE") {
+	case "", "local":
+		dir := os.Getenv("METADATA_DIR")
+		if dir == "" {
+			dir = "data/metadata"
+		}
+
+		local, err := NewLocalStore(dir, os.Getenv("METADATA_PUBLIC_URL"))
+		if err != nil {
+			return err
+		}
+		store = local
+	case "ipfs":
+		apiURL := os.Getenv("IPFS_API_URL")
+		if apiURL == "" {
+			return errors.New("IPFS_API_URL must be set when METADATA_STORE=ipfs")
+		}
+		store = NewIPFSStore(apiURL, os.Getenv("IPFS_GATEWAY_URL"))
+	default:
+		return errors.New("METADATA_STORE must be local or ipfs")
+	}
+
+	return nil
+}
+
+// Default returns the configured store, or nil if Init has not run
+func Default() Store {
+	return store
+}
+
+// SetDefault replaces the configured store
+func SetDefault(s Store) {
+	store = s
+}
+
+// Canonicalize encodes v as canonical JSON: object keys sorted, no insignificant
+// whitespace, numbers kept exactly as written
+func Canonicalize(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(generic); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// Hash returns the hex sha256 of a document
+func Hash(document []byte) string {
+	sum := sha256.Sum256(document)
+	return hex.EncodeToString(sum[:])
+}
+
+// PutJSON canonicalizes v and stores it in the default store
+func PutJSON(ctx context.Context, v interface{}) (Document, []byte, error) {
+	if store == nil {
+		return Document{}, nil, errors.New("metadata store not initialized")
+	}
+
+	document, err := Canonicalize(v)
+	if err != nil {
+		return Document{}, nil, err
+	}
+
+	stored, err := store.Put(ctx, document)
+	if err != nil {
+		return Document{}, nil, err
+	}
+
+	return stored, document, nil
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader carries a merchant API key. Keys are also accepted as a Bearer token.
+const APIKeyHeader = "X-API-Key"
+
+const apiKeyKey = "apiKey"
+
+// OptionalAPIKey leaves anonymous and wallet requests alone, but a request that
+// presents an API key must carry a valid key with scope. Handlers then limit the
+// request to the key's merchant through APIKeyMerchant.
+func OptionalAPIKey(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if presentedAPIKey(ctx) == "" {
+			ctx.Next()
+			return
+		}
+
+		if authenticateAPIKey(ctx, scope) {
+			ctx.Next()
+		}
+	}
+}
+
+// RequireMerchantAuth accepts either an API key with scope or a signed in wallet.
+// Handlers check the wallet's ownership themselves since the merchant often comes
+// from the request body.
+func RequireMerchantAuth(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if presentedAPIKey(ctx) != "" {
+			if authenticateAPIKey(ctx, scope) {
+				ctx.Next()
+			}
+			return
+		}
+
+		if _, ok := authenticate(ctx); ok {
+			ctx.Next()
+		}
+	}
+}
+
+// APIKeyMerchant returns the merchant of the API key the request was made with
+func APIKeyMerchant(ctx *gin.Context) (string, bool) {
+	value, exists := ctx.Get(apiKeyKey)
+	if !exists {
+		return "", false
+	}
+
+	apiKey, ok := value.(*models.APIKeyDB)
+	if !ok {
+		return "", false
+	}
+
+	return apiKey.MerchantId, true
+}
+
+func presentedAPIKey(ctx *gin.Context) string {
+	if key := ctx.GetHeader(APIKeyHeader); key != "" {
+		return key
+	}
+
+	if token, found := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer "); found {
+		if token = strings.TrimSpace(token); services.IsAPIKey(token) {
+			return token
+		}
+	}
+
+	return ""
+}
+
+func authenticateAPIKey(ctx *gin.Context, scope string) bool {
+	apiKey, err := services.AuthenticateAPIKey(presentedAPIKey(ctx), ctx.ClientIP())
+	if err != nil {
+		apierror.Abort(ctx, err)
+		return false
+	}
+
+	if !services.APIKeyHasScope(apiKey, scope) {
+		apierror.Abort(ctx, apierror.New(http.StatusForbidden, apierror.CodePermissionDenied, "API key is missing scope "+scope).
+			WithMeta("scope", scope))
+		return false
+	}
+
+	ctx.Set(apiKeyKey, apiKey)
+	return true
+}
@@ -0,0 +1,159 @@
+// Package middleware holds the Gin middleware shared by the route groups
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/auth"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// SessionCookie carries the session token for browser clients
+const SessionCookie = "sm_session"
+
+// Browsers cannot set headers on a WebSocket upgrade, so they offer StreamProtocol
+// and the session token as a second subprotocol, "bearer.<token>". The server
+// only ever selects StreamProtocol, so the token is not echoed back.
+const (
+	StreamProtocol      = "stablemarket.v1"
+	streamTokenProtocol = "bearer."
+)
+
+const walletKey = "walletAddress"
+
+// RequireWallet rejects requests without a valid session token and records the
+// signed in wallet on the context. The token is read from the Authorization
+// header or the session cookie; WebSocket upgrades may also pass it as a
+// subprotocol (see StreamProtocol). Tokens are never read from the URL, which
+// ends up in access logs and browser history.
+func RequireWallet() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, ok := authenticate(ctx); ok {
+			ctx.Next()
+		}
+	}
+}
+
+// OptionalWallet records the signed in wallet on the context when the request
+// carries a valid session token, and lets anonymous requests and stale sessions
+// through unchanged
+func OptionalWallet() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if token := sessionToken(ctx); token != "" {
+			if claims, err := auth.ParseToken(token); err == nil {
+				ctx.Set(walletKey, claims.Address())
+			}
+		}
+		ctx.Next()
+	}
+}
+
+// RequireMerchantOwner allows only the owner of the merchant named by the given
+// path parameter, signing the request in as RequireWallet does
+func RequireMerchantOwner(param string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		wallet, ok := authenticate(ctx)
+		if !ok {
+			return
+		}
+
+		if _, err := services.AuthorizeMerchant(ctx.Param(param), wallet); err != nil {
+			apierror.Abort(ctx, err)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequireOrderMerchantOwner allows only the owner of the merchant an order
+// belongs to, signing the request in as RequireWallet does
+func RequireOrderMerchantOwner(param string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		wallet, ok := authenticate(ctx)
+		if !ok {
+			return
+		}
+
+		orderId := ctx.Param(param)
+		if !strings.HasPrefix(orderId, "0x") {
+			orderId = "0x" + orderId
+		}
+
+		order, err := services.GetOrder(orderId)
+		if err != nil {
+			apierror.Abort(ctx, err)
+			return
+		}
+
+		if _, err := services.AuthorizeMerchant(order.MerchantId, wallet); err != nil {
+			apierror.Abort(ctx, err)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// Wallet returns the signed in wallet recorded by RequireWallet
+func Wallet(ctx *gin.Context) (common.Address, bool) {
+	value, exists := ctx.Get(walletKey)
+	if !exists {
+		return common.Address{}, false
+	}
+
+	wallet, ok := value.(common.Address)
+	return wallet, ok
+}
+
+// authenticate records the session's wallet on the context, aborting with 401
+// when there is no valid session
+func authenticate(ctx *gin.Context) (common.Address, bool) {
+	if wallet, ok := Wallet(ctx); ok {
+		return wallet, true
+	}
+
+	token := sessionToken(ctx)
+	if token == "" {
+		apierror.Abort(ctx, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Sign in required"))
+		return common.Address{}, false
+	}
+
+	claims, err := auth.ParseToken(token)
+	if err != nil {
+		apierror.Abort(ctx, apierror.New(http.StatusUnauthorized, apierror.CodeInvalidSession, "Invalid or expired session"))
+		return common.Address{}, false
+	}
+
+	wallet := claims.Address()
+	ctx.Set(walletKey, wallet)
+	return wallet, true
+}
+
+func sessionToken(ctx *gin.Context) string {
+	if header := ctx.GetHeader("Authorization"); header != "" {
+		// API keys share the Bearer scheme but are not session tokens
+		if token, found := strings.CutPrefix(header, "Bearer "); found && !services.IsAPIKey(strings.TrimSpace(token)) {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+
+	if cookie, err := ctx.Cookie(SessionCookie); err == nil && cookie != "" {
+		return cookie
+	}
+
+	if strings.EqualFold(ctx.GetHeader("Upgrade"), "websocket") {
+		for _, protocol := range strings.Split(ctx.GetHeader("Sec-WebSocket-Protocol"), ",") {
+			if token, found := strings.CutPrefix(strings.TrimSpace(protocol), streamTokenProtocol); found {
+				return token
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission allows only wallets holding a role that grants permission,
+// signing the request in as RequireWallet does
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		wallet, ok := authenticate(ctx)
+		if !ok {
+			return
+		}
+
+		if err := services.CheckPermission(wallet, permission); err != nil {
+			apierror.Abort(ctx, err)
+			return
+		}
+
+		ctx.Next()
+	}
+}
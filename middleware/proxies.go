@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"os"
+	"strings"
+)
+
+// TrustedProxies returns the proxies whose X-Forwarded-For headers are believed
+// (TRUSTED_PROXIES, comma separated IPs or CIDRs). It is nil by default, so the
+// client IP used for API key allowlists and rate limits is the peer address and
+// cannot be spoofed with a header.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/auth"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// GroupRateLimits holds one limiter per budget for a route group
+type GroupRateLimits struct {
+	Read   gin.HandlerFunc
+	Write  gin.HandlerFunc
+	RPC    gin.HandlerFunc
+	Upload gin.HandlerFunc
+}
+
+// RateLimits builds the limiters for a route group. Buckets are separate per group
+// and budget, and limits are read through ratelimit.LimitFor.
+func RateLimits(group string) GroupRateLimits {
+	return GroupRateLimits{
+		Read:   RateLimit(group, ratelimit.BudgetRead),
+		Write:  RateLimit(group, ratelimit.BudgetWrite),
+		RPC:    RateLimit(group, ratelimit.BudgetRPC),
+		Upload: RateLimit(group, ratelimit.BudgetUpload),
+	}
+}
+
+// RateLimit takes a token from the caller's bucket for budget, answering 429 with
+// Retry-After once it is empty. Callers are identified by API key, then by signed
+// in wallet, then by IP address.
+func RateLimit(group, budget string) gin.HandlerFunc {
+	limit, enabled := ratelimit.LimitFor(group, budget)
+
+	return func(ctx *gin.Context) {
+		store := ratelimit.Default()
+		if !enabled || store == nil {
+			ctx.Next()
+			return
+		}
+
+		key := group + ":" + budget + ":" + rateLimitIdentity(ctx)
+		result, err := store.Take(ctx.Request.Context(), key, limit, time.Now())
+		if err != nil {
+			// A store outage should not take the API down with it
+			log.Printf("ratelimit: %v", err)
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		ctx.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+
+			ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+			apierror.Abort(ctx, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited,
+				"Too many requests, retry after "+strconv.Itoa(retryAfter)+"s").WithMeta("retryAfter", retryAfter))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// MaxBodySize caps the request body; reads past the limit fail and the handler
+// reports a bad request
+func MaxBodySize(bytes int64) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.ContentLength > bytes {
+			apierror.Abort(ctx, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge,
+				"Request body exceeds "+strconv.FormatInt(bytes>>20, 10)+"MB"))
+			return
+		}
+
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, bytes)
+		ctx.Next()
+	}
+}
+
+// rateLimitIdentity only trusts credentials that have been verified, so made up
+// keys or tokens fall back to the IP and cannot be used to get fresh buckets. API
+// keys are verified by the auth middleware, which must run first on key routes.
+func rateLimitIdentity(ctx *gin.Context) string {
+	if value, exists := ctx.Get(apiKeyKey); exists {
+		if apiKey, ok := value.(*models.APIKeyDB); ok {
+			return "key:" + strconv.FormatInt(apiKey.Id, 10)
+		}
+	}
+
+	if wallet, ok := Wallet(ctx); ok {
+		return "wallet:" + wallet.Hex()
+	}
+
+	// Session tokens can be checked without a database round trip
+	if token := sessionToken(ctx); token != "" {
+		if claims, err := auth.ParseToken(token); err == nil {
+			return "wallet:" + claims.Address().Hex()
+		}
+	}
+
+	return "ip:" + ctx.ClientIP()
+}
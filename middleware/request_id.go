@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// requestIdPattern limits the ids accepted from clients or proxies so they are
+// safe to echo back and write to logs
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID tags every request with an id, reusing the caller's X-Request-ID when
+// it is well formed. The id is echoed in the response header and in error bodies.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestId := ctx.GetHeader(apierror.RequestIDHeader)
+		if !requestIdPattern.MatchString(requestId) {
+			requestId = newRequestId()
+		}
+
+		ctx.Set(apierror.RequestIDKey, requestId)
+		ctx.Header(apierror.RequestIDHeader, requestId)
+		ctx.Next()
+	}
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,88 @@
+package models
+
+// Analytics grouping periods
+const (
+	AnalyticsGroupDay   = "day"
+	AnalyticsGroupWeek  = "week"
+	AnalyticsGroupMonth = "month"
+)
+
+// AnalyticsRow is a row of the merchant_analytics database function
+type AnalyticsRow struct {
+	Period          string `json:"period"`
+	Network         string `json:"network"`
+	TokenAddress    string `json:"tokenAddress"`
+	OrdersCreated   int64  `json:"ordersCreated"`
+	OrdersPaid      int64  `json:"ordersPaid"`
+	OrdersSettled   int64  `json:"ordersSettled"`
+	OrdersRefunded  int64  `json:"ordersRefunded"`
+	OrdersCancelled int64  `json:"ordersCancelled"`
+	GrossVolume     string `json:"grossVolume"`
+	RefundedVolume  string `json:"refundedVolume"`
+}
+
+// OrderCounts counts the orders that reached each status in a period
+type OrderCounts struct {
+	Created   int64 `json:"created"`
+	Paid      int64 `json:"paid"`
+	Settled   int64 `json:"settled"`
+	Refunded  int64 `json:"refunded"`
+	Cancelled int64 `json:"cancelled"`
+}
+
+// AnalyticsMetrics holds the order counts of a period with the rates derived
+// from them. ConversionRate is paid over created orders and RefundRate refunded
+// over paid orders, both counted within the period.
+type AnalyticsMetrics struct {
+	Orders         OrderCounts `json:"orders"`
+	ConversionRate float64     `json:"conversionRate"`
+	RefundRate     float64     `json:"refundRate"`
+}
+
+// TokenAnalytics holds the metrics of one token on one network. Volumes are in
+// token base units; NetVolume is gross volume less refunds.
+type TokenAnalytics struct {
+	Network      string `json:"network"`
+	TokenAddress string `json:"tokenAddress"`
+	Symbol       string `json:"symbol,omitempty"`
+	Decimals     *uint8 `json:"decimals,omitempty"`
+	AnalyticsMetrics
+	GrossVolume       string `json:"grossVolume"`
+	RefundedVolume    string `json:"refundedVolume"`
+	NetVolume         string `json:"netVolume"`
+	AverageOrderValue string `json:"averageOrderValue"`
+}
+
+// AnalyticsSummary holds the metrics across all tokens and the per token breakdown
+type AnalyticsSummary struct {
+	AnalyticsMetrics
+	Tokens []TokenAnalytics `json:"tokens"`
+}
+
+type AnalyticsPeriod struct {
+	Start string `json:"start"`
+	AnalyticsSummary
+}
+
+// ProductSales is a product's paid sales in one token
+type ProductSales struct {
+	ProductId    int64  `json:"productId"`
+	Name         string `json:"name"`
+	Network      string `json:"network"`
+	TokenAddress string `json:"tokenAddress"`
+	Symbol       string `json:"symbol,omitempty"`
+	Decimals     *uint8 `json:"decimals,omitempty"`
+	Quantity     int64  `json:"quantity"`
+	SalesVolume  string `json:"salesVolume"`
+	OrderCount   int64  `json:"orderCount"`
+}
+
+type MerchantAnalyticsResponse struct {
+	MerchantId  string            `json:"merchantId"`
+	GroupBy     string            `json:"groupBy"`
+	From        string            `json:"from"`
+	To          string            `json:"to"`
+	Totals      AnalyticsSummary  `json:"totals"`
+	Periods     []AnalyticsPeriod `json:"periods"`
+	TopProducts []ProductSales    `json:"topProducts"`
+}
@@ -0,0 +1,56 @@
+package models
+
+// API key scopes
+const (
+	APIKeyScopeOrdersRead    = "orders:read"
+	APIKeyScopeOrdersWrite   = "orders:write"
+	APIKeyScopeProductsWrite = "products:write"
+)
+
+// APIKeyScopes lists every scope a key can be granted
+var APIKeyScopes = []string{
+	APIKeyScopeOrdersRead,
+	APIKeyScopeOrdersWrite,
+	APIKeyScopeProductsWrite,
+}
+
+// APIKeyDB is a stored key. Only the sha256 of the key is kept; the prefix is
+// enough for the merchant to tell keys apart.
+type APIKeyDB struct {
+	Id         int64    `json:"id,omitempty"`
+	MerchantId string   `json:"merchantId"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	KeyHash    string   `json:"keyHash,omitempty"`
+	Scopes     []string `json:"scopes"`
+	AllowedIPs []string `json:"allowedIps"`
+	ExpiresAt  *string  `json:"expiresAt,omitempty"`
+	RevokedAt  *string  `json:"revokedAt,omitempty"`
+	LastUsedAt *string  `json:"lastUsedAt,omitempty"`
+	LastUsedIP *string  `json:"lastUsedIp,omitempty"`
+	CreatedBy  string   `json:"createdBy"`
+	CreatedAt  string   `json:"createdAt,omitempty"`
+}
+
+// APIKey is a key as shown to the merchant; Key is only set when it is created
+type APIKey struct {
+	Id         int64    `json:"id"`
+	MerchantId string   `json:"merchantId"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Key        string   `json:"key,omitempty"`
+	Scopes     []string `json:"scopes"`
+	AllowedIPs []string `json:"allowedIps"`
+	ExpiresAt  *string  `json:"expiresAt,omitempty"`
+	RevokedAt  *string  `json:"revokedAt,omitempty"`
+	LastUsedAt *string  `json:"lastUsedAt,omitempty"`
+	LastUsedIP *string  `json:"lastUsedIp,omitempty"`
+	CreatedAt  string   `json:"createdAt"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	Scopes     []string `json:"scopes" binding:"required"`
+	AllowedIPs []string `json:"allowedIps"`
+	ExpiresAt  string   `json:"expiresAt"`
+}
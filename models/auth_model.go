@@ -0,0 +1,28 @@
+package models
+
+type AuthNonceDB struct {
+	Nonce     string  `json:"nonce"`
+	ExpiresAt string  `json:"expiresAt"`
+	UsedAt    *string `json:"usedAt,omitempty"`
+	UsedBy    *string `json:"usedBy,omitempty"`
+	CreatedAt string  `json:"createdAt,omitempty"`
+}
+
+type AuthNonceResponse struct {
+	Nonce     string  `json:"nonce"`
+	ExpiresAt string  `json:"expiresAt"`
+	Domain    string  `json:"domain"`
+	ChainIds  []int64 `json:"chainIds"`
+}
+
+type VerifySIWERequest struct {
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+type AuthSessionResponse struct {
+	Token     string `json:"token"`
+	Address   string `json:"address"`
+	ChainId   int64  `json:"chainId"`
+	ExpiresAt string `json:"expiresAt"`
+}
@@ -0,0 +1,53 @@
+package models
+
+// Balance history bucket sizes
+const (
+	BalanceBucketHour = "hour"
+	BalanceBucketDay  = "day"
+)
+
+// BalanceSnapshotDB is one holder's balance of a token at a block
+type BalanceSnapshotDB struct {
+	Network       string `json:"network"`
+	TokenAddress  string `json:"tokenAddress"`
+	HolderAddress string `json:"holderAddress"`
+	BlockNumber   int64  `json:"blockNumber"`
+	Balance       string `json:"balance"`
+	CapturedAt    string `json:"capturedAt,omitempty"`
+}
+
+// BalanceHistoryRow is a row of the balance_history database function
+type BalanceHistoryRow struct {
+	Network       string `json:"network"`
+	TokenAddress  string `json:"tokenAddress"`
+	HolderAddress string `json:"holderAddress"`
+	Bucket        string `json:"bucket"`
+	Balance       string `json:"balance"`
+	BlockNumber   int64  `json:"blockNumber"`
+}
+
+// BalancePoint is the closing balance of a bucket
+type BalancePoint struct {
+	Time        string `json:"time"`
+	Balance     string `json:"balance"`
+	Formatted   string `json:"formatted,omitempty"`
+	BlockNumber int64  `json:"blockNumber"`
+}
+
+// BalanceSeries is one holder's balance of one token over time. Buckets without
+// a snapshot are left out.
+type BalanceSeries struct {
+	Network       string         `json:"network"`
+	HolderAddress string         `json:"holderAddress"`
+	TokenAddress  string         `json:"tokenAddress"`
+	Symbol        string         `json:"symbol,omitempty"`
+	Decimals      *uint8         `json:"decimals,omitempty"`
+	Points        []BalancePoint `json:"points"`
+}
+
+type BalanceHistoryResponse struct {
+	Bucket string          `json:"bucket"`
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Series []BalanceSeries `json:"series"`
+}
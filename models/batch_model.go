@@ -0,0 +1,51 @@
+package models
+
+// MaxBatchOrders caps the orders of one batch settle or refund request
+const MaxBatchOrders = 50
+
+// Batch actions
+const (
+	BatchActionSettle = "settle"
+	BatchActionRefund = "refund"
+)
+
+// PrepareBatchOrdersRequest lists the orders of a batch; repeated ids are dropped
+type PrepareBatchOrdersRequest struct {
+	OrderIds []string `json:"orderIds" binding:"required,min=1"`
+}
+
+// ConfirmBatchOrdersRequest lists the orders of a batch with the hashes of the
+// transactions sent for them, in any order. One transaction may cover several orders.
+type ConfirmBatchOrdersRequest struct {
+	OrderIds          []string `json:"orderIds" binding:"required,min=1"`
+	TransactionHashes []string `json:"transactionHashes" binding:"required,min=1"`
+}
+
+// BatchOrderError explains why one order of a batch was rejected
+type BatchOrderError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// BatchOrderResult is the outcome for one order of a batch. Prepared orders
+// carry their transaction, confirmed ones the transaction that applied them.
+type BatchOrderResult struct {
+	OrderId         string           `json:"orderId"`
+	Success         bool             `json:"success"`
+	Status          string           `json:"status,omitempty"`
+	TransactionData *TransactionData `json:"transactionData,omitempty"`
+	TransactionHash string           `json:"transactionHash,omitempty"`
+	BlockNumber     uint64           `json:"blockNumber,omitempty"`
+	ExplorerURL     string           `json:"explorerUrl,omitempty"`
+	Event           *OrderEvent      `json:"event,omitempty"`
+	Settlement      *SettlementDB    `json:"settlement,omitempty"`
+	Error           *BatchOrderError `json:"error,omitempty"`
+}
+
+type BatchOrdersResponse struct {
+	Action    string             `json:"action"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Orders    []BatchOrderResult `json:"orders"`
+	Message   string             `json:"message"`
+}
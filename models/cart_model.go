@@ -0,0 +1,125 @@
+package models
+
+// Cart statuses for the carts table
+const (
+	CartStatusOpen       = "open"
+	CartStatusCheckedOut = "checked_out"
+	CartStatusOrdered    = "ordered"
+)
+
+// CartDB is a cart row. CheckoutSessionId is the session paying for the cart,
+// which holds it while the session can be completed. A checked out cart keeps
+// its checkout until CheckoutExpiresAt, and only an order created with the
+// checkout's MetadataURI is accepted for it.
+type CartDB struct {
+	Id                string  `json:"id"`
+	MerchantId        string  `json:"merchantId"`
+	BuyerAddress      string  `json:"buyerAddress"`
+	Status            string  `json:"status"`
+	ReservationId     string  `json:"reservationId,omitempty"`
+	OrderId           *string `json:"orderId,omitempty"`
+	CheckoutSessionId *string `json:"checkoutSessionId,omitempty"`
+	CheckoutExpiresAt *string `json:"checkoutExpiresAt,omitempty"`
+	MetadataURI       string  `json:"metadataURI,omitempty"`
+	CreatedAt         string  `json:"createdAt,omitempty"`
+	UpdatedAt         *string `json:"updatedAt,omitempty"`
+}
+
+type CartItemDB struct {
+	Id        int64  `json:"id,omitempty"`
+	CartId    string `json:"cartId"`
+	ProductId int64  `json:"productId"`
+	Quantity  int64  `json:"quantity"`
+}
+
+// OrderItemDB is a priced line item, written at checkout and attached to the
+// order once it is confirmed on-chain
+type OrderItemDB struct {
+	Id              int64   `json:"id,omitempty"`
+	OrderId         *string `json:"orderId"`
+	CartId          string  `json:"cartId"`
+	ProductId       int64   `json:"productId"`
+	Name            string  `json:"name"`
+	UnitPrice       string  `json:"unitPrice"`
+	Quantity        int64   `json:"quantity"`
+	LineTotal       string  `json:"lineTotal"`
+	LineTotalAmount string  `json:"lineTotalAmount"`
+	TokenAddress    string  `json:"tokenAddress"`
+}
+
+type CreateCartRequest struct {
+	MerchantId   string `json:"merchantId"`
+	BuyerAddress string `json:"buyerAddress"`
+}
+
+type CartItemRequest struct {
+	ProductId int64 `json:"productId" binding:"required"`
+	Quantity  int64 `json:"quantity" binding:"required"`
+}
+
+type UpdateCartItemRequest struct {
+	Quantity *int64 `json:"quantity" binding:"required"`
+}
+
+type CartLine struct {
+	ProductId int64  `json:"productId"`
+	Name      string `json:"name"`
+	ImageUrl  string `json:"imageUrl"`
+	UnitPrice string `json:"unitPrice"`
+	Quantity  int64  `json:"quantity"`
+	LineTotal string `json:"lineTotal"`
+}
+
+type CartResponse struct {
+	CartDB
+	Items     []CartLine `json:"items"`
+	ItemCount int64      `json:"itemCount"`
+	Subtotal  string     `json:"subtotal"`
+}
+
+type CheckoutCartRequest struct {
+	TokenAddress string `json:"tokenAddress" binding:"required"`
+	BuyerAddress string `json:"buyerAddress"`
+}
+
+// OrderMetadata is the metadata document referenced by an order's metadataURI
+type OrderMetadata struct {
+	Type      string                  `json:"type"`
+	Version   int                     `json:"version"`
+	Merchant  OrderMetadataMerchant   `json:"merchant"`
+	Buyer     string                  `json:"buyer,omitempty"`
+	LineItems []OrderMetadataLineItem `json:"lineItems"`
+	Currency  OrderMetadataCurrency   `json:"currency"`
+	Total     string                  `json:"total"`
+	Amount    string                  `json:"amount"`
+	CartId    string                  `json:"cartId,omitempty"`
+	CreatedAt string                  `json:"createdAt"`
+}
+
+type OrderMetadataMerchant struct {
+	MerchantId    string `json:"merchantId"`
+	Name          string `json:"name"`
+	PayoutAddress string `json:"payoutAddress"`
+}
+
+type OrderMetadataLineItem struct {
+	ProductId       int64  `json:"productId"`
+	Name            string `json:"name"`
+	Quantity        int64  `json:"quantity"`
+	UnitPrice       string `json:"unitPrice"`
+	LineTotal       string `json:"lineTotal"`
+	LineTotalAmount string `json:"lineTotalAmount"`
+}
+
+type OrderMetadataCurrency struct {
+	TokenAddress string `json:"tokenAddress"`
+	Decimals     uint8  `json:"decimals"`
+	Network      string `json:"network"`
+	ChainId      int64  `json:"chainId"`
+}
+
+type CheckoutCartResponse struct {
+	PrepareCreateOrderResponse
+	CartId   string        `json:"cartId"`
+	Metadata OrderMetadata `json:"metadata"`
+}
@@ -0,0 +1,102 @@
+package models
+
+// Checkout session statuses, in the order a payer moves through them
+const (
+	CheckoutStatusOpen         = "open"
+	CheckoutStatusApproved     = "approved"
+	CheckoutStatusOrderPending = "order_pending"
+	CheckoutStatusOrderCreated = "order_created"
+	CheckoutStatusPaid         = "paid"
+	CheckoutStatusExpired      = "expired"
+)
+
+type CheckoutSessionDB struct {
+	Id            string  `json:"id"`
+	MerchantId    string  `json:"merchantId"`
+	CartId        *string `json:"cartId,omitempty"`
+	TokenAddress  string  `json:"tokenAddress"`
+	Amount        string  `json:"amount"`
+	Network       string  `json:"network"`
+	Status        string  `json:"status"`
+	MetadataURI   *string `json:"metadataURI,omitempty"`
+	ReservationId *string `json:"reservationId,omitempty"`
+	OrderId       *string `json:"orderId,omitempty"`
+	PayerAddress  *string `json:"payerAddress,omitempty"`
+	SuccessUrl    string  `json:"successUrl,omitempty"`
+	CancelUrl     string  `json:"cancelUrl,omitempty"`
+	ExpiresAt     string  `json:"expiresAt"`
+	CreatedAt     string  `json:"createdAt,omitempty"`
+	UpdatedAt     *string `json:"updatedAt,omitempty"`
+	// OrderExpiresIn is how many seconds the session's order may stay unpaid
+	OrderExpiresIn *int64 `json:"orderExpiresIn,omitempty"`
+}
+
+// CreateCheckoutSessionRequest needs either an amount in token base units or a cartId
+type CreateCheckoutSessionRequest struct {
+	MerchantId   string `json:"merchantId"`
+	Amount       string `json:"amount"`
+	CartId       string `json:"cartId"`
+	TokenAddress string `json:"tokenAddress" binding:"required"`
+	Network      string `json:"network"`
+	ExpiresIn    int64  `json:"expiresIn"`
+	SuccessUrl   string `json:"successUrl"`
+	CancelUrl    string `json:"cancelUrl"`
+	// OrderExpiresIn overrides the merchant's order expiry, in seconds, for
+	// the order created from the session
+	OrderExpiresIn int64 `json:"orderExpiresIn"`
+}
+
+type CheckoutSessionResponse struct {
+	CheckoutSessionDB
+	URL        string `json:"url"`
+	PaymentURI string `json:"paymentUri"`
+	QRCode     string `json:"qrCode,omitempty"`
+	NextStep   string `json:"nextStep,omitempty"`
+}
+
+type CheckoutStepRequest struct {
+	BuyerAddress    string `json:"buyerAddress"`
+	PayerAddress    string `json:"payerAddress"`
+	TransactionHash string `json:"transactionHash"`
+}
+
+// CheckoutStep is the session state returned with every checkout step
+type CheckoutStep struct {
+	SessionId     string `json:"sessionId"`
+	SessionStatus string `json:"sessionStatus"`
+	NextStep      string `json:"nextStep,omitempty"`
+	RedirectUrl   string `json:"redirectUrl,omitempty"`
+}
+
+type CheckoutApproveResponse struct {
+	PrepareApproveResponse
+	CheckoutStep
+}
+
+type CheckoutConfirmApproveResponse struct {
+	ConfirmApproveResponse
+	CheckoutStep
+}
+
+// CheckoutOrderResponse carries the cart and its order document for cart sessions
+type CheckoutOrderResponse struct {
+	PrepareCreateOrderResponse
+	CartId   string         `json:"cartId,omitempty"`
+	Metadata *OrderMetadata `json:"metadata,omitempty"`
+	CheckoutStep
+}
+
+type CheckoutConfirmOrderResponse struct {
+	ConfirmCreateOrderResponse
+	CheckoutStep
+}
+
+type CheckoutPayResponse struct {
+	PreparePayOrderResponse
+	CheckoutStep
+}
+
+type CheckoutConfirmPaymentResponse struct {
+	ConfirmPayOrderResponse
+	CheckoutStep
+}
@@ -0,0 +1,107 @@
+package models
+
+// Dispute reasons a buyer can give
+const (
+	DisputeReasonNotReceived    = "not_received"
+	DisputeReasonNotAsDescribed = "not_as_described"
+	DisputeReasonUnauthorized   = "unauthorized"
+	DisputeReasonOther          = "other"
+)
+
+// Dispute statuses. A dispute is open until the merchant responds and stays
+// responded until a reviewer resolves it.
+const (
+	DisputeStatusOpen      = "open"
+	DisputeStatusResponded = "responded"
+	DisputeStatusResolved  = "resolved"
+)
+
+// Dispute outcomes a reviewer can decide on
+const (
+	DisputeOutcomeRefund = "refund"
+	DisputeOutcomeSettle = "settle"
+)
+
+// Dispute event actions
+const (
+	DisputeActionOpened    = "opened"
+	DisputeActionEvidence  = "evidence"
+	DisputeActionResponded = "responded"
+	DisputeActionResolved  = "resolved"
+	DisputeActionRefunded  = "refunded"
+	DisputeActionSettled   = "settled"
+)
+
+// Parties to a dispute
+const (
+	DisputePartyBuyer    = "buyer"
+	DisputePartyMerchant = "merchant"
+	DisputePartyPlatform = "platform"
+)
+
+// MaxDisputeEvidence caps the evidence images of one dispute
+const MaxDisputeEvidence = 10
+
+type DisputeDB struct {
+	Id             int64  `json:"id,omitempty"`
+	OrderId        string `json:"orderId"`
+	MerchantId     string `json:"merchantId"`
+	BuyerAddress   string `json:"buyerAddress"`
+	Reason         string `json:"reason"`
+	Description    string `json:"description"`
+	Status         string `json:"status"`
+	Outcome        string `json:"outcome"`
+	ResolutionNote string `json:"resolutionNote"`
+	ResolvedBy     string `json:"resolvedBy"`
+	ResolvedAt     string `json:"resolvedAt,omitempty"`
+	CreatedAt      string `json:"createdAt,omitempty"`
+	UpdatedAt      string `json:"updatedAt,omitempty"`
+}
+
+// DisputeEventDB is one recorded step of a dispute. Evidence events carry the
+// uploaded image's URL.
+type DisputeEventDB struct {
+	Id          int64  `json:"id,omitempty"`
+	DisputeId   int64  `json:"disputeId"`
+	OrderId     string `json:"orderId"`
+	Action      string `json:"action"`
+	Party       string `json:"party"`
+	Actor       string `json:"actor"`
+	Message     string `json:"message"`
+	EvidenceURL string `json:"evidenceUrl"`
+	CreatedAt   string `json:"createdAt,omitempty"`
+}
+
+type OpenDisputeRequest struct {
+	Reason      string `json:"reason" binding:"required"`
+	Description string `json:"description" binding:"required,max=2000"`
+}
+
+type RespondDisputeRequest struct {
+	Message string `json:"message" binding:"required,max=2000"`
+}
+
+type ResolveDisputeRequest struct {
+	Outcome string `json:"outcome" binding:"required"`
+	Note    string `json:"note" binding:"max=2000"`
+}
+
+// DisputeDetails is a dispute with its recorded steps, oldest first
+type DisputeDetails struct {
+	DisputeDB
+	Events []DisputeEventDB `json:"events"`
+}
+
+// ResolveDisputeResponse is a resolved dispute with the refund or settlement
+// queued to carry out its decision. Attempt is left out when the order was
+// already refunded or settled.
+type ResolveDisputeResponse struct {
+	DisputeDetails
+	Attempt *SettlementAttemptDB `json:"attempt,omitempty"`
+}
+
+type DisputeListResponse struct {
+	Disputes   []DisputeDB `json:"disputes"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+	HasMore    bool        `json:"hasMore"`
+}
@@ -0,0 +1,130 @@
+package models
+
+// Export kinds
+const (
+	ExportKindOrders      = "orders"
+	ExportKindSettlements = "settlements"
+	ExportKindRefunds     = "refunds"
+	ExportKindWithdrawals = "withdrawals"
+)
+
+// Export formats
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+	ExportFormatPDF   = "pdf"
+)
+
+// Export job statuses
+const (
+	ExportJobQueued  = "queued"
+	ExportJobRunning = "running"
+	ExportJobReady   = "ready"
+	ExportJobFailed  = "failed"
+	ExportJobExpired = "expired"
+)
+
+// ExportRow is a row of the export_records database function
+type ExportRow struct {
+	Id              int64  `json:"id"`
+	Kind            string `json:"kind"`
+	Reference       string `json:"reference"`
+	MerchantId      string `json:"merchantId"`
+	Network         string `json:"network"`
+	TokenAddress    string `json:"tokenAddress"`
+	Amount          string `json:"amount"`
+	PlatformFee     string `json:"platformFee"`
+	MerchantNet     string `json:"merchantNet"`
+	Status          string `json:"status"`
+	Counterparty    string `json:"counterparty"`
+	TransactionHash string `json:"transactionHash"`
+	BlockNumber     uint64 `json:"blockNumber"`
+	RecordedAt      string `json:"recordedAt"`
+}
+
+// ExportRecord is one exported order, settlement, refund or withdrawal. Amounts
+// are token base units with their decimal form alongside; the fee and net are
+// only set on settlements.
+type ExportRecord struct {
+	Kind            string `json:"kind"`
+	Reference       string `json:"reference"`
+	MerchantId      string `json:"merchantId,omitempty"`
+	Network         string `json:"network"`
+	TokenAddress    string `json:"tokenAddress"`
+	Symbol          string `json:"symbol,omitempty"`
+	Decimals        *uint8 `json:"decimals,omitempty"`
+	Amount          string `json:"amount"`
+	AmountFormatted string `json:"amountFormatted,omitempty"`
+	PlatformFee     string `json:"platformFee,omitempty"`
+	MerchantNet     string `json:"merchantNet,omitempty"`
+	Status          string `json:"status"`
+	Counterparty    string `json:"counterparty"`
+	TransactionHash string `json:"transactionHash"`
+	BlockNumber     uint64 `json:"blockNumber,omitempty"`
+	BlockTimestamp  string `json:"blockTimestamp,omitempty"`
+	ExplorerURL     string `json:"explorerUrl,omitempty"`
+	RecordedAt      string `json:"recordedAt"`
+}
+
+// ExportTotal sums the exported records of one token on one network, in
+// decimal token units when the token is registered and base units otherwise
+type ExportTotal struct {
+	Network      string
+	TokenAddress string
+	Symbol       string
+	Records      int64
+	Amount       string
+	PlatformFee  string
+	MerchantNet  string
+}
+
+// ExportStatement is the content of a PDF statement
+type ExportStatement struct {
+	Title       string
+	Lines       []string
+	Records     []ExportRecord
+	Totals      []ExportTotal
+	GeneratedAt string
+}
+
+type ExportJobDB struct {
+	Id          int64   `json:"id,omitempty"`
+	MerchantId  string  `json:"merchantId"`
+	Kind        string  `json:"kind"`
+	Format      string  `json:"format"`
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	Status      string  `json:"status,omitempty"`
+	RowCount    int64   `json:"rowCount"`
+	FileName    string  `json:"fileName,omitempty"`
+	FileSize    int64   `json:"fileSize"`
+	Error       string  `json:"error,omitempty"`
+	CreatedBy   string  `json:"createdBy"`
+	CreatedAt   string  `json:"createdAt,omitempty"`
+	StartedAt   *string `json:"startedAt,omitempty"`
+	CompletedAt *string `json:"completedAt,omitempty"`
+	ExpiresAt   *string `json:"expiresAt,omitempty"`
+}
+
+// ExportJob is an export job as shown to its requester. The download link is
+// set once the file is ready.
+type ExportJob struct {
+	Id          int64   `json:"id"`
+	MerchantId  string  `json:"merchantId,omitempty"`
+	Kind        string  `json:"kind"`
+	Format      string  `json:"format"`
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	Status      string  `json:"status"`
+	RowCount    int64   `json:"rowCount"`
+	FileSize    int64   `json:"fileSize"`
+	Error       string  `json:"error,omitempty"`
+	DownloadURL string  `json:"downloadUrl,omitempty"`
+	CreatedAt   string  `json:"createdAt"`
+	CompletedAt *string `json:"completedAt,omitempty"`
+	ExpiresAt   *string `json:"expiresAt,omitempty"`
+}
+
+type ExportJobListResponse struct {
+	Jobs []ExportJob `json:"jobs"`
+}
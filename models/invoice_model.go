@@ -0,0 +1,80 @@
+package models
+
+// Order document types
+const (
+	DocumentTypeInvoice    = "invoice"
+	DocumentTypeCreditNote = "credit_note"
+)
+
+// Order document formats
+const (
+	DocumentFormatHTML = "html"
+	DocumentFormatPDF  = "pdf"
+	DocumentFormatJSON = "json"
+)
+
+// InvoiceLine is a line item copied onto an invoice. Amount is token base
+// units; UnitPrice is the catalog price in token units.
+type InvoiceLine struct {
+	ProductId       int64  `json:"productId,omitempty"`
+	Name            string `json:"name"`
+	Quantity        int64  `json:"quantity"`
+	UnitPrice       string `json:"unitPrice,omitempty"`
+	Amount          string `json:"amount"`
+	AmountFormatted string `json:"amountFormatted,omitempty"`
+}
+
+// InvoiceDB is an issued invoice or credit note
+type InvoiceDB struct {
+	Id                    int64         `json:"id"`
+	MerchantId            string        `json:"merchantId"`
+	DocumentType          string        `json:"documentType"`
+	Sequence              int64         `json:"sequence"`
+	InvoiceNumber         string        `json:"invoiceNumber"`
+	CreditedInvoiceNumber string        `json:"creditedInvoiceNumber"`
+	OrderId               string        `json:"orderId"`
+	MerchantName          string        `json:"merchantName"`
+	PayoutAddress         string        `json:"payoutAddress"`
+	BuyerAddress          string        `json:"buyerAddress"`
+	Network               string        `json:"network"`
+	TokenAddress          string        `json:"tokenAddress"`
+	TokenSymbol           string        `json:"tokenSymbol"`
+	TokenDecimals         *uint8        `json:"tokenDecimals"`
+	Amount                string        `json:"amount"`
+	LineItems             []InvoiceLine `json:"lineItems"`
+	TransactionHash       string        `json:"transactionHash"`
+	BlockNumber           uint64        `json:"blockNumber"`
+	BlockTimestamp        *string       `json:"blockTimestamp"`
+	IssuedAt              string        `json:"issuedAt"`
+}
+
+// Invoice is an invoice or credit note as served to the buyer and merchant
+type Invoice struct {
+	Id                    int64         `json:"id"`
+	DocumentType          string        `json:"documentType"`
+	InvoiceNumber         string        `json:"invoiceNumber"`
+	CreditedInvoiceNumber string        `json:"creditedInvoiceNumber,omitempty"`
+	OrderId               string        `json:"orderId"`
+	MerchantId            string        `json:"merchantId"`
+	MerchantName          string        `json:"merchantName"`
+	PayoutAddress         string        `json:"payoutAddress"`
+	BuyerAddress          string        `json:"buyerAddress"`
+	Network               string        `json:"network"`
+	TokenAddress          string        `json:"tokenAddress"`
+	Symbol                string        `json:"symbol,omitempty"`
+	Decimals              *uint8        `json:"decimals,omitempty"`
+	Amount                string        `json:"amount"`
+	AmountFormatted       string        `json:"amountFormatted,omitempty"`
+	LineItems             []InvoiceLine `json:"lineItems"`
+	TransactionHash       string        `json:"transactionHash"`
+	BlockNumber           uint64        `json:"blockNumber,omitempty"`
+	BlockTimestamp        string        `json:"blockTimestamp,omitempty"`
+	ExplorerURL           string        `json:"explorerUrl,omitempty"`
+	IssuedAt              string        `json:"issuedAt"`
+}
+
+type InvoiceListResponse struct {
+	Invoices   []Invoice `json:"invoices"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+	HasMore    bool      `json:"hasMore"`
+}
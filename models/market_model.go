@@ -1,11 +1,34 @@
 package models
 
+import "encoding/json"
+
 type Products struct {
+	Id          int64   `json:"id,omitempty"`
 	Name        string  `json:"name" binding:"required"`
 	Price       float64 `json:"price" binding:"required"`
 	ImageUrl    string  `json:"imageUrl" binding:"required"`
 	Description string  `json:"description" binding:"required"`
 	MerchantId  string  `json:"merchantId"`
+	Stock       *int64  `json:"stock"`
+	CreatedAt   string  `json:"createdAt,omitempty"`
+	UpdatedAt   *string `json:"updatedAt,omitempty"`
+	DeletedAt   *string `json:"deletedAt,omitempty"`
+}
+
+// ProductPrice is a product's price as the exact decimal stored, read for
+// pricing orders instead of going through a float
+type ProductPrice struct {
+	Id         int64       `json:"id"`
+	Name       string      `json:"name"`
+	ImageUrl   string      `json:"imageUrl"`
+	MerchantId string      `json:"merchantId"`
+	Price      json.Number `json:"price"`
+}
+
+type ProductListResponse struct {
+	Products   []Products `json:"products"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+	HasMore    bool       `json:"hasMore"`
 }
 
 type ProductRequest struct {
@@ -14,3 +37,21 @@ type ProductRequest struct {
 	Description string  `json:"description"`
 	MerchantId  string  `json:"merchantId" binding:"required"`
 }
+
+// Reservation statuses for inventory_reservations
+const (
+	ReservationStatusReserved  = "reserved"
+	ReservationStatusCommitted = "committed"
+	ReservationStatusReleased  = "released"
+)
+
+type InventoryReservation struct {
+	Id        int64   `json:"id"`
+	Reference string  `json:"reference"`
+	ProductId int64   `json:"productId"`
+	Quantity  int64   `json:"quantity"`
+	Status    string  `json:"status"`
+	OrderId   *string `json:"orderId"`
+	ExpiresAt string  `json:"expiresAt"`
+	CreatedAt string  `json:"createdAt"`
+}
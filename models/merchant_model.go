@@ -8,6 +8,7 @@ type MerchantResponse struct {
 	MerchantId         common.Hash    `json:"merchantId"`
 	Message            string         `json:"message"`
 	MetadataURI        string         `json:"metadataURI"`
+	MetadataHash       string         `json:"metadataHash,omitempty"`
 	WalletAddress      common.Address `json:"walletAddress"`
 	MerchantName       string         `json:"merchantName"`
 	TransactionHash    common.Hash    `json:"transactionHash"`
@@ -19,7 +20,11 @@ type MerchantInfo struct {
 	MerchantName        string         `json:"merchantName" binding:"required"`
 	MerchantId          common.Hash    `json:"merchantId"`
 	PayoutWalletAddress common.Address `json:"payoutWalletAddress" binding:"required"`
-	MetadataURI         string         `json:"metadataURI" binding:"required"`
+	MetadataURI         string         `json:"metadataURI"`
+	Description         string         `json:"description,omitempty"`
+	Website             string         `json:"website,omitempty"`
+	LogoUrl             string         `json:"logoUrl,omitempty"`
+	ContactEmail        string         `json:"contactEmail,omitempty"`
 	IsMerchantVerified  bool           `json:"isMerchantVerified"`
 	TransactionHash     common.Hash    `json:"transactionHash"`
 }
@@ -28,6 +33,7 @@ type MerchantDB struct {
 	MerchantName        string `json:"merchantName"`
 	MerchantId          string `json:"merchantId"`
 	PayoutWalletAddress string `json:"payoutWalletAddress"`
+	OwnerAddress        string `json:"ownerAddress,omitempty"`
 	MetadataURI         string `json:"metadataURI"`
 	TransactionHash     string `json:"transactionHash"`
 }
@@ -36,11 +42,16 @@ type MerchantUpdateRequest struct {
 	MerchantName        *string `json:"merchantName,omitempty"`
 	PayoutWalletAddress *string `json:"payoutWalletAddress,omitempty"`
 	MetadataURI         *string `json:"metadataURI,omitempty"`
+	Description         *string `json:"description,omitempty"`
+	Website             *string `json:"website,omitempty"`
+	LogoUrl             *string `json:"logoUrl,omitempty"`
+	ContactEmail        *string `json:"contactEmail,omitempty"`
 }
 
+// TokenBalance holds the query parameters of a merchant balance lookup
 type TokenBalance struct {
-	WalletAddress common.Address `json:"walletAddress" binding:"required"`
-	TokenAddress  common.Address `json:"tokenAddress" binding:"required"`
+	WalletAddress string `form:"walletAddress" binding:"required"`
+	TokenAddress  string `form:"tokenAddress" binding:"required"`
 }
 
 type TokenBalanceDB struct {
@@ -63,6 +74,7 @@ type PrepareUpdateResponse struct {
 	MerchantId          string          `json:"merchantId"`
 	PayoutWalletAddress string          `json:"payoutWalletAddress"`
 	MetadataURI         string          `json:"metadataURI"`
+	MetadataHash        string          `json:"metadataHash,omitempty"`
 	Message             string          `json:"message"`
 }
 
@@ -0,0 +1,35 @@
+package models
+
+import "encoding/json"
+
+// MerchantMetadata is the metadata document referenced by a merchant's metadataURI
+type MerchantMetadata struct {
+	Type          string `json:"type"`
+	Version       int    `json:"version"`
+	MerchantId    string `json:"merchantId,omitempty"`
+	Name          string `json:"name"`
+	PayoutAddress string `json:"payoutAddress"`
+	Description   string `json:"description,omitempty"`
+	Website       string `json:"website,omitempty"`
+	LogoUrl       string `json:"logoUrl,omitempty"`
+	ContactEmail  string `json:"contactEmail,omitempty"`
+	UpdatedAt     string `json:"updatedAt"`
+}
+
+type MetadataDocumentResponse struct {
+	URI      string          `json:"uri"`
+	Hash     string          `json:"hash"`
+	Document json.RawMessage `json:"document"`
+}
+
+// MetadataResolution reports whether a stored document matches its URI and the
+// URI recorded by the on-chain transaction
+type MetadataResolution struct {
+	URI             string          `json:"uri"`
+	Hash            string          `json:"hash"`
+	ContentVerified bool            `json:"contentVerified"`
+	OnChainURI      string          `json:"onChainURI,omitempty"`
+	MatchesOnChain  *bool           `json:"matchesOnChain,omitempty"`
+	TransactionHash string          `json:"transactionHash,omitempty"`
+	Document        json.RawMessage `json:"document"`
+}
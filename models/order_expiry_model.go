@@ -0,0 +1,14 @@
+package models
+
+// OrderExpiryPolicyDB is how long a merchant's orders may stay unpaid before
+// they are cancelled
+type OrderExpiryPolicyDB struct {
+	MerchantId    string `json:"merchantId"`
+	ExpiryMinutes int    `json:"expiryMinutes"`
+	UpdatedBy     string `json:"updatedBy,omitempty"`
+	UpdatedAt     string `json:"updatedAt,omitempty"`
+}
+
+type UpdateOrderExpiryPolicyRequest struct {
+	ExpiryMinutes int `json:"expiryMinutes" binding:"required"`
+}
@@ -23,6 +23,12 @@ type ConfirmApproveRequest struct {
 	TransactionHash string `json:"transactionHash" binding:"required"`
 }
 
+type ConfirmApproveResponse struct {
+	Message         string `json:"message"`
+	TransactionHash string `json:"transactionHash"`
+	ExplorerURL     string `json:"explorerUrl"`
+}
+
 type ConfirmRefundRequest struct {
 	TransactionHash string `json:"transactionHash" binding:"required"`
 }
@@ -31,18 +37,27 @@ type CreateOrderRequest struct {
 	MerchantId   string `json:"merchantId" binding:"required"`
 	TokenAddress string `json:"tokenAddress" binding:"required"`
 	Amount       string `json:"amount" binding:"required"`
-	MetadataURI  string `json:"metadataURI" binding:"required"`
+	MetadataURI  string `json:"metadataURI"`
+	BuyerAddress string `json:"buyerAddress,omitempty"`
+	ProductId    int64  `json:"productId,omitempty"`
+	Quantity     int64  `json:"quantity,omitempty"`
 }
 
 type PrepareCreateOrderResponse struct {
-	TransactionData TransactionData `json:"transactionData"`
-	MerchantId      string          `json:"merchantId"`
-	TokenAddress    string          `json:"tokenAddress"`
-	Amount          string          `json:"amount"`
-	MetadataURI     string          `json:"metadataURI"`
-	Message         string          `json:"message"`
-}
-
+	TransactionData      TransactionData        `json:"transactionData"`
+	MerchantId           string                 `json:"merchantId"`
+	TokenAddress         string                 `json:"tokenAddress"`
+	Amount               string                 `json:"amount"`
+	MetadataURI          string                 `json:"metadataURI"`
+	MetadataHash         string                 `json:"metadataHash,omitempty"`
+	ReservationId        string                 `json:"reservationId,omitempty"`
+	ReservationExpiresAt string                 `json:"reservationExpiresAt,omitempty"`
+	Reservations         []InventoryReservation `json:"reservations,omitempty"`
+	Message              string                 `json:"message"`
+}
+
+// ConfirmCreateOrderRequest confirms a mined createOrder transaction. TokenAddress,
+// Amount and MetadataURI must be those of the order created on-chain.
 type ConfirmCreateOrderRequest struct {
 	TransactionHash string `json:"transactionHash" binding:"required"`
 	MerchantId      string `json:"merchantId" binding:"required"`
@@ -50,6 +65,18 @@ type ConfirmCreateOrderRequest struct {
 	Amount          string `json:"amount" binding:"required"`
 	MetadataURI     string `json:"metadataURI" binding:"required"`
 	PayerAddress    string `json:"payerAddress" binding:"required"`
+	ReservationId   string `json:"reservationId,omitempty"`
+	CartId          string `json:"cartId,omitempty"`
+	// ExpiresIn is how many seconds the order may stay unpaid; the merchant's
+	// order expiry applies when it is zero. It is only honoured for the
+	// merchant's API key, the merchant's owner and checkout sessions.
+	ExpiresIn int64 `json:"expiresIn,omitempty"`
+}
+
+type ConfirmCreateOrderResponse struct {
+	Success     bool    `json:"success"`
+	Order       OrderDB `json:"order"`
+	ExplorerURL string  `json:"explorerUrl"`
 }
 
 type PrepareOrder struct {
@@ -76,7 +103,18 @@ type ConfirmPayOrderRequest struct {
 	Amount          string `json:"amount" binding:"required"`
 }
 
+type ConfirmPayOrderResponse struct {
+	Success         bool        `json:"success"`
+	OrderId         string      `json:"orderId"`
+	Message         string      `json:"message"`
+	Status          string      `json:"status"`
+	Event           *OrderEvent `json:"event"`
+	TransactionHash string      `json:"transactionHash"`
+	ExplorerURL     string      `json:"explorerUrl"`
+}
+
 type OrderDB struct {
+	Id              int64  `json:"id,omitempty"`
 	OrderId         string `json:"orderId"`
 	MerchantId      string `json:"merchantId"`
 	PayerAddress    string `json:"payerAddress"`
@@ -85,6 +123,27 @@ type OrderDB struct {
 	Status          string `json:"status"`
 	MetadataURI     string `json:"metadataURI"`
 	TransactionHash string `json:"transactionHash"`
+	Network         string `json:"network,omitempty"`
+	ExpiresAt       string `json:"expiresAt,omitempty"`
+	CreatedAt       string `json:"createdAt,omitempty"`
+}
+
+type OrderHistoryEntry struct {
+	OrderEvent
+	ExplorerURL string `json:"explorerUrl,omitempty"`
+}
+
+type OrderDetails struct {
+	OrderDB
+	ExplorerURL string              `json:"explorerUrl"`
+	History     []OrderHistoryEntry `json:"history"`
+	Items       []OrderItemDB       `json:"items,omitempty"`
+}
+
+type OrderListResponse struct {
+	Orders     []OrderDetails `json:"orders"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+	HasMore    bool           `json:"hasMore"`
 }
 
 type PrepareSettleOrderRequest struct {
@@ -119,6 +178,19 @@ type EmergencyWithdraw struct {
 	TransactionHash string `json:"transactionHash"`
 }
 
+// EmergencyWithdrawalDB is a row of the emergencyWithdrawal table
+type EmergencyWithdrawalDB struct {
+	Id              int64  `json:"id,omitempty"`
+	TokenAddress    string `json:"tokenAddress"`
+	ReceiverAddress string `json:"receiverAddress"`
+	Amount          string `json:"amount"`
+	SenderAddress   string `json:"senderAddress"`
+	TransactionHash string `json:"transactionHash"`
+	BlockNumber     uint64 `json:"blockNumber"`
+	Network         string `json:"network"`
+	CreatedAt       string `json:"createdAt,omitempty"`
+}
+
 type WithdrawalStatus struct {
 	IsWithdrawalEnabled *bool `json:"isWithdrawalEnabled" binding:"required"`
 }
@@ -132,16 +204,30 @@ type TokenSupport struct {
 	StatusValue  string `json:"statusValue" binding:"required"`
 }
 
+// PlatformBalanceCheck holds the query parameters of a platform wallet balance lookup
 type PlatformBalanceCheck struct {
-	PlatformWallet string `json:"platformWallet" binding:"required"`
-	TokenAddress   string `json:"tokenAddress" binding:"required"`
+	PlatformWallet string `form:"platformWallet" binding:"required"`
+	TokenAddress   string `form:"tokenAddress" binding:"required"`
 }
 
+// ContractBalanceCheck holds the query parameters of a contract balance lookup
 type ContractBalanceCheck struct {
-	TokenAddress string `json:"tokenAddress" binding:"required"`
+	TokenAddress string `form:"tokenAddress" binding:"required"`
 }
 
 type UpdateMerchantVerificationStatus struct {
 	MerchantId         string `json:"merchantId" binding:"required"`
 	VerificationStatus string `json:"verificationStatus" binding:"required"`
 }
+
+type OrderEvent struct {
+	Id              int64  `json:"id,omitempty"`
+	OrderId         string `json:"orderId"`
+	MerchantId      string `json:"merchantId,omitempty"`
+	FromStatus      string `json:"fromStatus"`
+	ToStatus        string `json:"toStatus"`
+	TransactionHash string `json:"transactionHash"`
+	BlockNumber     uint64 `json:"blockNumber,omitempty"`
+	Actor           string `json:"actor"`
+	CreatedAt       string `json:"createdAt,omitempty"`
+}
@@ -0,0 +1,41 @@
+package models
+
+// Order statuses as stored in the orders table
+const (
+	OrderStatusCreated   = "created"
+	OrderStatusPaid      = "paid"
+	OrderStatusSettled   = "settled"
+	OrderStatusRefunded  = "refunded"
+	OrderStatusCancelled = "cancelled"
+)
+
+// orderTransitions lists the statuses an order may move to from each status.
+// Settled and refunded are final: both release the escrowed funds.
+var orderTransitions = map[string][]string{
+	OrderStatusCreated: {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:    {OrderStatusSettled, OrderStatusRefunded},
+}
+
+// IsValidOrderStatus reports whether status is a known order status
+func IsValidOrderStatus(status string) bool {
+	switch status {
+	case OrderStatusCreated, OrderStatusPaid, OrderStatusSettled, OrderStatusRefunded, OrderStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// CanTransitionOrder reports whether an order may move from one status to another
+func CanTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminalOrderStatus reports whether no further transitions are possible from status
+func IsTerminalOrderStatus(status string) bool {
+	return len(orderTransitions[status]) == 0
+}
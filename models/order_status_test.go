@@ -0,0 +1,30 @@
+package models
+
+import "testing"
+
+func TestCanTransitionOrder(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{OrderStatusCreated, OrderStatusPaid, true},
+		{OrderStatusCreated, OrderStatusCancelled, true},
+		{OrderStatusPaid, OrderStatusSettled, true},
+		{OrderStatusPaid, OrderStatusRefunded, true},
+		// An order the expiry job cancelled can no longer be paid
+		{OrderStatusCancelled, OrderStatusPaid, false},
+		{OrderStatusCancelled, OrderStatusCreated, false},
+		// A settled order's funds have left escrow, so it cannot be refunded
+		{OrderStatusSettled, OrderStatusRefunded, false},
+		{OrderStatusRefunded, OrderStatusSettled, false},
+		{OrderStatusPaid, OrderStatusCancelled, false},
+		{OrderStatusCreated, OrderStatusSettled, false},
+		{"unknown", OrderStatusPaid, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransitionOrder(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionOrder(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
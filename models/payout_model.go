@@ -0,0 +1,85 @@
+package models
+
+// Payout policies: when a merchant's paid orders are settled automatically
+const (
+	PayoutPolicyImmediate = "immediate"
+	PayoutPolicyDelayed   = "delayed"
+	PayoutPolicyDaily     = "daily"
+	PayoutPolicyManual    = "manual"
+)
+
+// Settlement attempt statuses. A queued attempt waits for the settlement
+// worker to send it; an attempt is submitting until its transaction is signed,
+// and submitted until the transaction is mined or dropped.
+const (
+	SettlementAttemptQueued     = "queued"
+	SettlementAttemptSubmitting = "submitting"
+	SettlementAttemptSubmitted  = "submitted"
+	SettlementAttemptConfirmed  = "confirmed"
+	SettlementAttemptFailed     = "failed"
+)
+
+// Settlement attempt actions. Refund attempts carry out dispute decisions.
+const (
+	SettlementActionSettle = "settle"
+	SettlementActionRefund = "refund"
+)
+
+// Settlement hold reasons
+const (
+	SettlementHoldManual  = "manual"
+	SettlementHoldDispute = "dispute"
+)
+
+// PayoutPolicyDB is a merchant's automatic settlement policy. HoldHours is the
+// wait after payment for delayed payouts and the minimum wait for daily ones.
+type PayoutPolicyDB struct {
+	MerchantId string `json:"merchantId"`
+	Policy     string `json:"policy"`
+	HoldHours  int    `json:"holdHours"`
+	UpdatedBy  string `json:"updatedBy,omitempty"`
+	UpdatedAt  string `json:"updatedAt,omitempty"`
+}
+
+type UpdatePayoutPolicyRequest struct {
+	Policy    string `json:"policy" binding:"required"`
+	HoldHours int    `json:"holdHours"`
+}
+
+// SettlementHoldDB keeps the settlement worker away from an order
+type SettlementHoldDB struct {
+	OrderId   string `json:"orderId"`
+	Reason    string `json:"reason"`
+	Note      string `json:"note"`
+	CreatedBy string `json:"createdBy"`
+	CreatedAt string `json:"createdAt,omitempty"`
+}
+
+type HoldSettlementRequest struct {
+	Note string `json:"note"`
+}
+
+// SettlementAttemptDB is one settleOrder or refundOrder transaction signed with
+// the platform key
+type SettlementAttemptDB struct {
+	Id              int64  `json:"id,omitempty"`
+	OrderId         string `json:"orderId"`
+	MerchantId      string `json:"merchantId"`
+	Network         string `json:"network"`
+	Action          string `json:"action"`
+	Status          string `json:"status"`
+	TransactionHash string `json:"transactionHash"`
+	BlockNumber     uint64 `json:"blockNumber"`
+	Error           string `json:"error"`
+	CreatedAt       string `json:"createdAt,omitempty"`
+	UpdatedAt       string `json:"updatedAt,omitempty"`
+}
+
+// OrderSettlementStatus is an order's automatic settlement state
+type OrderSettlementStatus struct {
+	OrderId  string                `json:"orderId"`
+	Status   string                `json:"status"`
+	Policy   PayoutPolicyDB        `json:"policy"`
+	Holds    []SettlementHoldDB    `json:"holds"`
+	Attempts []SettlementAttemptDB `json:"attempts"`
+}
@@ -0,0 +1,108 @@
+package models
+
+// Roles a wallet can hold
+const (
+	RolePlatformAdmin = "platform-admin"
+	RoleCompliance    = "compliance"
+	RoleSupport       = "support"
+	RoleMerchant      = "merchant"
+	RoleBuyer         = "buyer"
+)
+
+// Permissions checked by the route middleware
+const (
+	PermissionWithdrawalExecute   = "withdrawal:execute"
+	PermissionWithdrawalConfigure = "withdrawal:configure"
+	PermissionRegistryUpdate      = "registry:update"
+	PermissionTokenManage         = "token:manage"
+	PermissionMerchantVerify      = "merchant:verify"
+	PermissionOrderSettle         = "order:settle"
+	PermissionOrderRefund         = "order:refund"
+	PermissionBalanceRead         = "balance:read"
+	PermissionRolesManage         = "roles:manage"
+	PermissionAuditRead           = "audit:read"
+	PermissionFeesRead            = "fees:read"
+	PermissionExportsRead         = "exports:read"
+	PermissionDisputeResolve      = "dispute:resolve"
+)
+
+// Role audit actions
+const (
+	RoleActionGrant  = "grant"
+	RoleActionRevoke = "revoke"
+)
+
+// rolePermissions lists what each role may do. Merchant and buyer carry no
+// platform permissions; merchants are authorized per merchant by ownership.
+var rolePermissions = map[string][]string{
+	RolePlatformAdmin: {
+		PermissionWithdrawalExecute, PermissionWithdrawalConfigure, PermissionRegistryUpdate,
+		PermissionTokenManage, PermissionMerchantVerify, PermissionOrderSettle, PermissionOrderRefund,
+		PermissionBalanceRead, PermissionRolesManage, PermissionAuditRead, PermissionFeesRead,
+		PermissionExportsRead, PermissionDisputeResolve,
+	},
+	RoleCompliance: {PermissionMerchantVerify, PermissionBalanceRead, PermissionAuditRead, PermissionFeesRead, PermissionExportsRead},
+	RoleSupport:    {PermissionOrderRefund, PermissionBalanceRead, PermissionDisputeResolve},
+	RoleMerchant:   {},
+	RoleBuyer:      {},
+}
+
+// Roles lists every role in the order they are documented
+var Roles = []string{RolePlatformAdmin, RoleCompliance, RoleSupport, RoleMerchant, RoleBuyer}
+
+// IsValidRole reports whether role is a known role
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolePermissions returns the permissions granted by role
+func RolePermissions(role string) []string {
+	return rolePermissions[role]
+}
+
+// RolesHavePermission reports whether any of roles grants permission
+func RolesHavePermission(roles []string, permission string) bool {
+	for _, role := range roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type UserRoleDB struct {
+	Id            int64  `json:"id,omitempty"`
+	WalletAddress string `json:"walletAddress"`
+	Role          string `json:"role"`
+	GrantedBy     string `json:"grantedBy"`
+	CreatedAt     string `json:"createdAt,omitempty"`
+}
+
+type RoleAuditEntry struct {
+	Id            int64  `json:"id,omitempty"`
+	Action        string `json:"action"`
+	WalletAddress string `json:"walletAddress"`
+	Role          string `json:"role"`
+	Actor         string `json:"actor"`
+	Reason        string `json:"reason,omitempty"`
+	CreatedAt     string `json:"createdAt,omitempty"`
+}
+
+type GrantRoleRequest struct {
+	WalletAddress string `json:"walletAddress" binding:"required"`
+	Role          string `json:"role" binding:"required"`
+	Reason        string `json:"reason"`
+}
+
+type RevokeRoleRequest struct {
+	Reason string `json:"reason"`
+}
+
+type RoleAuditListResponse struct {
+	Entries    []RoleAuditEntry `json:"entries"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+	HasMore    bool             `json:"hasMore"`
+}
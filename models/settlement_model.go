@@ -0,0 +1,62 @@
+package models
+
+// SettlementDB records how a settled order's amount was split between the
+// platform fee and the merchant's payout
+type SettlementDB struct {
+	OrderId         string `json:"orderId"`
+	MerchantId      string `json:"merchantId"`
+	Network         string `json:"network"`
+	TokenAddress    string `json:"tokenAddress"`
+	GrossAmount     string `json:"grossAmount"`
+	PlatformFee     string `json:"platformFee"`
+	MerchantNet     string `json:"merchantNet"`
+	PayoutAddress   string `json:"payoutAddress"`
+	FeeRecipient    string `json:"feeRecipient"`
+	TransactionHash string `json:"transactionHash"`
+	BlockNumber     uint64 `json:"blockNumber"`
+	SettledAt       string `json:"settledAt,omitempty"`
+}
+
+// FeeReportRow is a row of the fee_report database function
+type FeeReportRow struct {
+	Period       string `json:"period"`
+	MerchantId   string `json:"merchantId"`
+	Network      string `json:"network"`
+	TokenAddress string `json:"tokenAddress"`
+	Settlements  int64  `json:"settlements"`
+	GrossAmount  string `json:"grossAmount"`
+	PlatformFee  string `json:"platformFee"`
+	MerchantNet  string `json:"merchantNet"`
+}
+
+// FeeTotals sums the settlements of one token on one network
+type FeeTotals struct {
+	Network      string `json:"network"`
+	TokenAddress string `json:"tokenAddress"`
+	Symbol       string `json:"symbol,omitempty"`
+	Decimals     *uint8 `json:"decimals,omitempty"`
+	Settlements  int64  `json:"settlements"`
+	GrossAmount  string `json:"grossAmount"`
+	PlatformFee  string `json:"platformFee"`
+	MerchantNet  string `json:"merchantNet"`
+}
+
+type FeePeriod struct {
+	Start  string      `json:"start"`
+	Tokens []FeeTotals `json:"tokens"`
+}
+
+type MerchantFees struct {
+	MerchantId string      `json:"merchantId"`
+	Tokens     []FeeTotals `json:"tokens"`
+}
+
+// FeeReportResponse breaks fee revenue down by period, by merchant and by token
+type FeeReportResponse struct {
+	GroupBy   string         `json:"groupBy"`
+	From      string         `json:"from"`
+	To        string         `json:"to"`
+	Tokens    []FeeTotals    `json:"tokens"`
+	Periods   []FeePeriod    `json:"periods"`
+	Merchants []MerchantFees `json:"merchants"`
+}
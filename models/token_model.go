@@ -0,0 +1,45 @@
+package models
+
+type TokenDB struct {
+	Network   string  `json:"network"`
+	Address   string  `json:"address"`
+	Symbol    string  `json:"symbol"`
+	Decimals  uint8   `json:"decimals"`
+	Enabled   bool    `json:"enabled"`
+	CreatedAt string  `json:"createdAt,omitempty"`
+	UpdatedAt *string `json:"updatedAt,omitempty"`
+}
+
+// TokenAmount is an amount in token base units with its decimal rendering
+type TokenAmount struct {
+	TokenAddress string `json:"tokenAddress"`
+	Symbol       string `json:"symbol,omitempty"`
+	Decimals     *uint8 `json:"decimals,omitempty"`
+	Amount       string `json:"amount"`
+	Formatted    string `json:"formatted,omitempty"`
+}
+
+// NetworkBalances holds a wallet's token balances on one network. Error is set,
+// and Balances empty, when the network could not be read.
+type NetworkBalances struct {
+	Network  string        `json:"network"`
+	ChainId  int64         `json:"chainId"`
+	Balances []TokenAmount `json:"balances"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// PendingSettlement totals a merchant's paid orders in one token that have not
+// been settled yet
+type PendingSettlement struct {
+	TokenAmount
+	Network    string `json:"network"`
+	OrderCount int    `json:"orderCount"`
+}
+
+type MerchantBalancesResponse struct {
+	MerchantId          string              `json:"merchantId"`
+	PayoutWalletAddress string              `json:"payoutWalletAddress"`
+	Networks            []NetworkBalances   `json:"networks"`
+	PendingSettlements  []PendingSettlement `json:"pendingSettlements"`
+	RetrievedAt         string              `json:"retrievedAt"`
+}
@@ -0,0 +1,112 @@
+package models
+
+import "encoding/json"
+
+// Webhook event types, one per order status
+const (
+	WebhookEventOrderCreated   = "order.created"
+	WebhookEventOrderPaid      = "order.paid"
+	WebhookEventOrderSettled   = "order.settled"
+	WebhookEventOrderRefunded  = "order.refunded"
+	WebhookEventOrderCancelled = "order.cancelled"
+)
+
+// WebhookEventTypes lists every event an endpoint can subscribe to
+var WebhookEventTypes = []string{
+	WebhookEventOrderCreated,
+	WebhookEventOrderPaid,
+	WebhookEventOrderSettled,
+	WebhookEventOrderRefunded,
+	WebhookEventOrderCancelled,
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending    = "pending"
+	WebhookDeliveryDelivering = "delivering"
+	WebhookDeliverySucceeded  = "succeeded"
+	WebhookDeliveryFailed     = "failed"
+)
+
+type WebhookEndpointDB struct {
+	Id                      int64    `json:"id,omitempty"`
+	MerchantId              string   `json:"merchantId"`
+	URL                     string   `json:"url"`
+	Events                  []string `json:"events"`
+	Secret                  string   `json:"secret,omitempty"`
+	PreviousSecret          *string  `json:"previousSecret,omitempty"`
+	PreviousSecretExpiresAt *string  `json:"previousSecretExpiresAt,omitempty"`
+	Active                  bool     `json:"active"`
+	CreatedAt               string   `json:"createdAt,omitempty"`
+	UpdatedAt               *string  `json:"updatedAt,omitempty"`
+}
+
+// WebhookEndpoint is an endpoint as shown to the merchant; the secret is only
+// included when it is created or rotated
+type WebhookEndpoint struct {
+	Id         int64    `json:"id"`
+	MerchantId string   `json:"merchantId"`
+	URL        string   `json:"url"`
+	Events     []string `json:"events"`
+	Active     bool     `json:"active"`
+	Secret     string   `json:"secret,omitempty"`
+	CreatedAt  string   `json:"createdAt"`
+	UpdatedAt  *string  `json:"updatedAt,omitempty"`
+}
+
+type WebhookDeliveryDB struct {
+	Id             int64           `json:"id,omitempty"`
+	EndpointId     int64           `json:"endpointId"`
+	MerchantId     string          `json:"merchantId"`
+	EventId        string          `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	OrderId        string          `json:"orderId,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  string          `json:"nextAttemptAt,omitempty"`
+	LastStatusCode *int            `json:"lastStatusCode,omitempty"`
+	LastError      *string         `json:"lastError,omitempty"`
+	DeliveredAt    *string         `json:"deliveredAt,omitempty"`
+	CreatedAt      string          `json:"createdAt,omitempty"`
+}
+
+type WebhookDeliveryAttempt struct {
+	Id         int64   `json:"id,omitempty"`
+	DeliveryId int64   `json:"deliveryId"`
+	Attempt    int     `json:"attempt"`
+	StatusCode *int    `json:"statusCode,omitempty"`
+	Error      *string `json:"error,omitempty"`
+	DurationMs int64   `json:"durationMs"`
+	CreatedAt  string  `json:"createdAt,omitempty"`
+}
+
+// WebhookPayload is the JSON body posted to merchant endpoints
+type WebhookPayload struct {
+	Id        string             `json:"id"`
+	Type      string             `json:"type"`
+	CreatedAt string             `json:"createdAt"`
+	Data      WebhookPayloadData `json:"data"`
+}
+
+type WebhookPayloadData struct {
+	Order OrderDB    `json:"order"`
+	Event OrderEvent `json:"event"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events"`
+}
+
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryDB `json:"deliveries"`
+	NextCursor string              `json:"nextCursor,omitempty"`
+	HasMore    bool                `json:"hasMore"`
+}
@@ -69,3 +69,20 @@ func GetPolygonClient() *client.Client {
 	}
 	return instance.PolygonClient
 }
+
+// GetClient returns the client for a network by name, or nil when the network is
+// not configured or the clients are not initialized
+func GetClient(networkName string) *client.Client {
+	if instance == nil {
+		return nil
+	}
+
+	switch networkName {
+	case BaseSepoliaConfig.NetworkName:
+		return instance.BaseClient
+	case PolygonAmoyConfig.NetworkName:
+		return instance.PolygonClient
+	default:
+		return nil
+	}
+}
@@ -43,3 +43,20 @@ func GetNetworkConfig(networkName string) (client.NetworkConfig, bool) {
 func GetAllNetworkConfigs() []client.NetworkConfig {
 	return []client.NetworkConfig{BaseSepoliaConfig, PolygonAmoyConfig}
 }
+
+// DefaultNetworkName is the network used when a record does not specify one
+const DefaultNetworkName = "base-sepolia"
+
+// ExplorerTxURL returns the block explorer link for a transaction on the given network
+func ExplorerTxURL(networkName, txHash string) string {
+	if txHash == "" {
+		return ""
+	}
+
+	config, exists := GetNetworkConfig(networkName)
+	if !exists {
+		config = BaseSepoliaConfig
+	}
+
+	return config.ExplorerURL + "/tx/" + txHash
+}
@@ -0,0 +1,276 @@
+// Package openapi describes the HTTP API as an OpenAPI 3 document. The document
+// is built from the route table in routes.go and the request and response types
+// in models, and Verify checks the table against the router so the two cannot
+// drift apart.
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Dbriane208/stable-market/apierror"
+)
+
+// Version is the OpenAPI version the document is written against
+const Version = "3.0.3"
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower case HTTP methods to operations
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationId string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Security scheme names used in the document
+const (
+	SchemeSession       = "session"
+	SchemeSessionCookie = "sessionCookie"
+	SchemeAPIKey        = "apiKey"
+)
+
+var (
+	buildOnce sync.Once
+	document  *Document
+)
+
+// Build returns the API document. It is built once and shared, so callers must
+// not modify it.
+func Build() *Document {
+	buildOnce.Do(func() {
+		document = build()
+	})
+	return document
+}
+
+func build() *Document {
+	gen := newGenerator()
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "Stable Market API",
+			Description: "Stablecoin marketplace API. Errors are returned as {\"error\": {...}} with a stable code; see the Error schema.",
+			Version:     "1.0.0",
+		},
+		Tags:  tags,
+		Paths: make(map[string]PathItem),
+		Components: Components{
+			Schemas: gen.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				SchemeSession: {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "Session token from POST /api/auth/verify. Merchant API keys are also accepted as Bearer tokens where the apiKey scheme is allowed.",
+				},
+				SchemeSessionCookie: {Type: "apiKey", In: "cookie", Name: "sm_session"},
+				SchemeAPIKey:        {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			},
+		},
+	}
+
+	for _, route := range Routes {
+		path := openAPIPath(route.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = gen.operation(route)
+	}
+
+	return doc
+}
+
+// errorSchema describes the envelope written by apierror.Respond
+func (g *generator) errorSchema() *Schema {
+	if _, ok := g.schemas["Error"]; !ok {
+		g.schemas["Error"] = &Schema{
+			Type:     "object",
+			Required: []string{"error"},
+			Properties: map[string]*Schema{
+				"error": {
+					Type:     "object",
+					Required: []string{"code", "message"},
+					Properties: map[string]*Schema{
+						"code":      {Type: "string", Description: "Stable machine readable error code"},
+						"message":   {Type: "string"},
+						"details":   {Type: "array", Items: g.schema(apierror.FieldError{})},
+						"meta":      {Type: "object", AdditionalProperties: &Schema{}},
+						"requestId": {Type: "string", Description: "Echoes the X-Request-ID response header"},
+					},
+				},
+			},
+		}
+	}
+	return ref("Error")
+}
+
+func (g *generator) operation(route Route) *Operation {
+	op := &Operation{
+		OperationId: strings.ToLower(route.Handler[:1]) + route.Handler[1:],
+		Summary:     route.Summary,
+		Description: route.Description,
+		Tags:        []string{route.Tag},
+		Responses:   make(map[string]Response),
+		Security:    route.Auth.requirements(),
+		Deprecated:  route.Deprecated,
+	}
+
+	if route.Permission != "" {
+		op.Description = strings.TrimSpace(op.Description + "\n\nRequires the " + route.Permission + " permission.")
+	}
+	if route.Scope != "" {
+		op.Description = strings.TrimSpace(op.Description + "\n\nAPI keys need the " + route.Scope + " scope.")
+	}
+
+	for _, name := range pathParams(route.Path) {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	for _, param := range route.Query {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      param.schema(),
+		})
+	}
+	if route.QueryType != nil {
+		op.Parameters = append(op.Parameters, g.queryParams(route.QueryType)...)
+	}
+
+	switch {
+	case route.Request != nil:
+		op.RequestBody = &RequestBody{Required: !route.OptionalBody, Content: jsonContent(g.schema(route.Request))}
+	case len(route.Form) > 0:
+		form := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for _, field := range route.Form {
+			form.Properties[field.Name] = field.schema()
+			if field.Required {
+				form.Required = append(form.Required, field.Name)
+			}
+		}
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"multipart/form-data": {Schema: form}}}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	switch {
+	case route.ContentType != "":
+		success.Content = map[string]MediaType{route.ContentType: {Schema: route.contentSchema()}}
+	case route.Response != nil:
+		success.Content = jsonContent(g.schema(route.Response))
+	default:
+		success.Content = jsonContent(&Schema{Type: "object", AdditionalProperties: &Schema{}})
+	}
+	op.Responses[strconv.Itoa(status)] = success
+	op.Responses["default"] = Response{Description: "Error", Content: jsonContent(g.errorSchema())}
+
+	return op
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// openAPIPath turns /orders/:orderId into /orders/{orderId}
+func openAPIPath(path string) string {
+	return ginParam.ReplaceAllString(path, "{$1}")
+}
+
+func pathParams(path string) []string {
+	var names []string
+	for _, match := range ginParam.FindAllStringSubmatch(path, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// Operations lists the method and path of every documented operation, sorted
+func (d *Document) Operations() []string {
+	var ops []string
+	for path, item := range d.Paths {
+		for method := range item {
+			ops = append(ops, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
@@ -0,0 +1,28 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Dbriane208/stable-market/openapi"
+	"github.com/Dbriane208/stable-market/routes"
+	"github.com/gin-gonic/gin"
+)
+
+// Every registered route must be described in the OpenAPI document, and every
+// documented route must be registered
+func TestRoutesAreDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.Setup(router)
+
+	if err := openapi.Verify(router.Routes()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildEncodes(t *testing.T) {
+	if _, err := json.Marshal(openapi.Build()); err != nil {
+		t.Fatal(err)
+	}
+}
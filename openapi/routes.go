@@ -0,0 +1,360 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/utils"
+)
+
+// Auth is how an operation authenticates its caller
+type Auth int
+
+const (
+	// Public operations take no credentials
+	Public Auth = iota
+	// Session operations need a signed in wallet
+	Session
+	// SessionOrAPIKey operations take a scoped merchant API key or a signed in wallet
+	SessionOrAPIKey
+	// OptionalAPIKey operations are public, but a presented API key must be valid
+	OptionalAPIKey
+	// OptionalSessionOrAPIKey operations are public like OptionalAPIKey ones, and
+	// also recognize a signed in wallet
+	OptionalSessionOrAPIKey
+)
+
+func (a Auth) requirements() []map[string][]string {
+	session := []map[string][]string{{SchemeSession: {}}, {SchemeSessionCookie: {}}}
+
+	switch a {
+	case Session:
+		return session
+	case SessionOrAPIKey:
+		return append(session, map[string][]string{SchemeAPIKey: {}})
+	case OptionalAPIKey:
+		return []map[string][]string{{}, {SchemeAPIKey: {}}}
+	case OptionalSessionOrAPIKey:
+		return append([]map[string][]string{{}}, append(session, map[string][]string{SchemeAPIKey: {}})...)
+	default:
+		return nil
+	}
+}
+
+// Param is a query parameter or multipart form field
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+	// Type is the OpenAPI type, string when empty. "file" is a multipart upload.
+	Type    string
+	Enum    []string
+	Default interface{}
+}
+
+func (p Param) schema() *Schema {
+	switch p.Type {
+	case "":
+		return &Schema{Type: "string", Enum: p.Enum, Default: p.Default}
+	case "file":
+		return &Schema{Type: "string", Format: "binary"}
+	default:
+		return &Schema{Type: p.Type, Enum: p.Enum, Default: p.Default}
+	}
+}
+
+// Route documents one route registered in the routes package
+type Route struct {
+	Method string
+	// Path uses gin syntax; path parameters are documented from it
+	Path string
+	// Handler is the controller function name, which Verify matches against the
+	// router and which becomes the operationId
+	Handler     string
+	Tag         string
+	Summary     string
+	Description string
+	Auth        Auth
+	Permission  string
+	Scope       string
+	Query       []Param
+	// QueryType is a struct bound with ctx.ShouldBindQuery
+	QueryType interface{}
+	// Request is the JSON body, Form the fields of a multipart body
+	Request      interface{}
+	OptionalBody bool
+	Form         []Param
+	// Status is the success status, 200 when zero
+	Status int
+	// Response is the JSON success body; nil documents a free form object
+	Response interface{}
+	// ContentType replaces the JSON success body with another media type
+	ContentType string
+	Deprecated  bool
+}
+
+func (r Route) contentSchema() *Schema {
+	switch r.ContentType {
+	case "image/png", "application/octet-stream":
+		return &Schema{Type: "string", Format: "binary"}
+	case "text/html":
+		return &Schema{Type: "string"}
+	case "text/event-stream":
+		return &Schema{Type: "string", Description: "Server-Sent Events stream of OrderEvent objects"}
+	default:
+		return &Schema{}
+	}
+}
+
+var tags = []Tag{
+	{Name: "auth", Description: "Sign-In With Ethereum sessions"},
+	{Name: "merchants", Description: "Merchant registration, updates and refunds"},
+	{Name: "platform", Description: "Platform administration and settlement"},
+	{Name: "admin", Description: "Role management"},
+	{Name: "orders", Description: "Order creation, payment and history"},
+	{Name: "market", Description: "Product catalog"},
+	{Name: "carts", Description: "Shopping carts"},
+	{Name: "metadata", Description: "Order and merchant metadata documents"},
+	{Name: "checkout", Description: "Hosted checkout sessions"},
+	{Name: "webhooks", Description: "Merchant webhook endpoints and deliveries"},
+	{Name: "api-keys", Description: "Merchant API keys"},
+	{Name: "exports", Description: "Order, settlement, refund and withdrawal exports"},
+	{Name: "invoices", Description: "Order invoices and credit notes"},
+	{Name: "disputes", Description: "Buyer disputes and their review"},
+	{Name: "docs", Description: "API description"},
+}
+
+var pagination = []Param{
+	{Name: "limit", Type: "integer", Default: utils.DefaultPageLimit, Description: "Page size, at most 100"},
+	{Name: "cursor", Description: "nextCursor from the previous page"},
+	{Name: "sort", Enum: []string{"-createdAt", "createdAt"}, Default: "-createdAt"},
+}
+
+var dateRange = []Param{
+	{Name: "from", Description: "RFC3339 timestamp or YYYY-MM-DD date"},
+	{Name: "to", Description: "RFC3339 timestamp or YYYY-MM-DD date; a plain date includes the whole day"},
+}
+
+var orderStatuses = []string{
+	models.OrderStatusCreated, models.OrderStatusPaid, models.OrderStatusSettled,
+	models.OrderStatusRefunded, models.OrderStatusCancelled,
+}
+
+var balanceHistory = []Param{
+	{Name: "bucket", Enum: []string{models.BalanceBucketHour, models.BalanceBucketDay}, Default: models.BalanceBucketDay, Description: "Hourly ranges cover at most 31 days, daily ranges 366"},
+	{Name: "network", Description: "Only this network"},
+	{Name: "tokenAddress", Description: "Only this token"},
+}
+
+// reportGrouping describes the groupBy, network and tokenAddress parameters of
+// reports built from daily data
+var reportGrouping = []Param{
+	{Name: "groupBy", Enum: []string{models.AnalyticsGroupDay, models.AnalyticsGroupWeek, models.AnalyticsGroupMonth}, Default: models.AnalyticsGroupDay, Description: "The range defaults to 30 days, 12 weeks or 12 months"},
+	{Name: "network", Description: "Only this network"},
+	{Name: "tokenAddress", Description: "Only this token"},
+}
+
+// exportParams describes the format and range of an export
+var exportParams = []Param{
+	{Name: "format", Enum: []string{models.ExportFormatCSV, models.ExportFormatJSONL, models.ExportFormatPDF}, Default: models.ExportFormatCSV, Description: "pdf is a statement with per token totals and explorer links"},
+	{Name: "from", Description: "RFC3339 timestamp or YYYY-MM-DD date; defaults to 30 days before to"},
+	{Name: "to", Description: "RFC3339 timestamp or YYYY-MM-DD date; a plain date includes the whole day. Defaults to now"},
+}
+
+// documentFormat selects how an invoice or credit note is served
+var documentFormat = []Param{
+	{Name: "format", Enum: []string{models.DocumentFormatHTML, models.DocumentFormatPDF, models.DocumentFormatJSON}, Default: models.DocumentFormatHTML, Description: "json returns the Invoice object"},
+}
+
+// disputeStatus filters dispute lists
+var disputeStatus = []Param{
+	{Name: "status", Enum: []string{models.DisputeStatusOpen, models.DisputeStatusResponded, models.DisputeStatusResolved}, Description: "Only disputes in this status"},
+}
+
+var disputeEvidenceForm = []Param{
+	{Name: "file", Type: "file", Description: "JPEG or PNG image, at most 5MB"},
+	{Name: "note", Description: "Optional, at most 2000 characters"},
+}
+
+var productForm = []Param{
+	{Name: "name"},
+	{Name: "price", Type: "number"},
+	{Name: "description"},
+	{Name: "merchantId"},
+	{Name: "stock", Type: "integer", Description: "Omit for untracked inventory"},
+	{Name: "file", Type: "file", Description: "Product image"},
+}
+
+func withQuery(params ...[]Param) []Param {
+	var all []Param
+	for _, p := range params {
+		all = append(all, p...)
+	}
+	return all
+}
+
+// Routes documents every route the server registers. Verify fails on any route
+// missing from this table, so new routes must be added here.
+var Routes = []Route{
+	// Docs
+	{Method: http.MethodGet, Path: "/openapi.json", Handler: "GetOpenAPISpec", Tag: "docs", Summary: "This OpenAPI document"},
+	{Method: http.MethodGet, Path: "/docs", Handler: "GetAPIDocs", Tag: "docs", Summary: "Interactive API documentation", ContentType: "text/html"},
+
+	// Auth
+	{Method: http.MethodGet, Path: "/api/auth/nonce", Handler: "GetAuthNonce", Tag: "auth", Summary: "Issue a SIWE nonce", Response: models.AuthNonceResponse{}},
+	{Method: http.MethodPost, Path: "/api/auth/verify", Handler: "VerifySIWE", Tag: "auth", Summary: "Verify a signed SIWE message and start a session", Request: models.VerifySIWERequest{}, Response: models.AuthSessionResponse{}},
+	{Method: http.MethodGet, Path: "/api/auth/me", Handler: "GetAuthSession", Tag: "auth", Summary: "Current session", Auth: Session},
+	{Method: http.MethodPost, Path: "/api/auth/logout", Handler: "Logout", Tag: "auth", Summary: "End the current session"},
+
+	// Merchants
+	{Method: http.MethodPost, Path: "/api/merchants/register", Handler: "RegisterMerchant", Tag: "merchants", Summary: "Register a merchant owned by the signed in wallet", Auth: Session, Request: models.MerchantInfo{}, Status: http.StatusCreated, Response: models.MerchantResponse{}},
+	{Method: http.MethodGet, Path: "/api/merchants/merchant-info/:merchantId", Handler: "GetMerchantInfoById", Tag: "merchants", Summary: "Get a merchant", Response: models.MerchantResponse{}},
+	{Method: http.MethodDelete, Path: "/api/merchants/delete/:merchantId", Handler: "DeleteMerchant", Tag: "merchants", Summary: "Delete a merchant", Auth: Session},
+	{Method: http.MethodGet, Path: "/api/merchants/balance/:merchantId", Handler: "GetMerchantBalance", Tag: "merchants", Summary: "Merchant token balance held by the contract", Description: "Reads a single token on Base Sepolia. Use GET /api/merchants/{merchantId}/balances instead.", QueryType: models.TokenBalance{}, Response: models.TokenBalanceDB{}, Deprecated: true},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/balances", Handler: "GetMerchantBalances", Tag: "merchants", Summary: "Payout wallet balances on every network and pending settlements", Description: "Reads every registered token on every configured network. A network that cannot be reached is listed with an error instead of failing the request.", Auth: Session, Response: models.MerchantBalancesResponse{}},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/balances/history", Handler: "GetMerchantBalanceHistory", Tag: "merchants", Summary: "Payout wallet balance history", Description: "Closing balance of each bucket from the scheduled snapshots of the merchant's current payout wallet. The range defaults to the last 7 days for hourly and 30 days for daily buckets.", Auth: Session, Query: withQuery(balanceHistory, dateRange), Response: models.BalanceHistoryResponse{}},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/analytics", Handler: "GetMerchantAnalytics", Tag: "merchants", Summary: "Sales volume, order funnel, refund rate and top products", Description: "Computed from daily rollups of the order history, refreshed every few minutes. Each status change counts in the period it happened; volumes are token base units.", Auth: Session, Query: withQuery(reportGrouping, dateRange), Response: models.MerchantAnalyticsResponse{}},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/payout-policy", Handler: "GetPayoutPolicy", Tag: "merchants", Summary: "Get the merchant's payout policy", Description: "Merchants that never set a policy are settled by hand.", Auth: Session, Response: models.PayoutPolicyDB{}},
+	{Method: http.MethodPut, Path: "/api/merchants/:merchantId/payout-policy", Handler: "UpdatePayoutPolicy", Tag: "merchants", Summary: "Set when paid orders are settled automatically", Description: "immediate settles paid orders on the next worker run, delayed holdHours after payment, daily in one batch after the UTC day of payment (waiting at least holdHours), and manual never.", Auth: Session, Request: models.UpdatePayoutPolicyRequest{}, Response: models.PayoutPolicyDB{}},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/order-expiry", Handler: "GetOrderExpiryPolicy", Tag: "merchants", Summary: "Get how long the merchant's orders may stay unpaid", Description: "Merchants that never set a policy get the platform default (ORDER_EXPIRY, 24 hours unless configured).", Auth: Session, Response: models.OrderExpiryPolicyDB{}},
+	{Method: http.MethodPut, Path: "/api/merchants/:merchantId/order-expiry", Handler: "UpdateOrderExpiryPolicy", Tag: "merchants", Summary: "Set how long the merchant's orders may stay unpaid", Description: "expiryMinutes must be between 5 minutes and 30 days. Unpaid orders past their expiry are cancelled on-chain, releasing their stock. Orders already created keep their expiry.", Auth: Session, Request: models.UpdateOrderExpiryPolicyRequest{}, Response: models.OrderExpiryPolicyDB{}},
+	{Method: http.MethodGet, Path: "/api/merchants/merchant-status/:merchantId", Handler: "IsMerchantVerified", Tag: "merchants", Summary: "Merchant verification status"},
+	{Method: http.MethodPost, Path: "/api/merchants/prepare-update/:merchantId", Handler: "PrepareUpdateMerchant", Tag: "merchants", Summary: "Prepare a merchant update transaction", Auth: Session, Request: models.MerchantUpdateRequest{}, Response: models.PrepareUpdateResponse{}},
+	{Method: http.MethodPost, Path: "/api/merchants/confirm-update/:merchantId", Handler: "ConfirmMerchantUpdate", Tag: "merchants", Summary: "Confirm a mined merchant update", Auth: Session, Request: models.ConfirmTransactionRequest{}},
+	{Method: http.MethodPost, Path: "/api/merchants/prepare-refund/:orderId", Handler: "PrepareRefundOrderMerchant", Tag: "merchants", Summary: "Prepare a merchant refund transaction", Auth: Session, Response: models.PrepareRefundResponse{}},
+	{Method: http.MethodPost, Path: "/api/merchants/confirm-refund/:orderId", Handler: "ConfirmRefundOrderMerchant", Tag: "merchants", Summary: "Confirm a mined merchant refund", Description: "The transaction must call refundOrder on the payment processor for the order and carry its OrderRefunded event.", Auth: Session, Request: models.ConfirmRefundRequest{}},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/orders/stream", Handler: "StreamMerchantOrders", Tag: "merchants", Summary: "WebSocket feed of the merchant's order transitions", Description: "Upgrades to a WebSocket carrying OrderEvent messages. Browsers, which cannot set headers on the upgrade, offer the subprotocols stablemarket.v1 and bearer.<session token>; the server selects stablemarket.v1.", Auth: Session, Query: []Param{{Name: "lastEventId", Type: "integer", Description: "Resume after this event id"}}, Status: http.StatusSwitchingProtocols, ContentType: "application/json"},
+
+	// Platform
+	{Method: http.MethodPost, Path: "/api/platform/emergency-withdrawal", Handler: "EmergencyWithdraw", Tag: "platform", Summary: "Emergency withdrawal", Auth: Session, Permission: models.PermissionWithdrawalExecute, Request: models.EmergencyWithdraw{}},
+	{Method: http.MethodPost, Path: "/api/platform/enable-emergency-withdrawal", Handler: "SetEmergencyWithdrawalEnabled", Tag: "platform", Summary: "Enable or disable emergency withdrawals", Auth: Session, Permission: models.PermissionWithdrawalConfigure, Request: models.WithdrawalStatus{}},
+	{Method: http.MethodPost, Path: "/api/platform/update-merchant-registry", Handler: "UpdateMerchantRegistry", Tag: "platform", Summary: "Point the platform at a merchant registry", Auth: Session, Permission: models.PermissionRegistryUpdate, Request: models.MerchantRegistryUpdate{}},
+	{Method: http.MethodPost, Path: "/api/platform/set-token-support", Handler: "SetTokenSupport", Tag: "platform", Summary: "Add or remove a supported token", Auth: Session, Permission: models.PermissionTokenManage, Request: models.TokenSupport{}},
+	{Method: http.MethodPost, Path: "/api/platform/merchant-verification-status", Handler: "UpdateMerchantVerificationStatus", Tag: "platform", Summary: "Set a merchant's verification status", Auth: Session, Permission: models.PermissionMerchantVerify, Request: models.UpdateMerchantVerificationStatus{}},
+	{Method: http.MethodGet, Path: "/api/platform/token-balance", Handler: "GetPlatformTokenBalance", Tag: "platform", Summary: "Token balance of a platform wallet", Auth: Session, Permission: models.PermissionBalanceRead, QueryType: models.PlatformBalanceCheck{}},
+	{Method: http.MethodGet, Path: "/api/platform/contract-token-balance", Handler: "GetContractTokenBalance", Tag: "platform", Summary: "Token balance held by the platform contract", Auth: Session, Permission: models.PermissionBalanceRead, QueryType: models.ContractBalanceCheck{}},
+	{Method: http.MethodGet, Path: "/api/platform/contract-balance-history", Handler: "GetContractBalanceHistory", Tag: "platform", Summary: "Payment processor contract balance history", Description: "Closing balance of each bucket from the scheduled snapshots, one series per network and token.", Auth: Session, Permission: models.PermissionBalanceRead, Query: withQuery(balanceHistory, dateRange), Response: models.BalanceHistoryResponse{}},
+	{Method: http.MethodGet, Path: "/api/platform/fee-report", Handler: "GetFeeReport", Tag: "platform", Summary: "Platform fee revenue per period, merchant and token", Description: "Built from the settlements recorded when settleOrder transactions are confirmed. Amounts are token base units.", Auth: Session, Permission: models.PermissionFeesRead, Query: withQuery(reportGrouping, []Param{{Name: "merchantId", Description: "Only this merchant"}}, dateRange), Response: models.FeeReportResponse{}},
+	{Method: http.MethodPost, Path: "/api/platform/approve-token", Handler: "PrepareApproveToken", Tag: "platform", Summary: "Prepare a token approval transaction", Request: models.ApproveTokenRequest{}, Response: models.PrepareApproveResponse{}},
+	{Method: http.MethodPost, Path: "/api/platform/confirm-approve", Handler: "ConfirmApproveToken", Tag: "platform", Summary: "Confirm a mined token approval", Request: models.ConfirmApproveRequest{}, Response: models.ConfirmApproveResponse{}},
+	{Method: http.MethodPost, Path: "/api/platform/prepare-settle", Handler: "PrepareSettleOrder", Tag: "platform", Summary: "Prepare a settlement transaction", Auth: Session, Permission: models.PermissionOrderSettle, Request: models.PrepareSettleOrderRequest{}, Response: models.PrepareSettleOrderResponse{}},
+	{Method: http.MethodPost, Path: "/api/platform/confirm-settle", Handler: "ConfirmSettleOrder", Tag: "platform", Summary: "Confirm a mined settlement", Description: "Orders under an open dispute are refused with ORDER_DISPUTED. The transaction must call settleOrder on the payment processor for the order and carry its OrderSettled event. The payout wallet, merchant net and platform fee are read from that event and recorded for the fee report; the token transfer to the payout wallet must cover the net.", Auth: Session, Permission: models.PermissionOrderSettle, Request: models.ConfirmSettleOrderRequest{}},
+	{Method: http.MethodPost, Path: "/api/platform/prepare-batch-settle", Handler: "PrepareBatchSettle", Tag: "platform", Summary: "Prepare settlement transactions for a batch of orders", Description: "Returns one settleOrder transaction per settleable order, up to 50 orders. Orders that cannot be settled are reported with an error code instead of failing the batch.", Auth: Session, Permission: models.PermissionOrderSettle, Request: models.PrepareBatchOrdersRequest{}, Response: models.BatchOrdersResponse{}},
+	{Method: http.MethodPost, Path: "/api/platform/confirm-batch-settle", Handler: "ConfirmBatchSettle", Tag: "platform", Summary: "Confirm a batch of mined settlements", Description: "Each order is matched to the transaction that called the payment processor's settleOrder for it and logged OrderSettled, then settled and its fee split recorded. Orders under an open dispute fail with ORDER_DISPUTED. Orders fail individually; an order whose transaction is not mined yet can be confirmed again.", Auth: Session, Permission: models.PermissionOrderSettle, Request: models.ConfirmBatchOrdersRequest{}, Response: models.BatchOrdersResponse{}},
+	{Method: http.MethodGet, Path: "/api/platform/orders/:orderId/settlement", Handler: "GetOrderSettlement", Tag: "platform", Summary: "Get an order's automatic settlement state", Description: "The merchant's payout policy, the order's settlement holds and the settlement worker's attempts, newest first.", Auth: Session, Permission: models.PermissionOrderSettle, Response: models.OrderSettlementStatus{}},
+	{Method: http.MethodPut, Path: "/api/platform/orders/:orderId/settlement-hold", Handler: "HoldOrderSettlement", Tag: "platform", Summary: "Hold an order back from automatic settlement", Description: "Settling the order by hand is still possible.", Auth: Session, Permission: models.PermissionOrderSettle, Request: models.HoldSettlementRequest{}, OptionalBody: true, Response: models.SettlementHoldDB{}},
+	{Method: http.MethodDelete, Path: "/api/platform/orders/:orderId/settlement-hold", Handler: "ReleaseOrderSettlement", Tag: "platform", Summary: "Release an operator's settlement hold", Description: "Holds placed for other reasons stay in place.", Auth: Session, Permission: models.PermissionOrderSettle},
+	{Method: http.MethodPost, Path: "/api/platform/prepare-refund", Handler: "PrepareRefundOrder", Tag: "platform", Summary: "Prepare a platform refund transaction", Auth: Session, Permission: models.PermissionOrderRefund, Request: models.PrepareRefundOrderRequest{}, Response: models.PrepareRefundResponse{}},
+	{Method: http.MethodPost, Path: "/api/platform/confirm-refund", Handler: "ConfirmRefundOrder", Tag: "platform", Summary: "Confirm a mined platform refund", Description: "The transaction must call refundOrder on the payment processor for the order and carry its OrderRefunded event.", Auth: Session, Permission: models.PermissionOrderRefund, Request: models.ConfirmRefundOrderRequest{}},
+	{Method: http.MethodPost, Path: "/api/platform/prepare-batch-refund", Handler: "PrepareBatchRefund", Tag: "platform", Summary: "Prepare refund transactions for a batch of orders", Description: "Returns one refundOrder transaction per refundable order, up to 50 orders. Orders that cannot be refunded are reported with an error code instead of failing the batch.", Auth: Session, Permission: models.PermissionOrderRefund, Request: models.PrepareBatchOrdersRequest{}, Response: models.BatchOrdersResponse{}},
+	{Method: http.MethodPost, Path: "/api/platform/confirm-batch-refund", Handler: "ConfirmBatchRefund", Tag: "platform", Summary: "Confirm a batch of mined refunds", Description: "Each order is matched to the transaction that called the payment processor's refundOrder for it and logged OrderRefunded. Orders fail individually; an order whose transaction is not mined yet can be confirmed again.", Auth: Session, Permission: models.PermissionOrderRefund, Request: models.ConfirmBatchOrdersRequest{}, Response: models.BatchOrdersResponse{}},
+
+	// Admin
+	{Method: http.MethodGet, Path: "/api/admin/roles", Handler: "ListRoles", Tag: "admin", Summary: "List role grants", Auth: Session, Permission: models.PermissionRolesManage, Query: []Param{{Name: "walletAddress"}}},
+	{Method: http.MethodPost, Path: "/api/admin/roles", Handler: "GrantRole", Tag: "admin", Summary: "Grant a role", Auth: Session, Permission: models.PermissionRolesManage, Request: models.GrantRoleRequest{}, Status: http.StatusCreated},
+	{Method: http.MethodDelete, Path: "/api/admin/roles/:walletAddress/:role", Handler: "RevokeRole", Tag: "admin", Summary: "Revoke a role", Auth: Session, Permission: models.PermissionRolesManage, Request: models.RevokeRoleRequest{}, OptionalBody: true},
+	{Method: http.MethodGet, Path: "/api/admin/roles/audit", Handler: "ListRoleAudit", Tag: "admin", Summary: "Role grant audit log", Auth: Session, Permission: models.PermissionAuditRead, Query: withQuery([]Param{{Name: "walletAddress"}}, pagination), Response: models.RoleAuditListResponse{}},
+
+	// Orders
+	{Method: http.MethodPost, Path: "/api/orders/prepare-create", Handler: "PrepareCreateOrder", Tag: "orders", Summary: "Prepare a createOrder transaction", Description: "With a productId the product must belong to merchantId and amount must be its price times quantity in the token; its stock is then reserved.", Auth: OptionalAPIKey, Scope: models.APIKeyScopeOrdersWrite, Request: models.CreateOrderRequest{}, Status: http.StatusCreated, Response: models.PrepareCreateOrderResponse{}},
+	{Method: http.MethodPost, Path: "/api/orders/confirm-create", Handler: "ConfirmCreateOrder", Tag: "orders", Summary: "Confirm a mined createOrder transaction", Description: "The order expires after the merchant's order expiry policy. expiresIn overrides it, in seconds, only when the request is made with the merchant's API key or its owner's session; it is ignored otherwise. tokenAddress, amount and metadataURI must match the order created on-chain. A createOrder transaction that was already confirmed answers 409 ORDER_EXISTS.", Auth: OptionalSessionOrAPIKey, Scope: models.APIKeyScopeOrdersWrite, Request: models.ConfirmCreateOrderRequest{}, Response: models.ConfirmCreateOrderResponse{}},
+	{Method: http.MethodPost, Path: "/api/orders/prepare-pay-order", Handler: "PreparePayOrder", Tag: "orders", Summary: "Prepare a payOrder transaction", Description: "Orders past their expiry answer 410 ORDER_EXPIRED.", Request: models.PrepareOrder{}, Response: models.PreparePayOrderResponse{}},
+	{Method: http.MethodPost, Path: "/api/orders/confirm-pay-order", Handler: "ConfirmPayOrder", Tag: "orders", Summary: "Confirm a mined payOrder transaction", Description: "The transaction must call payOrder on the payment processor for the order and carry its OrderPaid event.", Request: models.ConfirmPayOrderRequest{}, Response: models.ConfirmPayOrderResponse{}},
+	{Method: http.MethodPost, Path: "/api/orders/cancel", Handler: "CancelOrder", Tag: "orders", Summary: "Cancel an unpaid order", Description: "Only the order's merchant, signed in or with an API key, can cancel it.", Auth: SessionOrAPIKey, Scope: models.APIKeyScopeOrdersWrite, Request: models.PrepareOrder{}},
+	{Method: http.MethodGet, Path: "/api/orders", Handler: "ListOrders", Tag: "orders", Summary: "List orders", Auth: OptionalAPIKey, Scope: models.APIKeyScopeOrdersRead, Query: withQuery([]Param{
+		{Name: "merchantId"},
+		{Name: "payerAddress"},
+		{Name: "token", Description: "Token address"},
+		{Name: "status", Enum: orderStatuses},
+		{Name: "network"},
+	}, dateRange, pagination), Response: models.OrderListResponse{}},
+	{Method: http.MethodGet, Path: "/api/orders/:orderId", Handler: "GetOrderById", Tag: "orders", Summary: "Get an order with its status history", Auth: OptionalAPIKey, Scope: models.APIKeyScopeOrdersRead, Response: models.OrderDetails{}},
+	{Method: http.MethodGet, Path: "/api/orders/:orderId/events", Handler: "StreamOrderEvents", Tag: "orders", Summary: "Stream an order's status transitions", Description: "Reconnecting clients send Last-Event-ID to receive only what they missed.", ContentType: "text/event-stream"},
+
+	// Market
+	{Method: http.MethodPost, Path: "/api/market/add-product", Handler: "CreateProduct", Tag: "market", Summary: "Add a product", Auth: SessionOrAPIKey, Scope: models.APIKeyScopeProductsWrite, Form: []Param{
+		{Name: "name", Required: true},
+		{Name: "price", Type: "number", Required: true},
+		{Name: "description"},
+		{Name: "merchantId", Required: true},
+		{Name: "stock", Type: "integer", Description: "Omit for untracked inventory"},
+		{Name: "file", Type: "file", Required: true, Description: "Product image"},
+	}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/market/products", Handler: "GetAllProducts", Tag: "market", Summary: "List products", Query: withQuery([]Param{{Name: "merchantId"}}, pagination), Response: models.ProductListResponse{}},
+	{Method: http.MethodGet, Path: "/api/market/products/:productId", Handler: "GetProductById", Tag: "market", Summary: "Get a product", Response: models.Products{}},
+	{Method: http.MethodPatch, Path: "/api/market/products/:productId", Handler: "UpdateProduct", Tag: "market", Summary: "Update a product", Description: "Only the fields sent are changed.", Auth: SessionOrAPIKey, Scope: models.APIKeyScopeProductsWrite, Form: productForm},
+	{Method: http.MethodDelete, Path: "/api/market/products/:productId", Handler: "DeleteProduct", Tag: "market", Summary: "Delete a product", Auth: SessionOrAPIKey, Scope: models.APIKeyScopeProductsWrite},
+	{Method: http.MethodGet, Path: "/api/market/merchants/:merchantId/products", Handler: "GetMerchantProducts", Tag: "market", Summary: "List a merchant's products", Query: pagination, Response: models.ProductListResponse{}},
+	{Method: http.MethodGet, Path: "/api/market/merchants/:merchantId/low-stock", Handler: "GetLowStockProducts", Tag: "market", Summary: "Products at or below a stock threshold", Auth: Session, Query: []Param{{Name: "threshold", Type: "integer", Default: 5}}},
+
+	// Carts
+	{Method: http.MethodPost, Path: "/api/carts", Handler: "CreateCart", Tag: "carts", Summary: "Create a cart", Request: models.CreateCartRequest{}, Status: http.StatusCreated, Response: models.CartResponse{}},
+	{Method: http.MethodGet, Path: "/api/carts/:cartId", Handler: "GetCart", Tag: "carts", Summary: "Get a cart", Response: models.CartResponse{}},
+	{Method: http.MethodPost, Path: "/api/carts/:cartId/items", Handler: "AddCartItem", Tag: "carts", Summary: "Add an item to a cart", Request: models.CartItemRequest{}, Response: models.CartResponse{}},
+	{Method: http.MethodPatch, Path: "/api/carts/:cartId/items/:productId", Handler: "UpdateCartItem", Tag: "carts", Summary: "Change an item's quantity", Request: models.UpdateCartItemRequest{}, Response: models.CartResponse{}},
+	{Method: http.MethodDelete, Path: "/api/carts/:cartId/items/:productId", Handler: "RemoveCartItem", Tag: "carts", Summary: "Remove an item from a cart", Response: models.CartResponse{}},
+	{Method: http.MethodPost, Path: "/api/carts/:cartId/checkout", Handler: "CheckoutCart", Tag: "carts", Summary: "Prepare a createOrder transaction for the cart total", Description: "A checked out cart cannot be edited. It can be checked out again, replacing the earlier checkout, once checkoutExpiresAt passes; until then, and while a checkout session holds the cart, the request answers 409 CART_CHECKOUT_ACTIVE.", Request: models.CheckoutCartRequest{}, Status: http.StatusCreated, Response: models.CheckoutCartResponse{}},
+
+	// Metadata
+	{Method: http.MethodGet, Path: "/api/metadata/resolve", Handler: "ResolveMetadata", Tag: "metadata", Summary: "Fetch and verify a metadata document", Query: []Param{
+		{Name: "uri"},
+		{Name: "transactionHash"},
+		{Name: "orderId", Description: "Resolve the order's metadata URI"},
+		{Name: "merchantId", Description: "Resolve the merchant's metadata URI"},
+	}, Response: models.MetadataResolution{}},
+	{Method: http.MethodGet, Path: "/api/metadata/:hash", Handler: "GetMetadataDocument", Tag: "metadata", Summary: "Get a stored metadata document by its sha256 hash", ContentType: "application/json"},
+
+	// Checkout
+	{Method: http.MethodPost, Path: "/api/checkout/sessions", Handler: "CreateCheckoutSession", Tag: "checkout", Summary: "Create a checkout session", Description: "Only the merchant, signed in or with an API key, can create its sessions. successUrl and cancelUrl must be absolute https URLs.", Auth: SessionOrAPIKey, Scope: models.APIKeyScopeOrdersWrite, Request: models.CreateCheckoutSessionRequest{}, Status: http.StatusCreated, Response: models.CheckoutSessionResponse{}},
+	{Method: http.MethodGet, Path: "/api/checkout/sessions/:sessionId", Handler: "GetCheckoutSession", Tag: "checkout", Summary: "Get a checkout session", Response: models.CheckoutSessionResponse{}},
+	{Method: http.MethodGet, Path: "/api/checkout/sessions/:sessionId/qr.png", Handler: "GetCheckoutSessionQRCode", Tag: "checkout", Summary: "QR code for the session", Query: []Param{{Name: "target", Enum: []string{"url"}, Description: "Encode the hosted checkout URL instead of the payment URI"}}, ContentType: "image/png"},
+	{Method: http.MethodPost, Path: "/api/checkout/sessions/:sessionId/approve", Handler: "ApproveCheckoutSession", Tag: "checkout", Summary: "Prepare the token approval for the session", Response: models.CheckoutApproveResponse{}},
+	{Method: http.MethodPost, Path: "/api/checkout/sessions/:sessionId/confirm-approve", Handler: "ConfirmApproveCheckoutSession", Tag: "checkout", Summary: "Confirm the session's token approval", Request: models.CheckoutStepRequest{}, OptionalBody: true, Response: models.CheckoutConfirmApproveResponse{}},
+	{Method: http.MethodPost, Path: "/api/checkout/sessions/:sessionId/create", Handler: "CreateCheckoutOrder", Tag: "checkout", Summary: "Prepare the session's createOrder transaction", Request: models.CheckoutStepRequest{}, OptionalBody: true, Status: http.StatusCreated, Response: models.CheckoutOrderResponse{}},
+	{Method: http.MethodPost, Path: "/api/checkout/sessions/:sessionId/confirm-create", Handler: "ConfirmCheckoutOrder", Tag: "checkout", Summary: "Confirm the session's order", Description: "The order created on-chain must carry the session's token, amount and order document.", Request: models.CheckoutStepRequest{}, OptionalBody: true, Response: models.CheckoutConfirmOrderResponse{}},
+	{Method: http.MethodPost, Path: "/api/checkout/sessions/:sessionId/pay", Handler: "PayCheckoutOrder", Tag: "checkout", Summary: "Prepare the session's payOrder transaction", Response: models.CheckoutPayResponse{}},
+	{Method: http.MethodPost, Path: "/api/checkout/sessions/:sessionId/confirm-pay", Handler: "ConfirmCheckoutPayment", Tag: "checkout", Summary: "Confirm the session's payment", Request: models.CheckoutStepRequest{}, OptionalBody: true, Response: models.CheckoutConfirmPaymentResponse{}},
+
+	// Webhooks
+	{Method: http.MethodPost, Path: "/api/merchants/:merchantId/webhooks", Handler: "CreateWebhook", Tag: "webhooks", Summary: "Register a webhook endpoint", Description: "The url must be https on a public host; redirects are not followed. The signing secret is only returned here and by rotate-secret.", Auth: Session, Request: models.CreateWebhookRequest{}, Status: http.StatusCreated, Response: models.WebhookEndpoint{}},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/webhooks", Handler: "ListWebhooks", Tag: "webhooks", Summary: "List webhook endpoints", Auth: Session},
+	{Method: http.MethodPatch, Path: "/api/merchants/:merchantId/webhooks/:webhookId", Handler: "UpdateWebhook", Tag: "webhooks", Summary: "Update a webhook endpoint", Auth: Session, Request: models.UpdateWebhookRequest{}, Response: models.WebhookEndpoint{}},
+	{Method: http.MethodDelete, Path: "/api/merchants/:merchantId/webhooks/:webhookId", Handler: "DeleteWebhook", Tag: "webhooks", Summary: "Delete a webhook endpoint", Auth: Session},
+	{Method: http.MethodPost, Path: "/api/merchants/:merchantId/webhooks/:webhookId/rotate-secret", Handler: "RotateWebhookSecret", Tag: "webhooks", Summary: "Rotate an endpoint's signing secret", Auth: Session},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/webhooks/:webhookId/deliveries", Handler: "ListWebhookDeliveries", Tag: "webhooks", Summary: "List deliveries to an endpoint", Auth: Session, Query: pagination, Response: models.WebhookDeliveryListResponse{}},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/webhooks/:webhookId/deliveries/:deliveryId", Handler: "GetWebhookDelivery", Tag: "webhooks", Summary: "Get a delivery with its attempts", Auth: Session},
+	{Method: http.MethodPost, Path: "/api/merchants/:merchantId/webhooks/:webhookId/deliveries/:deliveryId/redeliver", Handler: "RedeliverWebhook", Tag: "webhooks", Summary: "Queue a delivery again", Auth: Session},
+
+	// API keys
+	{Method: http.MethodPost, Path: "/api/merchants/:merchantId/api-keys", Handler: "CreateAPIKey", Tag: "api-keys", Summary: "Create an API key", Description: "The key is only returned in this response.", Auth: Session, Request: models.CreateAPIKeyRequest{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/api-keys", Handler: "ListAPIKeys", Tag: "api-keys", Summary: "List API keys", Auth: Session},
+	{Method: http.MethodDelete, Path: "/api/merchants/:merchantId/api-keys/:keyId", Handler: "RevokeAPIKey", Tag: "api-keys", Summary: "Revoke an API key", Auth: Session},
+
+	// Exports. Merchant kinds are orders, settlements and refunds; the platform
+	// can also export withdrawals.
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/exports/:kind", Handler: "ExportMerchantRecords", Tag: "exports", Summary: "Download the merchant's orders, settlements or refunds", Description: "Served as text/csv, application/x-ndjson or application/pdf. Exports of more than 5000 records fail with EXPORT_TOO_LARGE; queue them as a job instead.", Auth: Session, Query: exportParams, ContentType: "application/octet-stream"},
+	{Method: http.MethodPost, Path: "/api/merchants/:merchantId/exports/:kind", Handler: "CreateMerchantExportJob", Tag: "exports", Summary: "Queue a merchant export job", Description: "The file is written in the background and kept for 7 days; poll the job for its download link.", Auth: Session, Query: exportParams, Status: http.StatusAccepted, Response: models.ExportJob{}},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/export-jobs", Handler: "ListMerchantExportJobs", Tag: "exports", Summary: "List the merchant's recent export jobs", Auth: Session, Response: models.ExportJobListResponse{}},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/export-jobs/:jobId", Handler: "GetMerchantExportJob", Tag: "exports", Summary: "Get a merchant export job", Auth: Session, Response: models.ExportJob{}},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/export-jobs/:jobId/download", Handler: "DownloadMerchantExport", Tag: "exports", Summary: "Download a merchant export job's file", Description: "Redirects with 302 to a short-lived signed URL when files are kept in shared storage, and serves the file directly otherwise.", Auth: Session, ContentType: "application/octet-stream"},
+	{Method: http.MethodGet, Path: "/api/platform/exports/:kind", Handler: "ExportPlatformRecords", Tag: "exports", Summary: "Download every merchant's orders, settlements, refunds or withdrawals", Description: "Served as text/csv, application/x-ndjson or application/pdf. Exports of more than 5000 records fail with EXPORT_TOO_LARGE; queue them as a job instead.", Auth: Session, Permission: models.PermissionExportsRead, Query: exportParams, ContentType: "application/octet-stream"},
+	{Method: http.MethodPost, Path: "/api/platform/exports/:kind", Handler: "CreatePlatformExportJob", Tag: "exports", Summary: "Queue a platform export job", Description: "The file is written in the background and kept for 7 days; poll the job for its download link.", Auth: Session, Permission: models.PermissionExportsRead, Query: exportParams, Status: http.StatusAccepted, Response: models.ExportJob{}},
+	{Method: http.MethodGet, Path: "/api/platform/export-jobs", Handler: "ListPlatformExportJobs", Tag: "exports", Summary: "List recent platform export jobs", Auth: Session, Permission: models.PermissionExportsRead, Response: models.ExportJobListResponse{}},
+	{Method: http.MethodGet, Path: "/api/platform/export-jobs/:jobId", Handler: "GetPlatformExportJob", Tag: "exports", Summary: "Get a platform export job", Auth: Session, Permission: models.PermissionExportsRead, Response: models.ExportJob{}},
+	{Method: http.MethodGet, Path: "/api/platform/export-jobs/:jobId/download", Handler: "DownloadPlatformExport", Tag: "exports", Summary: "Download a platform export job's file", Description: "Redirects with 302 to a short-lived signed URL when files are kept in shared storage, and serves the file directly otherwise.", Auth: Session, Permission: models.PermissionExportsRead, ContentType: "application/octet-stream"},
+
+	// Invoices. The buyer's wallet and the merchant's owner can read an order's documents.
+	{Method: http.MethodGet, Path: "/api/orders/:orderId/invoice", Handler: "GetOrderInvoice", Tag: "invoices", Summary: "Get a paid order's invoice", Description: "Invoices are numbered per merchant without gaps (INV-000001, ...) and snapshot the merchant, line items and payment transaction. Orders that are not paid yet fail with INVOICE_NOT_ISSUED.", Auth: Session, Query: documentFormat, ContentType: "text/html"},
+	{Method: http.MethodGet, Path: "/api/orders/:orderId/credit-note", Handler: "GetOrderCreditNote", Tag: "invoices", Summary: "Get a refunded order's credit note", Description: "Credit notes are numbered per merchant (CN-000001, ...) and name the invoice they credit.", Auth: Session, Query: documentFormat, ContentType: "text/html"},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/invoices", Handler: "ListMerchantInvoices", Tag: "invoices", Summary: "List the merchant's invoices and credit notes", Auth: Session, Query: withQuery([]Param{{Name: "type", Enum: []string{models.DocumentTypeInvoice, models.DocumentTypeCreditNote}, Description: "Only documents of this type"}}, pagination[:2]), Response: models.InvoiceListResponse{}},
+
+	// Disputes. The buyer's wallet and the merchant's owner take part in an order's dispute.
+	{Method: http.MethodPost, Path: "/api/orders/:orderId/dispute", Handler: "OpenOrderDispute", Tag: "disputes", Summary: "Dispute a paid order", Description: "Only the buyer can dispute, and only while the order is paid and not settled. The order cannot be settled until a reviewer resolves the dispute. reason is not_received, not_as_described, unauthorized or other.", Auth: Session, Request: models.OpenDisputeRequest{}, Status: http.StatusCreated, Response: models.DisputeDetails{}},
+	{Method: http.MethodGet, Path: "/api/orders/:orderId/dispute", Handler: "GetOrderDispute", Tag: "disputes", Summary: "Get an order's dispute and its recorded steps", Auth: Session, Response: models.DisputeDetails{}},
+	{Method: http.MethodPost, Path: "/api/orders/:orderId/dispute/evidence", Handler: "AddOrderDisputeEvidence", Tag: "disputes", Summary: "Upload an evidence image", Description: "The buyer and the merchant can each add evidence until the dispute is resolved, up to 10 images per dispute.", Auth: Session, Form: disputeEvidenceForm, Status: http.StatusCreated, Response: models.DisputeEventDB{}},
+	{Method: http.MethodPost, Path: "/api/orders/:orderId/dispute/response", Handler: "RespondOrderDispute", Tag: "disputes", Summary: "Respond to a dispute as the merchant", Description: "Merchants that accept the dispute can refund the order instead, which resolves it.", Auth: Session, Request: models.RespondDisputeRequest{}, Response: models.DisputeDetails{}},
+	{Method: http.MethodGet, Path: "/api/merchants/:merchantId/disputes", Handler: "ListMerchantDisputes", Tag: "disputes", Summary: "List the disputes on the merchant's orders", Auth: Session, Query: withQuery(disputeStatus, pagination[:2]), Response: models.DisputeListResponse{}},
+	{Method: http.MethodGet, Path: "/api/platform/disputes", Handler: "ListDisputes", Tag: "disputes", Summary: "List disputes for review", Auth: Session, Permission: models.PermissionDisputeResolve, Query: withQuery(disputeStatus, pagination[:2]), Response: models.DisputeListResponse{}},
+	{Method: http.MethodGet, Path: "/api/platform/orders/:orderId/dispute", Handler: "GetDispute", Tag: "disputes", Summary: "Get a dispute for review", Auth: Session, Permission: models.PermissionDisputeResolve, Response: models.DisputeDetails{}},
+	{Method: http.MethodPost, Path: "/api/platform/orders/:orderId/dispute/resolve", Handler: "ResolveOrderDispute", Tag: "disputes", Summary: "Resolve a dispute by refunding or settling the order", Description: "The decision is recorded and its refund or settlement is queued for the settlement worker, which sends it with the platform key; follow it at the order's settlement status in Location. Settling also needs the order:settle permission. If the payout fails the decision stands, and resolving again with the same outcome queues it again.", Auth: Session, Permission: models.PermissionDisputeResolve, Request: models.ResolveDisputeRequest{}, Status: http.StatusAccepted, Response: models.ResolveDisputeResponse{}},
+}
@@ -0,0 +1,48 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Verify compares the route table with the routes registered on the router. It
+// reports routes the document is missing, documented routes that are not
+// registered, and routes served by a different handler than documented.
+func Verify(registered gin.RoutesInfo) error {
+	documented := make(map[string]string, len(Routes))
+	for _, route := range Routes {
+		documented[route.Method+" "+route.Path] = route.Handler
+	}
+
+	var problems []string
+	for _, info := range registered {
+		key := info.Method + " " + info.Path
+		handler, ok := documented[key]
+		if !ok {
+			problems = append(problems, key+" is not documented")
+			continue
+		}
+		delete(documented, key)
+
+		if name := handlerName(info.Handler); name != handler {
+			problems = append(problems, fmt.Sprintf("%s is served by %s but documented as %s", key, name, handler))
+		}
+	}
+	for key := range documented {
+		problems = append(problems, key+" is documented but not registered")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("openapi document does not match the router:\n  %s", strings.Join(problems, "\n  "))
+}
+
+// handlerName strips the package path from a handler's function name
+func handlerName(fullName string) string {
+	return fullName[strings.LastIndex(fullName, ".")+1:]
+}
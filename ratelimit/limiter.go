@@ -0,0 +1,136 @@
+// Package ratelimit implements token bucket rate limiting over a pluggable store
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Budgets group routes by how expensive they are to serve
+const (
+	BudgetRead   = "read"
+	BudgetWrite  = "write"
+	BudgetRPC    = "rpc"
+	BudgetUpload = "upload"
+)
+
+// Limit is a token bucket: Burst requests at once, refilled at Burst per Period
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Rate returns the refill rate in tokens per second
+func (l Limit) Rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Store keeps bucket state. Take removes one token from the bucket under key.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+var defaultLimits = map[string]Limit{
+	BudgetRead:   {Burst: 120, Period: time.Minute},
+	BudgetWrite:  {Burst: 30, Period: time.Minute},
+	BudgetRPC:    {Burst: 10, Period: time.Minute},
+	BudgetUpload: {Burst: 5, Period: time.Minute},
+}
+
+var store Store
+
+// Init configures the store from RATE_LIMIT_STORE ("memory" or "redis", default "memory")
+func Init() error {
+	switch os.Getenv("RATE_LIMIT_STORE") {
+	case "", "memory":
+		store = NewMemoryStore()
+	case "redis":
+		redisURL := os.Getenv("REDIS_URL")
+		if redisURL == "" {
+			return errors.New("REDIS_URL must be set when RATE_LIMIT_STORE=redis")
+		}
+
+		redisStore, err := NewRedisStore(redisURL)
+		if err != nil {
+			return err
+		}
+		store = redisStore
+	default:
+		return errors.New("RATE_LIMIT_STORE must be memory or redis")
+	}
+
+	return nil
+}
+
+// Default returns the configured store, or nil if Init has not run
+func Default() Store {
+	return store
+}
+
+// SetDefault replaces the configured store
+func SetDefault(s Store) {
+	store = s
+}
+
+// LimitFor returns the limit for a budget within a route group. It reads
+// RATE_LIMIT_<GROUP>_<BUDGET>, then RATE_LIMIT_<BUDGET>, as "<requests>/<period>"
+// (e.g. "30/1m"), and falls back to the built in default. "off" disables the limit.
+func LimitFor(group, budget string) (Limit, bool) {
+	for _, name := range []string{envName(group, budget), envName("", budget)} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		if value == "off" {
+			return Limit{}, false
+		}
+
+		limit, err := ParseLimit(value)
+		if err == nil {
+			return limit, true
+		}
+	}
+
+	limit, ok := defaultLimits[budget]
+	return limit, ok
+}
+
+// ParseLimit parses "<requests>/<period>", where period is a Go duration
+func ParseLimit(value string) (Limit, error) {
+	requests, period, found := strings.Cut(value, "/")
+	if !found {
+		return Limit{}, fmt.Errorf("ratelimit: %q is not <requests>/<period>", value)
+	}
+
+	burst, err := strconv.Atoi(requests)
+	if err != nil || burst <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid request count in %q", value)
+	}
+
+	duration, err := time.ParseDuration(period)
+	if err != nil || duration <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid period in %q", value)
+	}
+
+	return Limit{Burst: burst, Period: duration}, nil
+}
+
+func envName(group, budget string) string {
+	name := "RATE_LIMIT_"
+	if group != "" {
+		name += strings.ToUpper(strings.ReplaceAll(group, "-", "_")) + "_"
+	}
+	return name + strings.ToUpper(budget)
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	limit := Limit{Burst: 3, Period: 3 * time.Second}
+	start := time.Unix(1700000000, 0)
+
+	type take struct {
+		key           string
+		after         time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}
+	tests := []struct {
+		name  string
+		takes []take
+	}{
+		{
+			name: "burst then denied",
+			takes: []take{
+				{key: "a", wantAllowed: true, wantRemaining: 2},
+				{key: "a", wantAllowed: true, wantRemaining: 1},
+				{key: "a", wantAllowed: true, wantRemaining: 0},
+				{key: "a", wantRetry: time.Second},
+			},
+		},
+		{
+			name: "refills over time",
+			takes: []take{
+				{key: "a", wantAllowed: true, wantRemaining: 2},
+				{key: "a", wantAllowed: true, wantRemaining: 1},
+				{key: "a", wantAllowed: true, wantRemaining: 0},
+				{key: "a", after: 500 * time.Millisecond, wantRetry: 500 * time.Millisecond},
+				{key: "a", after: time.Second, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+		{
+			name: "refill is capped at the burst",
+			takes: []take{
+				{key: "a", wantAllowed: true, wantRemaining: 2},
+				{key: "a", after: time.Hour, wantAllowed: true, wantRemaining: 2},
+			},
+		},
+		{
+			name: "keys have separate buckets",
+			takes: []take{
+				{key: "a", wantAllowed: true, wantRemaining: 2},
+				{key: "a", wantAllowed: true, wantRemaining: 1},
+				{key: "a", wantAllowed: true, wantRemaining: 0},
+				{key: "b", wantAllowed: true, wantRemaining: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			now := start
+			for i, tk := range tt.takes {
+				now = now.Add(tk.after)
+				got, err := store.Take(context.Background(), tk.key, limit, now)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := Result{Allowed: tk.wantAllowed, Remaining: tk.wantRemaining, RetryAfter: tk.wantRetry}
+				if got != want {
+					t.Errorf("take %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Limit
+		wantErr bool
+	}{
+		{value: "10/1m", want: Limit{Burst: 10, Period: time.Minute}},
+		{value: "5/30s", want: Limit{Burst: 5, Period: 30 * time.Second}},
+		{value: "10", wantErr: true},
+		{value: "0/1m", wantErr: true},
+		{value: "ten/1m", wantErr: true},
+		{value: "10/minute", wantErr: true},
+		{value: "10/0s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLimit(%q) err = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
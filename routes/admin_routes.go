@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminRoutes configures role management routes
+func SetupAdminRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("admin")
+
+	admin := router.Group("/api/admin")
+	{
+		admin.GET("/roles", limits.Read, middleware.RequirePermission(models.PermissionRolesManage), controllers.ListRoles)
+		admin.POST("/roles", limits.Write, middleware.RequirePermission(models.PermissionRolesManage), controllers.GrantRole)
+		admin.DELETE("/roles/:walletAddress/:role", limits.Write, middleware.RequirePermission(models.PermissionRolesManage), controllers.RevokeRole)
+		admin.GET("/roles/audit", limits.Read, middleware.RequirePermission(models.PermissionAuditRead), controllers.ListRoleAudit)
+	}
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAPIKeyRoutes configures merchant API key management. Keys are managed from
+// a wallet session only, so a leaked key cannot mint more keys.
+func SetupAPIKeyRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("api-keys")
+
+	apiKeys := router.Group("/api/merchants/:merchantId/api-keys", middleware.RequireMerchantOwner("merchantId"))
+	{
+		apiKeys.POST("", limits.Write, controllers.CreateAPIKey)
+		apiKeys.GET("", limits.Read, controllers.ListAPIKeys)
+		apiKeys.DELETE("/:keyId", limits.Write, controllers.RevokeAPIKey)
+	}
+}
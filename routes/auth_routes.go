@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAuthRoutes configures Sign-In With Ethereum routes
+func SetupAuthRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("auth")
+
+	auth := router.Group("/api/auth")
+	{
+		auth.GET("/nonce", limits.Write, controllers.GetAuthNonce)
+		auth.POST("/verify", limits.Write, controllers.VerifySIWE)
+		auth.GET("/me", limits.Read, middleware.RequireWallet(), controllers.GetAuthSession)
+		auth.POST("/logout", limits.Read, controllers.Logout)
+	}
+}
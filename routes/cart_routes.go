@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupCartRoutes configures cart and checkout routes
+func SetupCartRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("carts")
+
+	cart := router.Group("/api/carts")
+	{
+		cart.POST("", limits.Write, controllers.CreateCart)
+		cart.GET("/:cartId", limits.Read, controllers.GetCart)
+		cart.POST("/:cartId/items", limits.Write, controllers.AddCartItem)
+		cart.PATCH("/:cartId/items/:productId", limits.Write, controllers.UpdateCartItem)
+		cart.DELETE("/:cartId/items/:productId", limits.Write, controllers.RemoveCartItem)
+
+		// Produces createOrder calldata for the cart total; confirm with /api/orders/confirm-create
+		cart.POST("/:cartId/checkout", limits.Write, controllers.CheckoutCart)
+	}
+}
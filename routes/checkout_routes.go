@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupCheckoutRoutes configures hosted checkout session routes
+func SetupCheckoutRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("checkout")
+
+	checkout := router.Group("/api/checkout/sessions")
+	{
+		// Sessions are created by the merchant's backend or dashboard
+		checkout.POST("", middleware.RequireMerchantAuth(models.APIKeyScopeOrdersWrite), limits.Write, controllers.CreateCheckoutSession)
+		checkout.GET("/:sessionId", limits.Read, controllers.GetCheckoutSession)
+		checkout.GET("/:sessionId/qr.png", limits.Read, controllers.GetCheckoutSessionQRCode)
+
+		// Payer steps: approve -> create -> pay, each with a confirm call after signing
+		checkout.POST("/:sessionId/approve", limits.Write, controllers.ApproveCheckoutSession)
+		checkout.POST("/:sessionId/confirm-approve", limits.Write, controllers.ConfirmApproveCheckoutSession)
+		checkout.POST("/:sessionId/create", limits.Write, controllers.CreateCheckoutOrder)
+		checkout.POST("/:sessionId/confirm-create", limits.RPC, controllers.ConfirmCheckoutOrder)
+		checkout.POST("/:sessionId/pay", limits.Write, controllers.PayCheckoutOrder)
+		checkout.POST("/:sessionId/confirm-pay", limits.RPC, controllers.ConfirmCheckoutPayment)
+	}
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupDisputeRoutes configures order dispute routes. The buyer opens a
+// dispute, both parties add evidence, the merchant responds and a platform
+// reviewer decides whether the order is refunded or settled.
+func SetupDisputeRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("disputes")
+	maxUpload := middleware.MaxBodySize(utils.MaxFileSize + 1<<20)
+
+	order := router.Group("/api/orders/:orderId/dispute")
+	{
+		order.POST("", limits.Write, middleware.RequireWallet(), controllers.OpenOrderDispute)
+		order.GET("", limits.Read, middleware.RequireWallet(), controllers.GetOrderDispute)
+		// Evidence images are size capped before the multipart body is parsed
+		order.POST("/evidence", maxUpload, middleware.RequireWallet(), limits.Upload, controllers.AddOrderDisputeEvidence)
+		order.POST("/response", limits.Write, middleware.RequireOrderMerchantOwner("orderId"), controllers.RespondOrderDispute)
+	}
+
+	router.GET("/api/merchants/:merchantId/disputes", limits.Read, middleware.RequireMerchantOwner("merchantId"), controllers.ListMerchantDisputes)
+
+	platform := router.Group("/api/platform", middleware.RequirePermission(models.PermissionDisputeResolve))
+	{
+		platform.GET("/disputes", limits.Read, controllers.ListDisputes)
+		platform.GET("/orders/:orderId/dispute", limits.Read, controllers.GetDispute)
+		// Resolving queues the refund or settlement for the settlement worker
+		platform.POST("/orders/:orderId/dispute/resolve", limits.Write, controllers.ResolveOrderDispute)
+	}
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupDocsRoutes serves the OpenAPI document and the interactive docs page
+func SetupDocsRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("docs")
+
+	router.GET("/openapi.json", limits.Read, controllers.GetOpenAPISpec)
+	router.GET("/docs", limits.Read, controllers.GetAPIDocs)
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupExportRoutes configures merchant and platform export routes. GET on an
+// export kind downloads it directly; POST queues it as a job whose file is
+// downloaded once ready.
+func SetupExportRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("exports")
+
+	merchant := router.Group("/api/merchants/:merchantId", middleware.RequireMerchantOwner("merchantId"))
+	{
+		merchant.GET("/exports/:kind", limits.Read, controllers.ExportMerchantRecords)
+		merchant.POST("/exports/:kind", limits.Write, controllers.CreateMerchantExportJob)
+		merchant.GET("/export-jobs", limits.Read, controllers.ListMerchantExportJobs)
+		merchant.GET("/export-jobs/:jobId", limits.Read, controllers.GetMerchantExportJob)
+		merchant.GET("/export-jobs/:jobId/download", limits.Read, controllers.DownloadMerchantExport)
+	}
+
+	platform := router.Group("/api/platform", middleware.RequirePermission(models.PermissionExportsRead))
+	{
+		platform.GET("/exports/:kind", limits.Read, controllers.ExportPlatformRecords)
+		platform.POST("/exports/:kind", limits.Write, controllers.CreatePlatformExportJob)
+		platform.GET("/export-jobs", limits.Read, controllers.ListPlatformExportJobs)
+		platform.GET("/export-jobs/:jobId", limits.Read, controllers.GetPlatformExportJob)
+		platform.GET("/export-jobs/:jobId/download", limits.Read, controllers.DownloadPlatformExport)
+	}
+}
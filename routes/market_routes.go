@@ -2,13 +2,26 @@ package routes
 
 import (
 	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/utils"
 	"github.com/gin-gonic/gin"
 )
 
 func SetupMarketRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("market")
+	maxUpload := middleware.MaxBodySize(utils.MaxFileSize + 1<<20)
+
 	market := router.Group("/api/market")
 	{
-		market.POST("/add-product", controllers.CreateProduct)
-		market.GET("/products", controllers.GetAllProducts)
+		// Product changes need a products:write API key or a signed in merchant owner.
+		// Uploads are size capped before the multipart body is parsed.
+		market.POST("/add-product", maxUpload, middleware.RequireMerchantAuth(models.APIKeyScopeProductsWrite), limits.Upload, controllers.CreateProduct)
+		market.GET("/products", limits.Read, controllers.GetAllProducts)
+		market.GET("/products/:productId", limits.Read, controllers.GetProductById)
+		market.PATCH("/products/:productId", maxUpload, middleware.RequireMerchantAuth(models.APIKeyScopeProductsWrite), limits.Upload, controllers.UpdateProduct)
+		market.DELETE("/products/:productId", middleware.RequireMerchantAuth(models.APIKeyScopeProductsWrite), limits.Write, controllers.DeleteProduct)
+		market.GET("/merchants/:merchantId/products", limits.Read, controllers.GetMerchantProducts)
+		market.GET("/merchants/:merchantId/low-stock", limits.Read, middleware.RequireMerchantOwner("merchantId"), controllers.GetLowStockProducts)
 	}
 }
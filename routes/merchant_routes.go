@@ -2,25 +2,48 @@ package routes
 
 import (
 	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 // SetupMerchantRoutes configures merchant-related routes
 func SetupMerchantRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("merchants")
+
 	merchant := router.Group("/api/merchants")
 	{
-		merchant.POST("/register", controllers.RegisterMerchant)
-		merchant.GET("/merchant-info/:merchantId", controllers.GetMerchantInfoById)
-		merchant.DELETE("/delete/:merchantId", controllers.DeleteMerchant)
-		merchant.GET("/balance/:merchantId", controllers.GetMerchantBalance)
-		merchant.GET("/merchant-status/:merchantId", controllers.IsMerchantVerified)
+		// The signed in wallet becomes the merchant's owner
+		merchant.POST("/register", limits.RPC, middleware.RequireWallet(), controllers.RegisterMerchant)
+		merchant.GET("/merchant-info/:merchantId", limits.Read, controllers.GetMerchantInfoById)
+		merchant.DELETE("/delete/:merchantId", limits.Write, middleware.RequireMerchantOwner("merchantId"), controllers.DeleteMerchant)
+		merchant.GET("/balance/:merchantId", limits.RPC, controllers.GetMerchantBalance)
+		merchant.GET("/merchant-status/:merchantId", limits.RPC, controllers.IsMerchantVerified)
+
+		// Payout wallet balances across every registered token and network
+		merchant.GET("/:merchantId/balances", limits.RPC, middleware.RequireMerchantOwner("merchantId"), controllers.GetMerchantBalances)
+		merchant.GET("/:merchantId/balances/history", limits.Read, middleware.RequireMerchantOwner("merchantId"), controllers.GetMerchantBalanceHistory)
+
+		// Sales analytics from the daily order rollups
+		merchant.GET("/:merchantId/analytics", limits.Read, middleware.RequireMerchantOwner("merchantId"), controllers.GetMerchantAnalytics)
+
+		// When paid orders are settled automatically
+		merchant.GET("/:merchantId/payout-policy", limits.Read, middleware.RequireMerchantOwner("merchantId"), controllers.GetPayoutPolicy)
+		merchant.PUT("/:merchantId/payout-policy", limits.Write, middleware.RequireMerchantOwner("merchantId"), controllers.UpdatePayoutPolicy)
+		merchant.GET("/:merchantId/order-expiry", limits.Read, middleware.RequireMerchantOwner("merchantId"), controllers.GetOrderExpiryPolicy)
+		merchant.PUT("/:merchantId/order-expiry", limits.Write, middleware.RequireMerchantOwner("merchantId"), controllers.UpdateOrderExpiryPolicy)
+
+		// Issued invoices and credit notes
+		merchant.GET("/:merchantId/invoices", limits.Read, middleware.RequireMerchantOwner("merchantId"), controllers.ListMerchantInvoices)
 
 		// Frontend signing endpoints for merchant updates
-		merchant.POST("/prepare-update/:merchantId", controllers.PrepareUpdateMerchant)
-		merchant.POST("/confirm-update/:merchantId", controllers.ConfirmMerchantUpdate)
+		merchant.POST("/prepare-update/:merchantId", limits.Write, middleware.RequireMerchantOwner("merchantId"), controllers.PrepareUpdateMerchant)
+		merchant.POST("/confirm-update/:merchantId", limits.Write, middleware.RequireMerchantOwner("merchantId"), controllers.ConfirmMerchantUpdate)
 
 		// Frontend signing endpoints for order refunds
-		merchant.POST("/prepare-refund/:orderId", controllers.PrepareRefundOrderMerchant)
-		merchant.POST("/confirm-refund/:orderId", controllers.ConfirmRefundOrderMerchant)
+		merchant.POST("/prepare-refund/:orderId", limits.Write, middleware.RequireOrderMerchantOwner("orderId"), controllers.PrepareRefundOrderMerchant)
+		merchant.POST("/confirm-refund/:orderId", limits.RPC, middleware.RequireOrderMerchantOwner("orderId"), controllers.ConfirmRefundOrderMerchant)
+
+		// WebSocket feed of the merchant's order status transitions
+		merchant.GET("/:merchantId/orders/stream", limits.Read, middleware.RequireMerchantOwner("merchantId"), controllers.StreamMerchantOrders)
 	}
 }
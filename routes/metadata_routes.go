@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupMetadataRoutes configures the metadata document routes
+func SetupMetadataRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("metadata")
+
+	metadata := router.Group("/api/metadata")
+	{
+		metadata.GET("/resolve", limits.RPC, controllers.ResolveMetadata)
+		metadata.GET("/:hash", limits.Read, controllers.GetMetadataDocument)
+	}
+}
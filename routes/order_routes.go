@@ -2,16 +2,34 @@ package routes
 
 import (
 	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
 	"github.com/gin-gonic/gin"
 )
 
 func SetupOrderRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("orders")
+
 	order := router.Group("/api/orders")
 	{
-		order.POST("/prepare-create", controllers.PrepareCreateOrder)
-		order.POST("/confirm-create", controllers.ConfirmCreateOrder)
-		order.POST("/prepare-pay-order", controllers.PreparePayOrder)
-		order.POST("/confirm-pay-order", controllers.ConfirmPayOrder)
-		order.POST("/cancel", controllers.CancelOrder)
+		// Merchant backends may call these with an API key, limited to their own merchant.
+		// The key is checked before the rate limit so the limit applies per key.
+		order.POST("/prepare-create", middleware.OptionalAPIKey(models.APIKeyScopeOrdersWrite), limits.Write, controllers.PrepareCreateOrder)
+		// expiresIn is only taken from the merchant, by its API key or its owner's session
+		order.POST("/confirm-create", middleware.OptionalAPIKey(models.APIKeyScopeOrdersWrite), middleware.OptionalWallet(), limits.RPC, controllers.ConfirmCreateOrder)
+		order.POST("/prepare-pay-order", limits.Write, controllers.PreparePayOrder)
+		order.POST("/confirm-pay-order", limits.RPC, controllers.ConfirmPayOrder)
+		order.POST("/cancel", middleware.RequireMerchantAuth(models.APIKeyScopeOrdersWrite), limits.RPC, controllers.CancelOrder)
+
+		// Order history for merchant and buyer dashboards
+		order.GET("", middleware.OptionalAPIKey(models.APIKeyScopeOrdersRead), limits.Read, controllers.ListOrders)
+		order.GET("/:orderId", middleware.OptionalAPIKey(models.APIKeyScopeOrdersRead), limits.Read, controllers.GetOrderById)
+
+		// Invoices and credit notes for the order's buyer and merchant
+		order.GET("/:orderId/invoice", limits.Read, middleware.RequireWallet(), controllers.GetOrderInvoice)
+		order.GET("/:orderId/credit-note", limits.Read, middleware.RequireWallet(), controllers.GetOrderCreditNote)
+
+		// Live status transitions as Server-Sent Events
+		order.GET("/:orderId/events", limits.Read, controllers.StreamOrderEvents)
 	}
 }
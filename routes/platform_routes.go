@@ -2,31 +2,52 @@ package routes
 
 import (
 	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/Dbriane208/stable-market/models"
 	"github.com/gin-gonic/gin"
 )
 
-// SetupPlatformRoutes configures platform-related routes
+// SetupPlatformRoutes configures platform-related routes. Admin actions require a
+// signed in wallet holding the action's permission.
 func SetupPlatformRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("platform")
+
 	platform := router.Group("/api/platform")
 	{
-		platform.POST("/emergency-withdrawal", controllers.EmergencyWithdraw)
-		platform.POST("/enable-emergency-withdrawal", controllers.SetEmergencyWithdrawalEnabled)
-		platform.POST("/update-merchant-registry", controllers.UpdateMerchantRegistry)
-		platform.POST("/set-token-support", controllers.SetTokenSupport)
-		platform.POST("/merchant-verification-status", controllers.UpdateMerchantVerificationStatus)
-		platform.GET("/token-balance", controllers.GetPlatformTokenBalance)
-		platform.GET("/contract-token-balance", controllers.GetContractTokenBalance)
-
-		// Token approval with frontend signing
-		platform.POST("/approve-token", controllers.PrepareApproveToken)
-		platform.POST("/confirm-approve", controllers.ConfirmApproveToken)
+		platform.POST("/emergency-withdrawal", limits.RPC, middleware.RequirePermission(models.PermissionWithdrawalExecute), controllers.EmergencyWithdraw)
+		platform.POST("/enable-emergency-withdrawal", limits.RPC, middleware.RequirePermission(models.PermissionWithdrawalConfigure), controllers.SetEmergencyWithdrawalEnabled)
+		platform.POST("/update-merchant-registry", limits.RPC, middleware.RequirePermission(models.PermissionRegistryUpdate), controllers.UpdateMerchantRegistry)
+		platform.POST("/set-token-support", limits.RPC, middleware.RequirePermission(models.PermissionTokenManage), controllers.SetTokenSupport)
+		platform.POST("/merchant-verification-status", limits.RPC, middleware.RequirePermission(models.PermissionMerchantVerify), controllers.UpdateMerchantVerificationStatus)
+		platform.GET("/token-balance", limits.RPC, middleware.RequirePermission(models.PermissionBalanceRead), controllers.GetPlatformTokenBalance)
+		platform.GET("/contract-token-balance", limits.RPC, middleware.RequirePermission(models.PermissionBalanceRead), controllers.GetContractTokenBalance)
+		platform.GET("/contract-balance-history", limits.Read, middleware.RequirePermission(models.PermissionBalanceRead), controllers.GetContractBalanceHistory)
+		platform.GET("/fee-report", limits.Read, middleware.RequirePermission(models.PermissionFeesRead), controllers.GetFeeReport)
+
+		// Token approval with frontend signing. The payer approves their own tokens,
+		// so these stay open like the order routes.
+		platform.POST("/approve-token", limits.Write, controllers.PrepareApproveToken)
+		platform.POST("/confirm-approve", limits.Write, controllers.ConfirmApproveToken)
 
 		// Settle order with frontend signing
-		platform.POST("/prepare-settle", controllers.PrepareSettleOrder)
-		platform.POST("/confirm-settle", controllers.ConfirmSettleOrder)
+		platform.POST("/prepare-settle", limits.Write, middleware.RequirePermission(models.PermissionOrderSettle), controllers.PrepareSettleOrder)
+		platform.POST("/confirm-settle", limits.RPC, middleware.RequirePermission(models.PermissionOrderSettle), controllers.ConfirmSettleOrder)
+
+		// Settle up to 50 orders at once, one signed transaction per order
+		platform.POST("/prepare-batch-settle", limits.Write, middleware.RequirePermission(models.PermissionOrderSettle), controllers.PrepareBatchSettle)
+		platform.POST("/confirm-batch-settle", limits.RPC, middleware.RequirePermission(models.PermissionOrderSettle), controllers.ConfirmBatchSettle)
+
+		// Automatic settlement state and operator holds
+		platform.GET("/orders/:orderId/settlement", limits.Read, middleware.RequirePermission(models.PermissionOrderSettle), controllers.GetOrderSettlement)
+		platform.PUT("/orders/:orderId/settlement-hold", limits.Write, middleware.RequirePermission(models.PermissionOrderSettle), controllers.HoldOrderSettlement)
+		platform.DELETE("/orders/:orderId/settlement-hold", limits.Write, middleware.RequirePermission(models.PermissionOrderSettle), controllers.ReleaseOrderSettlement)
 
 		// Refund order with frontend signing
-		platform.POST("/prepare-refund", controllers.PrepareRefundOrder)
-		platform.POST("/confirm-refund", controllers.ConfirmRefundOrder)
+		platform.POST("/prepare-refund", limits.Write, middleware.RequirePermission(models.PermissionOrderRefund), controllers.PrepareRefundOrder)
+		platform.POST("/confirm-refund", limits.RPC, middleware.RequirePermission(models.PermissionOrderRefund), controllers.ConfirmRefundOrder)
+
+		// Refund up to 50 orders at once
+		platform.POST("/prepare-batch-refund", limits.Write, middleware.RequirePermission(models.PermissionOrderRefund), controllers.PrepareBatchRefund)
+		platform.POST("/confirm-batch-refund", limits.RPC, middleware.RequirePermission(models.PermissionOrderRefund), controllers.ConfirmBatchRefund)
 	}
 }
@@ -0,0 +1,22 @@
+package routes
+
+import "github.com/gin-gonic/gin"
+
+// Setup registers every route group of the API. The OpenAPI document is checked
+// against the routes it registers by the openapi package's tests.
+func Setup(router *gin.Engine) {
+	SetupAuthRoutes(router)
+	SetupMerchantRoutes(router)
+	SetupPlatformRoutes(router)
+	SetupAdminRoutes(router)
+	SetupOrderRoutes(router)
+	SetupMarketRoutes(router)
+	SetupCartRoutes(router)
+	SetupMetadataRoutes(router)
+	SetupCheckoutRoutes(router)
+	SetupWebhookRoutes(router)
+	SetupAPIKeyRoutes(router)
+	SetupExportRoutes(router)
+	SetupDisputeRoutes(router)
+	SetupDocsRoutes(router)
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/Dbriane208/stable-market/controllers"
+	"github.com/Dbriane208/stable-market/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupWebhookRoutes configures merchant webhook endpoint and delivery routes
+func SetupWebhookRoutes(router *gin.Engine) {
+	limits := middleware.RateLimits("webhooks")
+
+	webhooks := router.Group("/api/merchants/:merchantId/webhooks", middleware.RequireMerchantOwner("merchantId"))
+	{
+		webhooks.POST("", limits.Write, controllers.CreateWebhook)
+		webhooks.GET("", limits.Read, controllers.ListWebhooks)
+		webhooks.PATCH("/:webhookId", limits.Write, controllers.UpdateWebhook)
+		webhooks.DELETE("/:webhookId", limits.Write, controllers.DeleteWebhook)
+		webhooks.POST("/:webhookId/rotate-secret", limits.Write, controllers.RotateWebhookSecret)
+
+		// Delivery log and manual redelivery
+		webhooks.GET("/:webhookId/deliveries", limits.Read, controllers.ListWebhookDeliveries)
+		webhooks.GET("/:webhookId/deliveries/:deliveryId", limits.Read, controllers.GetWebhookDelivery)
+		webhooks.POST("/:webhookId/deliveries/:deliveryId/redeliver", limits.Write, controllers.RedeliverWebhook)
+	}
+}
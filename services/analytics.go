@@ -0,0 +1,209 @@
+package services
+
+import (
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// topProductsLimit caps the products listed by MerchantAnalytics
+const topProductsLimit = 10
+
+// AnalyticsQuery selects a merchant's analytics. From and To are whole UTC days;
+// Network and TokenAddress are optional filters.
+type AnalyticsQuery struct {
+	MerchantId   string
+	From         time.Time
+	To           time.Time
+	GroupBy      string
+	Network      string
+	TokenAddress string
+}
+
+// RefreshAnalyticsRollups rebuilds the daily order rollups from since's day onwards
+func RefreshAnalyticsRollups(since time.Time) error {
+	if db.Supabase == nil {
+		return ErrDatabaseNotInitialized
+	}
+
+	var result interface{}
+	return db.Supabase.DB.Rpc("refresh_merchant_rollups", map[string]interface{}{
+		"p_since": since.UTC().Format(time.RFC3339),
+	}).Execute(&result)
+}
+
+// MerchantAnalytics reads the merchant's rollups for the range, grouped into
+// periods and broken down by network and token, with its best selling products
+func MerchantAnalytics(query AnalyticsQuery) (*models.MerchantAnalyticsResponse, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	params := map[string]interface{}{
+		"p_merchant_id": query.MerchantId,
+		"p_from":        query.From.Format("2006-01-02"),
+		"p_to":          query.To.Format("2006-01-02"),
+	}
+	if query.Network != "" {
+		params["p_network"] = query.Network
+	}
+	if query.TokenAddress != "" {
+		params["p_token"] = query.TokenAddress
+	}
+
+	rowParams := map[string]interface{}{"p_group": query.GroupBy}
+	productParams := map[string]interface{}{"p_limit": topProductsLimit}
+	for key, value := range params {
+		rowParams[key] = value
+		productParams[key] = value
+	}
+
+	var rows []models.AnalyticsRow
+	if err := db.Supabase.DB.Rpc("merchant_analytics", rowParams).Execute(&rows); err != nil {
+		return nil, err
+	}
+
+	var products []models.ProductSales
+	if err := db.Supabase.DB.Rpc("merchant_top_products", productParams).Execute(&products); err != nil {
+		return nil, err
+	}
+
+	registry, err := tokenRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows arrive ordered by period, network and token
+	totals := newTokenTotals()
+	periods := []models.AnalyticsPeriod{}
+	var current *tokenTotals
+	for i, row := range rows {
+		if current == nil || row.Period != rows[i-1].Period {
+			if current != nil {
+				periods = append(periods, models.AnalyticsPeriod{Start: rows[i-1].Period, AnalyticsSummary: current.summary(registry)})
+			}
+			current = newTokenTotals()
+		}
+		current.add(row)
+		totals.add(row)
+	}
+	if current != nil {
+		periods = append(periods, models.AnalyticsPeriod{Start: rows[len(rows)-1].Period, AnalyticsSummary: current.summary(registry)})
+	}
+
+	for i := range products {
+		if token, ok := registry[tokenKey(products[i].Network, products[i].TokenAddress)]; ok {
+			decimals := token.Decimals
+			products[i].Symbol = token.Symbol
+			products[i].Decimals = &decimals
+		}
+		products[i].TokenAddress = common.HexToAddress(products[i].TokenAddress).Hex()
+	}
+	if products == nil {
+		products = []models.ProductSales{}
+	}
+
+	return &models.MerchantAnalyticsResponse{
+		MerchantId:  query.MerchantId,
+		GroupBy:     query.GroupBy,
+		From:        query.From.Format("2006-01-02"),
+		To:          query.To.Format("2006-01-02"),
+		Totals:      totals.summary(registry),
+		Periods:     periods,
+		TopProducts: products,
+	}, nil
+}
+
+// tokenTotals sums analytics rows per network and token, keeping the order in
+// which tokens were first seen
+type tokenTotals struct {
+	keys   []string
+	totals map[string]*tokenTotal
+}
+
+type tokenTotal struct {
+	network  string
+	token    string
+	counts   models.OrderCounts
+	gross    *big.Int
+	refunded *big.Int
+}
+
+func newTokenTotals() *tokenTotals {
+	return &tokenTotals{totals: make(map[string]*tokenTotal)}
+}
+
+func (t *tokenTotals) add(row models.AnalyticsRow) {
+	key := tokenKey(row.Network, row.TokenAddress)
+	total, ok := t.totals[key]
+	if !ok {
+		total = &tokenTotal{network: row.Network, token: row.TokenAddress, gross: new(big.Int), refunded: new(big.Int)}
+		t.totals[key] = total
+		t.keys = append(t.keys, key)
+	}
+
+	total.counts.Created += row.OrdersCreated
+	total.counts.Paid += row.OrdersPaid
+	total.counts.Settled += row.OrdersSettled
+	total.counts.Refunded += row.OrdersRefunded
+	total.counts.Cancelled += row.OrdersCancelled
+	addAmount(total.gross, row.GrossVolume)
+	addAmount(total.refunded, row.RefundedVolume)
+}
+
+func (t *tokenTotals) summary(registry map[string]models.TokenDB) models.AnalyticsSummary {
+	var counts models.OrderCounts
+	tokens := make([]models.TokenAnalytics, 0, len(t.keys))
+
+	for _, key := range t.keys {
+		total := t.totals[key]
+		counts.Created += total.counts.Created
+		counts.Paid += total.counts.Paid
+		counts.Settled += total.counts.Settled
+		counts.Refunded += total.counts.Refunded
+		counts.Cancelled += total.counts.Cancelled
+
+		average := new(big.Int)
+		if total.counts.Paid > 0 {
+			average.Quo(total.gross, big.NewInt(total.counts.Paid))
+		}
+
+		analytics := models.TokenAnalytics{
+			Network:           total.network,
+			TokenAddress:      common.HexToAddress(total.token).Hex(),
+			AnalyticsMetrics:  orderMetrics(total.counts),
+			GrossVolume:       total.gross.String(),
+			RefundedVolume:    total.refunded.String(),
+			NetVolume:         new(big.Int).Sub(total.gross, total.refunded).String(),
+			AverageOrderValue: average.String(),
+		}
+		if token, ok := registry[key]; ok {
+			decimals := token.Decimals
+			analytics.Symbol = token.Symbol
+			analytics.Decimals = &decimals
+		}
+		tokens = append(tokens, analytics)
+	}
+
+	return models.AnalyticsSummary{AnalyticsMetrics: orderMetrics(counts), Tokens: tokens}
+}
+
+func orderMetrics(counts models.OrderCounts) models.AnalyticsMetrics {
+	return models.AnalyticsMetrics{
+		Orders:         counts,
+		ConversionRate: ratio(counts.Paid, counts.Created),
+		RefundRate:     ratio(counts.Refunded, counts.Paid),
+	}
+}
+
+// ratio divides n by d to four decimal places, 0 when d is 0
+func ratio(n, d int64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(d)*10000) / 10000
+}
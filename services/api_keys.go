@@ -0,0 +1,277 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+)
+
+var (
+	ErrAPIKeyNotFound      = apierror.NotFound(apierror.CodeAPIKeyNotFound, "API key not found")
+	ErrInvalidAPIKey       = apierror.New(http.StatusUnauthorized, apierror.CodeInvalidAPIKey, "Invalid, revoked or expired API key")
+	ErrAPIKeyIPNotAllowed  = apierror.New(http.StatusForbidden, apierror.CodeIPNotAllowed, "API key is not allowed from this IP address")
+	ErrInvalidAPIKeyScope  = apierror.BadRequest(apierror.CodeInvalidAPIKeyScope, "Unknown API key scope")
+	ErrInvalidAllowedIP    = apierror.BadRequest(apierror.CodeInvalidAllowedIP, "allowedIps entries must be IP addresses or CIDR ranges")
+	ErrInvalidAPIKeyExpiry = apierror.InvalidField(apierror.CodeInvalidExpiry, "expiresAt", "expiresAt must be an RFC3339 time in the future")
+)
+
+// APIKeyPrefix starts every key so it is recognisable in headers and secret scanners
+const APIKeyPrefix = "smk_"
+
+// apiKeyDisplayLength is how much of a key is kept in clear to identify it
+const apiKeyDisplayLength = len(APIKeyPrefix) + 8
+
+// IsAPIKey reports whether value looks like a key issued by this service
+func IsAPIKey(value string) bool {
+	return strings.HasPrefix(value, APIKeyPrefix)
+}
+
+// HashAPIKey returns the stored form of a key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateAPIKeyScopes checks scopes against the known list and drops duplicates
+func ValidateAPIKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidAPIKeyScope.WithDetails(apierror.FieldError{Field: "scopes", Message: "at least one scope is required"})
+	}
+
+	seen := map[string]bool{}
+	validated := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		known := false
+		for _, candidate := range models.APIKeyScopes {
+			if scope == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, ErrInvalidAPIKeyScope.WithDetails(apierror.FieldError{Field: "scopes", Message: "unknown scope " + strconv.Quote(scope)})
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			validated = append(validated, scope)
+		}
+	}
+
+	return validated, nil
+}
+
+// ValidateAllowedIPs normalises an allowlist of addresses and CIDR ranges. An empty
+// list allows every address.
+func ValidateAllowedIPs(entries []string) ([]string, error) {
+	normalised := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			normalised = append(normalised, network.String())
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, ErrInvalidAllowedIP.WithDetails(apierror.FieldError{Field: "allowedIps", Message: strconv.Quote(entry) + " is not an IP address or CIDR range"})
+		}
+		normalised = append(normalised, ip.String())
+	}
+	return normalised, nil
+}
+
+// CreateAPIKey issues a key for a merchant. The returned key string is the only
+// copy; it cannot be recovered later.
+func CreateAPIKey(merchantId, name string, scopes, allowedIPs []string, expiresAt *time.Time, createdBy string) (*models.APIKeyDB, string, error) {
+	if db.Supabase == nil {
+		return nil, "", ErrDatabaseNotInitialized
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	key := APIKeyPrefix + hex.EncodeToString(b)
+
+	row := models.APIKeyDB{
+		MerchantId: merchantId,
+		Name:       name,
+		Prefix:     key[:apiKeyDisplayLength],
+		KeyHash:    HashAPIKey(key),
+		Scopes:     scopes,
+		AllowedIPs: allowedIPs,
+		CreatedBy:  createdBy,
+	}
+
+	if expiresAt != nil {
+		value := expiresAt.UTC().Format(time.RFC3339)
+		row.ExpiresAt = &value
+	}
+
+	var result []models.APIKeyDB
+	if err := db.Supabase.DB.From("api_keys").Insert(row).Execute(&result); err != nil {
+		return nil, "", err
+	}
+
+	if len(result) == 0 {
+		return &row, key, nil
+	}
+
+	return &result[0], key, nil
+}
+
+// ListAPIKeys returns a merchant's keys, newest first
+func ListAPIKeys(merchantId string) ([]models.APIKeyDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var keys []models.APIKeyDB
+	err := db.Supabase.DB.From("api_keys").Select("*").OrderBy("id", "desc").
+		Eq("merchantId", merchantId).
+		Execute(&keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey disables a key immediately. Revoking a revoked key is a no-op.
+func RevokeAPIKey(merchantId string, keyId int64) (*models.APIKeyDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var keys []models.APIKeyDB
+	err := db.Supabase.DB.From("api_keys").Select("*").
+		Eq("id", strconv.FormatInt(keyId, 10)).
+		Eq("merchantId", merchantId).
+		Execute(&keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	if keys[0].RevokedAt != nil {
+		return &keys[0], nil
+	}
+
+	updates := map[string]interface{}{
+		"revokedAt": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var result []models.APIKeyDB
+	err = db.Supabase.DB.From("api_keys").Update(updates).
+		Eq("id", strconv.FormatInt(keyId, 10)).
+		Execute(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	return &result[0], nil
+}
+
+// AuthenticateAPIKey resolves a presented key to its stored row, rejecting revoked,
+// expired and out of allowlist use, and records when and where it was used
+func AuthenticateAPIKey(key, clientIP string) (*models.APIKeyDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var keys []models.APIKeyDB
+	if err := db.Supabase.DB.From("api_keys").Select("*").Eq("keyHash", HashAPIKey(key)).Execute(&keys); err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrInvalidAPIKey
+	}
+	apiKey := keys[0]
+
+	if apiKey.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.ExpiresAt != nil {
+		expiresAt, err := time.Parse(time.RFC3339, *apiKey.ExpiresAt)
+		if err != nil || time.Now().After(expiresAt) {
+			return nil, ErrInvalidAPIKey
+		}
+	}
+
+	if !ipAllowed(apiKey.AllowedIPs, clientIP) {
+		return nil, ErrAPIKeyIPNotAllowed
+	}
+
+	// Usage tracking must not slow down or fail the request
+	go touchAPIKey(apiKey.Id, clientIP)
+
+	return &apiKey, nil
+}
+
+// APIKeyHasScope reports whether a key was granted scope
+func APIKeyHasScope(apiKey *models.APIKeyDB, scope string) bool {
+	for _, granted := range apiKey.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func ipAllowed(allowlist []string, clientIP string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range allowlist {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func touchAPIKey(keyId int64, clientIP string) {
+	updates := map[string]interface{}{
+		"lastUsedAt": time.Now().UTC().Format(time.RFC3339),
+		"lastUsedIp": clientIP,
+	}
+
+	var result []models.APIKeyDB
+	err := db.Supabase.DB.From("api_keys").Update(updates).
+		Eq("id", strconv.FormatInt(keyId, 10)).
+		Execute(&result)
+	if err != nil {
+		log.Printf("api keys: could not record use of key %d: %v", keyId, err)
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrInvalidNonce     = apierror.New(http.StatusUnauthorized, apierror.CodeSIWERejected, "Sign in nonce is unknown, expired or already used")
+	ErrNotMerchantOwner = apierror.New(http.StatusForbidden, apierror.CodeNotMerchantOwner, "Only the merchant's owner can perform this action")
+)
+
+// AuthNonceTTL is how long a sign in nonce can be used after it is issued
+const AuthNonceTTL = 10 * time.Minute
+
+// CreateAuthNonce stores a new single use sign in nonce
+func CreateAuthNonce() (*models.AuthNonceDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	nonce := models.AuthNonceDB{
+		Nonce:     NewReference(),
+		ExpiresAt: time.Now().Add(AuthNonceTTL).UTC().Format(time.RFC3339),
+	}
+
+	var result []models.AuthNonceDB
+	if err := db.Supabase.DB.From("auth_nonces").Insert(nonce).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	return &nonce, nil
+}
+
+// ConsumeAuthNonce marks a nonce used by wallet. The update only matches an unused,
+// unexpired nonce, so a signed message can be exchanged for a session once.
+func ConsumeAuthNonce(nonce string, wallet common.Address) error {
+	if db.Supabase == nil {
+		return ErrDatabaseNotInitialized
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	updates := map[string]interface{}{
+		"usedAt": now,
+		"usedBy": wallet.Hex(),
+	}
+
+	var result []models.AuthNonceDB
+	err := db.Supabase.DB.From("auth_nonces").
+		Update(updates).
+		Eq("nonce", nonce).
+		IsNull("usedAt").
+		Filter("expiresAt", "gt", now).
+		Execute(&result)
+	if err != nil {
+		return err
+	}
+
+	if len(result) == 0 {
+		return ErrInvalidNonce
+	}
+
+	return nil
+}
+
+// MerchantOwner returns the wallet allowed to manage a merchant. Merchants created
+// before owners were recorded fall back to their payout wallet.
+func MerchantOwner(merchant models.MerchantDB) string {
+	if merchant.OwnerAddress != "" {
+		return merchant.OwnerAddress
+	}
+	return merchant.PayoutWalletAddress
+}
+
+// AuthorizeMerchant loads a merchant and checks that wallet owns it
+func AuthorizeMerchant(merchantId string, wallet common.Address) (*models.MerchantDB, error) {
+	merchant, err := GetMerchant(merchantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(MerchantOwner(*merchant), wallet.Hex()) {
+		return merchant, ErrNotMerchantOwner
+	}
+
+	return merchant, nil
+}
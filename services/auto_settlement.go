@@ -0,0 +1,639 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dbriane208/stable-market/abi"
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	sdkclient "github.com/Dbriane208/stablebase-go-sdk/client"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// MaxPayoutHoldHours is the longest wait a payout policy may set
+	MaxPayoutHoldHours = 90 * 24
+
+	// Attempts whose transaction is still unknown to the node after
+	// settlementAttemptLease are failed, and orders whose attempt failed wait
+	// settlementRetryDelay before they are tried again
+	settlementAttemptLease = 10 * time.Minute
+	settlementRetryDelay   = time.Hour
+
+	// settlementGasMargin pads the gas estimate, in percent
+	settlementGasMargin = 20
+)
+
+var (
+	ErrInvalidPayoutPolicy    = apierror.InvalidField(apierror.CodeInvalidPayoutPolicy, "policy", "policy must be immediate, delayed, daily or manual")
+	ErrSettlementHoldNotFound = apierror.NotFound(apierror.CodeSettlementHoldNotFound, "Order is not held")
+	ErrOrderNotHoldable       = apierror.Conflict(apierror.CodeIllegalStateTransition, "Only created or paid orders can be held")
+	ErrSettlementInFlight     = apierror.Conflict(apierror.CodeSettlementInFlight, "Order already has a settlement or refund in flight")
+)
+
+// settlementAttemptsInFlight are the statuses of attempts that keep another
+// attempt for the same order from starting
+var settlementAttemptsInFlight = []string{models.SettlementAttemptQueued, models.SettlementAttemptSubmitting, models.SettlementAttemptSubmitted}
+
+// settlementActionStatus is the order status each settlement attempt action
+// moves orders to
+var settlementActionStatus = map[string]string{
+	models.SettlementActionSettle: models.OrderStatusSettled,
+	models.SettlementActionRefund: models.OrderStatusRefunded,
+}
+
+var (
+	platformSignerOnce sync.Once
+	platformSignerKey  *ecdsa.PrivateKey
+	platformSignerErr  error
+)
+
+// platformSigner returns the key the SDK clients sign platform transactions with
+func platformSigner() (*ecdsa.PrivateKey, error) {
+	platformSignerOnce.Do(func() {
+		key := strings.TrimPrefix(os.Getenv("DEPLOYER_PRIVATE_KEY"), "0x")
+		if key == "" {
+			platformSignerErr = errors.New("DEPLOYER_PRIVATE_KEY is not set")
+			return
+		}
+		platformSignerKey, platformSignerErr = crypto.HexToECDSA(key)
+	})
+
+	return platformSignerKey, platformSignerErr
+}
+
+// IsPayoutPolicy reports whether policy is a known payout policy
+func IsPayoutPolicy(policy string) bool {
+	switch policy {
+	case models.PayoutPolicyImmediate, models.PayoutPolicyDelayed, models.PayoutPolicyDaily, models.PayoutPolicyManual:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetPayoutPolicy returns a merchant's payout policy. Merchants that never set
+// one are settled by hand.
+func GetPayoutPolicy(merchantId string) (*models.PayoutPolicyDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var policies []models.PayoutPolicyDB
+	if err := db.Supabase.DB.From("payout_policies").Select("*").Eq("merchantId", merchantId).Execute(&policies); err != nil {
+		return nil, err
+	}
+
+	if len(policies) == 0 {
+		return &models.PayoutPolicyDB{MerchantId: merchantId, Policy: models.PayoutPolicyManual}, nil
+	}
+	return &policies[0], nil
+}
+
+// SetPayoutPolicy replaces a merchant's payout policy. The hold only applies to
+// delayed and daily payouts, so it is cleared for the others.
+func SetPayoutPolicy(merchantId, policy string, holdHours int, updatedBy string) (*models.PayoutPolicyDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	if !IsPayoutPolicy(policy) {
+		return nil, ErrInvalidPayoutPolicy
+	}
+	if holdHours < 0 || holdHours > MaxPayoutHoldHours {
+		return nil, apierror.InvalidField(apierror.CodeInvalidPayoutPolicy, "holdHours", "holdHours must be between 0 and "+strconv.Itoa(MaxPayoutHoldHours))
+	}
+	if policy == models.PayoutPolicyDelayed && holdHours == 0 {
+		return nil, apierror.InvalidField(apierror.CodeInvalidPayoutPolicy, "holdHours", "delayed payouts need holdHours")
+	}
+	if policy == models.PayoutPolicyImmediate || policy == models.PayoutPolicyManual {
+		holdHours = 0
+	}
+
+	row := models.PayoutPolicyDB{
+		MerchantId: merchantId,
+		Policy:     policy,
+		HoldHours:  holdHours,
+		UpdatedBy:  updatedBy,
+		UpdatedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var result []models.PayoutPolicyDB
+	if err := db.Supabase.DB.From("payout_policies").Upsert(row).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return &row, nil
+	}
+	return &result[0], nil
+}
+
+// HoldSettlement keeps the settlement worker from settling an order until the
+// hold is released. Holding an order again for the same reason replaces the note.
+func HoldSettlement(orderId, reason, note, createdBy string) (*models.SettlementHoldDB, error) {
+	order, err := GetOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderStatusCreated && order.Status != models.OrderStatusPaid {
+		return nil, ErrOrderNotHoldable
+	}
+
+	hold := models.SettlementHoldDB{
+		OrderId:   orderId,
+		Reason:    reason,
+		Note:      note,
+		CreatedBy: createdBy,
+	}
+
+	var result []models.SettlementHoldDB
+	if err := db.Supabase.DB.From("settlement_holds").Upsert(hold).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return &hold, nil
+	}
+	return &result[0], nil
+}
+
+// ReleaseSettlement removes an order's hold for a reason
+func ReleaseSettlement(orderId, reason string) error {
+	if db.Supabase == nil {
+		return ErrDatabaseNotInitialized
+	}
+
+	var result []models.SettlementHoldDB
+	err := db.Supabase.DB.From("settlement_holds").Delete().
+		Eq("orderId", orderId).
+		Eq("reason", reason).
+		Execute(&result)
+	if err != nil {
+		return err
+	}
+
+	if len(result) == 0 {
+		return ErrSettlementHoldNotFound
+	}
+	return nil
+}
+
+// GetOrderSettlementStatus returns an order's payout policy, holds and
+// settlement attempts, newest attempt first
+func GetOrderSettlementStatus(orderId string) (*models.OrderSettlementStatus, error) {
+	order, err := GetOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := GetPayoutPolicy(order.MerchantId)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.OrderSettlementStatus{
+		OrderId:  orderId,
+		Status:   order.Status,
+		Policy:   *policy,
+		Holds:    []models.SettlementHoldDB{},
+		Attempts: []models.SettlementAttemptDB{},
+	}
+
+	if err := db.Supabase.DB.From("settlement_holds").Select("*").Eq("orderId", orderId).Execute(&status.Holds); err != nil {
+		return nil, err
+	}
+
+	query := db.Supabase.DB.From("settlement_attempts").Select("*")
+	query.OrderBy("id", "desc")
+	if err := query.Eq("orderId", orderId).Execute(&status.Attempts); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// SettleDueOrders sends the queued dispute payouts and settleOrder for up to
+// limit paid orders whose merchant's payout policy makes them due, with the
+// platform key, then completes the attempts whose receipts are in, and returns
+// how many orders it settled. Nothing waits for a receipt; attempts still
+// pending are completed by a later run.
+func SettleDueOrders(ctx context.Context, limit int) (int, error) {
+	if db.Supabase == nil {
+		return 0, ErrDatabaseNotInitialized
+	}
+
+	if err := sendQueuedAttempts(ctx, limit); err != nil {
+		log.Printf("settlements: sending queued attempts: %v", err)
+	}
+
+	now := time.Now().UTC()
+	var due []struct {
+		OrderId string `json:"orderId"`
+	}
+	err := db.Supabase.DB.Rpc("orders_due_for_settlement", map[string]interface{}{
+		"p_now":         now.Format(time.RFC3339),
+		"p_retry_after": now.Add(-settlementRetryDelay).Format(time.RFC3339),
+		"p_limit":       limit,
+	}).Execute(&due)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, order := range due {
+		if err := submitSettlementAttempt(ctx, order.OrderId, models.SettlementActionSettle); err != nil {
+			log.Printf("settlements: order %s: %v", order.OrderId, err)
+		}
+	}
+
+	return reconcileSettlementAttempts(ctx)
+}
+
+// QueueSettlementAttempt queues settleOrder or refundOrder for an order, for
+// the settlement worker to send with the platform key. Queueing the action
+// already in flight returns that attempt; any other action fails with
+// ErrSettlementInFlight.
+func QueueSettlementAttempt(orderId, action string) (*models.SettlementAttemptDB, error) {
+	order, err := CheckOrderTransition(orderId, settlementActionStatus[action])
+	if err != nil {
+		return nil, err
+	}
+
+	var inFlight []models.SettlementAttemptDB
+	err = db.Supabase.DB.From("settlement_attempts").Select("*").
+		Eq("orderId", order.OrderId).
+		In("status", settlementAttemptsInFlight).
+		Execute(&inFlight)
+	if err != nil {
+		return nil, err
+	}
+	if len(inFlight) > 0 {
+		if inFlight[0].Action != action {
+			return nil, ErrSettlementInFlight
+		}
+		return &inFlight[0], nil
+	}
+
+	network := order.Network
+	if network == "" {
+		network = networks.DefaultNetworkName
+	}
+
+	// The in-flight index rejects this insert if another attempt got in first
+	attempt := models.SettlementAttemptDB{
+		OrderId:    order.OrderId,
+		MerchantId: order.MerchantId,
+		Network:    network,
+		Action:     action,
+		Status:     models.SettlementAttemptQueued,
+	}
+	var inserted []models.SettlementAttemptDB
+	if err := db.Supabase.DB.From("settlement_attempts").Insert(attempt).Execute(&inserted); err != nil {
+		return nil, err
+	}
+	if len(inserted) == 0 {
+		return nil, errors.New("settlement attempt was not recorded")
+	}
+	return &inserted[0], nil
+}
+
+// sendQueuedAttempts claims up to limit queued attempts, oldest first, and
+// sends them. Attempts whose order can no longer take the action are failed.
+func sendQueuedAttempts(ctx context.Context, limit int) error {
+	query := db.Supabase.DB.From("settlement_attempts").Select("*")
+	query.OrderBy("id", "asc").Limit(limit)
+
+	var queued []models.SettlementAttemptDB
+	if err := query.Eq("status", models.SettlementAttemptQueued).Execute(&queued); err != nil {
+		return err
+	}
+
+	for _, attempt := range queued {
+		var claimed []models.SettlementAttemptDB
+		err := db.Supabase.DB.From("settlement_attempts").Update(map[string]interface{}{
+			"status":    models.SettlementAttemptSubmitting,
+			"updatedAt": time.Now().UTC().Format(time.RFC3339),
+		}).
+			Eq("id", strconv.FormatInt(attempt.Id, 10)).
+			Eq("status", models.SettlementAttemptQueued).
+			Execute(&claimed)
+		if err != nil {
+			log.Printf("settlements: claiming attempt %d: %v", attempt.Id, err)
+			continue
+		}
+		if len(claimed) == 0 {
+			continue
+		}
+
+		order, err := checkSettlementAction(attempt.OrderId, attempt.Action)
+		if err != nil {
+			failSettlementAttempt(attempt.Id, err)
+			continue
+		}
+		if err := sendSettlementAttempt(ctx, claimed[0], order); err != nil {
+			log.Printf("settlements: attempt %d: %v", attempt.Id, err)
+		}
+	}
+
+	return nil
+}
+
+// submitSettlementAttempt records an attempt to settle or refund one order and
+// sends it
+func submitSettlementAttempt(ctx context.Context, orderId, action string) error {
+	order, err := checkSettlementAction(orderId, action)
+	if err != nil {
+		return err
+	}
+
+	network := order.Network
+	if network == "" {
+		network = networks.DefaultNetworkName
+	}
+
+	// The in-flight index rejects this insert while another attempt is open
+	attempt := models.SettlementAttemptDB{
+		OrderId:    order.OrderId,
+		MerchantId: order.MerchantId,
+		Network:    network,
+		Action:     action,
+		Status:     models.SettlementAttemptSubmitting,
+	}
+	var inserted []models.SettlementAttemptDB
+	if err := db.Supabase.DB.From("settlement_attempts").Insert(attempt).Execute(&inserted); err != nil {
+		return err
+	}
+	if len(inserted) == 0 {
+		return errors.New("settlement attempt was not recorded")
+	}
+
+	return sendSettlementAttempt(ctx, inserted[0], order)
+}
+
+// checkSettlementAction returns the order when action can move it on. An
+// order under an open dispute is never settled.
+func checkSettlementAction(orderId, action string) (*models.OrderDB, error) {
+	order, err := CheckOrderTransition(orderId, settlementActionStatus[action])
+	if err != nil {
+		return nil, err
+	}
+	if action == models.SettlementActionSettle {
+		if err := CheckOrderNotDisputed(orderId); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// sendSettlementAttempt signs and sends a submitting attempt's settleOrder or
+// refundOrder with the platform key, recording it as submitted with its
+// transaction hash. The attempt stays submitted even when sending fails, since
+// the node may still have the transaction; reconcileSettlementAttempts decides
+// from the chain.
+func sendSettlementAttempt(ctx context.Context, attempt models.SettlementAttemptDB, order *models.OrderDB) error {
+	sdkClient := networks.GetClient(attempt.Network)
+	config, ok := networks.GetNetworkConfig(attempt.Network)
+	if sdkClient == nil || !ok {
+		return failSettlementAttempt(attempt.Id, fmt.Errorf("network %s is not available", attempt.Network))
+	}
+
+	key, err := platformSigner()
+	if err != nil {
+		return failSettlementAttempt(attempt.Id, err)
+	}
+
+	tx, err := signOrderCall(ctx, sdkClient, config.ChainID, attempt.Action+"Order", order.OrderId, key)
+	if err != nil {
+		return failSettlementAttempt(attempt.Id, err)
+	}
+
+	// The hash is stored before the transaction is sent, so a crash in between
+	// leaves an attempt that can be checked against the chain
+	err = updateSettlementAttempt(attempt.Id, map[string]interface{}{
+		"status":          models.SettlementAttemptSubmitted,
+		"transactionHash": tx.Hash().Hex(),
+	})
+	if err != nil {
+		return failSettlementAttempt(attempt.Id, err)
+	}
+
+	if err := sdkClient.EthClient.SendTransaction(ctx, tx); err != nil {
+		log.Printf("settlements: order %s: sending %s: %v", order.OrderId, tx.Hash().Hex(), err)
+		if err := updateSettlementAttempt(attempt.Id, map[string]interface{}{"error": err.Error()}); err != nil {
+			log.Printf("settlements: attempt %d: %v", attempt.Id, err)
+		}
+	}
+
+	return nil
+}
+
+// signOrderCall builds and signs a payment processor call taking only the
+// order id, such as settleOrder. Gas is estimated first, so an order the
+// contract would refuse to settle fails here instead of on chain.
+func signOrderCall(ctx context.Context, sdkClient *sdkclient.Client, chainId *big.Int, method, orderId string, key *ecdsa.PrivateKey) (*types.Transaction, error) {
+	contractABI, err := abi.GetPaymentProcessorABI()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := contractABI.Pack(method, common.HexToHash(orderId))
+	if err != nil {
+		return nil, err
+	}
+
+	eth := sdkClient.EthClient
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := sdkClient.PaymentProcessorAddress
+
+	nonce, err := eth.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	tip, err := eth.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := eth.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	feeCap := new(big.Int).Mul(tip, big.NewInt(2))
+	if head.BaseFee != nil {
+		feeCap.Add(tip, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	}
+
+	gas, err := eth.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &to, Data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainId,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       gas * (100 + settlementGasMargin) / 100,
+		To:        &to,
+		Data:      data,
+	})
+
+	return types.SignTx(tx, types.LatestSignerForChainID(chainId), key)
+}
+
+// completeSettlementAttempt moves the order on from a mined receipt like a
+// confirmed manual settlement or refund, recording a settlement's fee split first
+func completeSettlementAttempt(attempt models.SettlementAttemptDB, order *models.OrderDB, sdkClient *sdkclient.Client, receipt *types.Receipt) (bool, error) {
+	if receipt.Status == types.ReceiptStatusFailed {
+		return false, failSettlementAttempt(attempt.Id, errors.New("transaction reverted"))
+	}
+
+	to, ok := settlementActionStatus[attempt.Action]
+	if !ok {
+		return false, failSettlementAttempt(attempt.Id, fmt.Errorf("unknown action %q", attempt.Action))
+	}
+
+	if attempt.Action == models.SettlementActionSettle {
+		settlement, err := ParseSettlement(order, sdkClient.PaymentProcessorAddress, receipt)
+		if err != nil {
+			return false, failSettlementAttempt(attempt.Id, err)
+		}
+		if _, err := RecordSettlement(settlement); err != nil {
+			return false, err
+		}
+	}
+
+	actor := ""
+	if key, err := platformSigner(); err == nil {
+		actor = crypto.PubkeyToAddress(key.PublicKey).Hex()
+	}
+
+	_, _, err := TransitionOrder(OrderTransition{
+		OrderId:         order.OrderId,
+		To:              to,
+		TransactionHash: attempt.TransactionHash,
+		BlockNumber:     receipt.BlockNumber.Uint64(),
+		Actor:           actor,
+	})
+	var illegal *IllegalTransitionError
+	if err != nil && !(errors.As(err, &illegal) && illegal.From == to) {
+		return false, err
+	}
+
+	return true, updateSettlementAttempt(attempt.Id, map[string]interface{}{
+		"status":      models.SettlementAttemptConfirmed,
+		"blockNumber": receipt.BlockNumber.Uint64(),
+		"error":       "",
+	})
+}
+
+// reconcileSettlementAttempts completes the attempts in flight whose
+// transactions are mined and returns how many it completed. Attempts past their
+// lease are failed when their transaction was never signed or is unknown to the
+// node, which frees the order for a retry.
+func reconcileSettlementAttempts(ctx context.Context) (int, error) {
+	var attempts []models.SettlementAttemptDB
+	err := db.Supabase.DB.From("settlement_attempts").Select("*").
+		In("status", []string{models.SettlementAttemptSubmitting, models.SettlementAttemptSubmitted}).
+		Execute(&attempts)
+	if err != nil {
+		return 0, err
+	}
+
+	leaseStart := time.Now().Add(-settlementAttemptLease)
+	completed := 0
+	for _, attempt := range attempts {
+		expired := false
+		if updatedAt, err := time.Parse(time.RFC3339, attempt.UpdatedAt); err == nil {
+			expired = updatedAt.Before(leaseStart)
+		}
+
+		if attempt.Status == models.SettlementAttemptSubmitting {
+			if !expired {
+				continue
+			}
+			if err := failSettlementAttempt(attempt.Id, errors.New("abandoned before its transaction was signed")); err != nil {
+				log.Printf("settlements: attempt %d: %v", attempt.Id, err)
+			}
+			continue
+		}
+
+		sdkClient := networks.GetClient(attempt.Network)
+		if sdkClient == nil {
+			continue
+		}
+
+		txHash := common.HexToHash(attempt.TransactionHash)
+		receipt, err := sdkClient.EthClient.TransactionReceipt(ctx, txHash)
+		if errors.Is(err, ethereum.NotFound) {
+			if !expired {
+				continue
+			}
+			if _, _, err := sdkClient.EthClient.TransactionByHash(ctx, txHash); errors.Is(err, ethereum.NotFound) {
+				err = failSettlementAttempt(attempt.Id, errors.New("transaction was dropped"))
+				if err != nil {
+					log.Printf("settlements: attempt %d: %v", attempt.Id, err)
+				}
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("settlements: attempt %d receipt: %v", attempt.Id, err)
+			continue
+		}
+
+		order, err := GetOrder(attempt.OrderId)
+		if err != nil {
+			log.Printf("settlements: attempt %d: %v", attempt.Id, err)
+			continue
+		}
+		ok, err := completeSettlementAttempt(attempt, order, sdkClient, receipt)
+		if err != nil {
+			log.Printf("settlements: attempt %d: %v", attempt.Id, err)
+			continue
+		}
+		if ok && attempt.Action == models.SettlementActionSettle {
+			completed++
+		}
+	}
+
+	return completed, nil
+}
+
+// failSettlementAttempt marks an attempt failed with cause as its error, and
+// returns cause so callers can pass it on
+func failSettlementAttempt(attemptId int64, cause error) error {
+	err := updateSettlementAttempt(attemptId, map[string]interface{}{
+		"status": models.SettlementAttemptFailed,
+		"error":  cause.Error(),
+	})
+	if err != nil {
+		log.Printf("settlements: failing attempt %d: %v", attemptId, err)
+	}
+	return cause
+}
+
+func updateSettlementAttempt(attemptId int64, updates map[string]interface{}) error {
+	updates["updatedAt"] = time.Now().UTC().Format(time.RFC3339)
+
+	var result []models.SettlementAttemptDB
+	return db.Supabase.DB.From("settlement_attempts").Update(updates).
+		Eq("id", strconv.FormatInt(attemptId, 10)).
+		Execute(&result)
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Dbriane208/stable-market/abi"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// snapshotBatchSize caps the balanceOf calls sent in one multicall
+const snapshotBatchSize = 200
+
+var (
+	snapshotMu         sync.Mutex
+	lastSnapshotBlocks = map[string]uint64{}
+)
+
+// BalanceHistoryQuery selects the snapshots of a balance history. Holders are
+// checksummed addresses; Network and TokenAddress are optional filters.
+type BalanceHistoryQuery struct {
+	Holders      []string
+	From         time.Time
+	To           time.Time
+	Bucket       string
+	Network      string
+	TokenAddress string
+}
+
+// SnapshotBalances records the balance of every enabled token held by each
+// merchant payout wallet and by the payment processor contract. Networks whose
+// head has not moved since their last snapshot are skipped, and a network that
+// fails is logged without stopping the others. It returns the rows written.
+func SnapshotBalances(ctx context.Context) (int, error) {
+	tokens, err := ListEnabledTokens()
+	if err != nil {
+		return 0, err
+	}
+
+	wallets, err := payoutWallets()
+	if err != nil {
+		return 0, err
+	}
+
+	byNetwork := make(map[string][]models.TokenDB)
+	for _, token := range tokens {
+		byNetwork[token.Network] = append(byNetwork[token.Network], token)
+	}
+
+	written := 0
+	for _, config := range networks.GetAllNetworkConfigs() {
+		n, err := snapshotNetwork(ctx, config.NetworkName, wallets, byNetwork[config.NetworkName])
+		if err != nil {
+			log.Printf("balance snapshots: %s: %v", config.NetworkName, err)
+			continue
+		}
+		written += n
+	}
+
+	return written, nil
+}
+
+func snapshotNetwork(ctx context.Context, network string, wallets []common.Address, tokens []models.TokenDB) (int, error) {
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+
+	sdkClient := networks.GetClient(network)
+	if sdkClient == nil {
+		return 0, errors.New("network client not initialized")
+	}
+
+	header, err := sdkClient.EthClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	block := header.Number.Uint64()
+
+	snapshotMu.Lock()
+	unchanged := lastSnapshotBlocks[network] == block
+	snapshotMu.Unlock()
+	if unchanged {
+		return 0, nil
+	}
+
+	// The contract's holdings are its balanceOf in each token, read in the same
+	// multicall so every row reflects the same block
+	holders := []common.Address{sdkClient.PaymentProcessorAddress}
+	for _, wallet := range wallets {
+		if wallet != sdkClient.PaymentProcessorAddress {
+			holders = append(holders, wallet)
+		}
+	}
+	rows, err := walletSnapshots(ctx, sdkClient.EthClient, network, header.Number, holders, tokens)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	// The block number is part of the key, so a repeated snapshot merges
+	var result []models.BalanceSnapshotDB
+	if err := db.Supabase.DB.From("balance_snapshots").Upsert(rows).Execute(&result); err != nil {
+		return 0, err
+	}
+
+	snapshotMu.Lock()
+	lastSnapshotBlocks[network] = block
+	snapshotMu.Unlock()
+
+	return len(rows), nil
+}
+
+// walletSnapshots reads every wallet's balance of every token at blockNumber
+func walletSnapshots(ctx context.Context, client *ethclient.Client, network string, blockNumber *big.Int, wallets []common.Address, tokens []models.TokenDB) ([]models.BalanceSnapshotDB, error) {
+	erc20ABI, err := abi.GetERC20ABI()
+	if err != nil {
+		return nil, err
+	}
+
+	var calls []Call
+	var rows []models.BalanceSnapshotDB
+	for _, wallet := range wallets {
+		callData, err := erc20ABI.Pack("balanceOf", wallet)
+		if err != nil {
+			return nil, err
+		}
+		for _, token := range tokens {
+			tokenAddress := common.HexToAddress(token.Address)
+			calls = append(calls, Call{Target: tokenAddress, CallData: callData})
+			rows = append(rows, models.BalanceSnapshotDB{
+				Network:       network,
+				TokenAddress:  tokenAddress.Hex(),
+				HolderAddress: wallet.Hex(),
+				BlockNumber:   blockNumber.Int64(),
+			})
+		}
+	}
+
+	snapshots := make([]models.BalanceSnapshotDB, 0, len(rows))
+	for start := 0; start < len(calls); start += snapshotBatchSize {
+		end := start + snapshotBatchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+
+		results, err := Multicall(ctx, client, blockNumber, calls[start:end])
+		if err != nil {
+			return nil, err
+		}
+
+		for i, result := range results {
+			value, err := unpackResult(erc20ABI, "balanceOf", result)
+			balance, ok := value.(*big.Int)
+			if err != nil || !ok {
+				continue
+			}
+			row := rows[start+i]
+			row.Balance = balance.String()
+			snapshots = append(snapshots, row)
+		}
+	}
+
+	return snapshots, nil
+}
+
+// payoutWallets returns the distinct payout wallets of all merchants
+func payoutWallets() ([]common.Address, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var merchants []models.MerchantDB
+	if err := db.Supabase.DB.From("merchants").Select("merchantId,payoutWalletAddress").Execute(&merchants); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[common.Address]bool, len(merchants))
+	wallets := make([]common.Address, 0, len(merchants))
+	for _, merchant := range merchants {
+		if !common.IsHexAddress(merchant.PayoutWalletAddress) {
+			continue
+		}
+		wallet := common.HexToAddress(merchant.PayoutWalletAddress)
+		if !seen[wallet] {
+			seen[wallet] = true
+			wallets = append(wallets, wallet)
+		}
+	}
+
+	return wallets, nil
+}
+
+// BalanceHistory returns the closing balance of each bucket in the range, one
+// series per network, holder and token
+func BalanceHistory(query BalanceHistoryQuery) (*models.BalanceHistoryResponse, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	params := map[string]interface{}{
+		"p_holders": query.Holders,
+		"p_from":    query.From.Format(time.RFC3339),
+		"p_to":      query.To.Format(time.RFC3339),
+		"p_bucket":  query.Bucket,
+	}
+	if query.Network != "" {
+		params["p_network"] = query.Network
+	}
+	if query.TokenAddress != "" {
+		params["p_token"] = query.TokenAddress
+	}
+
+	var rows []models.BalanceHistoryRow
+	if err := db.Supabase.DB.Rpc("balance_history", params).Execute(&rows); err != nil {
+		return nil, err
+	}
+
+	registry, err := tokenRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows arrive ordered by series and then bucket
+	series := []models.BalanceSeries{}
+	for _, row := range rows {
+		last := len(series) - 1
+		if last < 0 || series[last].Network != row.Network || series[last].TokenAddress != row.TokenAddress || series[last].HolderAddress != row.HolderAddress {
+			s := models.BalanceSeries{
+				Network:       row.Network,
+				HolderAddress: row.HolderAddress,
+				TokenAddress:  row.TokenAddress,
+				Points:        []models.BalancePoint{},
+			}
+			if token, ok := registry[tokenKey(row.Network, row.TokenAddress)]; ok {
+				decimals := token.Decimals
+				s.Symbol = token.Symbol
+				s.Decimals = &decimals
+			}
+			series = append(series, s)
+			last++
+		}
+
+		point := models.BalancePoint{Time: row.Bucket, Balance: row.Balance, BlockNumber: row.BlockNumber}
+		if t, err := time.Parse(time.RFC3339, row.Bucket); err == nil {
+			point.Time = t.UTC().Format(time.RFC3339)
+		}
+		if amount, ok := new(big.Int).SetString(row.Balance, 10); ok && series[last].Decimals != nil {
+			point.Formatted = utils.FormatTokenAmount(amount, *series[last].Decimals)
+		}
+		series[last].Points = append(series[last].Points, point)
+	}
+
+	return &models.BalanceHistoryResponse{
+		Bucket: query.Bucket,
+		From:   query.From.Format(time.RFC3339),
+		To:     query.To.Format(time.RFC3339),
+		Series: series,
+	}, nil
+}
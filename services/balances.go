@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Dbriane208/stable-market/abi"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// balanceTimeout bounds each network's multicall so one slow RPC cannot hold up
+// the whole portfolio
+const balanceTimeout = 10 * time.Second
+
+// MerchantBalances reads the merchant's payout wallet balance of every enabled
+// token on every configured network, with one multicall per network, and totals
+// the paid orders still waiting for settlement. A network that cannot be read is
+// reported with an error instead of failing the whole lookup.
+func MerchantBalances(ctx context.Context, merchant *models.MerchantDB) (*models.MerchantBalancesResponse, error) {
+	tokens, err := ListEnabledTokens()
+	if err != nil {
+		return nil, err
+	}
+
+	byNetwork := make(map[string][]models.TokenDB)
+	for _, token := range tokens {
+		byNetwork[token.Network] = append(byNetwork[token.Network], token)
+	}
+
+	wallet := common.HexToAddress(merchant.PayoutWalletAddress)
+	configs := networks.GetAllNetworkConfigs()
+	results := make([]models.NetworkBalances, len(configs))
+
+	var wg sync.WaitGroup
+	for i, config := range configs {
+		wg.Add(1)
+		go func(i int, network string, chainId int64) {
+			defer wg.Done()
+			results[i] = networkBalances(ctx, network, chainId, wallet, byNetwork[network])
+		}(i, config.NetworkName, config.ChainID.Int64())
+	}
+	wg.Wait()
+
+	pending, err := pendingSettlements(merchant.MerchantId, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MerchantBalancesResponse{
+		MerchantId:          merchant.MerchantId,
+		PayoutWalletAddress: wallet.Hex(),
+		Networks:            results,
+		PendingSettlements:  pending,
+		RetrievedAt:         time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+func networkBalances(ctx context.Context, network string, chainId int64, wallet common.Address, tokens []models.TokenDB) models.NetworkBalances {
+	result := models.NetworkBalances{Network: network, ChainId: chainId, Balances: []models.TokenAmount{}}
+	if len(tokens) == 0 {
+		return result
+	}
+
+	sdkClient := networks.GetClient(network)
+	if sdkClient == nil {
+		result.Error = "network client not initialized"
+		return result
+	}
+
+	erc20ABI, err := abi.GetERC20ABI()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	callData, err := erc20ABI.Pack("balanceOf", wallet)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	calls := make([]Call, len(tokens))
+	for i, token := range tokens {
+		calls[i] = Call{Target: common.HexToAddress(token.Address), CallData: callData}
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, balanceTimeout)
+	defer cancel()
+
+	outputs, err := Multicall(callCtx, sdkClient.EthClient, nil, calls)
+	if err != nil {
+		// RPC errors can include the node URL, so only the network is named
+		log.Printf("balances: multicall on %s failed: %v", network, err)
+		result.Error = "balance lookup failed"
+		return result
+	}
+
+	for i, token := range tokens {
+		value, err := unpackResult(erc20ABI, "balanceOf", outputs[i])
+		balance, ok := value.(*big.Int)
+		if err != nil || !ok {
+			log.Printf("balances: balanceOf %s on %s failed: %v", token.Address, network, err)
+			continue
+		}
+		result.Balances = append(result.Balances, tokenAmount(token.Address, &token, balance))
+	}
+
+	return result
+}
+
+// pendingSettlements totals the merchant's paid orders per network and token
+func pendingSettlements(merchantId string, tokens []models.TokenDB) ([]models.PendingSettlement, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var orders []models.OrderDB
+	err := db.Supabase.DB.From("orders").Select("network,tokenAddress,amount").
+		Eq("merchantId", merchantId).
+		Eq("status", models.OrderStatusPaid).
+		Execute(&orders)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := make(map[string]*models.TokenDB, len(tokens))
+	for i := range tokens {
+		registry[tokenKey(tokens[i].Network, tokens[i].Address)] = &tokens[i]
+	}
+
+	type total struct {
+		network string
+		token   string
+		amount  *big.Int
+		count   int
+	}
+	totals := make(map[string]*total)
+	for _, o := range orders {
+		network := o.Network
+		if network == "" {
+			network = networks.DefaultNetworkName
+		}
+		amount, ok := new(big.Int).SetString(o.Amount, 10)
+		if !ok {
+			continue
+		}
+
+		key := tokenKey(network, o.TokenAddress)
+		t, exists := totals[key]
+		if !exists {
+			t = &total{network: network, token: common.HexToAddress(o.TokenAddress).Hex(), amount: new(big.Int)}
+			totals[key] = t
+		}
+		t.amount.Add(t.amount, amount)
+		t.count++
+	}
+
+	pending := make([]models.PendingSettlement, 0, len(totals))
+	for key, t := range totals {
+		pending = append(pending, models.PendingSettlement{
+			TokenAmount: tokenAmount(t.token, registry[key], t.amount),
+			Network:     t.network,
+			OrderCount:  t.count,
+		})
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].Network != pending[j].Network {
+			return pending[i].Network < pending[j].Network
+		}
+		return pending[i].TokenAddress < pending[j].TokenAddress
+	})
+
+	return pending, nil
+}
+
+// tokenAmount renders amount with the token's registry details. Tokens missing
+// from the registry are reported in base units only.
+func tokenAmount(address string, token *models.TokenDB, amount *big.Int) models.TokenAmount {
+	result := models.TokenAmount{TokenAddress: address, Amount: amount.String()}
+	if token != nil {
+		decimals := token.Decimals
+		result.Symbol = token.Symbol
+		result.Decimals = &decimals
+		result.Formatted = utils.FormatTokenAmount(amount, decimals)
+	}
+	return result
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Dbriane208/stable-market/abi"
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// batchGasLimit is suggested for each transaction of a batch, like the single
+// order prepare endpoints
+const batchGasLimit = 300000
+
+var (
+	ErrBatchTooLarge      = apierror.InvalidField(apierror.CodeValidationFailed, "orderIds", fmt.Sprintf("A batch holds at most %d orders", models.MaxBatchOrders))
+	ErrBatchOrderNotFound = apierror.New(http.StatusUnprocessableEntity, apierror.CodeTxEventNotFound, "None of the transactions settled or refunded this order")
+	ErrBatchOrderPending  = apierror.Conflict(apierror.CodeTxNotMined, "Not all transactions are mined yet; confirm this order again once they are")
+	errBatchInvalidOrder  = apierror.New(http.StatusBadRequest, apierror.CodeInvalidOrderId, "Invalid orderId format (must be 32 bytes hex string)")
+)
+
+// batchTransition is the order status each batch action moves orders to
+var batchTransition = map[string]string{
+	models.BatchActionSettle: models.OrderStatusSettled,
+	models.BatchActionRefund: models.OrderStatusRefunded,
+}
+
+// batchEvent is the payment processor event each batch action logs
+var batchEvent = map[string]string{
+	models.BatchActionSettle: EventOrderSettled,
+	models.BatchActionRefund: EventOrderRefunded,
+}
+
+// batchReceipt is a mined transaction sent for a batch
+type batchReceipt struct {
+	hash    string
+	network string
+	receipt *types.Receipt
+	actor   string
+}
+
+// PrepareBatch checks each order can be settled or refunded and builds its
+// settleOrder or refundOrder transaction. The payment processor only accepts
+// these calls from the platform wallet itself, which a Multicall3 batch would
+// replace as the sender, so every order gets its own transaction.
+func PrepareBatch(action string, orderIds []string) ([]models.BatchOrderResult, error) {
+	ids, invalid, err := batchOrderIds(orderIds)
+	if err != nil {
+		return nil, err
+	}
+
+	contractABI, err := abi.GetPaymentProcessorABI()
+	if err != nil {
+		return nil, err
+	}
+	method := action + "Order"
+
+	results := make([]models.BatchOrderResult, 0, len(ids))
+	for _, orderId := range ids {
+		result := models.BatchOrderResult{OrderId: orderId}
+		if invalid[orderId] {
+			results = append(results, batchFailure(result, errBatchInvalidOrder))
+			continue
+		}
+
+		order, err := CheckOrderTransition(orderId, batchTransition[action])
+		if order != nil {
+			result.Status = order.Status
+		}
+		if err == nil && action == models.BatchActionSettle {
+			err = CheckOrderNotDisputed(orderId)
+		}
+		if err != nil {
+			results = append(results, batchFailure(result, err))
+			continue
+		}
+
+		network := order.Network
+		if network == "" {
+			network = networks.DefaultNetworkName
+		}
+		config, ok := networks.GetNetworkConfig(network)
+		if !ok {
+			results = append(results, batchFailure(result, apierror.BadRequest(apierror.CodeUnsupportedNetwork, "Unsupported network: "+network)))
+			continue
+		}
+
+		data, err := contractABI.Pack(method, common.HexToHash(orderId))
+		if err != nil {
+			return nil, err
+		}
+
+		result.Success = true
+		result.TransactionData = &models.TransactionData{
+			To:       config.PaymentProcessorAddress.Hex(),
+			Data:     "0x" + hex.EncodeToString(data),
+			ChainId:  config.ChainID.Int64(),
+			Value:    "0",
+			GasLimit: batchGasLimit,
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// ConfirmBatch applies a batch from the receipts of its transactions. Each
+// order is matched to the transaction that called settleOrder or refundOrder
+// for it and logged the matching event, and is then settled or refunded like a
+// single confirmed order; orders
+// fail individually when no transaction covers them or their update fails.
+func ConfirmBatch(ctx context.Context, action string, orderIds, txHashes []string) ([]models.BatchOrderResult, error) {
+	ids, invalid, err := batchOrderIds(orderIds)
+	if err != nil {
+		return nil, err
+	}
+	if len(txHashes) > models.MaxBatchOrders {
+		return nil, apierror.InvalidField(apierror.CodeValidationFailed, "transactionHashes", fmt.Sprintf("A batch holds at most %d transactions", models.MaxBatchOrders))
+	}
+	for _, txHash := range txHashes {
+		if len(txHash) != 66 || !strings.HasPrefix(txHash, "0x") {
+			return nil, apierror.InvalidField(apierror.CodeInvalidTxHash, "transactionHashes", "Invalid transaction hash format: "+txHash)
+		}
+	}
+
+	orders := make(map[string]*models.OrderDB, len(ids))
+	orderErrs := make(map[string]error)
+	var orderNetworks []string
+	seenNetwork := make(map[string]bool)
+	for _, orderId := range ids {
+		if invalid[orderId] {
+			continue
+		}
+		order, err := GetOrder(orderId)
+		if err != nil {
+			orderErrs[orderId] = err
+			continue
+		}
+		if order.Network == "" {
+			order.Network = networks.DefaultNetworkName
+		}
+		orders[orderId] = order
+		if !seenNetwork[order.Network] {
+			seenNetwork[order.Network] = true
+			orderNetworks = append(orderNetworks, order.Network)
+		}
+	}
+
+	covered, pending, err := batchReceipts(ctx, action, txHashes, orderNetworks)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.BatchOrderResult, 0, len(ids))
+	for _, orderId := range ids {
+		result := models.BatchOrderResult{OrderId: orderId}
+		if invalid[orderId] {
+			results = append(results, batchFailure(result, errBatchInvalidOrder))
+			continue
+		}
+
+		order, ok := orders[orderId]
+		if !ok {
+			results = append(results, batchFailure(result, orderErrs[orderId]))
+			continue
+		}
+		result.Status = order.Status
+
+		mined, ok := covered[common.HexToHash(orderId)]
+		if !ok || mined.network != order.Network {
+			if pending {
+				results = append(results, batchFailure(result, ErrBatchOrderPending))
+			} else {
+				results = append(results, batchFailure(result, ErrBatchOrderNotFound))
+			}
+			continue
+		}
+		result.TransactionHash = mined.hash
+		result.BlockNumber = mined.receipt.BlockNumber.Uint64()
+		result.ExplorerURL = networks.ExplorerTxURL(mined.network, mined.hash)
+
+		if action == models.BatchActionSettle {
+			if err := CheckOrderNotDisputed(orderId); err != nil {
+				results = append(results, batchFailure(result, err))
+				continue
+			}
+			settlement, err := recordBatchSettlement(order, mined)
+			if err != nil {
+				results = append(results, batchFailure(result, err))
+				continue
+			}
+			result.Settlement = settlement
+		}
+
+		updated, event, err := TransitionOrder(OrderTransition{
+			OrderId:         orderId,
+			To:              batchTransition[action],
+			TransactionHash: mined.hash,
+			BlockNumber:     mined.receipt.BlockNumber.Uint64(),
+			Actor:           mined.actor,
+		})
+		if err != nil {
+			results = append(results, batchFailure(result, err))
+			continue
+		}
+
+		result.Success = true
+		result.Status = updated.Status
+		result.Event = event
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// batchOrderIds normalizes a batch's order ids to 0x prefixed hex, dropping
+// repeats. Malformed ids are kept as given, so they are reported in place, and
+// returned in invalid.
+func batchOrderIds(orderIds []string) ([]string, map[string]bool, error) {
+	if len(orderIds) > models.MaxBatchOrders {
+		return nil, nil, ErrBatchTooLarge
+	}
+
+	seen := make(map[string]bool, len(orderIds))
+	invalid := make(map[string]bool)
+	ids := make([]string, 0, len(orderIds))
+	for _, orderId := range orderIds {
+		normalized := "0x" + strings.TrimPrefix(orderId, "0x")
+		if raw, err := hex.DecodeString(strings.TrimPrefix(orderId, "0x")); err != nil || len(raw) != 32 {
+			normalized = orderId
+			invalid[orderId] = true
+		}
+
+		if seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		ids = append(ids, normalized)
+	}
+
+	return ids, invalid, nil
+}
+
+// batchReceipts fetches the receipts of a batch's transactions, looking each
+// one up on the networks of the batch's orders, and indexes the successful ones
+// by order id. A transaction only covers an order when it calls the payment
+// processor's settleOrder or refundOrder, as the action asks, for that order
+// and the processor logged the matching event for it. pending reports whether
+// any transaction was not found mined.
+func batchReceipts(ctx context.Context, action string, txHashes, orderNetworks []string) (map[common.Hash]batchReceipt, bool, error) {
+	covered := make(map[common.Hash]batchReceipt)
+	pending := false
+
+	for _, txHash := range txHashes {
+		hash := common.HexToHash(txHash)
+		found := false
+		for _, network := range orderNetworks {
+			sdkClient := networks.GetClient(network)
+			if sdkClient == nil {
+				continue
+			}
+
+			receipt, err := sdkClient.EthClient.TransactionReceipt(ctx, hash)
+			if errors.Is(err, ethereum.NotFound) {
+				continue
+			}
+			if err != nil {
+				log.Printf("batch: receipt of %s on %s: %v", txHash, network, err)
+				continue
+			}
+			found = true
+
+			if receipt.Status == types.ReceiptStatusFailed {
+				break
+			}
+
+			tx, _, err := sdkClient.EthClient.TransactionByHash(ctx, hash)
+			if err != nil {
+				log.Printf("batch: transaction %s on %s: %v", txHash, network, err)
+				break
+			}
+			orderId, ok, err := ProcessorOrderCall(tx, sdkClient.PaymentProcessorAddress, action+"Order")
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				break
+			}
+
+			logged, err := OrderEventLogged(receipt, sdkClient.PaymentProcessorAddress, batchEvent[action], orderId)
+			if err != nil {
+				return nil, false, err
+			}
+			if !logged {
+				break
+			}
+
+			actor, err := SenderOf(tx)
+			if err != nil {
+				log.Printf("batch: sender of %s: %v", txHash, err)
+			}
+
+			if _, exists := covered[orderId]; !exists {
+				covered[orderId] = batchReceipt{hash: txHash, network: network, receipt: receipt, actor: actor}
+			}
+			break
+		}
+
+		if !found {
+			pending = true
+		}
+	}
+
+	return covered, pending, nil
+}
+
+// recordBatchSettlement records the fee split of a batch order's settlement
+// before the order moves, as a single confirmation does
+func recordBatchSettlement(order *models.OrderDB, mined batchReceipt) (*models.SettlementDB, error) {
+	sdkClient := networks.GetClient(mined.network)
+	if sdkClient == nil {
+		return nil, fmt.Errorf("network %s is not available", mined.network)
+	}
+
+	settlement, err := ParseSettlement(order, sdkClient.PaymentProcessorAddress, mined.receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	return RecordSettlement(settlement)
+}
+
+// batchFailure marks a batch result failed with err's code and message. Errors
+// without an API code are logged and reported as internal errors.
+func batchFailure(result models.BatchOrderResult, err error) models.BatchOrderResult {
+	var illegal *IllegalTransitionError
+	var apiErr *apierror.Error
+
+	result.Success = false
+	switch {
+	case errors.As(err, &illegal):
+		result.Status = illegal.From
+		result.Error = &models.BatchOrderError{Code: apierror.CodeIllegalStateTransition, Message: "Illegal order state transition: " + illegal.Error()}
+	case errors.As(err, &apiErr):
+		result.Error = &models.BatchOrderError{Code: apiErr.Code, Message: apiErr.Message}
+	default:
+		log.Printf("batch: order %s: %v", result.OrderId, err)
+		result.Error = &models.BatchOrderError{Code: apierror.CodeInternal, Message: "Internal server error"}
+	}
+
+	return result
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+func TestBatchOrderIds(t *testing.T) {
+	id := "0x" + strings.Repeat("ab", 32)
+	other := "0x" + strings.Repeat("cd", 32)
+
+	tests := []struct {
+		name        string
+		orderIds    []string
+		wantIds     []string
+		wantInvalid map[string]bool
+		wantErr     error
+	}{
+		{
+			name:        "prefix is added",
+			orderIds:    []string{strings.TrimPrefix(id, "0x"), other},
+			wantIds:     []string{id, other},
+			wantInvalid: map[string]bool{},
+		},
+		{
+			name:        "duplicates are dropped",
+			orderIds:    []string{id, strings.TrimPrefix(id, "0x"), id},
+			wantIds:     []string{id},
+			wantInvalid: map[string]bool{},
+		},
+		{
+			name:        "invalid ids are kept as given",
+			orderIds:    []string{id, "0x1234", "not-hex", "0x1234"},
+			wantIds:     []string{id, "0x1234", "not-hex"},
+			wantInvalid: map[string]bool{"0x1234": true, "not-hex": true},
+		},
+		{
+			name:     "too many orders",
+			orderIds: make([]string, models.MaxBatchOrders+1),
+			wantErr:  ErrBatchTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, invalid, err := batchOrderIds(tt.orderIds)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !reflect.DeepEqual(ids, tt.wantIds) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIds)
+			}
+			if !reflect.DeepEqual(invalid, tt.wantInvalid) {
+				t.Errorf("invalid = %v, want %v", invalid, tt.wantInvalid)
+			}
+		})
+	}
+}
@@ -0,0 +1,607 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrCartNotFound         = apierror.NotFound(apierror.CodeCartNotFound, "Cart not found")
+	ErrCartNotOpen          = apierror.Conflict(apierror.CodeCartNotOpen, "Cart has already been checked out")
+	ErrCartMerchantMismatch = apierror.Conflict(apierror.CodeCartMerchantMismatch, "Cart can only contain products from one merchant")
+	ErrCartEmpty            = apierror.BadRequest(apierror.CodeCartEmpty, "Cart is empty")
+	ErrCartOrderMismatch    = apierror.New(http.StatusUnprocessableEntity, apierror.CodeCartMerchantMismatch, "Order created on-chain does not match the cart's current checkout")
+	ErrCartCheckoutActive   = apierror.Conflict(apierror.CodeCartCheckoutActive, "Cart has a checkout in progress and cannot be changed until it completes or expires")
+)
+
+// CreateCart opens a new cart. The merchant is fixed by the request or by the first item added.
+func CreateCart(merchantId, buyerAddress string) (*models.CartDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	cart := models.CartDB{
+		Id:           NewReference(),
+		MerchantId:   merchantId,
+		BuyerAddress: buyerAddress,
+		Status:       models.CartStatusOpen,
+	}
+
+	var result []models.CartDB
+	if err := db.Supabase.DB.From("carts").Insert(cart).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return &cart, nil
+	}
+
+	return &result[0], nil
+}
+
+// GetCart fetches a cart by id
+func GetCart(cartId string) (*models.CartDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	if !isReference(cartId) {
+		return nil, ErrCartNotFound
+	}
+
+	var carts []models.CartDB
+	if err := db.Supabase.DB.From("carts").Select("*").Eq("id", cartId).Execute(&carts); err != nil {
+		return nil, err
+	}
+
+	if len(carts) == 0 {
+		return nil, ErrCartNotFound
+	}
+
+	return &carts[0], nil
+}
+
+// GetOpenCart fetches a cart that can still be edited: one that is not checked
+// out and not held by a checkout session
+func GetOpenCart(cartId string) (*models.CartDB, error) {
+	cart, err := GetCart(cartId)
+	if err != nil {
+		return nil, err
+	}
+
+	if cart.Status != models.CartStatusOpen {
+		return nil, ErrCartNotOpen
+	}
+
+	if err := checkCartSession(cart, ""); err != nil {
+		return nil, err
+	}
+
+	return cart, nil
+}
+
+// checkCartSession refuses a cart held by a checkout session, other than
+// sessionId, that the payer can still complete
+func checkCartSession(cart *models.CartDB, sessionId string) error {
+	if cart.CheckoutSessionId == nil || *cart.CheckoutSessionId == sessionId {
+		return nil
+	}
+
+	session, err := GetActiveCheckoutSession(*cart.CheckoutSessionId)
+	switch err {
+	case nil:
+		return ErrCartCheckoutActive.WithMeta("checkoutSessionId", session.Id)
+	case ErrCheckoutSessionNotFound, ErrCheckoutSessionExpired, ErrCheckoutSessionPaid:
+		return nil
+	default:
+		return err
+	}
+}
+
+// UpdateCart applies changes to a cart row
+func UpdateCart(cartId string, updates map[string]interface{}) (*models.CartDB, error) {
+	if !isReference(cartId) {
+		return nil, ErrCartNotFound
+	}
+
+	updates["updatedAt"] = time.Now().UTC().Format(time.RFC3339)
+
+	var result []models.CartDB
+	if err := db.Supabase.DB.From("carts").Update(updates).Eq("id", cartId).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, ErrCartNotFound
+	}
+
+	return &result[0], nil
+}
+
+// AddCartItem adds quantity units of a product, increasing the line if it already exists
+func AddCartItem(cartId string, productId, quantity int64) error {
+	cart, err := GetOpenCart(cartId)
+	if err != nil {
+		return err
+	}
+
+	product, err := GetProduct(productId)
+	if err != nil {
+		return err
+	}
+
+	if cart.MerchantId == "" {
+		if _, err := UpdateCart(cartId, map[string]interface{}{"merchantId": product.MerchantId}); err != nil {
+			return err
+		}
+	} else if cart.MerchantId != product.MerchantId {
+		return ErrCartMerchantMismatch
+	}
+
+	existing, err := getCartItem(cartId, productId)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return SetCartItemQuantity(cartId, productId, existing.Quantity+quantity)
+	}
+
+	item := models.CartItemDB{
+		CartId:    cartId,
+		ProductId: productId,
+		Quantity:  quantity,
+	}
+
+	var result []models.CartItemDB
+	return db.Supabase.DB.From("cart_items").Insert(item).Execute(&result)
+}
+
+// SetCartItemQuantity changes a line's quantity; zero removes the line
+func SetCartItemQuantity(cartId string, productId, quantity int64) error {
+	if _, err := GetOpenCart(cartId); err != nil {
+		return err
+	}
+
+	if quantity <= 0 {
+		return RemoveCartItem(cartId, productId)
+	}
+
+	updates := map[string]interface{}{
+		"quantity": quantity,
+	}
+
+	var result []models.CartItemDB
+	err := db.Supabase.DB.From("cart_items").Update(updates).
+		Eq("cartId", cartId).
+		Eq("productId", strconv.FormatInt(productId, 10)).
+		Execute(&result)
+	if err != nil {
+		return err
+	}
+
+	if len(result) == 0 {
+		return ErrProductNotFound
+	}
+
+	return nil
+}
+
+// RemoveCartItem deletes a product line from a cart
+func RemoveCartItem(cartId string, productId int64) error {
+	if _, err := GetOpenCart(cartId); err != nil {
+		return err
+	}
+
+	var result []models.CartItemDB
+	return db.Supabase.DB.From("cart_items").Delete().
+		Eq("cartId", cartId).
+		Eq("productId", strconv.FormatInt(productId, 10)).
+		Execute(&result)
+}
+
+// PriceCart returns the cart's lines priced from the current products table.
+// Prices stay decimal strings, so totals are exact.
+func PriceCart(cartId string) ([]models.CartLine, string, error) {
+	if db.Supabase == nil {
+		return nil, "", ErrDatabaseNotInitialized
+	}
+
+	var items []models.CartItemDB
+	if err := db.Supabase.DB.From("cart_items").Select("*").OrderBy("id", "asc").Eq("cartId", cartId).Execute(&items); err != nil {
+		return nil, "", err
+	}
+
+	if len(items) == 0 {
+		return []models.CartLine{}, "0", nil
+	}
+
+	productIds := make([]int64, 0, len(items))
+	for _, item := range items {
+		productIds = append(productIds, item.ProductId)
+	}
+
+	byId, err := getProductPrices(productIds)
+	if err != nil {
+		return nil, "", err
+	}
+
+	lines := make([]models.CartLine, 0, len(items))
+	lineTotals := make([]string, 0, len(items))
+	for _, item := range items {
+		product, exists := byId[item.ProductId]
+		if !exists {
+			return nil, "", ErrProductNotFound
+		}
+
+		lineTotal, err := utils.MultiplyDecimal(product.Price.String(), item.Quantity)
+		if err != nil {
+			return nil, "", err
+		}
+		lineTotals = append(lineTotals, lineTotal)
+
+		lines = append(lines, models.CartLine{
+			ProductId: product.Id,
+			Name:      product.Name,
+			ImageUrl:  product.ImageUrl,
+			UnitPrice: product.Price.String(),
+			Quantity:  item.Quantity,
+			LineTotal: lineTotal,
+		})
+	}
+
+	subtotal, err := utils.AddDecimals(lineTotals...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return lines, subtotal, nil
+}
+
+// CheckoutCart prices the cart server side, reserves stock, publishes the order
+// metadata document and prepares the unsigned createOrder transaction for the
+// total. A checkout that fails part way is undone. sessionId names the checkout
+// session checking the cart out, or is empty for a direct checkout; a cart held
+// by another session cannot be checked out.
+func CheckoutCart(ctx context.Context, cartId, sessionId string, req models.CheckoutCartRequest) (*models.CheckoutCartResponse, error) {
+	if !common.IsHexAddress(req.TokenAddress) {
+		return nil, ErrInvalidTokenAddress
+	}
+	tokenAddress := common.HexToAddress(req.TokenAddress)
+
+	if req.BuyerAddress != "" && !common.IsHexAddress(req.BuyerAddress) {
+		return nil, apierror.InvalidField(apierror.CodeInvalidAddress, "buyerAddress", "Invalid buyer address")
+	}
+
+	cart, err := GetCheckoutCart(cartId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCartSession(cart, sessionId); err != nil {
+		return nil, err
+	}
+
+	// The order of a checkout can be created on-chain until it expires, so the
+	// checkout is not replaced before then
+	if cart.Status == models.CartStatusCheckedOut && cart.CheckoutExpiresAt != nil {
+		if expiresAt, err := time.Parse(time.RFC3339, *cart.CheckoutExpiresAt); err == nil && time.Now().Before(expiresAt) {
+			return nil, ErrCartCheckoutActive.WithMeta("checkoutExpiresAt", *cart.CheckoutExpiresAt)
+		}
+	}
+
+	lines, _, err := PriceCart(cartId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	merchant, err := GetMerchant(cart.MerchantId)
+	if err != nil {
+		return nil, err
+	}
+
+	sdkClient := networks.GetBaseClient()
+	if sdkClient == nil {
+		return nil, ErrBlockchainUnavailable
+	}
+
+	decimals, err := TokenDecimals(ctx, sdkClient.EthClient, tokenAddress)
+	if err != nil {
+		return nil, apierror.Wrap(http.StatusBadRequest, apierror.CodeInvalidTokenAddress, "Could not read token decimals", err)
+	}
+
+	buyerAddress := req.BuyerAddress
+	if buyerAddress == "" {
+		buyerAddress = cart.BuyerAddress
+	}
+
+	document := newOrderMetadata(merchant, tokenAddress, decimals, buyerAddress)
+	document.CartId = cartId
+
+	total := new(big.Int)
+	items := make([]models.OrderItemDB, 0, len(lines))
+	stock := make([]StockRequest, 0, len(lines))
+	for _, line := range lines {
+		unitAmount, err := utils.ParseTokenAmount(line.UnitPrice, decimals)
+		if err != nil {
+			return nil, apierror.New(http.StatusUnprocessableEntity, apierror.CodeTokenNotPayable, "Price of product "+strconv.FormatInt(line.ProductId, 10)+" cannot be paid in this token: "+apierror.From(err).Message)
+		}
+
+		lineAmount := new(big.Int).Mul(unitAmount, big.NewInt(line.Quantity))
+		total.Add(total, lineAmount)
+
+		document.LineItems = append(document.LineItems, models.OrderMetadataLineItem{
+			ProductId:       line.ProductId,
+			Name:            line.Name,
+			Quantity:        line.Quantity,
+			UnitPrice:       utils.FormatTokenAmount(unitAmount, decimals),
+			LineTotal:       utils.FormatTokenAmount(lineAmount, decimals),
+			LineTotalAmount: lineAmount.String(),
+		})
+
+		items = append(items, models.OrderItemDB{
+			CartId:          cartId,
+			ProductId:       line.ProductId,
+			Name:            line.Name,
+			UnitPrice:       line.UnitPrice,
+			Quantity:        line.Quantity,
+			LineTotal:       utils.FormatTokenAmount(lineAmount, decimals),
+			LineTotalAmount: lineAmount.String(),
+			TokenAddress:    tokenAddress.Hex(),
+		})
+
+		stock = append(stock, StockRequest{ProductId: line.ProductId, Quantity: line.Quantity})
+	}
+
+	if total.Sign() <= 0 {
+		return nil, apierror.New(http.StatusUnprocessableEntity, apierror.CodeInvalidAmount, "Cart total must be greater than zero")
+	}
+
+	document.Total = utils.FormatTokenAmount(total, decimals)
+	document.Amount = total.String()
+
+	stored, err := PublishMetadata(ctx, document)
+	if err != nil {
+		return nil, publishMetadataError(err)
+	}
+
+	var merchantId [32]byte
+	copy(merchantId[:], common.HexToHash(merchant.MerchantId).Bytes())
+
+	transactionData, err := createOrderTransaction(merchantId, tokenAddress, total, stored.URI)
+	if err != nil {
+		return nil, apierror.Internal(err)
+	}
+
+	// Checking out again once the earlier checkout expired replaces it and the
+	// stock it held
+	if cart.Status == models.CartStatusCheckedOut {
+		AbandonCartCheckout(cartId, cart.ReservationId)
+	}
+
+	reservationId, reservations, err := ReserveStock(stock)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := SaveCheckoutItems(cartId, items); err != nil {
+		AbandonCartCheckout(cartId, reservationId)
+		return nil, err
+	}
+
+	_, err = UpdateCart(cartId, map[string]interface{}{
+		"status":            models.CartStatusCheckedOut,
+		"reservationId":     reservationId,
+		"buyerAddress":      buyerAddress,
+		"checkoutExpiresAt": time.Now().Add(ReservationTTL()).UTC().Format(time.RFC3339),
+		"metadataURI":       stored.URI,
+	})
+	if err != nil {
+		AbandonCartCheckout(cartId, reservationId)
+		return nil, err
+	}
+
+	response := &models.CheckoutCartResponse{
+		PrepareCreateOrderResponse: models.PrepareCreateOrderResponse{
+			TransactionData: transactionData,
+			MerchantId:      merchant.MerchantId,
+			TokenAddress:    tokenAddress.Hex(),
+			Amount:          total.String(),
+			MetadataURI:     stored.URI,
+			MetadataHash:    stored.Hash,
+			ReservationId:   reservationId,
+			Reservations:    reservations,
+			Message:         "Please sign with your wallet and submit the transaction hash with this cartId to confirm.",
+		},
+		CartId:   cartId,
+		Metadata: document,
+	}
+
+	if len(reservations) > 0 {
+		response.ReservationExpiresAt = reservations[0].ExpiresAt
+	}
+
+	return response, nil
+}
+
+// GetCheckoutCart fetches a cart that can be checked out: an open cart, or one
+// checked out before whose order was never confirmed
+func GetCheckoutCart(cartId string) (*models.CartDB, error) {
+	cart, err := GetCart(cartId)
+	if err != nil {
+		return nil, err
+	}
+
+	if cart.Status != models.CartStatusOpen && cart.Status != models.CartStatusCheckedOut {
+		return nil, ErrCartNotOpen
+	}
+
+	return cart, nil
+}
+
+// SaveCheckoutItems stores the priced lines of a checkout until the order is
+// confirmed, replacing the lines of an earlier checkout of the cart
+func SaveCheckoutItems(cartId string, items []models.OrderItemDB) error {
+	if db.Supabase == nil {
+		return ErrDatabaseNotInitialized
+	}
+
+	if err := deletePendingItems(cartId); err != nil {
+		return err
+	}
+
+	var result []models.OrderItemDB
+	return db.Supabase.DB.From("order_items").Insert(items).Execute(&result)
+}
+
+// AbandonCartCheckout undoes a checkout that failed part way or is being
+// replaced: the stock it reserved is released, its priced lines are dropped
+// and the cart is open again
+func AbandonCartCheckout(cartId, reservationId string) {
+	if reservationId != "" {
+		releaseReference(reservationId)
+	}
+
+	if err := deletePendingItems(cartId); err != nil {
+		log.Printf("cart %s: failed to drop checkout items: %v", cartId, err)
+	}
+
+	_, err := UpdateCart(cartId, map[string]interface{}{
+		"status":            models.CartStatusOpen,
+		"reservationId":     nil,
+		"checkoutExpiresAt": nil,
+		"metadataURI":       nil,
+	})
+	if err != nil {
+		log.Printf("cart %s: failed to reopen: %v", cartId, err)
+	}
+}
+
+// CheckCartOrder verifies that the order created on-chain is the cart's current
+// checkout: its order document, the cart's merchant, and the token and total of
+// its priced lines
+func CheckCartOrder(cartId string, created *OrderCreated) error {
+	cart, err := GetCart(cartId)
+	if err != nil {
+		return err
+	}
+
+	if cart.Status != models.CartStatusCheckedOut {
+		return ErrCartNotOpen
+	}
+
+	// An order created for a checkout that was since replaced carries the
+	// replaced document
+	if cart.MetadataURI != "" && cart.MetadataURI != created.MetadataURI {
+		return ErrCartOrderMismatch
+	}
+
+	if common.HexToHash(cart.MerchantId) != common.HexToHash(created.MerchantId) {
+		return ErrCartOrderMismatch
+	}
+
+	var items []models.OrderItemDB
+	if err := db.Supabase.DB.From("order_items").Select("*").Eq("cartId", cartId).IsNull("orderId").Execute(&items); err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		return ErrCartEmpty
+	}
+
+	total := new(big.Int)
+	for _, item := range items {
+		amount, ok := new(big.Int).SetString(item.LineTotalAmount, 10)
+		if !ok || common.HexToAddress(item.TokenAddress) != common.HexToAddress(created.TokenAddress) {
+			return ErrCartOrderMismatch
+		}
+		total.Add(total, amount)
+	}
+
+	if total.String() != created.Amount {
+		return ErrCartOrderMismatch
+	}
+
+	return nil
+}
+
+// AttachCartToOrder links a checked out cart and its line items to the created order
+func AttachCartToOrder(cartId, orderId string) ([]models.OrderItemDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	updates := map[string]interface{}{
+		"orderId": orderId,
+	}
+
+	var items []models.OrderItemDB
+	err := db.Supabase.DB.From("order_items").Update(updates).Eq("cartId", cartId).IsNull("orderId").Execute(&items)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = UpdateCart(cartId, map[string]interface{}{
+		"status":  models.CartStatusOrdered,
+		"orderId": orderId,
+	})
+	if err != nil {
+		return items, err
+	}
+
+	return items, nil
+}
+
+// ListOrderItems returns the line items recorded for an order
+func ListOrderItems(orderId string) ([]models.OrderItemDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var items []models.OrderItemDB
+	err := db.Supabase.DB.From("order_items").Select("*").OrderBy("id", "asc").Eq("orderId", orderId).Execute(&items)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func getCartItem(cartId string, productId int64) (*models.CartItemDB, error) {
+	var items []models.CartItemDB
+	err := db.Supabase.DB.From("cart_items").Select("*").
+		Eq("cartId", cartId).
+		Eq("productId", strconv.FormatInt(productId, 10)).
+		Execute(&items)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	return &items[0], nil
+}
+
+// deletePendingItems drops the priced lines of a cart's checkout that were not
+// attached to an order
+func deletePendingItems(cartId string) error {
+	var deleted []models.OrderItemDB
+	return db.Supabase.DB.From("order_items").Delete().Eq("cartId", cartId).IsNull("orderId").Execute(&deleted)
+}
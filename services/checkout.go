@@ -0,0 +1,164 @@
+package services
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+)
+
+var (
+	ErrCheckoutSessionNotFound = apierror.NotFound(apierror.CodeCheckoutNotFound, "Checkout session not found")
+	ErrCheckoutSessionExpired  = apierror.New(http.StatusGone, apierror.CodeCheckoutExpired, "Checkout session has expired")
+	ErrCheckoutSessionPaid     = apierror.Conflict(apierror.CodeCheckoutPaid, "Checkout session has already been paid")
+)
+
+// DefaultCheckoutSessionTTL is how long a checkout link stays payable when the
+// merchant does not choose an expiry
+const DefaultCheckoutSessionTTL = 30 * time.Minute
+
+// MaxCheckoutSessionTTL caps merchant supplied expiries
+const MaxCheckoutSessionTTL = 7 * 24 * time.Hour
+
+// CheckoutSessionTTL returns the configured default session TTL (CHECKOUT_SESSION_TTL, e.g. "30m")
+func CheckoutSessionTTL() time.Duration {
+	if value := os.Getenv("CHECKOUT_SESSION_TTL"); value != "" {
+		if ttl, err := time.ParseDuration(value); err == nil && ttl > 0 {
+			return ttl
+		}
+	}
+	return DefaultCheckoutSessionTTL
+}
+
+// ValidateCheckoutRedirectURL checks that a session's success or cancel URL is an
+// absolute https URL. The payer is sent there after checkout, so other schemes
+// and relative or credential-bearing URLs are refused.
+func ValidateCheckoutRedirectURL(field, raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" || parsed.User != nil {
+		return apierror.InvalidField(apierror.CodeInvalidRedirectURL, field, field+" must be an absolute https URL")
+	}
+	return nil
+}
+
+// CreateCheckoutSession stores a new session under a random id. A cart
+// session holds its cart, so the cart cannot be edited or checked out elsewhere
+// while the session can be completed.
+func CreateCheckoutSession(session models.CheckoutSessionDB) (*models.CheckoutSessionDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	session.Id = NewReference()
+	session.Status = models.CheckoutStatusOpen
+
+	var result []models.CheckoutSessionDB
+	if err := db.Supabase.DB.From("checkout_sessions").Insert(session).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if session.CartId != nil {
+		if _, err := UpdateCart(*session.CartId, map[string]interface{}{"checkoutSessionId": session.Id}); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(result) == 0 {
+		return &session, nil
+	}
+
+	return &result[0], nil
+}
+
+// GetCheckoutSession fetches a session by id
+func GetCheckoutSession(sessionId string) (*models.CheckoutSessionDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	if !isReference(sessionId) {
+		return nil, ErrCheckoutSessionNotFound
+	}
+
+	var sessions []models.CheckoutSessionDB
+	if err := db.Supabase.DB.From("checkout_sessions").Select("*").Eq("id", sessionId).Execute(&sessions); err != nil {
+		return nil, err
+	}
+
+	if len(sessions) == 0 {
+		return nil, ErrCheckoutSessionNotFound
+	}
+
+	return &sessions[0], nil
+}
+
+// GetActiveCheckoutSession fetches a session the payer can still act on. A session
+// past its expiry is marked expired on first access.
+func GetActiveCheckoutSession(sessionId string) (*models.CheckoutSessionDB, error) {
+	session, err := GetCheckoutSession(sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	switch session.Status {
+	case models.CheckoutStatusPaid:
+		return session, ErrCheckoutSessionPaid
+	case models.CheckoutStatusExpired:
+		return session, ErrCheckoutSessionExpired
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, session.ExpiresAt)
+	if err == nil && time.Now().After(expiresAt) {
+		if _, err := UpdateCheckoutSession(sessionId, map[string]interface{}{"status": models.CheckoutStatusExpired}); err != nil {
+			return nil, err
+		}
+		session.Status = models.CheckoutStatusExpired
+		return session, ErrCheckoutSessionExpired
+	}
+
+	return session, nil
+}
+
+// UpdateCheckoutSession applies changes to a session row
+func UpdateCheckoutSession(sessionId string, updates map[string]interface{}) (*models.CheckoutSessionDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	if !isReference(sessionId) {
+		return nil, ErrCheckoutSessionNotFound
+	}
+
+	updates["updatedAt"] = time.Now().UTC().Format(time.RFC3339)
+
+	var result []models.CheckoutSessionDB
+	if err := db.Supabase.DB.From("checkout_sessions").Update(updates).Eq("id", sessionId).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, ErrCheckoutSessionNotFound
+	}
+
+	return &result[0], nil
+}
+
+// CheckoutNextStep names the session endpoint the payer should call next
+func CheckoutNextStep(status string) string {
+	switch status {
+	case models.CheckoutStatusOpen:
+		return "approve"
+	case models.CheckoutStatusApproved:
+		return "create"
+	case models.CheckoutStatusOrderPending:
+		return "confirm-create"
+	case models.CheckoutStatusOrderCreated:
+		return "pay"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,483 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrDisputeNotFound       = apierror.NotFound(apierror.CodeDisputeNotFound, "Order has no dispute")
+	ErrDisputeExists         = apierror.Conflict(apierror.CodeDisputeExists, "Order has already been disputed")
+	ErrDisputeResolved       = apierror.Conflict(apierror.CodeDisputeResolved, "Dispute has already been resolved")
+	ErrDisputeEvidenceLimit  = apierror.Conflict(apierror.CodeDisputeEvidenceLimit, "A dispute holds at most "+strconv.Itoa(models.MaxDisputeEvidence)+" evidence images")
+	ErrOrderDisputed         = apierror.Conflict(apierror.CodeOrderDisputed, "Order is disputed and cannot be settled until the dispute is resolved")
+	ErrOrderNotDisputable    = apierror.Conflict(apierror.CodeIllegalStateTransition, "Only paid orders that are not settled yet can be disputed")
+	ErrNotOrderBuyer         = apierror.New(http.StatusForbidden, apierror.CodeForbidden, "Only the order's buyer can open a dispute")
+	ErrNotDisputeParty       = apierror.New(http.StatusForbidden, apierror.CodeForbidden, "Only the order's buyer or merchant can take part in its dispute")
+	ErrInvalidDisputeReason  = apierror.InvalidField(apierror.CodeInvalidDisputeReason, "reason", "reason must be not_received, not_as_described, unauthorized or other")
+	ErrInvalidDisputeOutcome = apierror.InvalidField(apierror.CodeInvalidDisputeOutcome, "outcome", "outcome must be refund or settle")
+)
+
+// disputeOutcomeAction is the platform transaction that carries out each
+// dispute outcome
+var disputeOutcomeAction = map[string]string{
+	models.DisputeOutcomeRefund: models.SettlementActionRefund,
+	models.DisputeOutcomeSettle: models.SettlementActionSettle,
+}
+
+// unresolvedDisputeStatuses are the statuses of disputes that still hold their order
+var unresolvedDisputeStatuses = []string{models.DisputeStatusOpen, models.DisputeStatusResponded}
+
+// IsDisputeReason reports whether reason is a known dispute reason
+func IsDisputeReason(reason string) bool {
+	switch reason {
+	case models.DisputeReasonNotReceived, models.DisputeReasonNotAsDescribed, models.DisputeReasonUnauthorized, models.DisputeReasonOther:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsDisputeStatus reports whether status is a known dispute status
+func IsDisputeStatus(status string) bool {
+	switch status {
+	case models.DisputeStatusOpen, models.DisputeStatusResponded, models.DisputeStatusResolved:
+		return true
+	default:
+		return false
+	}
+}
+
+// DisputeParty returns whether wallet takes part in an order's dispute as its
+// buyer or as the owner of its merchant
+func DisputeParty(order models.OrderDB, wallet common.Address) (string, error) {
+	if strings.EqualFold(order.PayerAddress, wallet.Hex()) {
+		return models.DisputePartyBuyer, nil
+	}
+
+	if _, err := AuthorizeMerchant(order.MerchantId, wallet); err != nil {
+		if err == ErrNotMerchantOwner {
+			return "", ErrNotDisputeParty
+		}
+		return "", err
+	}
+
+	return models.DisputePartyMerchant, nil
+}
+
+// OpenDispute opens the buyer's dispute on a paid order and holds the order
+// back from settlement until a reviewer resolves it
+func OpenDispute(orderId string, buyer common.Address, reason, description string) (*models.DisputeDetails, error) {
+	order, err := GetOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(order.PayerAddress, buyer.Hex()) {
+		return nil, ErrNotOrderBuyer
+	}
+	if order.Status != models.OrderStatusPaid {
+		return nil, ErrOrderNotDisputable
+	}
+	if !IsDisputeReason(reason) {
+		return nil, ErrInvalidDisputeReason
+	}
+
+	if _, err := GetDispute(orderId); err != ErrDisputeNotFound {
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrDisputeExists
+	}
+
+	dispute := models.DisputeDB{
+		OrderId:      orderId,
+		MerchantId:   order.MerchantId,
+		BuyerAddress: buyer.Hex(),
+		Reason:       reason,
+		Description:  description,
+		Status:       models.DisputeStatusOpen,
+	}
+
+	var result []models.DisputeDB
+	if err := db.Supabase.DB.From("disputes").Insert(dispute).Execute(&result); err != nil {
+		// The unique order id catches a dispute opened concurrently
+		if _, lookupErr := GetDispute(orderId); lookupErr == nil {
+			return nil, ErrDisputeExists
+		}
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, errors.New("dispute was not recorded")
+	}
+	dispute = result[0]
+
+	if _, err := HoldSettlement(orderId, models.SettlementHoldDispute, "Disputed by the buyer: "+reason, buyer.Hex()); err != nil {
+		return nil, err
+	}
+
+	if _, err := recordDisputeEvent(dispute, models.DisputeActionOpened, models.DisputePartyBuyer, buyer.Hex(), description, ""); err != nil {
+		return nil, err
+	}
+
+	return GetDisputeDetails(orderId)
+}
+
+// GetDispute fetches an order's dispute
+func GetDispute(orderId string) (*models.DisputeDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var disputes []models.DisputeDB
+	if err := db.Supabase.DB.From("disputes").Select("*").Eq("orderId", orderId).Execute(&disputes); err != nil {
+		return nil, err
+	}
+
+	if len(disputes) == 0 {
+		return nil, ErrDisputeNotFound
+	}
+	return &disputes[0], nil
+}
+
+// GetDisputeDetails fetches an order's dispute with its recorded steps
+func GetDisputeDetails(orderId string) (*models.DisputeDetails, error) {
+	dispute, err := GetDispute(orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &models.DisputeDetails{DisputeDB: *dispute, Events: []models.DisputeEventDB{}}
+
+	query := db.Supabase.DB.From("dispute_events").Select("*")
+	query.OrderBy("id", "asc")
+	if err := query.Eq("disputeId", strconv.FormatInt(dispute.Id, 10)).Execute(&details.Events); err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+// ListDisputes returns disputes newest first, optionally limited to one
+// merchant and one status
+func ListDisputes(merchantId, status string, limit int, cursor int64) ([]models.DisputeDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	query := db.Supabase.DB.From("disputes").Select("*")
+	query.OrderBy("id", "desc").Limit(limit)
+
+	filter := query.Gt("id", "0")
+	if merchantId != "" {
+		filter.Eq("merchantId", merchantId)
+	}
+	if status != "" {
+		filter.Eq("status", status)
+	}
+	if cursor > 0 {
+		filter.Lt("id", strconv.FormatInt(cursor, 10))
+	}
+
+	var disputes []models.DisputeDB
+	if err := filter.Execute(&disputes); err != nil {
+		return nil, err
+	}
+
+	return disputes, nil
+}
+
+// CheckDisputeEvidence returns an order's dispute if it can take another
+// evidence image, so uploads are refused before they reach Cloudinary
+func CheckDisputeEvidence(orderId string) (*models.DisputeDB, error) {
+	dispute, err := GetDispute(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status == models.DisputeStatusResolved {
+		return nil, ErrDisputeResolved
+	}
+
+	var evidence []models.DisputeEventDB
+	err = db.Supabase.DB.From("dispute_events").Select("id").
+		Eq("disputeId", strconv.FormatInt(dispute.Id, 10)).
+		Eq("action", models.DisputeActionEvidence).
+		Execute(&evidence)
+	if err != nil {
+		return nil, err
+	}
+	if len(evidence) >= models.MaxDisputeEvidence {
+		return nil, ErrDisputeEvidenceLimit
+	}
+
+	return dispute, nil
+}
+
+// AddDisputeEvidence records an uploaded evidence image with an optional note
+func AddDisputeEvidence(dispute models.DisputeDB, party, actor, imageURL, note string) (*models.DisputeEventDB, error) {
+	return recordDisputeEvent(dispute, models.DisputeActionEvidence, party, actor, note, imageURL)
+}
+
+// RespondToDispute records the merchant's response. The merchant may respond
+// more than once until the dispute is resolved.
+func RespondToDispute(orderId, merchantWallet, message string) (*models.DisputeDetails, error) {
+	dispute, err := GetDispute(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status == models.DisputeStatusResolved {
+		return nil, ErrDisputeResolved
+	}
+
+	if dispute.Status == models.DisputeStatusOpen {
+		var result []models.DisputeDB
+		err := db.Supabase.DB.From("disputes").Update(map[string]interface{}{
+			"status":    models.DisputeStatusResponded,
+			"updatedAt": time.Now().UTC().Format(time.RFC3339),
+		}).Eq("id", strconv.FormatInt(dispute.Id, 10)).Eq("status", models.DisputeStatusOpen).Execute(&result)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := recordDisputeEvent(*dispute, models.DisputeActionResponded, models.DisputePartyMerchant, merchantWallet, message, ""); err != nil {
+		return nil, err
+	}
+
+	return GetDisputeDetails(orderId)
+}
+
+// ResolveDispute records a reviewer's decision, releases the order's dispute
+// hold and queues the refund or settlement for the settlement worker to send
+// with the platform key. Resolving a dispute again with the same outcome queues
+// a payout whose attempt failed again.
+func ResolveDispute(orderId, outcome, note, reviewer string) (*models.ResolveDisputeResponse, error) {
+	action, ok := disputeOutcomeAction[outcome]
+	if !ok {
+		return nil, ErrInvalidDisputeOutcome
+	}
+
+	dispute, err := GetDispute(orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	// The payout is checked before the decision is recorded, so a decision the
+	// order cannot carry out is refused
+	order, err := GetOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	paid := order.Status == settlementActionStatus[action]
+	if !paid {
+		if _, err := CheckOrderTransition(orderId, settlementActionStatus[action]); err != nil {
+			return nil, err
+		}
+	}
+
+	if dispute.Status == models.DisputeStatusResolved {
+		if dispute.Outcome != outcome {
+			return nil, ErrDisputeResolved
+		}
+	} else {
+		resolved, err := resolveDispute(*dispute, outcome, note, reviewer)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := recordDisputeEvent(*resolved, models.DisputeActionResolved, models.DisputePartyPlatform, reviewer, outcome+": "+note, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ReleaseSettlement(orderId, models.SettlementHoldDispute); err != nil && err != ErrSettlementHoldNotFound {
+		return nil, err
+	}
+
+	response := &models.ResolveDisputeResponse{}
+	if !paid {
+		if response.Attempt, err = QueueSettlementAttempt(orderId, action); err != nil {
+			return nil, err
+		}
+	}
+
+	details, err := GetDisputeDetails(orderId)
+	if err != nil {
+		return nil, err
+	}
+	response.DisputeDetails = *details
+
+	return response, nil
+}
+
+// CheckOrderNotDisputed refuses to settle an order while its dispute is open
+func CheckOrderNotDisputed(orderId string) error {
+	if db.Supabase == nil {
+		return ErrDatabaseNotInitialized
+	}
+
+	var disputes []models.DisputeDB
+	err := db.Supabase.DB.From("disputes").Select("id").
+		Eq("orderId", orderId).
+		In("status", unresolvedDisputeStatuses).
+		Execute(&disputes)
+	if err != nil {
+		return err
+	}
+
+	if len(disputes) > 0 {
+		return ErrOrderDisputed
+	}
+	return nil
+}
+
+// resolveDispute marks an unresolved dispute resolved. It fails with
+// ErrDisputeResolved when another reviewer got there first.
+func resolveDispute(dispute models.DisputeDB, outcome, note, reviewer string) (*models.DisputeDB, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	var result []models.DisputeDB
+	err := db.Supabase.DB.From("disputes").Update(map[string]interface{}{
+		"status":         models.DisputeStatusResolved,
+		"outcome":        outcome,
+		"resolutionNote": note,
+		"resolvedBy":     reviewer,
+		"resolvedAt":     now,
+		"updatedAt":      now,
+	}).Eq("id", strconv.FormatInt(dispute.Id, 10)).In("status", unresolvedDisputeStatuses).Execute(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, ErrDisputeResolved
+	}
+	return &result[0], nil
+}
+
+// RecordDisputeOutcomes records up to limit refunds and settlements of disputed
+// orders that their disputes do not show yet, oldest dispute first, and returns
+// how many it recorded
+func RecordDisputeOutcomes(limit int) (int, error) {
+	if db.Supabase == nil {
+		return 0, ErrDatabaseNotInitialized
+	}
+
+	var missing []struct {
+		OrderId string `json:"orderId"`
+	}
+	if err := db.Supabase.DB.Rpc("disputes_missing_outcomes", map[string]interface{}{"p_limit": limit}).Execute(&missing); err != nil {
+		return 0, err
+	}
+
+	recorded := 0
+	for _, m := range missing {
+		order, err := GetOrder(m.OrderId)
+		if err != nil {
+			log.Printf("disputes: order %s: %v", m.OrderId, err)
+			continue
+		}
+
+		events, err := ListOrderEvents(m.OrderId)
+		if err != nil {
+			log.Printf("disputes: order %s: %v", m.OrderId, err)
+			continue
+		}
+
+		// The outcome is recorded from the event that moved the order to its status
+		var outcome *models.OrderEvent
+		for i := len(events) - 1; i >= 0; i-- {
+			if events[i].ToStatus == order.Status {
+				outcome = &events[i]
+				break
+			}
+		}
+		if outcome == nil {
+			log.Printf("disputes: order %s has no %s event", m.OrderId, order.Status)
+			continue
+		}
+
+		if recordDisputeOutcome(*order, *outcome) {
+			recorded++
+		}
+	}
+
+	return recorded, nil
+}
+
+// recordDisputeOutcome records the refund or settlement of a disputed order.
+// An order refunded outside the dispute, such as by the merchant refunding it,
+// resolves the dispute as a refund. A settlement never decides a
+// dispute; one made outside the review is recorded and left for the reviewer.
+// It reports whether the outcome was recorded.
+func recordDisputeOutcome(order models.OrderDB, event models.OrderEvent) bool {
+	var action, outcome string
+	switch event.ToStatus {
+	case models.OrderStatusRefunded:
+		action, outcome = models.DisputeActionRefunded, models.DisputeOutcomeRefund
+	case models.OrderStatusSettled:
+		action, outcome = models.DisputeActionSettled, models.DisputeOutcomeSettle
+	default:
+		return false
+	}
+
+	dispute, err := GetDispute(order.OrderId)
+	if err != nil {
+		if err != ErrDisputeNotFound {
+			log.Printf("disputes: order %s: %v", order.OrderId, err)
+		}
+		return false
+	}
+
+	if dispute.Status != models.DisputeStatusResolved && outcome == models.DisputeOutcomeRefund {
+		resolved, err := resolveDispute(*dispute, outcome, "Order was "+event.ToStatus+" outside the dispute", event.Actor)
+		if err != nil && err != ErrDisputeResolved {
+			log.Printf("disputes: resolving order %s: %v", order.OrderId, err)
+			return false
+		}
+		if resolved != nil {
+			dispute = resolved
+			if _, err := recordDisputeEvent(*dispute, models.DisputeActionResolved, models.DisputePartyPlatform, event.Actor, dispute.ResolutionNote, ""); err != nil {
+				log.Printf("disputes: order %s: %v", order.OrderId, err)
+			}
+		}
+		if err := ReleaseSettlement(order.OrderId, models.SettlementHoldDispute); err != nil && err != ErrSettlementHoldNotFound {
+			log.Printf("disputes: releasing order %s: %v", order.OrderId, err)
+		}
+	}
+
+	if _, err := recordDisputeEvent(*dispute, action, models.DisputePartyPlatform, event.Actor, event.TransactionHash, ""); err != nil {
+		log.Printf("disputes: order %s: %v", order.OrderId, err)
+		return false
+	}
+	return true
+}
+
+func recordDisputeEvent(dispute models.DisputeDB, action, party, actor, message, evidenceURL string) (*models.DisputeEventDB, error) {
+	event := models.DisputeEventDB{
+		DisputeId:   dispute.Id,
+		OrderId:     dispute.OrderId,
+		Action:      action,
+		Party:       party,
+		Actor:       actor,
+		Message:     message,
+		EvidenceURL: evidenceURL,
+	}
+
+	var result []models.DisputeEventDB
+	if err := db.Supabase.DB.From("dispute_events").Insert(event).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return &event, nil
+	}
+	return &result[0], nil
+}
@@ -0,0 +1,647 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/export"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// ExportDirectLimit caps the records served by a direct export; larger
+	// exports run as jobs
+	ExportDirectLimit = 5000
+
+	// exportJobLimit caps the records of a CSV or JSON Lines export job, and
+	// exportStatementLimit those of a PDF statement, which is built in memory
+	exportJobLimit       = 1000000
+	exportStatementLimit = 20000
+
+	exportPageSize = 500
+
+	// ExportRetention is how long a finished export file can be downloaded
+	ExportRetention = 7 * 24 * time.Hour
+
+	// A running job that is not finished within the lease is queued again
+	exportJobLease = 30 * time.Minute
+
+	blockTimeBatchSize = 100
+	blockTimeCacheSize = 100000
+)
+
+var (
+	ErrExportTooLarge    = apierror.New(http.StatusUnprocessableEntity, apierror.CodeExportTooLarge, "Export has too many records; create an export job or narrow the date range")
+	ErrExportJobNotFound = apierror.NotFound(apierror.CodeExportNotFound, "Export job not found")
+	ErrExportNotReady    = apierror.Conflict(apierror.CodeExportNotReady, "Export file is not ready")
+)
+
+var (
+	blockTimeMu sync.Mutex
+	blockTimes  = map[string]string{}
+)
+
+// ExportQuery selects the records of an export. An empty MerchantId exports
+// the whole platform.
+type ExportQuery struct {
+	MerchantId string
+	Kind       string
+	Format     string
+	From       time.Time
+	To         time.Time
+}
+
+// FileName is the download name of an export, such as
+// "orders-2024-01-01-2024-01-31.csv"
+func (q ExportQuery) FileName() string {
+	return fmt.Sprintf("%s-%s-%s.%s", q.Kind, q.From.Format("2006-01-02"), q.To.Format("2006-01-02"), q.Format)
+}
+
+// ExportRecords reads the query's records oldest first, adding token details,
+// block timestamps and explorer links, and passes them to fn. It fails with
+// ErrExportTooLarge before passing anything past the limit'th record, and
+// returns the number of records passed.
+func ExportRecords(ctx context.Context, query ExportQuery, limit int, fn func(record models.ExportRecord) error) (int64, error) {
+	if db.Supabase == nil {
+		return 0, ErrDatabaseNotInitialized
+	}
+
+	registry, err := tokenRegistry()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	var after int64
+	for {
+		params := map[string]interface{}{
+			"p_kind":  query.Kind,
+			"p_from":  query.From.Format(time.RFC3339Nano),
+			"p_to":    query.To.Format(time.RFC3339Nano),
+			"p_after": after,
+			"p_limit": exportPageSize,
+		}
+		if query.MerchantId != "" {
+			params["p_merchant_id"] = query.MerchantId
+		}
+
+		var rows []models.ExportRow
+		if err := db.Supabase.DB.Rpc("export_records", params).Execute(&rows); err != nil {
+			return count, err
+		}
+		if count+int64(len(rows)) > int64(limit) {
+			return count, ErrExportTooLarge
+		}
+
+		blocks := make(map[string][]uint64)
+		for _, row := range rows {
+			if row.BlockNumber > 0 {
+				blocks[row.Network] = append(blocks[row.Network], row.BlockNumber)
+			}
+		}
+		timestamps := make(map[string]map[uint64]string, len(blocks))
+		for network, numbers := range blocks {
+			timestamps[network] = blockTimestamps(ctx, network, numbers)
+		}
+
+		for _, row := range rows {
+			record := exportRecord(row, registry)
+			record.BlockTimestamp = timestamps[row.Network][row.BlockNumber]
+			if err := fn(record); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if len(rows) < exportPageSize {
+			return count, nil
+		}
+		after = rows[len(rows)-1].Id
+	}
+}
+
+func exportRecord(row models.ExportRow, registry map[string]models.TokenDB) models.ExportRecord {
+	record := models.ExportRecord{
+		Kind:            row.Kind,
+		Reference:       row.Reference,
+		MerchantId:      row.MerchantId,
+		Network:         row.Network,
+		TokenAddress:    row.TokenAddress,
+		Amount:          row.Amount,
+		PlatformFee:     row.PlatformFee,
+		MerchantNet:     row.MerchantNet,
+		Status:          row.Status,
+		Counterparty:    row.Counterparty,
+		TransactionHash: row.TransactionHash,
+		BlockNumber:     row.BlockNumber,
+		ExplorerURL:     networks.ExplorerTxURL(row.Network, row.TransactionHash),
+		RecordedAt:      row.RecordedAt,
+	}
+	if common.IsHexAddress(row.TokenAddress) {
+		record.TokenAddress = common.HexToAddress(row.TokenAddress).Hex()
+	}
+	if t, err := time.Parse(time.RFC3339, row.RecordedAt); err == nil {
+		record.RecordedAt = t.UTC().Format(time.RFC3339)
+	}
+
+	if token, ok := registry[tokenKey(row.Network, row.TokenAddress)]; ok {
+		decimals := token.Decimals
+		record.Symbol = token.Symbol
+		record.Decimals = &decimals
+		if amount, ok := new(big.Int).SetString(row.Amount, 10); ok {
+			record.AmountFormatted = utils.FormatTokenAmount(amount, decimals)
+		}
+	}
+
+	return record
+}
+
+// blockTimestamps looks up the timestamps of blocks on a network in JSON-RPC
+// batches, remembering them across exports. Blocks that cannot be read are
+// left out and logged.
+func blockTimestamps(ctx context.Context, network string, numbers []uint64) map[uint64]string {
+	timestamps := make(map[uint64]string, len(numbers))
+
+	var missing []uint64
+	blockTimeMu.Lock()
+	for _, number := range numbers {
+		if timestamp, ok := blockTimes[network+"/"+strconv.FormatUint(number, 10)]; ok {
+			timestamps[number] = timestamp
+		} else if _, seen := timestamps[number]; !seen {
+			timestamps[number] = ""
+			missing = append(missing, number)
+		}
+	}
+	blockTimeMu.Unlock()
+
+	sdkClient := networks.GetClient(network)
+	if len(missing) == 0 || sdkClient == nil {
+		return timestamps
+	}
+
+	for start := 0; start < len(missing); start += blockTimeBatchSize {
+		end := start + blockTimeBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+
+		headers := make([]struct {
+			Timestamp hexutil.Uint64 `json:"timestamp"`
+		}, end-start)
+		batch := make([]rpc.BatchElem, end-start)
+		for i, number := range missing[start:end] {
+			batch[i] = rpc.BatchElem{
+				Method: "eth_getBlockByNumber",
+				Args:   []interface{}{hexutil.EncodeUint64(number), false},
+				Result: &headers[i],
+			}
+		}
+
+		if err := sdkClient.EthClient.Client().BatchCallContext(ctx, batch); err != nil {
+			log.Printf("exports: block timestamps on %s: %v", network, err)
+			return timestamps
+		}
+
+		blockTimeMu.Lock()
+		if len(blockTimes) > blockTimeCacheSize {
+			blockTimes = map[string]string{}
+		}
+		for i, number := range missing[start:end] {
+			if batch[i].Error != nil || headers[i].Timestamp == 0 {
+				continue
+			}
+			timestamp := time.Unix(int64(headers[i].Timestamp), 0).UTC().Format(time.RFC3339)
+			timestamps[number] = timestamp
+			blockTimes[network+"/"+strconv.FormatUint(number, 10)] = timestamp
+		}
+		blockTimeMu.Unlock()
+	}
+
+	return timestamps
+}
+
+// CheckExportSize fails with ErrExportTooLarge when the query has more than
+// limit records, so a streamed export can be refused before it starts
+func CheckExportSize(query ExportQuery, limit int) error {
+	if db.Supabase == nil {
+		return ErrDatabaseNotInitialized
+	}
+
+	params := map[string]interface{}{
+		"p_kind":  query.Kind,
+		"p_from":  query.From.Format(time.RFC3339Nano),
+		"p_to":    query.To.Format(time.RFC3339Nano),
+		"p_limit": limit + 1,
+	}
+	if query.MerchantId != "" {
+		params["p_merchant_id"] = query.MerchantId
+	}
+
+	var count int64
+	if err := db.Supabase.DB.Rpc("count_export_records", params).Execute(&count); err != nil {
+		return err
+	}
+	if count > int64(limit) {
+		return ErrExportTooLarge
+	}
+	return nil
+}
+
+// CollectExportRecords returns up to limit records of an export
+func CollectExportRecords(ctx context.Context, query ExportQuery, limit int) ([]models.ExportRecord, error) {
+	records := []models.ExportRecord{}
+	_, err := ExportRecords(ctx, query, limit, func(record models.ExportRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// WriteExport writes records in the query's format
+func WriteExport(w io.Writer, query ExportQuery, records []models.ExportRecord) error {
+	if query.Format == models.ExportFormatPDF {
+		return export.WriteStatement(w, exportStatement(query, records))
+	}
+
+	writer, err := export.NewWriter(w, query.Format)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// exportStatement titles a PDF statement and totals its records per token
+func exportStatement(query ExportQuery, records []models.ExportRecord) models.ExportStatement {
+	scope := "Platform"
+	if query.MerchantId != "" {
+		scope = "Merchant " + query.MerchantId
+	}
+
+	return models.ExportStatement{
+		Title: "Stable Market " + query.Kind + " statement",
+		Lines: []string{
+			scope,
+			"Period " + query.From.Format("2006-01-02 15:04") + " to " + query.To.Format("2006-01-02 15:04") + " UTC",
+			strconv.Itoa(len(records)) + " records",
+		},
+		Records:     records,
+		Totals:      exportTotals(records),
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func exportTotals(records []models.ExportRecord) []models.ExportTotal {
+	type total struct {
+		record  models.ExportRecord
+		records int64
+		amount  *big.Int
+		fee     *big.Int
+		net     *big.Int
+	}
+
+	var keys []string
+	totals := make(map[string]*total)
+	for _, record := range records {
+		key := tokenKey(record.Network, record.TokenAddress)
+		t, ok := totals[key]
+		if !ok {
+			t = &total{record: record, amount: new(big.Int), fee: new(big.Int), net: new(big.Int)}
+			totals[key] = t
+			keys = append(keys, key)
+		}
+		t.records++
+		addAmount(t.amount, record.Amount)
+		addAmount(t.fee, record.PlatformFee)
+		addAmount(t.net, record.MerchantNet)
+	}
+
+	format := func(amount *big.Int, decimals *uint8) string {
+		if decimals == nil {
+			return amount.String()
+		}
+		return utils.FormatTokenAmount(amount, *decimals)
+	}
+
+	list := make([]models.ExportTotal, 0, len(keys))
+	for _, key := range keys {
+		t := totals[key]
+		list = append(list, models.ExportTotal{
+			Network:      t.record.Network,
+			TokenAddress: t.record.TokenAddress,
+			Symbol:       t.record.Symbol,
+			Records:      t.records,
+			Amount:       format(t.amount, t.record.Decimals),
+			PlatformFee:  format(t.fee, t.record.Decimals),
+			MerchantNet:  format(t.net, t.record.Decimals),
+		})
+	}
+	return list
+}
+
+// CreateExportJob queues an export to be written by the export worker
+func CreateExportJob(query ExportQuery, createdBy string) (*models.ExportJobDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	job := models.ExportJobDB{
+		MerchantId: query.MerchantId,
+		Kind:       query.Kind,
+		Format:     query.Format,
+		From:       query.From.Format(time.RFC3339Nano),
+		To:         query.To.Format(time.RFC3339Nano),
+		CreatedBy:  createdBy,
+	}
+
+	var result []models.ExportJobDB
+	if err := db.Supabase.DB.From("export_jobs").Insert(job).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return &job, nil
+	}
+	return &result[0], nil
+}
+
+// GetExportJob fetches an export job of a merchant, or a platform export job
+// when merchantId is empty
+func GetExportJob(merchantId string, jobId int64) (*models.ExportJobDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var jobs []models.ExportJobDB
+	err := db.Supabase.DB.From("export_jobs").Select("*").
+		Eq("id", strconv.FormatInt(jobId, 10)).
+		Eq("merchantId", merchantId).
+		Execute(&jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(jobs) == 0 {
+		return nil, ErrExportJobNotFound
+	}
+	return &jobs[0], nil
+}
+
+// ListExportJobs returns the newest export jobs of a merchant, or of the
+// platform when merchantId is empty
+func ListExportJobs(merchantId string, limit int) ([]models.ExportJobDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	query := db.Supabase.DB.From("export_jobs").Select("*")
+	query.OrderBy("id", "desc").Limit(limit)
+
+	var jobs []models.ExportJobDB
+	if err := query.Eq("merchantId", merchantId).Execute(&jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// ExportJobFile returns where a ready export job's file is downloaded from
+func ExportJobFile(ctx context.Context, job *models.ExportJobDB) (export.Location, error) {
+	if job.Status != models.ExportJobReady {
+		return export.Location{}, ErrExportNotReady
+	}
+
+	query, err := ExportJobQuery(*job)
+	if err != nil {
+		return export.Location{}, err
+	}
+
+	location, err := export.Locate(ctx, job.FileName, query.FileName())
+	if err == export.ErrNotFound {
+		return export.Location{}, ErrExportNotReady
+	}
+	return location, err
+}
+
+// ProcessExportJobs writes up to limit queued export jobs, oldest first, and
+// returns how many it finished. Jobs whose lease ran out are queued again first.
+func ProcessExportJobs(ctx context.Context, limit int) (int, error) {
+	if db.Supabase == nil {
+		return 0, ErrDatabaseNotInitialized
+	}
+
+	var stale []models.ExportJobDB
+	err := db.Supabase.DB.From("export_jobs").Update(map[string]interface{}{
+		"status": models.ExportJobQueued,
+	}).
+		Eq("status", models.ExportJobRunning).
+		Lt("startedAt", time.Now().Add(-exportJobLease).UTC().Format(time.RFC3339)).
+		Execute(&stale)
+	if err != nil {
+		return 0, err
+	}
+
+	query := db.Supabase.DB.From("export_jobs").Select("*")
+	query.OrderBy("id", "asc").Limit(limit)
+
+	var jobs []models.ExportJobDB
+	if err := query.Eq("status", models.ExportJobQueued).Execute(&jobs); err != nil {
+		return 0, err
+	}
+
+	finished := 0
+	for _, job := range jobs {
+		ok, err := runExportJob(ctx, job)
+		if err != nil {
+			log.Printf("exports: job %d: %v", job.Id, err)
+			continue
+		}
+		if ok {
+			finished++
+		}
+	}
+
+	return finished, nil
+}
+
+// runExportJob claims a job, writes its file and records the outcome. It
+// returns false without error when another worker already claimed the job.
+func runExportJob(ctx context.Context, job models.ExportJobDB) (bool, error) {
+	var claimed []models.ExportJobDB
+	err := db.Supabase.DB.From("export_jobs").Update(map[string]interface{}{
+		"status":    models.ExportJobRunning,
+		"startedAt": time.Now().UTC().Format(time.RFC3339),
+	}).
+		Eq("id", strconv.FormatInt(job.Id, 10)).
+		Eq("status", models.ExportJobQueued).
+		Execute(&claimed)
+	if err != nil {
+		return false, err
+	}
+
+	if len(claimed) == 0 {
+		return false, nil
+	}
+
+	query, err := ExportJobQuery(job)
+	if err != nil {
+		return true, finishExportJob(job.Id, map[string]interface{}{"status": models.ExportJobFailed, "error": err.Error()})
+	}
+
+	// A job still running past its lease is queued again, so stop writing by then
+	ctx, cancel := context.WithTimeout(ctx, exportJobLease)
+	defer cancel()
+
+	fileName := fmt.Sprintf("export-%d.%s", job.Id, job.Format)
+	var rowCount int64
+	size, err := export.WriteFile(ctx, fileName, func(w io.Writer) error {
+		if query.Format == models.ExportFormatPDF {
+			records, err := CollectExportRecords(ctx, query, exportStatementLimit)
+			if err != nil {
+				return err
+			}
+			rowCount = int64(len(records))
+			return WriteExport(w, query, records)
+		}
+
+		writer, err := export.NewWriter(w, query.Format)
+		if err != nil {
+			return err
+		}
+		rowCount, err = ExportRecords(ctx, query, exportJobLimit, writer.Write)
+		if err != nil {
+			return err
+		}
+		return writer.Close()
+	})
+	if err != nil {
+		message := "Export could not be written"
+		if apiErr, ok := err.(*apierror.Error); ok {
+			message = apiErr.Message
+		}
+		log.Printf("exports: job %d failed: %v", job.Id, err)
+		return true, finishExportJob(job.Id, map[string]interface{}{"status": models.ExportJobFailed, "error": message})
+	}
+
+	completedAt := time.Now().UTC()
+	return true, finishExportJob(job.Id, map[string]interface{}{
+		"status":      models.ExportJobReady,
+		"rowCount":    rowCount,
+		"fileName":    fileName,
+		"fileSize":    size,
+		"completedAt": completedAt.Format(time.RFC3339),
+		"expiresAt":   completedAt.Add(ExportRetention).Format(time.RFC3339),
+	})
+}
+
+// ExportJobQuery returns the query a job was created with
+func ExportJobQuery(job models.ExportJobDB) (ExportQuery, error) {
+	from, err := time.Parse(time.RFC3339, job.From)
+	if err != nil {
+		return ExportQuery{}, err
+	}
+	to, err := time.Parse(time.RFC3339, job.To)
+	if err != nil {
+		return ExportQuery{}, err
+	}
+
+	return ExportQuery{MerchantId: job.MerchantId, Kind: job.Kind, Format: job.Format, From: from.UTC(), To: to.UTC()}, nil
+}
+
+func finishExportJob(jobId int64, updates map[string]interface{}) error {
+	if _, ok := updates["completedAt"]; !ok {
+		updates["completedAt"] = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	var result []models.ExportJobDB
+	return db.Supabase.DB.From("export_jobs").Update(updates).
+		Eq("id", strconv.FormatInt(jobId, 10)).
+		Execute(&result)
+}
+
+// ExpireExportJobs deletes the files of ready jobs past their expiry and marks
+// the jobs expired, returning how many it expired
+func ExpireExportJobs(ctx context.Context) (int, error) {
+	if db.Supabase == nil {
+		return 0, ErrDatabaseNotInitialized
+	}
+
+	var jobs []models.ExportJobDB
+	err := db.Supabase.DB.From("export_jobs").Select("*").
+		Eq("status", models.ExportJobReady).
+		Lt("expiresAt", time.Now().UTC().Format(time.RFC3339)).
+		Execute(&jobs)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, job := range jobs {
+		if err := export.Remove(ctx, job.FileName); err != nil {
+			log.Printf("exports: removing file of job %d: %v", job.Id, err)
+			continue
+		}
+
+		var result []models.ExportJobDB
+		err := db.Supabase.DB.From("export_jobs").Update(map[string]interface{}{
+			"status":   models.ExportJobExpired,
+			"fileName": "",
+		}).
+			Eq("id", strconv.FormatInt(job.Id, 10)).
+			Eq("status", models.ExportJobReady).
+			Execute(&result)
+		if err != nil {
+			log.Printf("exports: expiring job %d: %v", job.Id, err)
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// ToExportJob converts a stored job to its API shape, linking ready files to
+// downloadPath
+func ToExportJob(job models.ExportJobDB, downloadPath string) models.ExportJob {
+	view := models.ExportJob{
+		Id:          job.Id,
+		MerchantId:  job.MerchantId,
+		Kind:        job.Kind,
+		Format:      job.Format,
+		From:        job.From,
+		To:          job.To,
+		Status:      job.Status,
+		RowCount:    job.RowCount,
+		FileSize:    job.FileSize,
+		Error:       job.Error,
+		CreatedAt:   job.CreatedAt,
+		CompletedAt: job.CompletedAt,
+		ExpiresAt:   job.ExpiresAt,
+	}
+	if job.Status == models.ExportJobReady {
+		view.DownloadURL = downloadPath
+	}
+	return view
+}
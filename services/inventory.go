@@ -0,0 +1,183 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	postgrest "github.com/nedpals/supabase-go/postgrest/pkg"
+)
+
+var ErrInsufficientStock = apierror.Conflict(apierror.CodeInsufficientStock, "Insufficient stock for the requested quantity")
+
+// DefaultReservationTTL is how long checkout stock is held before the order is created on-chain
+const DefaultReservationTTL = 15 * time.Minute
+
+// StockRequest is one product line to take out of stock
+type StockRequest struct {
+	ProductId int64
+	Quantity  int64
+}
+
+// ReservationTTL returns the configured reservation TTL (RESERVATION_TTL, e.g. "15m")
+func ReservationTTL() time.Duration {
+	if value := os.Getenv("RESERVATION_TTL"); value != "" {
+		if ttl, err := time.ParseDuration(value); err == nil && ttl > 0 {
+			return ttl
+		}
+	}
+	return DefaultReservationTTL
+}
+
+// NewReference returns a random identifier for grouping records created in one request
+func NewReference() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// isReference reports whether value has the form of a NewReference id, so that
+// ids from requests are checked before they reach a PostgREST filter
+func isReference(value string) bool {
+	raw, err := hex.DecodeString(value)
+	return err == nil && len(raw) == 16
+}
+
+// ReserveStock reserves every line under one reference. Each line is an atomic
+// conditional update in the database; if any line fails, the lines already
+// reserved are released again.
+func ReserveStock(items []StockRequest) (string, []models.InventoryReservation, error) {
+	if db.Supabase == nil {
+		return "", nil, ErrDatabaseNotInitialized
+	}
+
+	reference := NewReference()
+	ttl := ReservationTTL()
+
+	var reservations []models.InventoryReservation
+	for _, item := range items {
+		var result []models.InventoryReservation
+		err := db.Supabase.DB.Rpc("reserve_stock", map[string]interface{}{
+			"p_reference":   reference,
+			"p_product_id":  item.ProductId,
+			"p_quantity":    item.Quantity,
+			"p_ttl_seconds": int(ttl.Seconds()),
+		}).Execute(&result)
+		if err != nil {
+			releaseReference(reference)
+			if isInsufficientStock(err) {
+				return "", nil, ErrInsufficientStock
+			}
+			return "", nil, err
+		}
+		reservations = append(reservations, result...)
+	}
+
+	return reference, reservations, nil
+}
+
+// BindReservations attaches the reservations made at checkout to the created order
+func BindReservations(reference, orderId string) ([]models.InventoryReservation, error) {
+	return callReservationFunction("bind_reservations", map[string]interface{}{
+		"p_reference": reference,
+		"p_order_id":  orderId,
+	})
+}
+
+// CommitReservations makes an order's reserved stock permanent once it is paid
+func CommitReservations(orderId string) ([]models.InventoryReservation, error) {
+	return callReservationFunction("commit_reservations", map[string]interface{}{
+		"p_order_id": orderId,
+	})
+}
+
+// ReleaseReservations returns an order's reserved stock
+func ReleaseReservations(orderId string) ([]models.InventoryReservation, error) {
+	return callReservationFunction("release_reservations", map[string]interface{}{
+		"p_order_id": orderId,
+	})
+}
+
+// ReleaseExpiredReservations returns stock held by checkouts that never created an order
+func ReleaseExpiredReservations() ([]models.InventoryReservation, error) {
+	return callReservationFunction("release_expired_reservations", map[string]interface{}{})
+}
+
+// GetLowStockProducts lists a merchant's tracked products at or below threshold units
+func GetLowStockProducts(merchantId string, threshold int64) ([]models.Products, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var products []models.Products
+	err := db.Supabase.DB.From("products").Select("*").OrderBy("stock", "asc").
+		Eq("merchantId", merchantId).
+		IsNull("deletedAt").
+		Not().IsNull("stock").
+		Lte("stock", strconv.FormatInt(threshold, 10)).
+		Execute(&products)
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// syncInventory applies the inventory side effects of an order status change
+func syncInventory(orderId, status string) {
+	var err error
+	switch status {
+	case models.OrderStatusPaid:
+		_, err = CommitReservations(orderId)
+	case models.OrderStatusCancelled:
+		_, err = ReleaseReservations(orderId)
+	}
+
+	if err != nil {
+		log.Printf("inventory: failed to sync reservations for order %s (%s): %v", orderId, status, err)
+	}
+}
+
+// releaseReference releases reservations of a checkout that failed part way
+func releaseReference(reference string) {
+	var released []models.InventoryReservation
+	err := db.Supabase.DB.Rpc("bind_reservations", map[string]interface{}{
+		"p_reference": reference,
+		"p_order_id":  "released:" + reference,
+	}).Execute(&released)
+	if err == nil && len(released) > 0 {
+		_, err = ReleaseReservations("released:" + reference)
+	}
+
+	if err != nil {
+		log.Printf("inventory: failed to release reservations %s: %v", reference, err)
+	}
+}
+
+func callReservationFunction(name string, params map[string]interface{}) ([]models.InventoryReservation, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var result []models.InventoryReservation
+	if err := db.Supabase.DB.Rpc(name, params).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func isInsufficientStock(err error) bool {
+	var reqErr *postgrest.RequestError
+	return errors.As(err, &reqErr) && strings.Contains(reqErr.Message, "insufficient_stock")
+}
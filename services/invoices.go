@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrInvoiceNotFound  = apierror.NotFound(apierror.CodeInvoiceNotFound, "Invoice not found")
+	ErrInvoiceNotIssued = apierror.Conflict(apierror.CodeInvoiceNotIssued, "Order has no document of this type; invoices are issued once an order is paid and credit notes once it is refunded")
+	ErrNotOrderParty    = apierror.New(http.StatusForbidden, apierror.CodeForbidden, "Only the order's buyer or merchant can read its invoices")
+)
+
+// documentIssued reports whether an order in the given status has a document of the type
+func documentIssued(status, documentType string) bool {
+	switch documentType {
+	case models.DocumentTypeInvoice:
+		return status == models.OrderStatusPaid || status == models.OrderStatusSettled || status == models.OrderStatusRefunded
+	case models.DocumentTypeCreditNote:
+		return status == models.OrderStatusRefunded
+	default:
+		return false
+	}
+}
+
+// AuthorizeOrderDocument checks that wallet paid the order or owns its merchant
+func AuthorizeOrderDocument(order models.OrderDB, wallet common.Address) error {
+	if strings.EqualFold(order.PayerAddress, wallet.Hex()) {
+		return nil
+	}
+
+	if _, err := AuthorizeMerchant(order.MerchantId, wallet); err != nil {
+		if err == ErrNotMerchantOwner {
+			return ErrNotOrderParty
+		}
+		return err
+	}
+
+	return nil
+}
+
+// OrderDocument returns the order's invoice or credit note, issuing it first
+// when the order's status calls for one that has not been issued yet
+func OrderDocument(ctx context.Context, order models.OrderDB, documentType string) (*models.InvoiceDB, error) {
+	document, err := GetOrderDocument(order.OrderId, documentType)
+	if err != ErrInvoiceNotFound {
+		return document, err
+	}
+
+	if !documentIssued(order.Status, documentType) {
+		return nil, ErrInvoiceNotIssued
+	}
+
+	return issueOrderDocument(ctx, order, documentType)
+}
+
+// GetOrderDocument fetches an issued invoice or credit note of an order
+func GetOrderDocument(orderId, documentType string) (*models.InvoiceDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var documents []models.InvoiceDB
+	err := db.Supabase.DB.From("invoices").Select("*").
+		Eq("orderId", orderId).
+		Eq("documentType", documentType).
+		Execute(&documents)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(documents) == 0 {
+		return nil, ErrInvoiceNotFound
+	}
+	return &documents[0], nil
+}
+
+// issueOrderDocument snapshots the merchant, line items and payment of an order
+// into a new document with the merchant's next number. A credit note needs the
+// invoice it credits, so that is issued first.
+func issueOrderDocument(ctx context.Context, order models.OrderDB, documentType string) (*models.InvoiceDB, error) {
+	if documentType == models.DocumentTypeCreditNote {
+		if _, err := OrderDocument(ctx, order, models.DocumentTypeInvoice); err != nil {
+			return nil, err
+		}
+	}
+
+	merchant, err := GetMerchant(order.MerchantId)
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := tokenRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	network := order.Network
+	if network == "" {
+		network = networks.DefaultNetworkName
+	}
+
+	var symbol string
+	var decimals *uint8
+	if token, ok := registry[tokenKey(network, order.TokenAddress)]; ok {
+		symbol = token.Symbol
+		decimals = &token.Decimals
+	}
+
+	lines, err := invoiceLines(order, decimals)
+	if err != nil {
+		return nil, err
+	}
+
+	// The payment transaction for an invoice, the refund for a credit note
+	status := models.OrderStatusPaid
+	if documentType == models.DocumentTypeCreditNote {
+		status = models.OrderStatusRefunded
+	}
+	events, err := ListOrderEvents(order.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	var event models.OrderEvent
+	for _, e := range events {
+		if e.ToStatus == status {
+			event = e
+		}
+	}
+
+	params := map[string]interface{}{
+		"p_document_type":    documentType,
+		"p_order_id":         order.OrderId,
+		"p_merchant_id":      order.MerchantId,
+		"p_merchant_name":    merchant.MerchantName,
+		"p_payout_address":   merchant.PayoutWalletAddress,
+		"p_buyer_address":    order.PayerAddress,
+		"p_network":          network,
+		"p_token_address":    order.TokenAddress,
+		"p_token_symbol":     symbol,
+		"p_token_decimals":   nil,
+		"p_amount":           order.Amount,
+		"p_line_items":       lines,
+		"p_transaction_hash": event.TransactionHash,
+		"p_block_number":     event.BlockNumber,
+		"p_block_timestamp":  nil,
+	}
+	if decimals != nil {
+		params["p_token_decimals"] = *decimals
+	}
+	if event.BlockNumber > 0 {
+		if timestamp := blockTimestamps(ctx, network, []uint64{event.BlockNumber})[event.BlockNumber]; timestamp != "" {
+			params["p_block_timestamp"] = timestamp
+		}
+	}
+
+	var result []models.InvoiceDB
+	if err := db.Supabase.DB.Rpc("issue_order_document", params).Execute(&result); err != nil {
+		// A concurrent issuer may have won the order's document
+		if document, getErr := GetOrderDocument(order.OrderId, documentType); getErr == nil {
+			return document, nil
+		}
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return GetOrderDocument(order.OrderId, documentType)
+	}
+	return &result[0], nil
+}
+
+// invoiceLines copies the order's line items, or describes the whole order as
+// one line when it was not created from a cart
+func invoiceLines(order models.OrderDB, decimals *uint8) ([]models.InvoiceLine, error) {
+	items, err := ListOrderItems(order.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	format := func(amount string) string {
+		value, ok := new(big.Int).SetString(amount, 10)
+		if decimals == nil || !ok {
+			return ""
+		}
+		return utils.FormatTokenAmount(value, *decimals)
+	}
+
+	if len(items) == 0 {
+		return []models.InvoiceLine{{
+			Name:            "Order " + order.OrderId,
+			Quantity:        1,
+			Amount:          order.Amount,
+			AmountFormatted: format(order.Amount),
+		}}, nil
+	}
+
+	lines := make([]models.InvoiceLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, models.InvoiceLine{
+			ProductId:       item.ProductId,
+			Name:            item.Name,
+			Quantity:        item.Quantity,
+			UnitPrice:       item.UnitPrice,
+			Amount:          item.LineTotalAmount,
+			AmountFormatted: format(item.LineTotalAmount),
+		})
+	}
+	return lines, nil
+}
+
+// IssueMissingDocuments issues up to limit invoices and credit notes that
+// orders should have but do not, oldest order first, and returns how many it issued
+func IssueMissingDocuments(ctx context.Context, limit int) (int, error) {
+	if db.Supabase == nil {
+		return 0, ErrDatabaseNotInitialized
+	}
+
+	var missing []struct {
+		OrderId      string `json:"orderId"`
+		DocumentType string `json:"documentType"`
+	}
+	if err := db.Supabase.DB.Rpc("orders_missing_documents", map[string]interface{}{"p_limit": limit}).Execute(&missing); err != nil {
+		return 0, err
+	}
+
+	issued := 0
+	for _, m := range missing {
+		order, err := GetOrder(m.OrderId)
+		if err != nil {
+			log.Printf("invoices: order %s: %v", m.OrderId, err)
+			continue
+		}
+
+		if _, err := OrderDocument(ctx, *order, m.DocumentType); err != nil {
+			log.Printf("invoices: could not issue %s for order %s: %v", m.DocumentType, m.OrderId, err)
+			continue
+		}
+		issued++
+	}
+
+	return issued, nil
+}
+
+// ListInvoices returns a merchant's documents newest first, optionally of one
+// type, with ids below cursor when it is set
+func ListInvoices(merchantId, documentType string, limit int, cursor int64) ([]models.InvoiceDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	query := db.Supabase.DB.From("invoices").Select("*")
+	query.OrderBy("id", "desc").Limit(limit)
+
+	filter := query.Eq("merchantId", merchantId)
+	if documentType != "" {
+		filter.Eq("documentType", documentType)
+	}
+	if cursor > 0 {
+		filter.Lt("id", strconv.FormatInt(cursor, 10))
+	}
+
+	var documents []models.InvoiceDB
+	if err := filter.Execute(&documents); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// ToInvoice converts a stored document to its API shape, formatting the amount
+// and linking the transaction to the block explorer
+func ToInvoice(document models.InvoiceDB) models.Invoice {
+	invoice := models.Invoice{
+		Id:                    document.Id,
+		DocumentType:          document.DocumentType,
+		InvoiceNumber:         document.InvoiceNumber,
+		CreditedInvoiceNumber: document.CreditedInvoiceNumber,
+		OrderId:               document.OrderId,
+		MerchantId:            document.MerchantId,
+		MerchantName:          document.MerchantName,
+		PayoutAddress:         document.PayoutAddress,
+		BuyerAddress:          document.BuyerAddress,
+		Network:               document.Network,
+		TokenAddress:          document.TokenAddress,
+		Symbol:                document.TokenSymbol,
+		Decimals:              document.TokenDecimals,
+		Amount:                document.Amount,
+		LineItems:             document.LineItems,
+		TransactionHash:       document.TransactionHash,
+		BlockNumber:           document.BlockNumber,
+		ExplorerURL:           networks.ExplorerTxURL(document.Network, document.TransactionHash),
+		IssuedAt:              document.IssuedAt,
+	}
+	if invoice.LineItems == nil {
+		invoice.LineItems = []models.InvoiceLine{}
+	}
+	if document.TokenDecimals != nil {
+		if amount, ok := new(big.Int).SetString(document.Amount, 10); ok {
+			invoice.AmountFormatted = utils.FormatTokenAmount(amount, *document.TokenDecimals)
+		}
+	}
+	if document.BlockTimestamp != nil {
+		invoice.BlockTimestamp = *document.BlockTimestamp
+		if t, err := time.Parse(time.RFC3339, *document.BlockTimestamp); err == nil {
+			invoice.BlockTimestamp = t.UTC().Format(time.RFC3339)
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, document.IssuedAt); err == nil {
+		invoice.IssuedAt = t.UTC().Format(time.RFC3339)
+	}
+
+	return invoice
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/utils"
+)
+
+var ErrMerchantNotFound = apierror.NotFound(apierror.CodeMerchantNotFound, "Merchant not found")
+
+// GetMerchant fetches a merchant by its hex merchantId
+func GetMerchant(merchantId string) (*models.MerchantDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	if !utils.IsHexId(merchantId) {
+		return nil, ErrMerchantNotFound
+	}
+
+	var merchants []models.MerchantDB
+	if err := db.Supabase.DB.From("merchants").Select("*").Eq("merchantId", merchantId).Execute(&merchants); err != nil {
+		return nil, err
+	}
+
+	if len(merchants) == 0 {
+		return nil, ErrMerchantNotFound
+	}
+
+	return &merchants[0], nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/abi"
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/metadata"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	ErrMetadataStoreNotInitialized = apierror.New(http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Metadata store not initialized")
+	ErrNoOnChainMetadataURI        = errors.New("transaction does not set a metadata URI")
+)
+
+// PublishMetadata stores v as a canonical JSON document and returns its URI and hash
+func PublishMetadata(ctx context.Context, v interface{}) (metadata.Document, error) {
+	if metadata.Default() == nil {
+		return metadata.Document{}, ErrMetadataStoreNotInitialized
+	}
+
+	document, _, err := metadata.PutJSON(ctx, v)
+	return document, err
+}
+
+// LoadMerchantMetadata reads a merchant document back from the store. Documents
+// stored elsewhere, or that are not merchant documents, return nil without error.
+func LoadMerchantMetadata(ctx context.Context, uri string) (*models.MerchantMetadata, error) {
+	store := metadata.Default()
+	if store == nil || uri == "" {
+		return nil, nil
+	}
+
+	raw, err := store.Get(ctx, uri)
+	if errors.Is(err, metadata.ErrUnsupportedURI) || errors.Is(err, metadata.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var document models.MerchantMetadata
+	if err := json.Unmarshal(raw, &document); err != nil || document.Type != "merchant" {
+		return nil, nil
+	}
+
+	return &document, nil
+}
+
+// ResolveMetadata fetches the document behind uri and checks that its content is
+// the content the URI addresses
+func ResolveMetadata(ctx context.Context, uri string) (*models.MetadataResolution, error) {
+	store := metadata.Default()
+	if store == nil {
+		return nil, ErrMetadataStoreNotInitialized
+	}
+
+	raw, err := store.Get(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	verified, err := store.Verify(ctx, uri, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	resolution := &models.MetadataResolution{
+		URI:             uri,
+		Hash:            metadata.Hash(raw),
+		ContentVerified: verified,
+		Document:        raw,
+	}
+
+	if !json.Valid(raw) {
+		// Still report the hash, but never embed invalid JSON in the response
+		resolution.ContentVerified = false
+		resolution.Document = nil
+	}
+
+	return resolution, nil
+}
+
+// OnChainMetadataURI decodes the metadata URI argument of a createOrder,
+// registerMerchant or updateMerchant transaction
+func OnChainMetadataURI(ctx context.Context, client *ethclient.Client, txHash common.Hash) (string, error) {
+	tx, _, err := client.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return "", err
+	}
+
+	data := tx.Data()
+	if len(data) < 4 {
+		return "", ErrNoOnChainMetadataURI
+	}
+
+	paymentProcessorABI, err := abi.GetPaymentProcessorABI()
+	if err != nil {
+		return "", err
+	}
+
+	merchantRegistryABI, err := abi.GetMerchantRegistryABI()
+	if err != nil {
+		return "", err
+	}
+
+	method, err := paymentProcessorABI.MethodById(data[:4])
+	if err != nil {
+		method, err = merchantRegistryABI.MethodById(data[:4])
+		if err != nil {
+			return "", ErrNoOnChainMetadataURI
+		}
+	}
+
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return "", err
+	}
+
+	for i, input := range method.Inputs {
+		if input.Name != "_metadataUri" {
+			continue
+		}
+		if uri, ok := args[i].(string); ok {
+			return uri, nil
+		}
+	}
+
+	return "", ErrNoOnChainMetadataURI
+}
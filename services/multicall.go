@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/Dbriane208/stable-market/abi"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Call is one contract call in a multicall batch
+type Call struct {
+	Target   common.Address
+	CallData []byte
+}
+
+// CallResult is the outcome of one call. A failed call does not fail the batch.
+type CallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// multicall3Call mirrors the Call3 struct of aggregate3
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// Multicall runs calls in a single eth_call through Multicall3, returning one
+// result per call in order. A nil blockNumber reads the latest block.
+func Multicall(ctx context.Context, client *ethclient.Client, blockNumber *big.Int, calls []Call) ([]CallResult, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	multicallABI, err := abi.GetMulticall3ABI()
+	if err != nil {
+		return nil, err
+	}
+
+	batch := make([]multicall3Call, len(calls))
+	for i, call := range calls {
+		batch[i] = multicall3Call{Target: call.Target, AllowFailure: true, CallData: call.CallData}
+	}
+
+	callData, err := multicallABI.Pack("aggregate3", batch)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.CallContract(ctx, ethereum.CallMsg{To: &abi.Multicall3Address, Data: callData}, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded []CallResult
+	if err := multicallABI.UnpackIntoInterface(&decoded, "aggregate3", output); err != nil {
+		return nil, err
+	}
+	if len(decoded) != len(calls) {
+		return nil, errors.New("multicall returned an unexpected number of results")
+	}
+
+	return decoded, nil
+}
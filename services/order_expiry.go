@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	sdkorder "github.com/Dbriane208/stablebase-go-sdk/order"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// DefaultOrderExpiry is how long orders of merchants without an expiry
+	// policy may stay unpaid
+	DefaultOrderExpiry = 24 * time.Hour
+
+	// MinOrderExpiry and MaxOrderExpiry bound every order expiry
+	MinOrderExpiry = 5 * time.Minute
+	MaxOrderExpiry = 30 * 24 * time.Hour
+
+	// orderExpiryRetryDelay is the wait before cancelling an order is tried
+	// again after it failed, such as when the order was paid on-chain but
+	// not confirmed yet
+	orderExpiryRetryDelay = 15 * time.Minute
+)
+
+var ErrOrderExpired = apierror.New(http.StatusGone, apierror.CodeOrderExpired, "Order has expired and can no longer be paid")
+
+// OrderExpiry returns the platform's default order expiry (ORDER_EXPIRY, e.g. "24h")
+func OrderExpiry() time.Duration {
+	if value := os.Getenv("ORDER_EXPIRY"); value != "" {
+		if expiry, err := time.ParseDuration(value); err == nil && expiry >= MinOrderExpiry && expiry <= MaxOrderExpiry {
+			return expiry
+		}
+	}
+	return DefaultOrderExpiry
+}
+
+// CheckOrderExpiry rejects an expiry outside MinOrderExpiry and MaxOrderExpiry
+// as an invalid value of field
+func CheckOrderExpiry(expiry time.Duration, field string) error {
+	if expiry < MinOrderExpiry || expiry > MaxOrderExpiry {
+		return apierror.InvalidField(apierror.CodeInvalidExpiry, field, field+" must be between 5 minutes and 30 days")
+	}
+	return nil
+}
+
+// GetOrderExpiryPolicy returns how long a merchant's orders may stay unpaid.
+// Merchants that never set a policy get the platform default.
+func GetOrderExpiryPolicy(merchantId string) (*models.OrderExpiryPolicyDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var policies []models.OrderExpiryPolicyDB
+	if err := db.Supabase.DB.From("order_expiry_policies").Select("*").Eq("merchantId", merchantId).Execute(&policies); err != nil {
+		return nil, err
+	}
+
+	if len(policies) == 0 {
+		return &models.OrderExpiryPolicyDB{MerchantId: merchantId, ExpiryMinutes: int(OrderExpiry() / time.Minute)}, nil
+	}
+	return &policies[0], nil
+}
+
+// SetOrderExpiryPolicy replaces how long a merchant's new orders may stay
+// unpaid. Orders already created keep their expiry.
+func SetOrderExpiryPolicy(merchantId string, expiryMinutes int, updatedBy string) (*models.OrderExpiryPolicyDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	if err := CheckOrderExpiry(time.Duration(expiryMinutes)*time.Minute, "expiryMinutes"); err != nil {
+		return nil, err
+	}
+
+	row := models.OrderExpiryPolicyDB{
+		MerchantId:    merchantId,
+		ExpiryMinutes: expiryMinutes,
+		UpdatedBy:     updatedBy,
+		UpdatedAt:     time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var result []models.OrderExpiryPolicyDB
+	if err := db.Supabase.DB.From("order_expiry_policies").Upsert(row).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return &row, nil
+	}
+	return &result[0], nil
+}
+
+// OrderExpiresAt returns when an order created at createdAt expires: after
+// expiresIn seconds when it is set, else after the merchant's order expiry
+func OrderExpiresAt(merchantId string, expiresIn int64, createdAt time.Time) (string, error) {
+	expiry := time.Duration(expiresIn) * time.Second
+	if expiresIn == 0 {
+		policy, err := GetOrderExpiryPolicy(merchantId)
+		if err != nil {
+			return "", err
+		}
+		expiry = time.Duration(policy.ExpiryMinutes) * time.Minute
+	}
+
+	if err := CheckOrderExpiry(expiry, "expiresIn"); err != nil {
+		return "", err
+	}
+
+	return createdAt.Add(expiry).UTC().Format(time.RFC3339), nil
+}
+
+// CheckOrderNotExpired refuses orders past their expiry. Orders created before
+// expiries were recorded have none and never expire.
+func CheckOrderNotExpired(order *models.OrderDB) error {
+	if order.ExpiresAt == "" {
+		return nil
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, order.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("order %s has an unreadable expiry %q: %w", order.OrderId, order.ExpiresAt, err)
+	}
+
+	if !time.Now().Before(expiresAt) {
+		return ErrOrderExpired.WithMeta("expiresAt", order.ExpiresAt)
+	}
+	return nil
+}
+
+// CancelExpiredOrders cancels up to limit unpaid orders past their expiry and
+// returns how many it cancelled. Each order is cancelled on-chain with the
+// platform key like the cancel endpoint does, which releases its stock
+// reservations; orders that fail to cancel are retried after a delay.
+func CancelExpiredOrders(ctx context.Context, limit int) (int, error) {
+	if db.Supabase == nil {
+		return 0, ErrDatabaseNotInitialized
+	}
+
+	now := time.Now().UTC()
+	var due []struct {
+		OrderId string `json:"orderId"`
+		Network string `json:"network"`
+	}
+	err := db.Supabase.DB.Rpc("orders_due_for_expiry", map[string]interface{}{
+		"p_now":         now.Format(time.RFC3339),
+		"p_retry_after": now.Add(-orderExpiryRetryDelay).Format(time.RFC3339),
+		"p_limit":       limit,
+	}).Execute(&due)
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, order := range due {
+		ok, err := cancelExpiredOrder(ctx, order.OrderId, order.Network)
+		if err != nil {
+			log.Printf("order expiry: order %s: %v", order.OrderId, err)
+			continue
+		}
+		if ok {
+			cancelled++
+		}
+	}
+
+	return cancelled, nil
+}
+
+// cancelExpiredOrder cancels one expired order. It returns false without error
+// when the order was paid or cancelled in the meantime.
+func cancelExpiredOrder(ctx context.Context, orderId, network string) (bool, error) {
+	// Recording the attempt first keeps an order that fails to cancel out of
+	// the next runs until orderExpiryRetryDelay has passed
+	var attempted []models.OrderDB
+	err := db.Supabase.DB.From("orders").Update(map[string]interface{}{
+		"expiryAttemptedAt": time.Now().UTC().Format(time.RFC3339),
+	}).Eq("orderId", orderId).Eq("status", models.OrderStatusCreated).Execute(&attempted)
+	if err != nil {
+		return false, err
+	}
+	if len(attempted) == 0 {
+		return false, nil
+	}
+
+	if network == "" {
+		network = networks.DefaultNetworkName
+	}
+	sdkClient := networks.GetClient(network)
+	if sdkClient == nil {
+		return false, fmt.Errorf("network %s is not available", network)
+	}
+
+	tx, receipt, err := sdkorder.New(sdkClient).CancelOrder(ctx, common.HexToHash(orderId))
+	if err != nil {
+		return false, err
+	}
+
+	actor, _ := SenderOf(tx)
+
+	_, _, err = TransitionOrder(OrderTransition{
+		OrderId:         orderId,
+		To:              models.OrderStatusCancelled,
+		TransactionHash: receipt.TxHash.Hex(),
+		BlockNumber:     receipt.BlockNumber.Uint64(),
+		Actor:           actor,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
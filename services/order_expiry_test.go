@@ -0,0 +1,45 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/models"
+)
+
+func TestCheckOrderNotExpired(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		expiresAt string
+		wantErr   bool
+		wantCode  string
+	}{
+		{name: "no expiry", expiresAt: ""},
+		{name: "not yet expired", expiresAt: now.Add(time.Hour).Format(time.RFC3339)},
+		{name: "expired", expiresAt: now.Add(-time.Minute).Format(time.RFC3339), wantErr: true, wantCode: apierror.CodeOrderExpired},
+		{name: "unreadable expiry", expiresAt: "tomorrow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckOrderNotExpired(&models.OrderDB{OrderId: "0x01", ExpiresAt: tt.expiresAt})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var apiErr *apierror.Error
+			isAPIErr := errors.As(err, &apiErr)
+			if tt.wantCode != "" && (!isAPIErr || apiErr.Code != tt.wantCode) {
+				t.Errorf("err = %v, want code %s", err, tt.wantCode)
+			}
+			// An unreadable expiry is a data problem, not an expired order
+			if tt.wantErr && tt.wantCode == "" && isAPIErr {
+				t.Errorf("err = %v, want a plain error", err)
+			}
+		})
+	}
+}
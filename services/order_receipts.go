@@ -0,0 +1,146 @@
+package services
+
+import (
+	"bytes"
+	"math/big"
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/abi"
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	ErrOrderCreatedNotFound = apierror.New(http.StatusUnprocessableEntity, apierror.CodeTxEventNotFound, "Order created but orderId not found in transaction logs")
+	ErrTxNotForOrder        = apierror.New(http.StatusUnprocessableEntity, apierror.CodeTxEventNotFound, "Transaction does not call the payment processor for this order")
+)
+
+// OrderCreated is a payment processor OrderCreated event. OrderId, Payer and
+// MerchantId are indexed, so they are read from the log topics.
+type OrderCreated struct {
+	OrderId        string
+	Payer          string
+	MerchantId     string
+	MerchantPayout string
+	TokenAddress   string
+	Amount         string
+	MetadataURI    string
+}
+
+// ParseOrderCreated reads the OrderCreated event the payment processor logged
+// in a createOrder receipt
+func ParseOrderCreated(receipt *types.Receipt, processor common.Address) (*OrderCreated, error) {
+	contractABI, err := abi.GetPaymentProcessorABI()
+	if err != nil {
+		return nil, err
+	}
+	created := contractABI.Events["OrderCreated"]
+
+	for _, log := range receipt.Logs {
+		if log.Address != processor || len(log.Topics) != 4 || log.Topics[0] != created.ID {
+			continue
+		}
+
+		var data struct {
+			MerchantPayout common.Address
+			Token          common.Address
+			Amount         *big.Int
+			Status         uint8
+			MetadataUri    string
+		}
+		if err := contractABI.UnpackIntoInterface(&data, "OrderCreated", log.Data); err != nil {
+			return nil, err
+		}
+
+		return &OrderCreated{
+			OrderId:        log.Topics[1].Hex(),
+			Payer:          common.BytesToAddress(log.Topics[2].Bytes()).Hex(),
+			MerchantId:     log.Topics[3].Hex(),
+			MerchantPayout: data.MerchantPayout.Hex(),
+			TokenAddress:   data.Token.Hex(),
+			Amount:         data.Amount.String(),
+			MetadataURI:    data.MetadataUri,
+		}, nil
+	}
+
+	return nil, ErrOrderCreatedNotFound
+}
+
+// Payment processor events logged when an order is paid and when it leaves the
+// paid state
+const (
+	EventOrderPaid     = "OrderPaid"
+	EventOrderSettled  = "OrderSettled"
+	EventOrderRefunded = "OrderRefunded"
+)
+
+// OrderEventLogged reports whether the payment processor logged event for
+// orderId in receipt. The order id is the event's first indexed field.
+func OrderEventLogged(receipt *types.Receipt, processor common.Address, event string, orderId common.Hash) (bool, error) {
+	contractABI, err := abi.GetPaymentProcessorABI()
+	if err != nil {
+		return false, err
+	}
+	topic := contractABI.Events[event].ID
+
+	for _, log := range receipt.Logs {
+		if log.Address == processor && len(log.Topics) > 1 && log.Topics[0] == topic && log.Topics[1] == orderId {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ProcessorOrderCall returns the order id tx passes to the payment processor's
+// method. ok is false when tx calls another contract or another method.
+func ProcessorOrderCall(tx *types.Transaction, processor common.Address, method string) (common.Hash, bool, error) {
+	contractABI, err := abi.GetPaymentProcessorABI()
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	m := contractABI.Methods[method]
+
+	if tx.To() == nil || *tx.To() != processor {
+		return common.Hash{}, false, nil
+	}
+
+	data := tx.Data()
+	if len(data) < 4 || !bytes.Equal(data[:4], m.ID) {
+		return common.Hash{}, false, nil
+	}
+	args, err := m.Inputs.Unpack(data[4:])
+	if err != nil || len(args) != 1 {
+		return common.Hash{}, false, nil
+	}
+	id, ok := args[0].([32]byte)
+	if !ok {
+		return common.Hash{}, false, nil
+	}
+
+	return common.Hash(id), true, nil
+}
+
+// CheckOrderTransaction checks that tx called the payment processor's method for
+// orderId and that the processor logged event for the order in its receipt.
+// Otherwise any successful transaction could move the order.
+func CheckOrderTransaction(tx *types.Transaction, receipt *types.Receipt, processor common.Address, method, event string, orderId common.Hash) error {
+	called, ok, err := ProcessorOrderCall(tx, processor, method)
+	if err != nil {
+		return apierror.Internal(err)
+	}
+	if !ok || called != orderId {
+		return ErrTxNotForOrder
+	}
+
+	logged, err := OrderEventLogged(receipt, processor, event, orderId)
+	if err != nil {
+		return apierror.Internal(err)
+	}
+	if !logged {
+		return ErrTxNotForOrder
+	}
+
+	return nil
+}
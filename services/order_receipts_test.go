@@ -0,0 +1,60 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Dbriane208/stable-market/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestCheckOrderTransaction(t *testing.T) {
+	contractABI, err := abi.GetPaymentProcessorABI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		processor = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		stranger  = common.HexToAddress("0x5000000000000000000000000000000000000005")
+		orderId   = common.HexToHash("0xabc1")
+		otherId   = common.HexToHash("0xabc2")
+	)
+
+	call := func(to common.Address, method string, id common.Hash) *types.Transaction {
+		data, err := contractABI.Pack(method, [32]byte(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return types.NewTx(&types.LegacyTx{To: &to, Data: data})
+	}
+	logged := func(from common.Address, event string, id common.Hash) *types.Receipt {
+		return &types.Receipt{Logs: []*types.Log{{Address: from, Topics: []common.Hash{contractABI.Events[event].ID, id}}}}
+	}
+
+	tests := []struct {
+		name    string
+		tx      *types.Transaction
+		receipt *types.Receipt
+		wantErr error
+	}{
+		{"pays the order", call(processor, "payOrder", orderId), logged(processor, EventOrderPaid, orderId), nil},
+		{"calls another contract", call(stranger, "payOrder", orderId), logged(processor, EventOrderPaid, orderId), ErrTxNotForOrder},
+		{"calls another method", call(processor, "cancelOrder", orderId), logged(processor, EventOrderPaid, orderId), ErrTxNotForOrder},
+		{"pays another order", call(processor, "payOrder", otherId), logged(processor, EventOrderPaid, otherId), ErrTxNotForOrder},
+		{"event missing", call(processor, "payOrder", orderId), &types.Receipt{}, ErrTxNotForOrder},
+		{"event for another order", call(processor, "payOrder", orderId), logged(processor, EventOrderPaid, otherId), ErrTxNotForOrder},
+		{"event from another contract", call(processor, "payOrder", orderId), logged(stranger, EventOrderPaid, orderId), ErrTxNotForOrder},
+		{"contract creation", types.NewTx(&types.LegacyTx{}), logged(processor, EventOrderPaid, orderId), ErrTxNotForOrder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckOrderTransaction(tt.tx, tt.receipt, processor, "payOrder", EventOrderPaid, orderId)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	ErrDatabaseNotInitialized = apierror.New(http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Database client not initialized")
+	ErrOrderNotFound          = apierror.NotFound(apierror.CodeOrderNotFound, "Order not found")
+)
+
+// IllegalTransitionError is returned when an order cannot move to the requested status
+type IllegalTransitionError struct {
+	OrderId string
+	From    string
+	To      string
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("order %s cannot move from '%s' to '%s'", e.OrderId, e.From, e.To)
+}
+
+// OrderTransition describes a requested status change for an order
+type OrderTransition struct {
+	OrderId         string
+	To              string
+	TransactionHash string
+	BlockNumber     uint64
+	Actor           string
+}
+
+// GetOrder fetches a single order by its hex orderId
+func GetOrder(orderId string) (*models.OrderDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	if !utils.IsHexId(orderId) {
+		return nil, ErrOrderNotFound
+	}
+
+	var orders []models.OrderDB
+	if err := db.Supabase.DB.From("orders").Select("*").Eq("orderId", orderId).Execute(&orders); err != nil {
+		return nil, err
+	}
+
+	if len(orders) == 0 {
+		return nil, ErrOrderNotFound
+	}
+
+	return &orders[0], nil
+}
+
+// CheckOrderTransition loads the order and verifies it may move to the given status
+func CheckOrderTransition(orderId, to string) (*models.OrderDB, error) {
+	order, err := GetOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !models.CanTransitionOrder(order.Status, to) {
+		return order, &IllegalTransitionError{OrderId: orderId, From: order.Status, To: to}
+	}
+
+	return order, nil
+}
+
+// TransitionOrder moves an order to a new status and records the change in order_events.
+// The update only applies while the order is still in the status it was read in, so two
+// concurrent transitions cannot both succeed.
+func TransitionOrder(t OrderTransition) (*models.OrderDB, *models.OrderEvent, error) {
+	order, err := CheckOrderTransition(t.OrderId, t.To)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updates := map[string]interface{}{
+		"status": t.To,
+	}
+
+	var result []models.OrderDB
+	err = db.Supabase.DB.From("orders").Update(updates).Eq("orderId", t.OrderId).Eq("status", order.Status).Execute(&result)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(result) == 0 {
+		// Someone else moved the order between our read and the update
+		current, err := GetOrder(t.OrderId)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, &IllegalTransitionError{OrderId: t.OrderId, From: current.Status, To: t.To}
+	}
+
+	event := models.OrderEvent{
+		OrderId:         t.OrderId,
+		MerchantId:      order.MerchantId,
+		FromStatus:      order.Status,
+		ToStatus:        t.To,
+		TransactionHash: t.TransactionHash,
+		BlockNumber:     t.BlockNumber,
+		Actor:           t.Actor,
+	}
+
+	syncInventory(t.OrderId, t.To)
+
+	recorded, err := RecordOrderEvent(event)
+	if err != nil {
+		return &result[0], nil, err
+	}
+
+	PublishOrderEvent(result[0], *recorded)
+
+	return &result[0], recorded, nil
+}
+
+// PublishOrderEvent pushes a recorded order status change to live subscribers and
+// merchant webhooks. Failures are logged and never undo the change itself. The
+// invoices, credit notes and dispute outcomes a change calls for are recorded
+// by their sweepers.
+func PublishOrderEvent(order models.OrderDB, event models.OrderEvent) {
+	publishLiveOrderEvent(order, event)
+
+	if err := enqueueOrderWebhooks(order, event); err != nil {
+		log.Printf("webhooks: could not queue %s for order %s: %v", event.ToStatus, order.OrderId, err)
+	}
+}
+
+// RecordOrderEvent inserts a row into the order_events history table
+func RecordOrderEvent(event models.OrderEvent) (*models.OrderEvent, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var result []models.OrderEvent
+	if err := db.Supabase.DB.From("order_events").Insert(event).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return &event, nil
+	}
+
+	return &result[0], nil
+}
+
+// ListOrderEvents returns the transition history of an order, oldest first
+func ListOrderEvents(orderId string) ([]models.OrderEvent, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var events []models.OrderEvent
+	err := db.Supabase.DB.From("order_events").Select("*").OrderBy("id", "asc").Eq("orderId", orderId).Execute(&events)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// TransactionSender recovers the address that signed the given transaction
+func TransactionSender(ctx context.Context, client *ethclient.Client, txHash common.Hash) (string, error) {
+	tx, _, err := client.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return "", err
+	}
+
+	return SenderOf(tx)
+}
+
+// SenderOf recovers the signer of an already fetched transaction
+func SenderOf(tx *types.Transaction) (string, error) {
+	if tx == nil {
+		return "", errors.New("transaction is nil")
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return "", err
+	}
+
+	return sender.Hex(), nil
+}
+
+// ListOrderEventsByOrder returns the transition history of several orders keyed by orderId
+func ListOrderEventsByOrder(orderIds []string) (map[string][]models.OrderEvent, error) {
+	history := make(map[string][]models.OrderEvent, len(orderIds))
+	if len(orderIds) == 0 {
+		return history, nil
+	}
+
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var events []models.OrderEvent
+	err := db.Supabase.DB.From("order_events").Select("*").OrderBy("id", "asc").In("orderId", orderIds).Execute(&events)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		history[event.OrderId] = append(history[event.OrderId], event)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/events"
+	"github.com/Dbriane208/stable-market/models"
+)
+
+// MaxOrderEventReplay caps how many missed events a resuming client is sent
+const MaxOrderEventReplay = 500
+
+// OrderStreamEvent wraps a recorded order event for SSE and WebSocket clients.
+// The event id is the order_events id, so clients can resume from it.
+func OrderStreamEvent(event models.OrderEvent) events.Event {
+	data, err := json.Marshal(event)
+	if err != nil {
+		data = []byte("{}")
+	}
+
+	return events.Event{
+		ID:   event.Id,
+		Type: "order." + event.ToStatus,
+		Data: data,
+	}
+}
+
+// publishLiveOrderEvent sends the event to the order's topic and its merchant's topic
+func publishLiveOrderEvent(order models.OrderDB, event models.OrderEvent) {
+	broker := events.Default()
+	if broker == nil {
+		return
+	}
+
+	if event.MerchantId == "" {
+		event.MerchantId = order.MerchantId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	streamEvent := OrderStreamEvent(event)
+	for _, topic := range []string{events.OrderTopic(order.OrderId), events.MerchantTopic(order.MerchantId)} {
+		if err := broker.Publish(ctx, topic, streamEvent); err != nil {
+			log.Printf("events: could not publish %s for order %s: %v", streamEvent.Type, order.OrderId, err)
+		}
+	}
+}
+
+// ListOrderEventsSince returns an order's events with ids after afterId, oldest first
+func ListOrderEventsSince(orderId string, afterId int64) ([]models.OrderEvent, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	query := db.Supabase.DB.From("order_events").Select("*")
+	query.OrderBy("id", "asc").Limit(MaxOrderEventReplay)
+
+	var orderEvents []models.OrderEvent
+	err := query.Eq("orderId", orderId).Gt("id", strconv.FormatInt(afterId, 10)).Execute(&orderEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	return orderEvents, nil
+}
+
+// ListMerchantOrderEventsSince returns the events of every order of a merchant with
+// ids after afterId, oldest first
+func ListMerchantOrderEventsSince(merchantId string, afterId int64) ([]models.OrderEvent, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	query := db.Supabase.DB.From("order_events").Select("*")
+	query.OrderBy("id", "asc").Limit(MaxOrderEventReplay)
+
+	var orderEvents []models.OrderEvent
+	err := query.Eq("merchantId", merchantId).Gt("id", strconv.FormatInt(afterId, 10)).Execute(&orderEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	return orderEvents, nil
+}
@@ -0,0 +1,576 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Dbriane208/stable-market/abi"
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/metadata"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Errors of the order flows, shared with the controllers
+var (
+	ErrBlockchainUnavailable   = apierror.New(http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Blockchain client not initialized")
+	ErrInvalidOrderId          = apierror.InvalidField(apierror.CodeInvalidOrderId, "orderId", "Invalid orderId format (must be 32 bytes hex string)")
+	ErrInvalidMerchantId       = apierror.InvalidField(apierror.CodeInvalidMerchantId, "merchantId", "Invalid merchantId format (must be 32 bytes hex string)")
+	ErrInvalidTokenAddress     = apierror.InvalidField(apierror.CodeInvalidTokenAddress, "tokenAddress", "Invalid token address")
+	ErrInvalidTxHash           = apierror.InvalidField(apierror.CodeInvalidTxHash, "transactionHash", "Invalid transaction hash format")
+	ErrTxNotMined              = apierror.Conflict(apierror.CodeTxNotMined, "Transaction not found or not yet mined; wait for it to be confirmed and try again")
+	ErrTxReverted              = apierror.New(http.StatusUnprocessableEntity, apierror.CodeTxReverted, "Transaction failed on blockchain")
+	ErrCreatedForOtherMerchant = apierror.New(http.StatusUnprocessableEntity, apierror.CodeTxEventNotFound, "Transaction did not create an order for this merchant")
+	ErrOrderExists             = apierror.Conflict(apierror.CodeOrderExists, "Order has already been confirmed")
+	ErrCreatedOrderMismatch    = apierror.New(http.StatusUnprocessableEntity, apierror.CodeTxEventNotFound, "Order created on-chain does not match the requested token, amount or metadata")
+)
+
+// ReceiptError reports a failed receipt lookup. A transaction that has not been
+// mined yet can be retried; anything else is a node failure.
+func ReceiptError(err error) error {
+	if errors.Is(err, ethereum.NotFound) {
+		return ErrTxNotMined
+	}
+	return ChainError(err)
+}
+
+// ChainError reports a failed contract call or node request. Revert reasons come
+// from the contracts and are passed on; other failures can include the RPC URL,
+// so they are only logged.
+func ChainError(err error) error {
+	if i := strings.Index(err.Error(), "execution reverted"); i >= 0 {
+		return apierror.Wrap(http.StatusUnprocessableEntity, apierror.CodeCallReverted, err.Error()[i:], err)
+	}
+	return apierror.Wrap(http.StatusBadGateway, apierror.CodeChainUnavailable, "Blockchain request failed", err)
+}
+
+// PrepareApproveToken encodes the approval letting the PaymentProcessor spend
+// amount of the payer's token
+func PrepareApproveToken(req models.ApproveTokenRequest) (*models.PrepareApproveResponse, error) {
+	tokenAddress := common.HexToAddress(req.TokenAddress)
+	if tokenAddress == (common.Address{}) {
+		return nil, ErrInvalidTokenAddress
+	}
+
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok {
+		return nil, apierror.InvalidField(apierror.CodeInvalidAmount, "amount", "Invalid amount")
+	}
+
+	contractABI, err := abi.GetERC20ABI()
+	if err != nil {
+		return nil, apierror.Internal(err)
+	}
+
+	spenderAddress := networks.BaseSepoliaConfig.PaymentProcessorAddress
+
+	callData, err := contractABI.Pack("approve", spenderAddress, amount)
+	if err != nil {
+		return nil, apierror.Internal(err)
+	}
+
+	return &models.PrepareApproveResponse{
+		TransactionData: models.TransactionData{
+			To:       tokenAddress.Hex(),
+			Data:     "0x" + common.Bytes2Hex(callData),
+			ChainId:  networks.BaseSepoliaConfig.ChainID.Int64(),
+			Value:    "0",
+			GasLimit: 100000,
+		},
+		TokenAddress: req.TokenAddress,
+		Spender:      spenderAddress.Hex(),
+		Amount:       req.Amount,
+		Message:      "Sign this transaction to approve PaymentProcessor to spend your tokens",
+	}, nil
+}
+
+// ConfirmApproveToken acknowledges a submitted approval transaction
+func ConfirmApproveToken(req models.ConfirmApproveRequest) *models.ConfirmApproveResponse {
+	return &models.ConfirmApproveResponse{
+		Message:         "Token approval confirmed",
+		TransactionHash: req.TransactionHash,
+		ExplorerURL:     networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + req.TransactionHash,
+	}
+}
+
+// PrepareCreateOrder prepares the unsigned createOrder transaction for a direct
+// order. Without a metadata URI an order document is published from our own
+// records; with a product, its stock is reserved until the order is created.
+func PrepareCreateOrder(ctx context.Context, req models.CreateOrderRequest) (*models.PrepareCreateOrderResponse, error) {
+	var merchantId [32]byte
+	merchantIdBytes, err := hex.DecodeString(strings.TrimPrefix(req.MerchantId, "0x"))
+	if err != nil || len(merchantIdBytes) != 32 {
+		return nil, ErrInvalidMerchantId
+	}
+	copy(merchantId[:], merchantIdBytes)
+
+	if !common.IsHexAddress(req.TokenAddress) {
+		return nil, ErrInvalidTokenAddress
+	}
+	tokenAddress := common.HexToAddress(req.TokenAddress)
+
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok {
+		return nil, apierror.InvalidField(apierror.CodeInvalidAmount, "amount", "Invalid amount format")
+	}
+
+	if amount.Sign() <= 0 {
+		return nil, apierror.InvalidField(apierror.CodeInvalidAmount, "amount", "Amount must be greater than zero")
+	}
+
+	if req.BuyerAddress != "" && !common.IsHexAddress(req.BuyerAddress) {
+		return nil, apierror.InvalidField(apierror.CodeInvalidAddress, "buyerAddress", "Invalid buyer address")
+	}
+
+	// The product must be the merchant's and the amount its price, before
+	// any of its stock is held
+	quantity := req.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+	if req.ProductId > 0 {
+		sdkClient := networks.GetBaseClient()
+		if sdkClient == nil {
+			return nil, ErrBlockchainUnavailable
+		}
+
+		if err := CheckProductOrder(ctx, sdkClient.EthClient, req.ProductId, quantity, req.MerchantId, tokenAddress, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	// Without a client supplied URI, publish an order document built from our own records
+	metadataURI := req.MetadataURI
+	var metadataHash string
+	if metadataURI == "" {
+		document, err := publishCreateOrderMetadata(ctx, req, tokenAddress, amount, quantity)
+		if err != nil {
+			return nil, err
+		}
+		metadataURI, metadataHash = document.URI, document.Hash
+	}
+
+	transactionData, err := createOrderTransaction(merchantId, tokenAddress, amount, metadataURI)
+	if err != nil {
+		return nil, apierror.Internal(err)
+	}
+
+	// Hold stock for the product until the order is created on-chain
+	var reservationId string
+	var reservations []models.InventoryReservation
+	if req.ProductId > 0 {
+		reservationId, reservations, err = ReserveStock([]StockRequest{
+			{ProductId: req.ProductId, Quantity: quantity},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response := &models.PrepareCreateOrderResponse{
+		TransactionData: transactionData,
+		MerchantId:      req.MerchantId,
+		TokenAddress:    req.TokenAddress,
+		Amount:          req.Amount,
+		MetadataURI:     metadataURI,
+		MetadataHash:    metadataHash,
+		ReservationId:   reservationId,
+		Reservations:    reservations,
+		Message:         "Please sign with your wallet and submit the transaction hash to confirm.",
+	}
+
+	if len(reservations) > 0 {
+		response.ReservationExpiresAt = reservations[0].ExpiresAt
+	}
+
+	return response, nil
+}
+
+// ConfirmCreateOrder records an order from its mined createOrder transaction.
+// The order's values are read from the OrderCreated event, and the cart and
+// stock reservations of its checkout are attached to it.
+func ConfirmCreateOrder(ctx context.Context, req models.ConfirmCreateOrderRequest) (*models.ConfirmCreateOrderResponse, error) {
+	if !utils.IsHexId(req.TransactionHash) {
+		return nil, ErrInvalidTxHash
+	}
+
+	if !common.IsHexAddress(req.PayerAddress) {
+		return nil, apierror.InvalidField(apierror.CodeInvalidAddress, "payerAddress", "Invalid payer address")
+	}
+
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	merchantIdHex := req.MerchantId
+	if !strings.HasPrefix(merchantIdHex, "0x") {
+		merchantIdHex = "0x" + merchantIdHex
+	}
+
+	if _, err := GetMerchant(merchantIdHex); err != nil {
+		return nil, err
+	}
+
+	// Unpaid orders are cancelled by the expiry job once this passes
+	expiresAt, err := OrderExpiresAt(merchantIdHex, req.ExpiresIn, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	sdkClient := networks.GetBaseClient()
+	if sdkClient == nil {
+		return nil, ErrBlockchainUnavailable
+	}
+
+	txHash := common.HexToHash(req.TransactionHash)
+	receipt, err := sdkClient.EthClient.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, ReceiptError(err)
+	}
+
+	if receipt.Status == 0 {
+		return nil, ErrTxReverted
+	}
+
+	created, err := ParseOrderCreated(receipt, sdkClient.PaymentProcessorAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(created.MerchantId, merchantIdHex) {
+		return nil, ErrCreatedForOtherMerchant
+	}
+	if err := checkCreatedOrder(created, req); err != nil {
+		return nil, err
+	}
+	orderId := created.OrderId
+
+	// A cart's order must be the one its checkout prepared
+	if req.CartId != "" {
+		if err := CheckCartOrder(req.CartId, created); err != nil {
+			return nil, err
+		}
+	}
+
+	dbOrder := models.OrderDB{
+		OrderId:         orderId,
+		MerchantId:      merchantIdHex,
+		PayerAddress:    created.Payer,
+		TokenAddress:    created.TokenAddress,
+		Amount:          created.Amount,
+		Status:          models.OrderStatusCreated,
+		MetadataURI:     created.MetadataURI,
+		TransactionHash: req.TransactionHash,
+		Network:         networks.BaseSepoliaConfig.NetworkName,
+		ExpiresAt:       expiresAt,
+	}
+
+	var result []models.OrderDB
+	if err := db.Supabase.DB.From("orders").Insert(dbOrder).Execute(&result); err != nil {
+		// The unique order id refuses a createOrder transaction confirmed twice
+		if _, lookupErr := GetOrder(orderId); lookupErr == nil {
+			return nil, ErrOrderExists
+		}
+		return nil, apierror.Wrap(http.StatusInternalServerError, apierror.CodeInternal, "Order not saved to database", err)
+	}
+
+	createdEvent, err := RecordOrderEvent(models.OrderEvent{
+		OrderId:         orderId,
+		MerchantId:      merchantIdHex,
+		ToStatus:        models.OrderStatusCreated,
+		TransactionHash: req.TransactionHash,
+		BlockNumber:     receipt.BlockNumber.Uint64(),
+		Actor:           transactionActor(ctx, sdkClient.EthClient, txHash),
+	})
+	if err != nil {
+		return nil, apierror.Wrap(http.StatusInternalServerError, apierror.CodeInternal, "Order saved but history not recorded", err)
+	}
+
+	savedOrder := dbOrder
+	if len(result) > 0 {
+		savedOrder = result[0]
+	}
+	PublishOrderEvent(savedOrder, *createdEvent)
+
+	reservationId := req.ReservationId
+	if req.CartId != "" {
+		cart, err := GetCart(req.CartId)
+		if err != nil {
+			return nil, apierror.Wrap(http.StatusInternalServerError, apierror.CodeInternal, "Order saved but cart not found", err)
+		}
+
+		if reservationId == "" {
+			reservationId = cart.ReservationId
+		}
+
+		if _, err := AttachCartToOrder(req.CartId, orderId); err != nil {
+			return nil, apierror.Wrap(http.StatusInternalServerError, apierror.CodeInternal, "Order saved but line items not attached", err)
+		}
+	}
+
+	if reservationId != "" {
+		if _, err := BindReservations(reservationId, orderId); err != nil {
+			return nil, apierror.Wrap(http.StatusInternalServerError, apierror.CodeInternal, "Order saved but stock reservation not attached", err)
+		}
+	}
+
+	return &models.ConfirmCreateOrderResponse{
+		Success:     true,
+		Order:       savedOrder,
+		ExplorerURL: networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + req.TransactionHash,
+	}, nil
+}
+
+// PreparePayOrder prepares the unsigned payOrder transaction for an order that
+// can still be paid
+func PreparePayOrder(orderIdHex string) (*models.PreparePayOrderResponse, error) {
+	var orderId [32]byte
+	orderIdBytes, err := hex.DecodeString(strings.TrimPrefix(orderIdHex, "0x"))
+	if err != nil || len(orderIdBytes) != 32 {
+		return nil, ErrInvalidOrderId
+	}
+	copy(orderId[:], orderIdBytes)
+
+	if !strings.HasPrefix(orderIdHex, "0x") {
+		orderIdHex = "0x" + orderIdHex
+	}
+
+	existing, err := CheckOrderTransition(orderIdHex, models.OrderStatusPaid)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckOrderNotExpired(existing); err != nil {
+		return nil, err
+	}
+
+	contractABI, err := abi.GetPaymentProcessorABI()
+	if err != nil {
+		return nil, apierror.Internal(err)
+	}
+
+	data, err := contractABI.Pack("payOrder", orderId)
+	if err != nil {
+		return nil, apierror.Internal(err)
+	}
+
+	return &models.PreparePayOrderResponse{
+		TransactionData: models.TransactionData{
+			To:       networks.BaseSepoliaConfig.PaymentProcessorAddress.Hex(),
+			Data:     "0x" + hex.EncodeToString(data),
+			ChainId:  networks.BaseSepoliaConfig.ChainID.Int64(),
+			Value:    "0",
+			GasLimit: 300000,
+		},
+		OrderId: orderIdHex,
+		Message: "Please sign with your wallet to Pay the order.",
+	}, nil
+}
+
+// ConfirmPayOrder moves an order to paid from its mined payOrder transaction.
+// The transaction must call payOrder on the payment processor for this order
+// and the processor must have logged OrderPaid for it.
+func ConfirmPayOrder(ctx context.Context, req models.ConfirmPayOrderRequest) (*models.ConfirmPayOrderResponse, error) {
+	if !utils.IsHexId(req.TransactionHash) {
+		return nil, ErrInvalidTxHash
+	}
+
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	orderIdHex := req.OrderId
+	if !strings.HasPrefix(orderIdHex, "0x") {
+		orderIdHex = "0x" + orderIdHex
+	}
+
+	// Cancelled orders, including those the expiry job cancelled, cannot be paid
+	if _, err := CheckOrderTransition(orderIdHex, models.OrderStatusPaid); err != nil {
+		return nil, err
+	}
+
+	sdkClient := networks.GetBaseClient()
+	if sdkClient == nil {
+		return nil, ErrBlockchainUnavailable
+	}
+
+	txHash := common.HexToHash(req.TransactionHash)
+	receipt, err := sdkClient.EthClient.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, ReceiptError(err)
+	}
+
+	if receipt.Status == 0 {
+		return nil, ErrTxReverted
+	}
+
+	tx, _, err := sdkClient.EthClient.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return nil, ReceiptError(err)
+	}
+	if err := CheckOrderTransaction(tx, receipt, sdkClient.PaymentProcessorAddress, "payOrder", EventOrderPaid, common.HexToHash(orderIdHex)); err != nil {
+		return nil, err
+	}
+
+	// The signer is only recorded, so a sender that cannot be recovered is left empty
+	actor, _ := SenderOf(tx)
+
+	_, event, err := TransitionOrder(OrderTransition{
+		OrderId:         orderIdHex,
+		To:              models.OrderStatusPaid,
+		TransactionHash: req.TransactionHash,
+		BlockNumber:     receipt.BlockNumber.Uint64(),
+		Actor:           actor,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ConfirmPayOrderResponse{
+		Success:         true,
+		OrderId:         orderIdHex,
+		Message:         "Order paid successfully",
+		Status:          models.OrderStatusPaid,
+		Event:           event,
+		TransactionHash: req.TransactionHash,
+		ExplorerURL:     networks.BaseSepoliaConfig.ExplorerURL + "/tx/" + req.TransactionHash,
+	}, nil
+}
+
+// checkCreatedOrder compares the order created on-chain with the token, amount
+// and metadata the request confirms. A checkout session confirms its own
+// values, so a payer cannot complete it with a cheaper order.
+func checkCreatedOrder(created *OrderCreated, req models.ConfirmCreateOrderRequest) error {
+	if !common.IsHexAddress(req.TokenAddress) || common.HexToAddress(req.TokenAddress) != common.HexToAddress(created.TokenAddress) {
+		return ErrCreatedOrderMismatch.WithMeta("field", "tokenAddress")
+	}
+
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok || amount.String() != created.Amount {
+		return ErrCreatedOrderMismatch.WithMeta("field", "amount")
+	}
+
+	if req.MetadataURI != created.MetadataURI {
+		return ErrCreatedOrderMismatch.WithMeta("field", "metadataURI")
+	}
+
+	return nil
+}
+
+// createOrderTransaction encodes an unsigned PaymentProcessor createOrder call
+func createOrderTransaction(merchantId [32]byte, tokenAddress common.Address, amount *big.Int, metadataURI string) (models.TransactionData, error) {
+	contractABI, err := abi.GetPaymentProcessorABI()
+	if err != nil {
+		return models.TransactionData{}, err
+	}
+
+	data, err := contractABI.Pack("createOrder", merchantId, tokenAddress, amount, metadataURI)
+	if err != nil {
+		return models.TransactionData{}, err
+	}
+
+	return models.TransactionData{
+		To:       networks.BaseSepoliaConfig.PaymentProcessorAddress.Hex(),
+		Data:     "0x" + hex.EncodeToString(data),
+		ChainId:  networks.BaseSepoliaConfig.ChainID.Int64(),
+		Value:    "0",
+		GasLimit: 300000,
+	}, nil
+}
+
+// newOrderMetadata starts an order document for a merchant, paid in the given token
+func newOrderMetadata(merchant *models.MerchantDB, tokenAddress common.Address, decimals uint8, buyer string) models.OrderMetadata {
+	return models.OrderMetadata{
+		Type:    "order",
+		Version: 1,
+		Merchant: models.OrderMetadataMerchant{
+			MerchantId:    merchant.MerchantId,
+			Name:          merchant.MerchantName,
+			PayoutAddress: merchant.PayoutWalletAddress,
+		},
+		Buyer: buyer,
+		Currency: models.OrderMetadataCurrency{
+			TokenAddress: tokenAddress.Hex(),
+			Decimals:     decimals,
+			Network:      networks.BaseSepoliaConfig.NetworkName,
+			ChainId:      networks.BaseSepoliaConfig.ChainID.Int64(),
+		},
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// publishCreateOrderMetadata builds and stores the order document for a direct
+// createOrder request
+func publishCreateOrderMetadata(ctx context.Context, req models.CreateOrderRequest, tokenAddress common.Address, amount *big.Int, quantity int64) (metadata.Document, error) {
+	merchant, err := GetMerchant(common.HexToHash(req.MerchantId).Hex())
+	if err != nil {
+		return metadata.Document{}, err
+	}
+
+	sdkClient := networks.GetBaseClient()
+	if sdkClient == nil {
+		return metadata.Document{}, ErrBlockchainUnavailable
+	}
+
+	decimals, err := TokenDecimals(ctx, sdkClient.EthClient, tokenAddress)
+	if err != nil {
+		return metadata.Document{}, apierror.Wrap(http.StatusBadRequest, apierror.CodeInvalidTokenAddress, "Could not read token decimals", err)
+	}
+
+	document := newOrderMetadata(merchant, tokenAddress, decimals, req.BuyerAddress)
+	document.Total = utils.FormatTokenAmount(amount, decimals)
+	document.Amount = amount.String()
+	document.LineItems = []models.OrderMetadataLineItem{}
+
+	if req.ProductId > 0 {
+		product, err := GetProduct(req.ProductId)
+		if err != nil {
+			return metadata.Document{}, err
+		}
+
+		unitAmount := new(big.Int).Div(amount, big.NewInt(quantity))
+		document.LineItems = append(document.LineItems, models.OrderMetadataLineItem{
+			ProductId:       product.Id,
+			Name:            product.Name,
+			Quantity:        quantity,
+			UnitPrice:       utils.FormatTokenAmount(unitAmount, decimals),
+			LineTotal:       document.Total,
+			LineTotalAmount: document.Amount,
+		})
+	}
+
+	stored, err := PublishMetadata(ctx, document)
+	if err != nil {
+		return metadata.Document{}, publishMetadataError(err)
+	}
+
+	return stored, nil
+}
+
+// publishMetadataError reports a failed order document publish. Failures the
+// store does not classify come from IPFS.
+func publishMetadataError(err error) error {
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return apierror.Wrap(http.StatusBadGateway, apierror.CodeUpstreamFailed, "Could not publish order metadata", err)
+}
+
+// transactionActor returns the signer of txHash, or an empty string if it cannot be recovered
+func transactionActor(ctx context.Context, client *ethclient.Client, txHash common.Hash) string {
+	sender, err := TransactionSender(ctx, client, txHash)
+	if err != nil {
+		return ""
+	}
+	return sender
+}
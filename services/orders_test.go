@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Dbriane208/stable-market/models"
+)
+
+func TestCheckCreatedOrder(t *testing.T) {
+	created := &OrderCreated{
+		TokenAddress: "0x2000000000000000000000000000000000000002",
+		Amount:       "100000000",
+		MetadataURI:  "ipfs://bafyorder",
+	}
+	request := func(change func(req *models.ConfirmCreateOrderRequest)) models.ConfirmCreateOrderRequest {
+		req := models.ConfirmCreateOrderRequest{
+			TokenAddress: "0x2000000000000000000000000000000000000002",
+			Amount:       "100000000",
+			MetadataURI:  "ipfs://bafyorder",
+		}
+		change(&req)
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		req     models.ConfirmCreateOrderRequest
+		wantErr bool
+	}{
+		{"matches", request(func(*models.ConfirmCreateOrderRequest) {}), false},
+		{"cheaper order", request(func(r *models.ConfirmCreateOrderRequest) { r.Amount = "1" }), true},
+		{"unreadable amount", request(func(r *models.ConfirmCreateOrderRequest) { r.Amount = "100 USDC" }), true},
+		{"other token", request(func(r *models.ConfirmCreateOrderRequest) {
+			r.TokenAddress = "0x3000000000000000000000000000000000000003"
+		}), true},
+		{"invalid token", request(func(r *models.ConfirmCreateOrderRequest) { r.TokenAddress = "usdc" }), true},
+		{"other document", request(func(r *models.ConfirmCreateOrderRequest) { r.MetadataURI = "ipfs://bafyother" }), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCreatedOrder(created, tt.req)
+			if tt.wantErr != errors.Is(err, ErrCreatedOrderMismatch) || (!tt.wantErr && err != nil) {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	ErrProductNotFound         = apierror.NotFound(apierror.CodeProductNotFound, "Product not found")
+	ErrProductMerchantMismatch = apierror.New(http.StatusUnprocessableEntity, apierror.CodeProductMerchantMismatch, "Product does not belong to the order's merchant")
+	ErrProductAmountMismatch   = apierror.InvalidField(apierror.CodeInvalidAmount, "amount", "amount must be the product price times the quantity")
+)
+
+// GetProduct fetches a product that has not been soft deleted
+func GetProduct(productId int64) (*models.Products, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var products []models.Products
+	err := db.Supabase.DB.From("products").Select("*").Eq("id", strconv.FormatInt(productId, 10)).IsNull("deletedAt").Execute(&products)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(products) == 0 {
+		return nil, ErrProductNotFound
+	}
+
+	return &products[0], nil
+}
+
+// CheckProductOrder verifies that an order for quantity units of a product is
+// placed with the product's merchant for the product's price in token, before
+// any of its stock is reserved
+func CheckProductOrder(ctx context.Context, client *ethclient.Client, productId, quantity int64, merchantId string, token common.Address, amount *big.Int) error {
+	products, err := getProductPrices([]int64{productId})
+	if err != nil {
+		return err
+	}
+	product, exists := products[productId]
+	if !exists {
+		return ErrProductNotFound
+	}
+
+	if common.HexToHash(product.MerchantId) != common.HexToHash(merchantId) {
+		return ErrProductMerchantMismatch
+	}
+
+	decimals, err := TokenDecimals(ctx, client, token)
+	if err != nil {
+		return apierror.Wrap(http.StatusBadRequest, apierror.CodeInvalidTokenAddress, "Could not read token decimals", err)
+	}
+
+	unitAmount, err := utils.ParseTokenAmount(product.Price.String(), decimals)
+	if err != nil {
+		return apierror.New(http.StatusUnprocessableEntity, apierror.CodeTokenNotPayable, "Price of product "+strconv.FormatInt(productId, 10)+" cannot be paid in this token: "+apierror.From(err).Message)
+	}
+
+	if new(big.Int).Mul(unitAmount, big.NewInt(quantity)).Cmp(amount) != 0 {
+		return ErrProductAmountMismatch
+	}
+
+	return nil
+}
+
+// getProductPrices reads the prices of products that have not been soft
+// deleted, keyed by product id
+func getProductPrices(productIds []int64) (map[int64]models.ProductPrice, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	ids := make([]string, 0, len(productIds))
+	for _, productId := range productIds {
+		ids = append(ids, strconv.FormatInt(productId, 10))
+	}
+
+	var products []models.ProductPrice
+	err := db.Supabase.DB.From("products").Select("id,name,imageUrl,merchantId,price").In("id", ids).IsNull("deletedAt").Execute(&products)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[int64]models.ProductPrice, len(products))
+	for _, product := range products {
+		byId[product.Id] = product
+	}
+	return byId, nil
+}
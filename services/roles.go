@@ -0,0 +1,236 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrInvalidRole        = apierror.BadRequest(apierror.CodeInvalidRole, "Unknown role")
+	ErrRoleAlreadyGranted = apierror.Conflict(apierror.CodeRoleAlreadyGranted, "Wallet already holds this role")
+	ErrRoleNotGranted     = apierror.NotFound(apierror.CodeRoleNotGranted, "Wallet does not hold this role")
+	ErrBootstrapAdminRole = apierror.Conflict(apierror.CodeBootstrapAdminRole, "Platform admins from PLATFORM_ADMIN_WALLETS cannot be revoked through the API")
+)
+
+// SystemActor is recorded in the audit log for changes the service makes itself
+const SystemActor = "system"
+
+// PlatformAdminWallets returns the wallets configured in PLATFORM_ADMIN_WALLETS
+// (comma separated). They always hold platform-admin, so the first admin can
+// grant roles before any exist in the database.
+func PlatformAdminWallets() []common.Address {
+	var wallets []common.Address
+	for _, value := range strings.Split(os.Getenv("PLATFORM_ADMIN_WALLETS"), ",") {
+		value = strings.TrimSpace(value)
+		if common.IsHexAddress(value) {
+			wallets = append(wallets, common.HexToAddress(value))
+		}
+	}
+	return wallets
+}
+
+func isBootstrapAdmin(wallet common.Address) bool {
+	for _, admin := range PlatformAdminWallets() {
+		if admin == wallet {
+			return true
+		}
+	}
+	return false
+}
+
+// ListWalletRoles returns the role rows stored for a wallet
+func ListWalletRoles(wallet common.Address) ([]models.UserRoleDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var roles []models.UserRoleDB
+	err := db.Supabase.DB.From("user_roles").Select("*").OrderBy("id", "asc").
+		Eq("walletAddress", wallet.Hex()).
+		Execute(&roles)
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// CheckPermission fails with a permission denied error unless one of the
+// wallet's roles grants permission
+func CheckPermission(wallet common.Address, permission string) error {
+	roles, err := WalletRoles(wallet)
+	if err != nil {
+		return err
+	}
+
+	if !models.RolesHavePermission(roles, permission) {
+		return apierror.New(http.StatusForbidden, apierror.CodePermissionDenied, "Missing permission "+permission).
+			WithMeta("permission", permission)
+	}
+	return nil
+}
+
+// WalletRoles returns every role a wallet holds, including platform-admin from
+// PLATFORM_ADMIN_WALLETS
+func WalletRoles(wallet common.Address) ([]string, error) {
+	var roles []string
+	if isBootstrapAdmin(wallet) {
+		roles = append(roles, models.RolePlatformAdmin)
+	}
+
+	rows, err := ListWalletRoles(wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if row.Role == models.RolePlatformAdmin && isBootstrapAdmin(wallet) {
+			continue
+		}
+		roles = append(roles, row.Role)
+	}
+
+	return roles, nil
+}
+
+// GrantRole gives a wallet a role and records the change
+func GrantRole(wallet common.Address, role, actor, reason string) (*models.UserRoleDB, error) {
+	if !models.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	existing, err := findWalletRole(wallet, role)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, ErrRoleAlreadyGranted
+	}
+
+	row := models.UserRoleDB{
+		WalletAddress: wallet.Hex(),
+		Role:          role,
+		GrantedBy:     actor,
+	}
+
+	var result []models.UserRoleDB
+	if err := db.Supabase.DB.From("user_roles").Insert(row).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if err := recordRoleAudit(models.RoleActionGrant, wallet, role, actor, reason); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return &row, nil
+	}
+
+	return &result[0], nil
+}
+
+// EnsureRole grants role unless the wallet already holds it. Failures are logged
+// rather than returned, for callers where the role is a side effect.
+func EnsureRole(wallet common.Address, role, reason string) {
+	if _, err := GrantRole(wallet, role, SystemActor, reason); err != nil && !errors.Is(err, ErrRoleAlreadyGranted) {
+		log.Printf("roles: could not grant %s to %s: %v", role, wallet.Hex(), err)
+	}
+}
+
+// RevokeRole removes a role from a wallet and records the change
+func RevokeRole(wallet common.Address, role, actor, reason string) error {
+	if !models.IsValidRole(role) {
+		return ErrInvalidRole
+	}
+
+	if role == models.RolePlatformAdmin && isBootstrapAdmin(wallet) {
+		return ErrBootstrapAdminRole
+	}
+
+	existing, err := findWalletRole(wallet, role)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrRoleNotGranted
+	}
+
+	var result []models.UserRoleDB
+	err = db.Supabase.DB.From("user_roles").Delete().
+		Eq("id", strconv.FormatInt(existing.Id, 10)).
+		Execute(&result)
+	if err != nil {
+		return err
+	}
+
+	return recordRoleAudit(models.RoleActionRevoke, wallet, role, actor, reason)
+}
+
+// ListRoleAudit returns audit entries newest first, optionally for one wallet.
+// cursor is the id of the last entry already returned, or 0 for the first page.
+func ListRoleAudit(wallet string, limit int, cursor int64) ([]models.RoleAuditEntry, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	query := db.Supabase.DB.From("role_audit_log").Select("*")
+	query.OrderBy("id", "desc").Limit(limit)
+
+	filter := query.Gt("id", "0")
+	if wallet != "" {
+		filter.Eq("walletAddress", wallet)
+	}
+	if cursor > 0 {
+		filter.Lt("id", strconv.FormatInt(cursor, 10))
+	}
+
+	var entries []models.RoleAuditEntry
+	if err := filter.Execute(&entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func findWalletRole(wallet common.Address, role string) (*models.UserRoleDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var roles []models.UserRoleDB
+	err := db.Supabase.DB.From("user_roles").Select("*").
+		Eq("walletAddress", wallet.Hex()).
+		Eq("role", role).
+		Execute(&roles)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roles) == 0 {
+		return nil, nil
+	}
+
+	return &roles[0], nil
+}
+
+func recordRoleAudit(action string, wallet common.Address, role, actor, reason string) error {
+	entry := models.RoleAuditEntry{
+		Action:        action,
+		WalletAddress: wallet.Hex(),
+		Role:          role,
+		Actor:         actor,
+		Reason:        reason,
+	}
+
+	var result []models.RoleAuditEntry
+	return db.Supabase.DB.From("role_audit_log").Insert(entry).Execute(&result)
+}
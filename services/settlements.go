@@ -0,0 +1,275 @@
+package services
+
+import (
+	"math/big"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Dbriane208/stable-market/abi"
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/Dbriane208/stable-market/networks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	ErrSettlementEventNotFound = apierror.New(http.StatusUnprocessableEntity, apierror.CodeTxEventNotFound, "Transaction does not settle this order")
+	ErrSettlementTransfers     = apierror.New(http.StatusUnprocessableEntity, apierror.CodeTxEventNotFound, "Settlement transfer to the merchant's payout wallet does not match the OrderSettled event")
+)
+
+// FeeReportQuery selects the settlements of a fee report. From and To are whole
+// UTC days; the other filters are optional.
+type FeeReportQuery struct {
+	From         time.Time
+	To           time.Time
+	GroupBy      string
+	MerchantId   string
+	Network      string
+	TokenAddress string
+}
+
+// ParseSettlement reads an order's settlement from the OrderSettled event the
+// payment processor logged for it: the merchant payout wallet, the merchant
+// net and the platform fee, whose sum is the gross. The processor's transfer of
+// the token to that wallet is only a cross-check; when it is missing or short of
+// the net, ErrSettlementTransfers is returned.
+func ParseSettlement(order *models.OrderDB, processor common.Address, receipt *types.Receipt) (*models.SettlementDB, error) {
+	contractABI, err := abi.GetPaymentProcessorABI()
+	if err != nil {
+		return nil, err
+	}
+	settledEvent := contractABI.Events[EventOrderSettled]
+	orderId := common.HexToHash(order.OrderId)
+
+	var settled *types.Log
+	for _, log := range receipt.Logs {
+		if log.Address == processor && len(log.Topics) == 4 && log.Topics[0] == settledEvent.ID && log.Topics[1] == orderId {
+			settled = log
+			break
+		}
+	}
+	if settled == nil {
+		return nil, ErrSettlementEventNotFound
+	}
+
+	var data struct {
+		Token          common.Address
+		MerchantAmount *big.Int
+		PlatformFee    *big.Int
+		Status         uint8
+	}
+	if err := contractABI.UnpackIntoInterface(&data, EventOrderSettled, settled.Data); err != nil {
+		return nil, err
+	}
+	payout := common.BytesToAddress(settled.Topics[3].Bytes())
+
+	erc20ABI, err := abi.GetERC20ABI()
+	if err != nil {
+		return nil, err
+	}
+	transferTopic := erc20ABI.Events["Transfer"].ID
+
+	transfers := make(map[common.Address]*big.Int)
+	for _, log := range receipt.Logs {
+		if log.Address != data.Token || len(log.Topics) != 3 || log.Topics[0] != transferTopic {
+			continue
+		}
+		if common.BytesToAddress(log.Topics[1].Bytes()) != processor {
+			continue
+		}
+
+		to := common.BytesToAddress(log.Topics[2].Bytes())
+		if transfers[to] == nil {
+			transfers[to] = new(big.Int)
+		}
+		transfers[to].Add(transfers[to], new(big.Int).SetBytes(log.Data))
+	}
+
+	if paid := transfers[payout]; paid == nil || paid.Cmp(data.MerchantAmount) < 0 {
+		return nil, ErrSettlementTransfers
+	}
+
+	// A single other recipient is the fee wallet; otherwise the fee stayed in
+	// the contract or was split
+	feeRecipient := ""
+	if len(transfers) == 2 {
+		for to := range transfers {
+			if to != payout {
+				feeRecipient = to.Hex()
+			}
+		}
+	}
+
+	network := order.Network
+	if network == "" {
+		network = networks.DefaultNetworkName
+	}
+
+	return &models.SettlementDB{
+		OrderId:         order.OrderId,
+		MerchantId:      order.MerchantId,
+		Network:         network,
+		TokenAddress:    data.Token.Hex(),
+		GrossAmount:     new(big.Int).Add(data.MerchantAmount, data.PlatformFee).String(),
+		PlatformFee:     data.PlatformFee.String(),
+		MerchantNet:     data.MerchantAmount.String(),
+		PayoutAddress:   payout.Hex(),
+		FeeRecipient:    feeRecipient,
+		TransactionHash: receipt.TxHash.Hex(),
+		BlockNumber:     receipt.BlockNumber.Uint64(),
+	}, nil
+}
+
+// RecordSettlement stores a settlement. Recording the same order again replaces
+// the earlier row, so a retried confirmation is safe.
+func RecordSettlement(settlement *models.SettlementDB) (*models.SettlementDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var result []models.SettlementDB
+	if err := db.Supabase.DB.From("settlements").Upsert(settlement).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return settlement, nil
+	}
+	return &result[0], nil
+}
+
+// FeeReport sums the platform fees of the settlements in the range per period,
+// per merchant and per token
+func FeeReport(query FeeReportQuery) (*models.FeeReportResponse, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	params := map[string]interface{}{
+		"p_from":  query.From.Format("2006-01-02"),
+		"p_to":    query.To.Format("2006-01-02"),
+		"p_group": query.GroupBy,
+	}
+	if query.MerchantId != "" {
+		params["p_merchant_id"] = query.MerchantId
+	}
+	if query.Network != "" {
+		params["p_network"] = query.Network
+	}
+	if query.TokenAddress != "" {
+		params["p_token"] = query.TokenAddress
+	}
+
+	var rows []models.FeeReportRow
+	if err := db.Supabase.DB.Rpc("fee_report", params).Execute(&rows); err != nil {
+		return nil, err
+	}
+
+	registry, err := tokenRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := newFeeTotals()
+	periods := make(map[string]*feeTotals)
+	merchants := make(map[string]*feeTotals)
+	var periodKeys, merchantIds []string
+	for _, row := range rows {
+		if periods[row.Period] == nil {
+			periods[row.Period] = newFeeTotals()
+			periodKeys = append(periodKeys, row.Period)
+		}
+		if merchants[row.MerchantId] == nil {
+			merchants[row.MerchantId] = newFeeTotals()
+			merchantIds = append(merchantIds, row.MerchantId)
+		}
+		tokens.add(row)
+		periods[row.Period].add(row)
+		merchants[row.MerchantId].add(row)
+	}
+	sort.Strings(merchantIds)
+
+	report := &models.FeeReportResponse{
+		GroupBy:   query.GroupBy,
+		From:      query.From.Format("2006-01-02"),
+		To:        query.To.Format("2006-01-02"),
+		Tokens:    tokens.list(registry),
+		Periods:   make([]models.FeePeriod, 0, len(periodKeys)),
+		Merchants: make([]models.MerchantFees, 0, len(merchantIds)),
+	}
+	for _, period := range periodKeys {
+		report.Periods = append(report.Periods, models.FeePeriod{Start: period, Tokens: periods[period].list(registry)})
+	}
+	for _, merchantId := range merchantIds {
+		report.Merchants = append(report.Merchants, models.MerchantFees{MerchantId: merchantId, Tokens: merchants[merchantId].list(registry)})
+	}
+
+	return report, nil
+}
+
+// feeTotals sums fee report rows per network and token, keeping the order in
+// which tokens were first seen
+type feeTotals struct {
+	keys   []string
+	totals map[string]*feeTotal
+}
+
+type feeTotal struct {
+	network     string
+	token       string
+	settlements int64
+	gross       *big.Int
+	fee         *big.Int
+	net         *big.Int
+}
+
+func newFeeTotals() *feeTotals {
+	return &feeTotals{totals: make(map[string]*feeTotal)}
+}
+
+func (f *feeTotals) add(row models.FeeReportRow) {
+	key := tokenKey(row.Network, row.TokenAddress)
+	total, ok := f.totals[key]
+	if !ok {
+		total = &feeTotal{network: row.Network, token: row.TokenAddress, gross: new(big.Int), fee: new(big.Int), net: new(big.Int)}
+		f.totals[key] = total
+		f.keys = append(f.keys, key)
+	}
+
+	total.settlements += row.Settlements
+	addAmount(total.gross, row.GrossAmount)
+	addAmount(total.fee, row.PlatformFee)
+	addAmount(total.net, row.MerchantNet)
+}
+
+func (f *feeTotals) list(registry map[string]models.TokenDB) []models.FeeTotals {
+	list := make([]models.FeeTotals, 0, len(f.keys))
+	for _, key := range f.keys {
+		total := f.totals[key]
+		totals := models.FeeTotals{
+			Network:      total.network,
+			TokenAddress: common.HexToAddress(total.token).Hex(),
+			Settlements:  total.settlements,
+			GrossAmount:  total.gross.String(),
+			PlatformFee:  total.fee.String(),
+			MerchantNet:  total.net.String(),
+		}
+		if token, ok := registry[key]; ok {
+			decimals := token.Decimals
+			totals.Symbol = token.Symbol
+			totals.Decimals = &decimals
+		}
+		list = append(list, totals)
+	}
+	return list
+}
+
+// addAmount adds a base unit amount to sum, ignoring values that do not parse
+func addAmount(sum *big.Int, amount string) {
+	if value, ok := new(big.Int).SetString(amount, 10); ok {
+		sum.Add(sum, value)
+	}
+}
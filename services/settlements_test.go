@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/Dbriane208/stable-market/abi"
+	"github.com/Dbriane208/stable-market/models"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestParseSettlement(t *testing.T) {
+	processorABI, err := abi.GetPaymentProcessorABI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	erc20ABI, err := abi.GetERC20ABI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		processor = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		token     = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		payout    = common.HexToAddress("0x3000000000000000000000000000000000000003")
+		feeWallet = common.HexToAddress("0x4000000000000000000000000000000000000004")
+		stranger  = common.HexToAddress("0x5000000000000000000000000000000000000005")
+		orderId   = common.HexToHash("0xabc1")
+		otherId   = common.HexToHash("0xabc2")
+	)
+
+	settled := func(from common.Address, id common.Hash, to common.Address, net, fee int64) *types.Log {
+		event := processorABI.Events[EventOrderSettled]
+		data, err := event.Inputs.NonIndexed().Pack(token, big.NewInt(net), big.NewInt(fee), uint8(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &types.Log{
+			Address: from,
+			Topics:  []common.Hash{event.ID, id, common.HexToHash("0xbeef"), common.BytesToHash(to.Bytes())},
+			Data:    data,
+		}
+	}
+	transfer := func(from, to common.Address, amount int64) *types.Log {
+		return &types.Log{
+			Address: token,
+			Topics: []common.Hash{
+				erc20ABI.Events["Transfer"].ID,
+				common.BytesToHash(from.Bytes()),
+				common.BytesToHash(to.Bytes()),
+			},
+			Data: common.LeftPadBytes(big.NewInt(amount).Bytes(), 32),
+		}
+	}
+
+	tests := []struct {
+		name         string
+		logs         []*types.Log
+		wantErr      error
+		wantGross    string
+		wantFee      string
+		wantNet      string
+		feeRecipient string
+	}{
+		{
+			name:         "net and fee",
+			logs:         []*types.Log{settled(processor, orderId, payout, 970, 30), transfer(processor, payout, 970), transfer(processor, feeWallet, 30)},
+			wantGross:    "1000",
+			wantFee:      "30",
+			wantNet:      "970",
+			feeRecipient: feeWallet.Hex(),
+		},
+		{
+			name:      "fee kept by the processor",
+			logs:      []*types.Log{settled(processor, orderId, payout, 990, 10), transfer(processor, payout, 990)},
+			wantGross: "1000",
+			wantFee:   "10",
+			wantNet:   "990",
+		},
+		{
+			name:      "split fee has no single recipient",
+			logs:      []*types.Log{settled(processor, orderId, payout, 970, 30), transfer(processor, payout, 970), transfer(processor, feeWallet, 20), transfer(processor, stranger, 10)},
+			wantGross: "1000",
+			wantFee:   "30",
+			wantNet:   "970",
+		},
+		{
+			name:         "split payout transfers are summed",
+			logs:         []*types.Log{settled(processor, orderId, payout, 970, 30), transfer(processor, payout, 500), transfer(processor, payout, 470), transfer(processor, feeWallet, 30)},
+			wantGross:    "1000",
+			wantFee:      "30",
+			wantNet:      "970",
+			feeRecipient: feeWallet.Hex(),
+		},
+		{
+			name:         "transfers from other senders are ignored",
+			logs:         []*types.Log{settled(processor, orderId, payout, 970, 30), transfer(processor, payout, 970), transfer(processor, feeWallet, 30), transfer(stranger, payout, 500)},
+			wantGross:    "1000",
+			wantFee:      "30",
+			wantNet:      "970",
+			feeRecipient: feeWallet.Hex(),
+		},
+		{
+			name:    "no settle event",
+			logs:    []*types.Log{transfer(processor, payout, 1000)},
+			wantErr: ErrSettlementEventNotFound,
+		},
+		{
+			name:    "settle event for another order",
+			logs:    []*types.Log{settled(processor, otherId, payout, 970, 30), transfer(processor, payout, 970)},
+			wantErr: ErrSettlementEventNotFound,
+		},
+		{
+			name:    "settle event from another contract",
+			logs:    []*types.Log{settled(stranger, orderId, payout, 970, 30), transfer(processor, payout, 970)},
+			wantErr: ErrSettlementEventNotFound,
+		},
+		{
+			name:    "no transfer to the event's payout wallet",
+			logs:    []*types.Log{settled(processor, orderId, payout, 970, 30), transfer(processor, stranger, 970), transfer(processor, feeWallet, 30)},
+			wantErr: ErrSettlementTransfers,
+		},
+		{
+			name:    "transfer short of the net",
+			logs:    []*types.Log{settled(processor, orderId, payout, 970, 30), transfer(processor, payout, 1), transfer(processor, feeWallet, 30)},
+			wantErr: ErrSettlementTransfers,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &models.OrderDB{
+				OrderId:      orderId.Hex(),
+				MerchantId:   "merchant",
+				TokenAddress: token.Hex(),
+				Amount:       "1000",
+				Network:      "base-sepolia",
+			}
+			receipt := &types.Receipt{
+				Logs:        tt.logs,
+				TxHash:      common.HexToHash("0xfeed"),
+				BlockNumber: big.NewInt(42),
+			}
+
+			got, err := ParseSettlement(order, processor, receipt)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.GrossAmount != tt.wantGross || got.PlatformFee != tt.wantFee || got.MerchantNet != tt.wantNet {
+				t.Errorf("gross, fee, net = %s, %s, %s, want %s, %s, %s", got.GrossAmount, got.PlatformFee, got.MerchantNet, tt.wantGross, tt.wantFee, tt.wantNet)
+			}
+			if got.FeeRecipient != tt.feeRecipient {
+				t.Errorf("FeeRecipient = %q, want %q", got.FeeRecipient, tt.feeRecipient)
+			}
+			if got.PayoutAddress != payout.Hex() || got.TokenAddress != token.Hex() || got.TransactionHash != receipt.TxHash.Hex() || got.BlockNumber != 42 {
+				t.Errorf("settlement = %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dbriane208/stable-market/abi"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+	ethereum "github.com/ethereum/go-ethereum"
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	decimalsMu    sync.RWMutex
+	decimalsCache = map[common.Address]uint8{}
+)
+
+// TokenDecimals reads an ERC20 token's decimals, caching the result per token
+func TokenDecimals(ctx context.Context, client *ethclient.Client, token common.Address) (uint8, error) {
+	decimalsMu.RLock()
+	decimals, cached := decimalsCache[token]
+	decimalsMu.RUnlock()
+	if cached {
+		return decimals, nil
+	}
+
+	erc20ABI, err := abi.GetERC20ABI()
+	if err != nil {
+		return 0, err
+	}
+
+	callData, err := erc20ABI.Pack("decimals")
+	if err != nil {
+		return 0, err
+	}
+
+	output, err := client.CallContract(ctx, ethereum.CallMsg{To: &token, Data: callData}, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	values, err := erc20ABI.Unpack("decimals", output)
+	if err != nil {
+		return 0, err
+	}
+
+	decimals, ok := values[0].(uint8)
+	if !ok {
+		return 0, errors.New("unexpected decimals() return type")
+	}
+
+	decimalsMu.Lock()
+	decimalsCache[token] = decimals
+	decimalsMu.Unlock()
+
+	return decimals, nil
+}
+
+// ListEnabledTokens returns the enabled tokens of every network
+func ListEnabledTokens() ([]models.TokenDB, error) {
+	return listTokens(true)
+}
+
+// ListTokens returns every token in the registry, including disabled ones
+func ListTokens() ([]models.TokenDB, error) {
+	return listTokens(false)
+}
+
+// tokenRegistry indexes every token by tokenKey
+func tokenRegistry() (map[string]models.TokenDB, error) {
+	tokens, err := ListTokens()
+	if err != nil {
+		return nil, err
+	}
+
+	registry := make(map[string]models.TokenDB, len(tokens))
+	for _, token := range tokens {
+		registry[tokenKey(token.Network, token.Address)] = token
+	}
+	return registry, nil
+}
+
+// tokenKey identifies a token on a network regardless of address case
+func tokenKey(network, address string) string {
+	return network + "/" + strings.ToLower(address)
+}
+
+func listTokens(enabledOnly bool) ([]models.TokenDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	query := db.Supabase.DB.From("tokens").Select("*")
+	if enabledOnly {
+		query.Eq("enabled", "true")
+	}
+
+	var tokens []models.TokenDB
+	if err := query.Execute(&tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// SetTokenEnabled records a token's support on a network in the token registry,
+// reading its symbol and decimals from the chain the first time it is seen
+func SetTokenEnabled(ctx context.Context, client *ethclient.Client, network string, token common.Address, enabled bool) error {
+	if db.Supabase == nil {
+		return ErrDatabaseNotInitialized
+	}
+
+	erc20ABI, err := abi.GetERC20ABI()
+	if err != nil {
+		return err
+	}
+	decimalsCall, err := erc20ABI.Pack("decimals")
+	if err != nil {
+		return err
+	}
+	symbolCall, err := erc20ABI.Pack("symbol")
+	if err != nil {
+		return err
+	}
+
+	results, err := Multicall(ctx, client, nil, []Call{
+		{Target: token, CallData: decimalsCall},
+		{Target: token, CallData: symbolCall},
+	})
+	if err != nil {
+		return err
+	}
+
+	value, err := unpackResult(erc20ABI, "decimals", results[0])
+	if err != nil {
+		return err
+	}
+	decimals, ok := value.(uint8)
+	if !ok {
+		return errors.New("unexpected decimals() return type")
+	}
+
+	// Some tokens do not implement symbol(); the address stands in for it
+	symbol := token.Hex()
+	if value, err := unpackResult(erc20ABI, "symbol", results[1]); err == nil {
+		if s, ok := value.(string); ok && s != "" {
+			symbol = s
+		}
+	}
+
+	row := map[string]interface{}{
+		"network":   network,
+		"address":   token.Hex(),
+		"symbol":    symbol,
+		"decimals":  decimals,
+		"enabled":   enabled,
+		"updatedAt": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var result []models.TokenDB
+	return db.Supabase.DB.From("tokens").Upsert(row).Execute(&result)
+}
+
+// unpackResult decodes the single return value of a multicall result
+func unpackResult(contractABI gethabi.ABI, method string, result CallResult) (interface{}, error) {
+	if !result.Success {
+		return nil, errors.New(method + "() reverted")
+	}
+
+	values, err := contractABI.Unpack(method, result.ReturnData)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, errors.New(method + "() returned nothing")
+	}
+
+	return values[0], nil
+}
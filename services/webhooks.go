@@ -0,0 +1,645 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/Dbriane208/stable-market/db"
+	"github.com/Dbriane208/stable-market/models"
+)
+
+var (
+	ErrWebhookNotFound         = apierror.NotFound(apierror.CodeWebhookNotFound, "Webhook not found")
+	ErrWebhookDeliveryNotFound = apierror.NotFound(apierror.CodeWebhookDeliveryNotFound, "Webhook delivery not found")
+	ErrInvalidWebhookURL       = apierror.InvalidField(apierror.CodeInvalidWebhookURL, "url", "Webhook url must be an absolute https URL on a public host")
+	ErrInvalidWebhookEvent     = apierror.BadRequest(apierror.CodeInvalidWebhookEvent, "Unknown webhook event type")
+)
+
+// Headers sent with every webhook delivery
+const (
+	WebhookSignatureHeader = "X-StableMarket-Signature"
+	WebhookTimestampHeader = "X-StableMarket-Timestamp"
+	WebhookEventHeader     = "X-StableMarket-Event"
+	WebhookDeliveryHeader  = "X-StableMarket-Delivery"
+)
+
+const (
+	// DefaultWebhookMaxAttempts is how many times a delivery is tried before it is marked failed
+	DefaultWebhookMaxAttempts = 8
+
+	// WebhookSecretGracePeriod is how long the previous secret keeps signing after a rotation
+	WebhookSecretGracePeriod = 24 * time.Hour
+
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+
+	// A claimed delivery that is not finished within the lease is picked up again
+	webhookDeliveryLease = 2 * time.Minute
+)
+
+// errWebhookAddress is returned when an endpoint resolves to an address webhooks
+// may not reach
+var errWebhookAddress = errors.New("endpoint resolves to a non-public address")
+
+// webhookHTTPClient only dials public addresses and does not follow redirects, so
+// an endpoint cannot point deliveries at the internal network
+var webhookHTTPClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext:         dialWebhook,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// webhookAllowInsecure reports whether plain http and private addresses are
+// allowed for endpoints (WEBHOOK_ALLOW_INSECURE=true), for local development
+func webhookAllowInsecure() bool {
+	return os.Getenv("WEBHOOK_ALLOW_INSECURE") == "true"
+}
+
+// webhookAddressAllowed reports whether deliveries may be sent to ip
+func webhookAddressAllowed(ip net.IP) bool {
+	if webhookAllowInsecure() {
+		return true
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// dialWebhook resolves the endpoint host itself and dials the first allowed
+// address. Checking at dial time also covers hosts that resolve differently
+// from when the endpoint was registered.
+func dialWebhook(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addrs) == 0 {
+		return nil, errWebhookAddress
+	}
+	for _, addr := range addrs {
+		if !webhookAddressAllowed(addr.IP) {
+			return nil, errWebhookAddress
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(addrs[0].IP.String(), port))
+}
+
+// WebhookMaxAttempts returns the configured attempt limit (WEBHOOK_MAX_ATTEMPTS)
+func WebhookMaxAttempts() int {
+	if value := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); value != "" {
+		if attempts, err := strconv.Atoi(value); err == nil && attempts > 0 {
+			return attempts
+		}
+	}
+	return DefaultWebhookMaxAttempts
+}
+
+// WebhookBackoff returns the wait before the next try after the given number of attempts
+func WebhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// NewWebhookSecret returns a random signing secret
+func NewWebhookSecret() string {
+	return "whsec_" + NewReference() + NewReference()
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 of "{timestamp}.{body}"
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookSignature builds the signature header value. While a rotated secret is
+// in its grace period the body is signed with both secrets.
+func WebhookSignature(endpoint models.WebhookEndpointDB, timestamp int64, body []byte) string {
+	signature := fmt.Sprintf("t=%d,v1=%s", timestamp, SignWebhookPayload(endpoint.Secret, timestamp, body))
+
+	if endpoint.PreviousSecret != nil && endpoint.PreviousSecretExpiresAt != nil {
+		expiresAt, err := time.Parse(time.RFC3339, *endpoint.PreviousSecretExpiresAt)
+		if err == nil && time.Now().Before(expiresAt) {
+			signature += ",v1=" + SignWebhookPayload(*endpoint.PreviousSecret, timestamp, body)
+		}
+	}
+
+	return signature
+}
+
+// ValidateWebhookURL checks that raw is an absolute https URL whose host does not
+// resolve to a loopback, private, link-local or unspecified address. Plain http
+// and private hosts are only accepted with WEBHOOK_ALLOW_INSECURE.
+func ValidateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Hostname() == "" || parsed.User != nil {
+		return ErrInvalidWebhookURL
+	}
+
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && webhookAllowInsecure()) {
+		return ErrInvalidWebhookURL
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(context.Background(), parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return ErrInvalidWebhookURL
+	}
+
+	for _, addr := range addrs {
+		if !webhookAddressAllowed(addr.IP) {
+			return ErrInvalidWebhookURL
+		}
+	}
+
+	return nil
+}
+
+// ValidateWebhookEvents checks event names; an empty list subscribes to every event
+func ValidateWebhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return append([]string{}, models.WebhookEventTypes...), nil
+	}
+
+	known := make(map[string]bool, len(models.WebhookEventTypes))
+	for _, event := range models.WebhookEventTypes {
+		known[event] = true
+	}
+
+	for _, event := range events {
+		if !known[event] {
+			return nil, ErrInvalidWebhookEvent.WithDetails(apierror.FieldError{Field: "events", Message: "unknown event type " + event})
+		}
+	}
+
+	return events, nil
+}
+
+// CreateWebhookEndpoint registers an endpoint with a fresh signing secret
+func CreateWebhookEndpoint(merchantId, endpointURL string, events []string) (*models.WebhookEndpointDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	endpoint := models.WebhookEndpointDB{
+		MerchantId: merchantId,
+		URL:        endpointURL,
+		Events:     events,
+		Secret:     NewWebhookSecret(),
+		Active:     true,
+	}
+
+	var result []models.WebhookEndpointDB
+	if err := db.Supabase.DB.From("webhook_endpoints").Insert(endpoint).Execute(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return &endpoint, nil
+	}
+
+	return &result[0], nil
+}
+
+// ListWebhookEndpoints returns a merchant's endpoints
+func ListWebhookEndpoints(merchantId string) ([]models.WebhookEndpointDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var endpoints []models.WebhookEndpointDB
+	err := db.Supabase.DB.From("webhook_endpoints").Select("*").OrderBy("id", "asc").Eq("merchantId", merchantId).Execute(&endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpoints, nil
+}
+
+// GetWebhookEndpoint fetches one of a merchant's endpoints
+func GetWebhookEndpoint(merchantId string, endpointId int64) (*models.WebhookEndpointDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var endpoints []models.WebhookEndpointDB
+	err := db.Supabase.DB.From("webhook_endpoints").Select("*").
+		Eq("id", strconv.FormatInt(endpointId, 10)).
+		Eq("merchantId", merchantId).
+		Execute(&endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(endpoints) == 0 {
+		return nil, ErrWebhookNotFound
+	}
+
+	return &endpoints[0], nil
+}
+
+// UpdateWebhookEndpoint applies changes to one of a merchant's endpoints
+func UpdateWebhookEndpoint(merchantId string, endpointId int64, updates map[string]interface{}) (*models.WebhookEndpointDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	updates["updatedAt"] = time.Now().UTC().Format(time.RFC3339)
+
+	var result []models.WebhookEndpointDB
+	err := db.Supabase.DB.From("webhook_endpoints").Update(updates).
+		Eq("id", strconv.FormatInt(endpointId, 10)).
+		Eq("merchantId", merchantId).
+		Execute(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, ErrWebhookNotFound
+	}
+
+	return &result[0], nil
+}
+
+// DeleteWebhookEndpoint removes an endpoint and its delivery log
+func DeleteWebhookEndpoint(merchantId string, endpointId int64) error {
+	if _, err := GetWebhookEndpoint(merchantId, endpointId); err != nil {
+		return err
+	}
+
+	var result []models.WebhookEndpointDB
+	return db.Supabase.DB.From("webhook_endpoints").Delete().
+		Eq("id", strconv.FormatInt(endpointId, 10)).
+		Eq("merchantId", merchantId).
+		Execute(&result)
+}
+
+// RotateWebhookSecret issues a new secret. The old one keeps signing deliveries
+// for WebhookSecretGracePeriod so receivers can switch over without dropping events.
+func RotateWebhookSecret(merchantId string, endpointId int64) (*models.WebhookEndpointDB, error) {
+	endpoint, err := GetWebhookEndpoint(merchantId, endpointId)
+	if err != nil {
+		return nil, err
+	}
+
+	return UpdateWebhookEndpoint(merchantId, endpointId, map[string]interface{}{
+		"secret":                  NewWebhookSecret(),
+		"previousSecret":          endpoint.Secret,
+		"previousSecretExpiresAt": time.Now().Add(WebhookSecretGracePeriod).UTC().Format(time.RFC3339),
+	})
+}
+
+// enqueueOrderWebhooks queues the event for every active endpoint of the order's
+// merchant subscribed to it, and makes a first delivery attempt in the background
+func enqueueOrderWebhooks(order models.OrderDB, event models.OrderEvent) error {
+	if db.Supabase == nil {
+		return ErrDatabaseNotInitialized
+	}
+
+	eventType := "order." + event.ToStatus
+
+	var endpoints []models.WebhookEndpointDB
+	err := db.Supabase.DB.From("webhook_endpoints").Select("*").
+		Eq("merchantId", order.MerchantId).
+		Eq("active", "true").
+		Cs("events", []string{eventType}).
+		Execute(&endpoints)
+	if err != nil {
+		return err
+	}
+
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	payload := models.WebhookPayload{
+		Id:        "evt_" + NewReference(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Data: models.WebhookPayloadData{
+			Order: order,
+			Event: event,
+		},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDeliveryDB, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		deliveries = append(deliveries, models.WebhookDeliveryDB{
+			EndpointId: endpoint.Id,
+			MerchantId: order.MerchantId,
+			EventId:    payload.Id,
+			EventType:  eventType,
+			OrderId:    order.OrderId,
+			Payload:    body,
+			Status:     models.WebhookDeliveryPending,
+		})
+	}
+
+	var result []models.WebhookDeliveryDB
+	if err := db.Supabase.DB.From("webhook_deliveries").Insert(deliveries).Execute(&result); err != nil {
+		return err
+	}
+
+	for _, delivery := range result {
+		go func(delivery models.WebhookDeliveryDB) {
+			if _, err := attemptWebhookDelivery(delivery); err != nil {
+				log.Printf("webhooks: delivery %d: %v", delivery.Id, err)
+			}
+		}(delivery)
+	}
+
+	return nil
+}
+
+// ProcessDueWebhookDeliveries retries deliveries whose backoff has elapsed and
+// returns how many were attempted
+func ProcessDueWebhookDeliveries(limit int) (int, error) {
+	if db.Supabase == nil {
+		return 0, ErrDatabaseNotInitialized
+	}
+
+	query := db.Supabase.DB.From("webhook_deliveries").Select("*")
+	query.OrderBy("nextAttemptAt", "asc").Limit(limit)
+
+	var deliveries []models.WebhookDeliveryDB
+	err := query.
+		In("status", []string{models.WebhookDeliveryPending, models.WebhookDeliveryDelivering}).
+		Filter("nextAttemptAt", "lte", time.Now().UTC().Format(time.RFC3339)).
+		Execute(&deliveries)
+	if err != nil {
+		return 0, err
+	}
+
+	attempted := 0
+	for _, delivery := range deliveries {
+		ok, err := attemptWebhookDelivery(delivery)
+		if err != nil {
+			log.Printf("webhooks: delivery %d: %v", delivery.Id, err)
+			continue
+		}
+		if ok {
+			attempted++
+		}
+	}
+
+	return attempted, nil
+}
+
+// GetWebhookDelivery fetches a delivery belonging to one of a merchant's endpoints
+func GetWebhookDelivery(merchantId string, endpointId, deliveryId int64) (*models.WebhookDeliveryDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var deliveries []models.WebhookDeliveryDB
+	err := db.Supabase.DB.From("webhook_deliveries").Select("*").
+		Eq("id", strconv.FormatInt(deliveryId, 10)).
+		Eq("endpointId", strconv.FormatInt(endpointId, 10)).
+		Eq("merchantId", merchantId).
+		Execute(&deliveries)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(deliveries) == 0 {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	return &deliveries[0], nil
+}
+
+// ListWebhookDeliveries returns an endpoint's deliveries, newest first, with ids below cursor
+func ListWebhookDeliveries(endpointId int64, limit int, cursor int64) ([]models.WebhookDeliveryDB, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	query := db.Supabase.DB.From("webhook_deliveries").Select("*")
+	query.OrderBy("id", "desc").Limit(limit)
+
+	filter := query.Eq("endpointId", strconv.FormatInt(endpointId, 10))
+	if cursor > 0 {
+		filter.Lt("id", strconv.FormatInt(cursor, 10))
+	}
+
+	var deliveries []models.WebhookDeliveryDB
+	if err := filter.Execute(&deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ListWebhookDeliveryAttempts returns the HTTP attempts made for a delivery
+func ListWebhookDeliveryAttempts(deliveryId int64) ([]models.WebhookDeliveryAttempt, error) {
+	if db.Supabase == nil {
+		return nil, ErrDatabaseNotInitialized
+	}
+
+	var attempts []models.WebhookDeliveryAttempt
+	err := db.Supabase.DB.From("webhook_delivery_attempts").Select("*").OrderBy("id", "asc").
+		Eq("deliveryId", strconv.FormatInt(deliveryId, 10)).
+		Execute(&attempts)
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// RedeliverWebhook sends a delivery again right away, whatever its status, and
+// returns the updated delivery
+func RedeliverWebhook(merchantId string, endpointId, deliveryId int64) (*models.WebhookDeliveryDB, error) {
+	delivery, err := GetWebhookDelivery(merchantId, endpointId, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Put the delivery back in the queue so the attempt below can claim it
+	var result []models.WebhookDeliveryDB
+	err = db.Supabase.DB.From("webhook_deliveries").Update(map[string]interface{}{
+		"status":        models.WebhookDeliveryPending,
+		"nextAttemptAt": time.Now().UTC().Format(time.RFC3339),
+	}).Eq("id", strconv.FormatInt(deliveryId, 10)).Execute(&result)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) > 0 {
+		delivery = &result[0]
+	}
+
+	if _, err := attemptWebhookDelivery(*delivery); err != nil {
+		return nil, err
+	}
+
+	return GetWebhookDelivery(merchantId, endpointId, deliveryId)
+}
+
+// attemptWebhookDelivery claims a delivery, posts it and records the outcome. It
+// returns false without error when another worker already claimed the delivery.
+func attemptWebhookDelivery(delivery models.WebhookDeliveryDB) (bool, error) {
+	var claimed []models.WebhookDeliveryDB
+	err := db.Supabase.DB.From("webhook_deliveries").Update(map[string]interface{}{
+		"status":        models.WebhookDeliveryDelivering,
+		"nextAttemptAt": time.Now().Add(webhookDeliveryLease).UTC().Format(time.RFC3339),
+	}).
+		Eq("id", strconv.FormatInt(delivery.Id, 10)).
+		Eq("attempts", strconv.Itoa(delivery.Attempts)).
+		In("status", []string{models.WebhookDeliveryPending, models.WebhookDeliveryDelivering}).
+		Execute(&claimed)
+	if err != nil {
+		return false, err
+	}
+
+	if len(claimed) == 0 {
+		return false, nil
+	}
+
+	attempt := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts": attempt,
+	}
+
+	var statusCode int
+	var sendErr error
+	started := time.Now()
+
+	var endpoints []models.WebhookEndpointDB
+	err = db.Supabase.DB.From("webhook_endpoints").Select("*").Eq("id", strconv.FormatInt(delivery.EndpointId, 10)).Execute(&endpoints)
+	switch {
+	case err != nil:
+		sendErr = err
+	case len(endpoints) == 0 || !endpoints[0].Active:
+		sendErr = errors.New("endpoint is disabled")
+	default:
+		statusCode, sendErr = postWebhook(endpoints[0], delivery)
+	}
+
+	duration := time.Since(started).Milliseconds()
+
+	record := models.WebhookDeliveryAttempt{
+		DeliveryId: delivery.Id,
+		Attempt:    attempt,
+		DurationMs: duration,
+	}
+	if statusCode != 0 {
+		record.StatusCode = &statusCode
+		updates["lastStatusCode"] = statusCode
+	}
+
+	now := time.Now().UTC()
+	if sendErr == nil {
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["deliveredAt"] = now.Format(time.RFC3339)
+		updates["lastError"] = nil
+	} else {
+		message := sendErr.Error()
+		record.Error = &message
+		updates["lastError"] = message
+
+		if attempt >= WebhookMaxAttempts() {
+			updates["status"] = models.WebhookDeliveryFailed
+			log.Printf("webhooks: delivery %d (%s) to endpoint %d failed after %d attempts: %s",
+				delivery.Id, delivery.EventType, delivery.EndpointId, attempt, message)
+		} else {
+			backoff := WebhookBackoff(attempt)
+			updates["status"] = models.WebhookDeliveryPending
+			updates["nextAttemptAt"] = now.Add(backoff).Format(time.RFC3339)
+			log.Printf("webhooks: delivery %d (%s) to endpoint %d attempt %d failed, retrying in %s: %s",
+				delivery.Id, delivery.EventType, delivery.EndpointId, attempt, backoff, message)
+		}
+	}
+
+	var attempts []models.WebhookDeliveryAttempt
+	if err := db.Supabase.DB.From("webhook_delivery_attempts").Insert(record).Execute(&attempts); err != nil {
+		log.Printf("webhooks: could not log attempt %d of delivery %d: %v", attempt, delivery.Id, err)
+	}
+
+	var result []models.WebhookDeliveryDB
+	if err := db.Supabase.DB.From("webhook_deliveries").Update(updates).Eq("id", strconv.FormatInt(delivery.Id, 10)).Execute(&result); err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// postWebhook sends the signed payload; any 2xx response counts as delivered
+func postWebhook(endpoint models.WebhookEndpointDB, delivery models.WebhookDeliveryDB) (int, error) {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "StableMarket-Webhooks/1.0")
+	req.Header.Set(WebhookSignatureHeader, WebhookSignature(endpoint, timestamp, delivery.Payload))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.Id, 10))
+
+	resp, err := webhookHTTPClient.Do(req)
+	if err != nil {
+		// Transport errors can name internal addresses, so the merchant only
+		// sees that the request failed
+		log.Printf("webhooks: delivery %d to endpoint %d: %v", delivery.Id, endpoint.Id, err)
+		if errors.Is(err, errWebhookAddress) {
+			return 0, errWebhookAddress
+		}
+		return 0, errors.New("request failed")
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	// Only the status code is kept; the response body is the endpoint's and may
+	// not be meant for the merchant's dashboard
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
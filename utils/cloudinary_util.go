@@ -5,10 +5,12 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"io"
 	"net/http"
 	"os"
 
+	"github.com/Dbriane208/stable-market/apierror"
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/admin"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
@@ -45,47 +47,49 @@ func InitCloudinary() error {
 	return nil
 }
 
+// Cloudinary folders under StableMarket/ that images are stored in
+const (
+	ProductImageFolder    = "products"
+	DisputeEvidenceFolder = "disputes"
+)
+
+// UploadImageToCloudinary stores the JPEG or PNG in the request's "file" form
+// field as a product image
 func UploadImageToCloudinary(ctx *gin.Context) (*CloudinaryUploadResult, error) {
+	return UploadImageToFolder(ctx, ProductImageFolder)
+}
+
+// UploadImageToFolder stores the JPEG or PNG in the request's "file" form field
+// in folder. Images are named after their content hash, so re-uploads of the
+// same image return the existing asset.
+func UploadImageToFolder(ctx *gin.Context, folder string) (*CloudinaryUploadResult, error) {
 	if cld == nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Cloudinary client not initialized",
-		})
-		return nil, nil
+		return nil, apierror.New(http.StatusServiceUnavailable, apierror.CodeServiceUnavailable, "Cloudinary client not initialized")
 	}
 
 	file, header, err := ctx.Request.FormFile("file")
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "No file uploaded",
-		})
-		return nil, nil
+		return nil, apierror.InvalidField(apierror.CodeInvalidFile, "file", "No file uploaded")
 	}
 	defer file.Close()
 
 	if header.Size > MaxFileSize {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "File size must be less than 5MB",
-		})
-		return nil, nil
+		return nil, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodeFileTooLarge, "File size must be less than 5MB").
+			WithDetails(apierror.FieldError{Field: "file", Message: "file size must be less than 5MB"})
 	}
 
 	contentType := header.Header.Get("Content-Type")
 	if contentType != "image/jpeg" && contentType != "image/png" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "File type must be JPEG or PNG",
-		})
-		return nil, nil
+		return nil, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedFileType, "File type must be JPEG or PNG").
+			WithDetails(apierror.FieldError{Field: "file", Message: "file type must be JPEG or PNG"})
 	}
 
 	fileHash, fileData, err := computeFileHash(file)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to compute file hash: " + err.Error(),
-		})
-		return nil, nil
+		return nil, apierror.Wrap(http.StatusBadRequest, apierror.CodeInvalidFile, "Could not read the uploaded file", err)
 	}
 
-	publicId := "StableMarket/products/" + fileHash[:16]
+	publicId := "StableMarket/" + folder + "/" + fileHash[:16]
 
 	bgCtx := context.Background()
 
@@ -112,10 +116,12 @@ func UploadImageToCloudinary(ctx *gin.Context) (*CloudinaryUploadResult, error)
 	})
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Error uploading file: " + err.Error(),
-		})
-		return nil, nil
+		return nil, apierror.Wrap(http.StatusBadGateway, apierror.CodeUpstreamFailed, "Image upload failed", err)
+	}
+
+	// The upload API reports some failures in the result rather than as an error
+	if uploadResult.Error.Message != "" {
+		return nil, apierror.Wrap(http.StatusBadGateway, apierror.CodeUpstreamFailed, "Image upload failed", errors.New(uploadResult.Error.Message))
 	}
 
 	return &CloudinaryUploadResult{
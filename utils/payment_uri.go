@@ -0,0 +1,12 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// ERC20ApproveURI builds an EIP-681 URI asking a wallet to approve spender for
+// amount base units of token on the given chain
+func ERC20ApproveURI(token, spender string, chainId int64, amount *big.Int) string {
+	return fmt.Sprintf("ethereum:%s@%d/approve?address=%s&uint256=%s", token, chainId, spender, amount.String())
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dbriane208/stable-market/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+var errInvalidCursor = apierror.InvalidField(apierror.CodeInvalidPagination, "cursor", "cursor is invalid")
+
+// Pagination holds the parsed limit/cursor/sort query parameters for list endpoints.
+// Lists are keyset paginated on the row id, which follows creation order.
+type Pagination struct {
+	Limit     int
+	Cursor    int64
+	Ascending bool
+}
+
+// Direction returns the PostgREST order direction for the page
+func (p Pagination) Direction() string {
+	if p.Ascending {
+		return "asc"
+	}
+	return "desc"
+}
+
+// ParsePagination reads limit, cursor and sort from the query string.
+// sort accepts "createdAt" (oldest first) or "-createdAt" (newest first, the default).
+func ParsePagination(ctx *gin.Context) (Pagination, error) {
+	page := Pagination{Limit: DefaultPageLimit}
+
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return page, apierror.InvalidField(apierror.CodeInvalidPagination, "limit", "limit must be a positive integer")
+		}
+		if limit > MaxPageLimit {
+			limit = MaxPageLimit
+		}
+		page.Limit = limit
+	}
+
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		id, err := DecodeCursor(cursor)
+		if err != nil {
+			return page, err
+		}
+		page.Cursor = id
+	}
+
+	switch ctx.DefaultQuery("sort", "-createdAt") {
+	case "createdAt":
+		page.Ascending = true
+	case "-createdAt":
+		page.Ascending = false
+	default:
+		return page, apierror.InvalidField(apierror.CodeInvalidPagination, "sort", "sort must be createdAt or -createdAt")
+	}
+
+	return page, nil
+}
+
+// EncodeCursor turns a row id into an opaque cursor string
+func EncodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+// DecodeCursor reverses EncodeCursor
+func DecodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errInvalidCursor
+	}
+
+	return id, nil
+}
+
+// IsHexId reports whether value is a 32 byte hex id, with or without the 0x
+// prefix. Ids from requests are checked with it before they reach a PostgREST
+// filter, which the client does not escape.
+func IsHexId(value string) bool {
+	raw, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	return err == nil && len(raw) == 32
+}
+
+// ParseDateRange reads the optional from/to query parameters. Both accept RFC3339
+// timestamps or plain dates; a plain "to" date includes the whole day.
+func ParseDateRange(ctx *gin.Context) (from, to *time.Time, err error) {
+	if v := ctx.Query("from"); v != "" {
+		t, _, err := parseTimeParam(v)
+		if err != nil {
+			return nil, nil, apierror.InvalidField(apierror.CodeInvalidDateRange, "from", "from must be an RFC3339 timestamp or YYYY-MM-DD date")
+		}
+		from = &t
+	}
+
+	if v := ctx.Query("to"); v != "" {
+		t, dateOnly, err := parseTimeParam(v)
+		if err != nil {
+			return nil, nil, apierror.InvalidField(apierror.CodeInvalidDateRange, "to", "to must be an RFC3339 timestamp or YYYY-MM-DD date")
+		}
+		if dateOnly {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		to = &t
+	}
+
+	if from != nil && to != nil && to.Before(*from) {
+		return nil, nil, apierror.InvalidField(apierror.CodeInvalidDateRange, "to", "to must not be before from")
+	}
+
+	return from, to, nil
+}
+
+func parseTimeParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), false, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return t.UTC(), true, nil
+}
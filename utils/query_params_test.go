@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, id := range []int64{1, 42, 1 << 40} {
+		got, err := DecodeCursor(EncodeCursor(id))
+		if err != nil || got != id {
+			t.Errorf("DecodeCursor(EncodeCursor(%d)) = %d, %v", id, got, err)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "!!"},
+		{"padded", base64.URLEncoding.EncodeToString([]byte("42"))},
+		{"not a number", encode("abc")},
+		{"zero", encode("0")},
+		{"negative", encode("-5")},
+	}
+
+	for _, tt := range tests {
+		if _, err := DecodeCursor(tt.cursor); err == nil {
+			t.Errorf("%s: DecodeCursor(%q) succeeded, want an error", tt.name, tt.cursor)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/Dbriane208/stable-market/apierror"
+)
+
+// ParseTokenAmount converts a decimal string such as "12.5" into token base units
+func ParseTokenAmount(value string, decimals uint8) (*big.Int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.HasPrefix(value, "-") {
+		return nil, apierror.BadRequest(apierror.CodeInvalidAmount, "amount must be a non-negative decimal number")
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if len(fraction) > int(decimals) {
+		// Anything beyond the token's precision must be zero
+		if strings.Trim(fraction[decimals:], "0") != "" {
+			return nil, apierror.BadRequest(apierror.CodeInvalidAmount, "amount has more decimal places than the token supports")
+		}
+		fraction = fraction[:decimals]
+	}
+	fraction += strings.Repeat("0", int(decimals)-len(fraction))
+
+	amount, ok := new(big.Int).SetString(whole+fraction, 10)
+	if !ok {
+		return nil, apierror.BadRequest(apierror.CodeInvalidAmount, "amount must be a non-negative decimal number")
+	}
+
+	return amount, nil
+}
+
+// MultiplyDecimal multiplies a decimal string such as "12.5" by quantity without
+// losing precision, e.g. "12.5" times 3 is "37.5"
+func MultiplyDecimal(value string, quantity int64) (string, error) {
+	scale := decimalScale(value)
+	units, err := ParseTokenAmount(value, scale)
+	if err != nil {
+		return "", err
+	}
+
+	return FormatTokenAmount(units.Mul(units, big.NewInt(quantity)), scale), nil
+}
+
+// AddDecimals sums decimal strings without losing precision
+func AddDecimals(values ...string) (string, error) {
+	var scale uint8
+	for _, value := range values {
+		if s := decimalScale(value); s > scale {
+			scale = s
+		}
+	}
+
+	sum := new(big.Int)
+	for _, value := range values {
+		units, err := ParseTokenAmount(value, scale)
+		if err != nil {
+			return "", err
+		}
+		sum.Add(sum, units)
+	}
+
+	return FormatTokenAmount(sum, scale), nil
+}
+
+// decimalScale returns the number of decimal places written in value
+func decimalScale(value string) uint8 {
+	_, fraction, _ := strings.Cut(strings.TrimSpace(value), ".")
+	if len(fraction) > math.MaxUint8 {
+		return math.MaxUint8
+	}
+	return uint8(len(fraction))
+}
+
+// FormatTokenAmount renders base units as a decimal string, e.g. 12500000 with 6 decimals is "12.5"
+func FormatTokenAmount(amount *big.Int, decimals uint8) string {
+	if amount == nil {
+		return "0"
+	}
+
+	negative := amount.Sign() < 0
+	digits := new(big.Int).Abs(amount).String()
+
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+
+	whole := digits[:len(digits)-int(decimals)]
+	fraction := strings.TrimRight(digits[len(digits)-int(decimals):], "0")
+
+	result := whole
+	if fraction != "" {
+		result += "." + fraction
+	}
+	if negative {
+		result = "-" + result
+	}
+
+	return result
+}